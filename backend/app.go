@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend/ipc"
@@ -40,6 +41,15 @@ type App struct {
 	ServerManager   *ServerManager
 	ImageManager    *ImageManager
 	PlaybackManager *PlaybackManager
+	SmartPlaylists  *SmartPlaylistManager
+	LyricsManager   *LyricsManager
+	PlaylistFiles   *PlaylistFileManager
+	PlaylistFolders *PlaylistFolderManager
+	ServerSync      *ServerSyncManager
+	Downloads       *DownloadManager
+	FolderSync      *FolderSyncManager
+	Loudness        *LoudnessManager
+	Waveforms       *WaveformManager
 	LocalPlayer     *mpv.Player
 	UpdateChecker   UpdateChecker
 	MPRISHandler    *MPRISHandler
@@ -60,6 +70,16 @@ type App struct {
 	cancel        context.CancelFunc
 
 	lastWrittenCfg Config
+
+	// current output device of the local player and description of the
+	// device chosen in the settings, for when it is disconnected,
+	// guarded by audioDeviceLock
+	audioDeviceLock       sync.Mutex
+	audioDevice           string
+	audioDeviceDesc       string
+	pausedForDisconnect   bool
+	onAudioDeviceSwitched []func(device mpv.AudioDevice, connected bool)
+	onAudioDevicesChanged []func([]mpv.AudioDevice)
 }
 
 func (a *App) VersionTag() string {
@@ -121,6 +141,20 @@ func StartupApp(appName, displayAppName, appVersionTag, latestReleaseURL string)
 	a.ServerManager = NewServerManager(appName, a.Config, !portableMode /*use keyring*/)
 	a.PlaybackManager = NewPlaybackManager(a.bgrndCtx, a.ServerManager, a.LocalPlayer, &a.Config.Scrobbling, &a.Config.Transcoding)
 	a.ImageManager = NewImageManager(a.bgrndCtx, a.ServerManager, cacheDir)
+	libraryTracks := newLibraryTrackCache(a.ServerManager)
+	a.SmartPlaylists = NewSmartPlaylistManager(a.ServerManager, a.Config, libraryTracks)
+	a.PlaylistFiles = NewPlaylistFileManager(a.ServerManager, libraryTracks)
+	a.PlaylistFolders = NewPlaylistFolderManager(a.ServerManager, &a.Config.PlaylistsPage)
+	a.ServerSync = NewServerSyncManager(a.ServerManager, &a.Config.ServerSync)
+	a.ServerSync.Start(a.bgrndCtx)
+	a.Downloads = NewDownloadManager(a.ServerManager, &a.Config.Downloads, cacheDir)
+	a.LyricsManager = NewLyricsManager(a.ServerManager, a.Downloads, &a.Config.Lyrics, &a.Config.Application, cacheDir, confDir)
+	go a.LyricsManager.PruneExpiredCache()
+	a.FolderSync = NewFolderSyncManager(a.ServerManager, a.Downloads, &a.Config.FolderSync)
+	a.Loudness = NewLoudnessManager(a.ServerManager, cacheDir)
+	a.PlaybackManager.SetLoudnessManager(a.Loudness)
+	a.Waveforms = NewWaveformManager(a.ServerManager, cacheDir)
+	a.PlaybackManager.SetReplayGainOptions(a.Config.ReplayGain)
 	a.Config.Application.MaxImageCacheSizeMB = clamp(a.Config.Application.MaxImageCacheSizeMB, 1, 500)
 	a.ImageManager.SetMaxOnDiskCacheSizeBytes(int64(a.Config.Application.MaxImageCacheSizeMB) * 1_048_576)
 	a.ServerManager.SetPrefetchAlbumCoverCallback(func(coverID string) {
@@ -273,7 +307,9 @@ func (a *App) setupMPV() error {
 		// (e.g. a USB audio device that is currently unplugged)
 		desiredDevice = "auto"
 	}
-	a.LocalPlayer.SetAudioDevice(desiredDevice)
+	a.LocalPlayer.OnAudioDeviceSet(a.applyDeviceEqualizerPreset)
+	a.SetAudioDevice(desiredDevice)
+	a.LocalPlayer.OnAudioDevicesChanged(a.handleAudioDevicesChanged)
 
 	rgainOpts := []string{ReplayGainNone, ReplayGainAlbum, ReplayGainTrack, ReplayGainAuto}
 	if !slices.Contains(rgainOpts, a.Config.ReplayGain.Mode) {
@@ -293,17 +329,44 @@ func (a *App) setupMPV() error {
 		Mode:            mode,
 		PreventClipping: a.Config.ReplayGain.PreventClipping,
 		PreampGain:      a.Config.ReplayGain.PreampGainDB,
+		FallbackGain:    a.Config.ReplayGain.FallbackGainDB,
 	})
 	a.LocalPlayer.SetAudioExclusive(a.Config.LocalPlayback.AudioExclusive)
 
+	a.UpdateEqualizer()
+	a.UpdateAudioOutput()
+	a.UpdateVolumeFades()
+
+	return nil
+}
+
+// UpdateEqualizer sets the equalizer of the local player from the config.
+func (a *App) UpdateEqualizer() {
+	conf := &a.Config.LocalPlayback
+	if conf.EqualizerType == (*mpv.ParametricEqualizer)(nil).Type() {
+		peq := conf.ParametricEqualizer()
+		eq := &mpv.ParametricEqualizer{
+			EQPreamp: peq.Preamp,
+			Disabled: !conf.EqualizerEnabled,
+		}
+		for _, b := range peq.Bands {
+			eq.Bands = append(eq.Bands, mpv.EqualizerBand{
+				Type:      b.Type,
+				Frequency: b.Frequency,
+				Gain:      b.Gain,
+				Width:     b.Q,
+				WidthType: mpv.WidthTypeQ,
+			})
+		}
+		a.LocalPlayer.SetEqualizer(eq)
+		return
+	}
 	eq := &mpv.ISO15BandEqualizer{
-		EQPreamp: a.Config.LocalPlayback.EqualizerPreamp,
-		Disabled: !a.Config.LocalPlayback.EqualizerEnabled,
+		EQPreamp: conf.EqualizerPreamp,
+		Disabled: !conf.EqualizerEnabled,
 	}
-	copy(eq.BandGains[:], a.Config.LocalPlayback.GraphicEqualizerBands)
+	copy(eq.BandGains[:], conf.GraphicEqualizerBands)
 	a.LocalPlayer.SetEqualizer(eq)
-
-	return nil
 }
 
 func (a *App) setupMPRIS(mprisAppName string) {
@@ -410,6 +473,12 @@ func (a *App) checkFlagsAndSendIPCMsg(cli *ipc.Client) error {
 		return cli.SeekBackOrPrevious()
 	case *FlagNext:
 		return cli.SeekNext()
+	case *FlagABLoopA:
+		return cli.SetABLoopA()
+	case *FlagABLoopB:
+		return cli.SetABLoopB()
+	case *FlagABClear:
+		return cli.ClearABLoop()
 	case VolumeCLIArg >= 0:
 		return cli.SetVolume(VolumeCLIArg)
 	case SeekToCLIArg >= 0:
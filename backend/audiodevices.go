@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"log"
+	"slices"
+
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+// Actions when the audio device chosen in the settings is disconnected.
+// Playback switches back to the device when it is reconnected.
+const (
+	// Pause playback and switch to the default device
+	AudioDeviceDisconnectPause = "Pause"
+	// Keep playing on the default device
+	AudioDeviceDisconnectFallback = "Fallback"
+)
+
+// SetAudioDevice switches the output of the local player to the device.
+// The equalizer preset mapped to the device, if any, is then applied
+// by applyDeviceEqualizerPreset.
+func (a *App) SetAudioDevice(device string) error {
+	a.audioDeviceLock.Lock()
+	defer a.audioDeviceLock.Unlock()
+	return a.setAudioDevice(device)
+}
+
+// must be called with audioDeviceLock held
+func (a *App) setAudioDevice(device string) error {
+	if err := a.LocalPlayer.SetAudioDevice(device); err != nil {
+		return err
+	}
+	a.audioDevice = device
+	return nil
+}
+
+// invoked by the local player when its output is switched to the device
+func (a *App) applyDeviceEqualizerPreset(device string) {
+	if a.Config.LocalPlayback.ApplyDeviceEqualizerPreset(device) {
+		a.UpdateEqualizer()
+	}
+}
+
+// OnAudioDeviceSwitched registers a callback which is invoked when the output is
+// switched away from the audio device chosen in the settings because it was
+// disconnected (connected == false), or back to it when it is reconnected.
+func (a *App) OnAudioDeviceSwitched(cb func(device mpv.AudioDevice, connected bool)) {
+	a.onAudioDeviceSwitched = append(a.onAudioDeviceSwitched, cb)
+}
+
+// OnAudioDevicesChanged registers a callback which is invoked with the new list
+// of audio devices when an audio device is connected or disconnected.
+func (a *App) OnAudioDevicesChanged(cb func([]mpv.AudioDevice)) {
+	a.onAudioDevicesChanged = append(a.onAudioDevicesChanged, cb)
+}
+
+// invoked from the mpv event loop when an audio device is connected or disconnected.
+// Switching devices and pausing send commands to mpv, so they are handled
+// in the background rather than blocking the event loop.
+func (a *App) handleAudioDevicesChanged([]mpv.AudioDevice) {
+	go a.updateAudioDevices()
+}
+
+func (a *App) updateAudioDevices() {
+	a.audioDeviceLock.Lock()
+	// list the devices again, since the goroutines started
+	// by quick successive changes may run in any order
+	devs, err := a.LocalPlayer.ListAudioDevices()
+	if err != nil {
+		a.audioDeviceLock.Unlock()
+		log.Printf("error listing audio devices: %s", err.Error())
+		return
+	}
+	dev, connected, switched := a.switchAudioDevice(devs)
+	a.audioDeviceLock.Unlock()
+
+	for _, cb := range a.onAudioDevicesChanged {
+		cb(devs)
+	}
+	if switched {
+		for _, cb := range a.onAudioDeviceSwitched {
+			cb(dev, connected)
+		}
+	}
+}
+
+// switches away from the audio device chosen in the settings if it was disconnected,
+// or back to it if it was reconnected. Must be called with audioDeviceLock held.
+func (a *App) switchAudioDevice(devs []mpv.AudioDevice) (dev mpv.AudioDevice, connected, switched bool) {
+	preferred := a.Config.LocalPlayback.AudioDeviceName
+	idx := slices.IndexFunc(devs, func(d mpv.AudioDevice) bool { return d.Name == preferred })
+	if idx >= 0 {
+		a.audioDeviceDesc = devs[idx].Description
+	}
+	if preferred == "auto" {
+		return mpv.AudioDevice{}, false, false
+	}
+
+	switch {
+	case idx < 0 && a.audioDevice == preferred:
+		log.Printf("audio device %q disconnected", preferred)
+		playing := a.LocalPlayer.GetStatus().State == player.Playing
+		if playing && a.Config.LocalPlayback.AudioDeviceDisconnectAction != AudioDeviceDisconnectFallback {
+			a.PlaybackManager.Pause()
+			a.pausedForDisconnect = true
+		}
+		if err := a.setAudioDevice("auto"); err != nil {
+			log.Printf("error switching to default audio device: %s", err.Error())
+		}
+		return mpv.AudioDevice{Name: preferred, Description: a.audioDeviceDesc}, false, true
+	case idx >= 0 && a.audioDevice != preferred:
+		log.Printf("audio device %q reconnected", preferred)
+		if err := a.setAudioDevice(preferred); err != nil {
+			log.Printf("error switching to audio device: %s", err.Error())
+			return mpv.AudioDevice{}, false, false
+		}
+		if a.pausedForDisconnect && a.LocalPlayer.GetStatus().State == player.Paused {
+			a.PlaybackManager.Continue()
+		}
+		a.pausedForDisconnect = false
+		return devs[idx], true, true
+	}
+	return mpv.AudioDevice{}, false, false
+}
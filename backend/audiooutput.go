@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"log"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+// OutputOptions returns the options for the audio output of the local player.
+// In bit-perfect mode, the source sample rate and format are kept and the
+// volume is applied to the audio device.
+func (c *LocalPlaybackConfig) OutputOptions() mpv.OutputOptions {
+	if c.BitPerfect {
+		return mpv.OutputOptions{DisableSoftwareVolume: true}
+	}
+	return mpv.OutputOptions{
+		SampleRate:            c.OutputSampleRate,
+		SampleFormat:          c.OutputSampleFormat,
+		ResamplerQuality:      mpv.ResamplerQuality(c.ResamplerQuality),
+		DisableSoftwareVolume: c.DisableSoftwareVolume,
+	}
+}
+
+// BitPerfectConflicts returns the names of the enabled features which alter
+// the samples and so prevent bit-perfect playback, or nil if not in bit-perfect mode.
+func (c *Config) BitPerfectConflicts() []string {
+	if !c.LocalPlayback.BitPerfect {
+		return nil
+	}
+	var conflicts []string
+	if c.LocalPlayback.EqualizerEnabled {
+		conflicts = append(conflicts, "Equalizer")
+	}
+	if c.ReplayGain.Mode != ReplayGainNone {
+		conflicts = append(conflicts, "ReplayGain")
+	}
+	if c.ReplayGain.Mode != ReplayGainNone && c.ReplayGain.AnalyzeLoudness {
+		conflicts = append(conflicts, "Loudness analysis")
+	}
+	if c.LocalPlayback.FadeDurationMS > 0 {
+		conflicts = append(conflicts, "Volume fades")
+	}
+	return conflicts
+}
+
+// BitPerfectConflicts returns the names of the features which prevent bit-perfect
+// playback, as Config.BitPerfectConflicts, as well as the spectrum analyzer while it is open.
+func (a *App) BitPerfectConflicts() []string {
+	conflicts := a.Config.BitPerfectConflicts()
+	if a.Config.LocalPlayback.BitPerfect && a.LocalPlayer != nil && a.LocalPlayer.SpectrumEnabled() {
+		conflicts = append(conflicts, "Spectrum Analyzer")
+	}
+	return conflicts
+}
+
+// UpdateAudioOutput sets the audio output options of the local player from the config.
+func (a *App) UpdateAudioOutput() {
+	if err := a.LocalPlayer.SetOutputOptions(a.Config.LocalPlayback.OutputOptions()); err != nil {
+		log.Printf("error setting audio output options: %s", err.Error())
+	}
+}
+
+// UpdateVolumeFades sets the duration of the local player's volume fades from the config.
+func (a *App) UpdateVolumeFades() {
+	a.LocalPlayer.SetFadeDuration(time.Duration(a.Config.LocalPlayback.FadeDurationMS) * time.Millisecond)
+}
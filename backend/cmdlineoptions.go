@@ -15,6 +15,9 @@ var (
 	FlagPlayPause = flag.Bool("play-pause", false, "toggle play/pause state")
 	FlagPrevious  = flag.Bool("previous", false, "seek to previous track or beginning of current")
 	FlagNext      = flag.Bool("next", false, "seek to next track")
+	FlagABLoopA   = flag.Bool("ab-loop-a", false, "set the start of the A-B loop to the current position")
+	FlagABLoopB   = flag.Bool("ab-loop-b", false, "set the end of the A-B loop to the current position")
+	FlagABClear   = flag.Bool("ab-loop-clear", false, "clear the A-B loop")
 	FlagVersion   = flag.Bool("version", false, "print app version and exit")
 	FlagHelp      = flag.Bool("help", false, "print command line options and exit")
 )
@@ -2,8 +2,11 @@ package backend
 
 import (
 	"os"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
 	"github.com/google/uuid"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -48,6 +51,7 @@ type AppConfig struct {
 	ShowTrackChangeNotification bool
 	EnableLrcLib                bool
 	SkipSSLVerify               bool
+	ShowWaveformSeekBar         bool
 
 	// Experimental - may be removed in future
 	FontNormalTTF string
@@ -67,6 +71,7 @@ type AlbumsPageConfig struct {
 type ArtistPageConfig struct {
 	InitialView      string
 	DiscographySort  string
+	GroupDiscography bool
 	TracklistColumns []string
 }
 
@@ -86,6 +91,13 @@ type PlaylistPageConfig struct {
 
 type PlaylistsPageConfig struct {
 	InitialView string
+
+	// Infer playlist folders from names such as "Work / Focus / Deep"
+	InferFoldersFromNames bool
+	// server ID -> playlist ID -> folder path assigned by the user
+	PlaylistFolders map[string]map[string]string
+	// server ID -> paths of the folders collapsed by the user
+	CollapsedFolders map[string][]string
 }
 
 type TracksPageConfig struct {
@@ -104,6 +116,59 @@ type LocalPlaybackConfig struct {
 	EqualizerEnabled      bool
 	EqualizerPreamp       float64
 	GraphicEqualizerBands []float64
+
+	// Type of the active equalizer, "ISO15Band" or "Parametric"
+	EqualizerType             string
+	ParametricEqualizerPreamp float64
+	ParametricEqualizerBands  []ParametricEqualizerBand
+
+	// Name of the last applied equalizer preset
+	EqualizerPreset string
+	// User-saved equalizer presets
+	EqualizerPresets []EqualizerPreset
+	// Equalizer preset names keyed by audio device name,
+	// applied when the output switches to the device
+	DeviceEqualizerPresets map[string]string
+
+	// Output sample rate in Hz, or 0 to match the source
+	OutputSampleRate int
+	// Output sample format, e.g. "s16", "s32" or "float", or "" to choose automatically
+	OutputSampleFormat string
+	// Resampler quality preset, one of mpv.ResamplerQualities
+	ResamplerQuality string
+	// Control the volume of the audio device instead of scaling the samples
+	DisableSoftwareVolume bool
+	// Send the samples to the audio device unaltered by the output settings,
+	// overriding the output options above
+	BitPerfect bool
+
+	// What to do when AudioDeviceName is disconnected, AudioDeviceDisconnectPause or
+	// AudioDeviceDisconnectFallback. Playback switches back when it is reconnected.
+	AudioDeviceDisconnectAction string
+
+	// Duration in milliseconds of the volume fades on pause, resume,
+	// stop and manual track changes, or 0 to disable them
+	FadeDurationMS int
+}
+
+// ParametricEqualizerBand is a band of the parametric equalizer.
+type ParametricEqualizerBand struct {
+	// Filter type as named in EqualizerAPO files, e.g. "PK"; peaking if empty
+	Type      string
+	Frequency int
+	Gain      float64
+	Q         float64
+}
+
+type LyricsConfig struct {
+	// Lyrics sources to query, in order of priority
+	Sources []string
+	// Folder of user lyrics files. If empty, "lyrics" in the config dir is used.
+	LyricsFolder string
+	// How long fetched lyrics, and the absence of lyrics, are cached on disk
+	CacheTTLDays int
+	// Root URL of the LRCLIB instance to fetch and publish lyrics
+	LrcLibURL string
 }
 
 type ScrobbleConfig struct {
@@ -116,6 +181,12 @@ type ReplayGainConfig struct {
 	Mode            string
 	PreampGainDB    float64
 	PreventClipping bool
+
+	// Gain applied to tracks with no ReplayGain tags or metadata
+	FallbackGainDB float64
+	// Measure the loudness of tracks with no ReplayGain info from the server
+	// while they play, and normalize them on later plays
+	AnalyzeLoudness bool
 }
 
 type ThemeConfig struct {
@@ -132,6 +203,74 @@ type PeakMeterConfig struct {
 	WindowWidth  int
 }
 
+type SpectrumAnalyzerConfig struct {
+	WindowHeight int
+	WindowWidth  int
+	Mode         string // "Bars" or "Line"
+	PeakHold     bool
+}
+
+type SmartPlaylistRule struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+type SmartPlaylist struct {
+	ID             string
+	Name           string
+	MatchAny       bool
+	Rules          []SmartPlaylistRule
+	SortBy         string
+	SortDescending bool
+	Limit          int
+
+	// server ID -> ID of the server playlist this smart playlist was last saved to
+	ServerPlaylistIDs map[string]string
+}
+
+type ServerSyncConfig struct {
+	// IDs of the servers to copy from and to
+	SourceServerID string
+	TargetServerID string
+
+	SyncPlaylists bool
+	SyncFavorites bool
+	SyncRatings   bool
+
+	// If > 0, a one-way sync is run in the background this often
+	AutoSyncIntervalHours int
+	LastAutoSync          time.Time
+}
+
+type DownloadConfig struct {
+	// Template for the path of each track within the destination folder
+	FolderTemplate string
+	MaxConcurrent  int
+	SkipExisting   bool
+
+	// If set, tracks are downloaded transcoded by the server to this format
+	TranscodeFormat  string
+	TranscodeBitRate int
+
+	LastDestination string
+}
+
+type FolderSyncConfig struct {
+	Destination string
+	// server ID -> IDs of the playlists to sync
+	PlaylistIDs map[string][]string
+	// server ID -> album ID -> name
+	Albums         map[string]map[string]string
+	FavoriteTracks bool
+	FavoriteAlbums bool
+
+	// Template for the path of each track within the destination folder
+	FolderTemplate   string
+	TranscodeFormat  string
+	TranscodeBitRate int
+}
+
 type Config struct {
 	Application      AppConfig
 	Servers          []*ServerConfig
@@ -145,11 +284,17 @@ type Config struct {
 	TracksPage       TracksPageConfig
 	NowPlayingConfig NowPlayingPageConfig
 	LocalPlayback    LocalPlaybackConfig
+	Lyrics           LyricsConfig
 	Scrobbling       ScrobbleConfig
 	ReplayGain       ReplayGainConfig
 	Transcoding      TranscodingConfig
 	Theme            ThemeConfig
 	PeakMeter        PeakMeterConfig
+	SpectrumAnalyzer SpectrumAnalyzerConfig
+	SmartPlaylists   []*SmartPlaylist
+	ServerSync       ServerSyncConfig
+	Downloads        DownloadConfig
+	FolderSync       FolderSyncConfig
 }
 
 var SupportedStartupPages = []string{"Albums", "Favorites", "Playlists"}
@@ -183,6 +328,7 @@ func DefaultConfig(appVersionTag string) *Config {
 		},
 		ArtistPage: ArtistPageConfig{
 			InitialView:      "Discography",
+			GroupDiscography: true,
 			TracklistColumns: []string{"Album", "Time", "Plays", "Favorite", "Rating"},
 		},
 		ArtistsPage: ArtistsPageConfig{
@@ -197,7 +343,8 @@ func DefaultConfig(appVersionTag string) *Config {
 			TracklistColumns: []string{"Album", "Time", "Plays"},
 		},
 		PlaylistsPage: PlaylistsPageConfig{
-			InitialView: "List",
+			InitialView:           "List",
+			InferFoldersFromNames: true,
 		},
 		NowPlayingConfig: NowPlayingPageConfig{
 			InitialView: "Play Queue",
@@ -214,6 +361,14 @@ func DefaultConfig(appVersionTag string) *Config {
 			EqualizerEnabled:      false,
 			EqualizerPreamp:       0,
 			GraphicEqualizerBands: make([]float64, 15),
+			EqualizerType:         "ISO15Band",
+
+			AudioDeviceDisconnectAction: AudioDeviceDisconnectPause,
+		},
+		Lyrics: LyricsConfig{
+			Sources:      slices.Clone(SupportedLyricsSources),
+			CacheTTLDays: 14,
+			LrcLibURL:    "https://lrclib.net",
 		},
 		Scrobbling: ScrobbleConfig{
 			Enabled:              true,
@@ -235,6 +390,25 @@ func DefaultConfig(appVersionTag string) *Config {
 			WindowWidth:  375,
 			WindowHeight: 100,
 		},
+		SpectrumAnalyzer: SpectrumAnalyzerConfig{
+			WindowWidth:  600,
+			WindowHeight: 250,
+			Mode:         "Bars",
+			PeakHold:     true,
+		},
+		ServerSync: ServerSyncConfig{
+			SyncPlaylists: true,
+			SyncFavorites: true,
+			SyncRatings:   true,
+		},
+		Downloads: DownloadConfig{
+			FolderTemplate: pathtemplate.DefaultTemplate,
+			MaxConcurrent:  3,
+			SkipExisting:   true,
+		},
+		FolderSync: FolderSyncConfig{
+			FolderTemplate: pathtemplate.DefaultTemplate,
+		},
 	}
 }
 
@@ -0,0 +1,524 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
+)
+
+// suffix of files which are still being downloaded
+const partialDownloadSuffix = ".part"
+
+// file in the cache dir listing the partial files of the downloads in progress,
+// which are deleted on the next startup if the app exits during a download
+const partialDownloadsFile = "partial_downloads.json"
+
+var ErrTranscodedDownloadUnsupported = errors.New("the server does not support transcoded downloads")
+
+type DownloadStatus int
+
+const (
+	DownloadQueued DownloadStatus = iota
+	DownloadActive
+	DownloadDone
+	DownloadSkipped
+	DownloadFailed
+	DownloadCanceled
+)
+
+// DownloadOptions are the options for a download job.
+type DownloadOptions struct {
+	// Template for the path of each track within the destination folder
+	FolderTemplate string
+	// If true, tracks whose file already exists are not downloaded again,
+	// so re-running an interrupted download skips the tracks it completed.
+	// Tracks which were partially downloaded are downloaded again in full.
+	SkipExisting bool
+	// If set, tracks are transcoded by the server to this format
+	TranscodeFormat  string
+	TranscodeBitRate int
+}
+
+// DownloadItem is a track to be downloaded to a file.
+type DownloadItem struct {
+	Track        *mediaprovider.Track
+	Path         string
+	Status       DownloadStatus
+	BytesWritten int64
+	Err          error
+}
+
+// DownloadFile is a track to be downloaded to a path, slash-separated
+// and relative to the destination folder.
+type DownloadFile struct {
+	Track *mediaprovider.Track
+	Path  string
+}
+
+// DownloadJob is a set of tracks downloaded together, e.g. an album.
+type DownloadJob struct {
+	ID    int
+	Name  string
+	Dir   string
+	Items []DownloadItem
+
+	opts       DownloadOptions
+	mp         mediaprovider.MediaProvider
+	ctx        context.Context
+	cancel     context.CancelFunc
+	onFinished func(DownloadJob)
+}
+
+// Count returns the number of items of the job with the given status.
+func (j *DownloadJob) Count(status DownloadStatus) int {
+	n := 0
+	for _, it := range j.Items {
+		if it.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// IsFinished returns true if none of the job's items are queued or downloading.
+func (j *DownloadJob) IsFinished() bool {
+	return j.Count(DownloadQueued) == 0 && j.Count(DownloadActive) == 0
+}
+
+// CanRetry returns true if the failed and canceled items of the job can be
+// queued again. Jobs with an onFinished hook, such as folder syncs, can't,
+// since the hook must only run once; they are started over instead.
+func (j *DownloadJob) CanRetry() bool {
+	return j.onFinished == nil
+}
+
+// DownloadManager downloads tracks to files laid out by a folder template,
+// running up to the configured number of downloads in parallel.
+// Files are written with a ".part" suffix and renamed when complete,
+// so a file with the final name is always a complete download.
+type DownloadManager struct {
+	sm   *ServerManager
+	conf *DownloadConfig
+
+	lock      sync.Mutex
+	jobs      []*DownloadJob
+	nextJobID int
+	active    int
+
+	// partial files of the downloads in progress, recorded in partialsPath
+	partials     map[string]struct{}
+	partialsPath string
+
+	onChanged     []func()
+	onJobFinished []func(DownloadJob)
+}
+
+func NewDownloadManager(sm *ServerManager, conf *DownloadConfig, cacheDir string) *DownloadManager {
+	d := &DownloadManager{
+		sm:           sm,
+		conf:         conf,
+		partials:     make(map[string]struct{}),
+		partialsPath: filepath.Join(cacheDir, partialDownloadsFile),
+	}
+	d.removeStalePartialFiles()
+	return d
+}
+
+// OnChanged registers a callback to be invoked when the status of a download changes.
+func (d *DownloadManager) OnChanged(cb func()) {
+	d.onChanged = append(d.onChanged, cb)
+}
+
+// OnJobFinished registers a callback to be invoked when all items of a job have finished.
+func (d *DownloadManager) OnJobFinished(cb func(DownloadJob)) {
+	d.onJobFinished = append(d.onJobFinished, cb)
+}
+
+// Enqueue adds a job to download the tracks into dir from the current server.
+func (d *DownloadManager) Enqueue(name, dir string, tracks []*mediaprovider.Track, opts DownloadOptions) (int, error) {
+	if err := pathtemplate.Validate(opts.FolderTemplate); err != nil {
+		return 0, err
+	}
+	mp := d.sm.Server
+	if mp == nil {
+		return 0, errors.New("not connected to a server")
+	}
+	if _, ok := mp.(mediaprovider.SupportsTranscodedDownload); opts.TranscodeFormat != "" && !ok {
+		return 0, ErrTranscodedDownloadUnsupported
+	}
+
+	paths := pathtemplate.RenderAll(opts.FolderTemplate, tracks, opts.TranscodeFormat)
+	files := make([]DownloadFile, len(tracks))
+	for i, tr := range tracks {
+		files[i] = DownloadFile{Track: tr, Path: paths[i]}
+	}
+	return d.enqueue(name, dir, mp, files, opts, nil), nil
+}
+
+// EnqueueFiles adds a job to download each track to the given path within dir
+// from the current server. The folder template of opts is not used.
+// onFinished, if non-nil, is called when all items of the job have finished.
+func (d *DownloadManager) EnqueueFiles(name, dir string, files []DownloadFile, opts DownloadOptions, onFinished func(DownloadJob)) (int, error) {
+	mp := d.sm.Server
+	if mp == nil {
+		return 0, errors.New("not connected to a server")
+	}
+	if _, ok := mp.(mediaprovider.SupportsTranscodedDownload); opts.TranscodeFormat != "" && !ok {
+		return 0, ErrTranscodedDownloadUnsupported
+	}
+	return d.enqueue(name, dir, mp, files, opts, onFinished), nil
+}
+
+func (d *DownloadManager) enqueue(name, dir string, mp mediaprovider.MediaProvider, files []DownloadFile, opts DownloadOptions, onFinished func(DownloadJob)) int {
+	job := &DownloadJob{Name: name, Dir: dir, opts: opts, mp: mp, onFinished: onFinished}
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	for _, f := range files {
+		job.Items = append(job.Items, DownloadItem{
+			Track: f.Track,
+			Path:  filepath.Join(dir, filepath.FromSlash(f.Path)),
+		})
+	}
+
+	d.lock.Lock()
+	d.nextJobID++
+	job.ID = d.nextJobID
+	d.jobs = append(d.jobs, job)
+	d.dispatch()
+	// an empty job is finished immediately
+	d.checkJobFinished(job)
+	d.lock.Unlock()
+	d.invokeOnChanged()
+	return job.ID
+}
+
+// Jobs returns a snapshot of the current download jobs.
+func (d *DownloadManager) Jobs() []DownloadJob {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	jobs := make([]DownloadJob, len(d.jobs))
+	for i, j := range d.jobs {
+		jobs[i] = *j
+		jobs[i].Items = append([]DownloadItem(nil), j.Items...)
+	}
+	return jobs
+}
+
+// DownloadedFilePaths returns the paths of the existing files the track was downloaded to
+// by the jobs of this session, or by a download to the last destination with the
+// current folder template.
+func (d *DownloadManager) DownloadedFilePaths(track *mediaprovider.Track) []string {
+	var paths []string
+	d.lock.Lock()
+	for _, j := range d.jobs {
+		for _, it := range j.Items {
+			if it.Track.ID == track.ID && (it.Status == DownloadDone || it.Status == DownloadSkipped) {
+				paths = append(paths, it.Path)
+			}
+		}
+	}
+	d.lock.Unlock()
+
+	if d.conf.LastDestination != "" && pathtemplate.Validate(d.conf.FolderTemplate) == nil {
+		p := filepath.Join(d.conf.LastDestination, filepath.FromSlash(
+			pathtemplate.Render(d.conf.FolderTemplate, track, pathtemplate.Extension(track, d.conf.TranscodeFormat))))
+		paths = append(paths, p)
+	}
+
+	var existing []string
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil && !slices.Contains(existing, p) {
+			existing = append(existing, p)
+		}
+	}
+	return existing
+}
+
+// Cancel cancels the queued and active downloads of the job.
+func (d *DownloadManager) Cancel(jobID int) {
+	d.lock.Lock()
+	if job := d.findJob(jobID); job != nil {
+		job.cancel()
+		for i := range job.Items {
+			if job.Items[i].Status == DownloadQueued {
+				job.Items[i].Status = DownloadCanceled
+			}
+		}
+		d.checkJobFinished(job)
+	}
+	d.lock.Unlock()
+	d.invokeOnChanged()
+}
+
+// RetryFailed queues the failed and canceled items of the job again,
+// if the job is finished and CanRetry.
+func (d *DownloadManager) RetryFailed(jobID int) {
+	d.lock.Lock()
+	if job := d.findJob(jobID); job != nil && job.IsFinished() && job.CanRetry() {
+		job.ctx, job.cancel = context.WithCancel(context.Background())
+		for i := range job.Items {
+			if s := job.Items[i].Status; s == DownloadFailed || s == DownloadCanceled {
+				job.Items[i].Status = DownloadQueued
+				job.Items[i].Err = nil
+				job.Items[i].BytesWritten = 0
+			}
+		}
+		d.dispatch()
+	}
+	d.lock.Unlock()
+	d.invokeOnChanged()
+}
+
+// ClearFinished removes the finished jobs from the list.
+func (d *DownloadManager) ClearFinished() {
+	d.lock.Lock()
+	d.jobs = slices.DeleteFunc(d.jobs, func(j *DownloadJob) bool { return j.IsFinished() })
+	d.lock.Unlock()
+	d.invokeOnChanged()
+}
+
+// HasActive returns true if any downloads are queued or in progress.
+func (d *DownloadManager) HasActive() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, j := range d.jobs {
+		if !j.IsFinished() {
+			return true
+		}
+	}
+	return false
+}
+
+// starts queued downloads, in order, up to the concurrency limit.
+// must be called with the lock held.
+func (d *DownloadManager) dispatch() {
+	maxActive := d.conf.MaxConcurrent
+	if maxActive < 1 {
+		maxActive = 1
+	}
+	for _, job := range d.jobs {
+		for i := range job.Items {
+			if d.active >= maxActive {
+				return
+			}
+			if job.Items[i].Status == DownloadQueued {
+				job.Items[i].Status = DownloadActive
+				d.active++
+				go d.download(job, i)
+			}
+		}
+	}
+}
+
+func (d *DownloadManager) download(job *DownloadJob, idx int) {
+	d.lock.Lock()
+	item := job.Items[idx]
+	ctx := job.ctx
+	d.lock.Unlock()
+
+	status, err := d.downloadFile(ctx, job, idx, &item)
+	if err != nil && status == DownloadFailed {
+		log.Printf("error downloading %s: %v", item.Track.Title, err)
+	}
+
+	d.lock.Lock()
+	job.Items[idx].Status = status
+	job.Items[idx].Err = err
+	d.active--
+	d.checkJobFinished(job)
+	d.dispatch()
+	d.lock.Unlock()
+	d.invokeOnChanged()
+}
+
+func (d *DownloadManager) downloadFile(ctx context.Context, job *DownloadJob, idx int, item *DownloadItem) (DownloadStatus, error) {
+	if job.opts.SkipExisting {
+		if _, err := os.Stat(item.Path); err == nil {
+			return DownloadSkipped, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+		return DownloadFailed, err
+	}
+
+	var r io.Reader
+	var err error
+	if job.opts.TranscodeFormat != "" {
+		r, err = job.mp.(mediaprovider.SupportsTranscodedDownload).DownloadTrackTranscoded(
+			item.Track.ID, job.opts.TranscodeFormat, job.opts.TranscodeBitRate)
+	} else {
+		r, err = job.mp.DownloadTrack(item.Track.ID)
+	}
+	if err != nil {
+		return DownloadFailed, err
+	}
+	cr := newContextReader(ctx, r)
+	defer cr.Close()
+
+	partPath := item.Path + partialDownloadSuffix
+	d.setPartial(partPath, true)
+	defer d.setPartial(partPath, false)
+	f, err := os.Create(partPath)
+	if err != nil {
+		return DownloadFailed, err
+	}
+	w := &progressWriter{w: f, onWrite: func(n int64) {
+		d.lock.Lock()
+		job.Items[idx].BytesWritten = n
+		d.lock.Unlock()
+	}}
+	_, err = io.Copy(w, cr)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		if ctx.Err() != nil {
+			return DownloadCanceled, nil
+		}
+		return DownloadFailed, err
+	}
+	if err := os.Rename(partPath, item.Path); err != nil {
+		return DownloadFailed, err
+	}
+	return DownloadDone, nil
+}
+
+// records the partial file of a download while it is in progress
+func (d *DownloadManager) setPartial(path string, inProgress bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if inProgress {
+		d.partials[path] = struct{}{}
+	} else {
+		delete(d.partials, path)
+	}
+	if len(d.partials) == 0 {
+		os.Remove(d.partialsPath)
+		return
+	}
+	paths := make([]string, 0, len(d.partials))
+	for p := range d.partials {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	b, err := json.Marshal(paths)
+	if err == nil {
+		err = os.WriteFile(d.partialsPath, b, 0644)
+	}
+	if err != nil {
+		log.Printf("error recording partial downloads: %s", err.Error())
+	}
+}
+
+// deletes the partial files left by downloads interrupted by the app exiting
+func (d *DownloadManager) removeStalePartialFiles() {
+	b, err := os.ReadFile(d.partialsPath)
+	if err != nil {
+		return
+	}
+	var paths []string
+	if err := json.Unmarshal(b, &paths); err == nil {
+		for _, p := range paths {
+			if strings.HasSuffix(p, partialDownloadSuffix) {
+				os.Remove(p)
+			}
+		}
+	}
+	os.Remove(d.partialsPath)
+}
+
+// must be called with the lock held
+func (d *DownloadManager) checkJobFinished(job *DownloadJob) {
+	if !job.IsFinished() {
+		return
+	}
+	snapshot := *job
+	snapshot.Items = append([]DownloadItem(nil), job.Items...)
+	if job.onFinished != nil {
+		go job.onFinished(snapshot)
+	}
+	for _, cb := range d.onJobFinished {
+		go cb(snapshot)
+	}
+}
+
+// must be called with the lock held
+func (d *DownloadManager) findJob(id int) *DownloadJob {
+	for _, j := range d.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (d *DownloadManager) invokeOnChanged() {
+	for _, cb := range d.onChanged {
+		cb()
+	}
+}
+
+// contextReader stops reading with the context's error once it is canceled.
+// If the underlying reader is an io.Closer, such as an HTTP response body,
+// it is closed on cancellation to interrupt a Read blocked on a stalled connection.
+type contextReader struct {
+	ctx  context.Context
+	r    io.Reader
+	stop func() bool
+}
+
+// newContextReader returns a contextReader reading from r, which must be closed when done.
+func newContextReader(ctx context.Context, r io.Reader) *contextReader {
+	c := &contextReader{ctx: ctx, r: r}
+	if cl, ok := r.(io.Closer); ok {
+		c.stop = context.AfterFunc(ctx, func() { cl.Close() })
+	}
+	return c
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if err != nil && c.ctx.Err() != nil {
+		// the read was interrupted by closing the reader
+		err = c.ctx.Err()
+	}
+	return n, err
+}
+
+// Close stops watching the context and closes the underlying reader, if it is an io.Closer.
+func (c *contextReader) Close() error {
+	if c.stop != nil {
+		c.stop()
+	}
+	if cl, ok := c.r.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// progressWriter reports the total number of bytes written after each write.
+type progressWriter struct {
+	w       io.Writer
+	n       int64
+	onWrite func(int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.n += int64(n)
+	p.onWrite(p.n)
+	return n, err
+}
@@ -0,0 +1,164 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// fakeDownloadServer serves the track with the given ID as its contents,
+// or a stalled reader for the ID "stall".
+type fakeDownloadServer struct {
+	mediaprovider.MediaProvider
+
+	stalled chan *io.PipeWriter
+}
+
+func (f *fakeDownloadServer) DownloadTrack(trackID string) (io.Reader, error) {
+	if trackID == "stall" {
+		r, w := io.Pipe()
+		f.stalled <- w
+		return r, nil
+	}
+	return strings.NewReader(trackID), nil
+}
+
+func newTestDownloadManager(t *testing.T, server *fakeDownloadServer) (*DownloadManager, chan DownloadJob) {
+	d := NewDownloadManager(&ServerManager{Server: server}, &DownloadConfig{MaxConcurrent: 2}, t.TempDir())
+	finished := make(chan DownloadJob, 1)
+	d.OnJobFinished(func(j DownloadJob) { finished <- j })
+	return d, finished
+}
+
+func waitJobFinished(t *testing.T, finished chan DownloadJob) DownloadJob {
+	select {
+	case j := <-finished:
+		return j
+	case <-time.After(5 * time.Second):
+		t.Fatal("download job did not finish")
+	}
+	return DownloadJob{}
+}
+
+func Test_DownloadSkipExisting(t *testing.T) {
+	d, finished := newTestDownloadManager(t, &fakeDownloadServer{})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.mp3"), []byte("existing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []DownloadFile{
+		{Track: &mediaprovider.Track{ID: "track a"}, Path: "a.mp3"},
+		{Track: &mediaprovider.Track{ID: "track b"}, Path: "sub/b.mp3"},
+	}
+	if _, err := d.EnqueueFiles("test", dir, files, DownloadOptions{SkipExisting: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	job := waitJobFinished(t, finished)
+
+	want := []DownloadStatus{DownloadSkipped, DownloadDone}
+	wantContents := []string{"existing", "track b"}
+	for i, it := range job.Items {
+		if it.Status != want[i] {
+			t.Errorf("item %d: got status %v, want %v", i, it.Status, want[i])
+		}
+		b, _ := os.ReadFile(it.Path)
+		if string(b) != wantContents[i] {
+			t.Errorf("item %d: got contents %q, want %q", i, b, wantContents[i])
+		}
+		if _, err := os.Stat(it.Path + partialDownloadSuffix); err == nil {
+			t.Errorf("item %d: partial file was not removed", i)
+		}
+	}
+	if _, err := os.Stat(d.partialsPath); err == nil {
+		t.Error("partial downloads file was not removed")
+	}
+}
+
+func Test_DownloadCancelStalled(t *testing.T) {
+	server := &fakeDownloadServer{stalled: make(chan *io.PipeWriter, 1)}
+	d, finished := newTestDownloadManager(t, server)
+	dir := t.TempDir()
+	files := []DownloadFile{{Track: &mediaprovider.Track{ID: "stall"}, Path: "a.mp3"}}
+	id, err := d.EnqueueFiles("test", dir, files, DownloadOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pipe is never written to, so the download blocks in Read until canceled
+	w := <-server.stalled
+	defer w.Close()
+	d.Cancel(id)
+	job := waitJobFinished(t, finished)
+
+	if s := job.Items[0].Status; s != DownloadCanceled {
+		t.Errorf("got status %v, want %v", s, DownloadCanceled)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.mp3"+partialDownloadSuffix)); err == nil {
+		t.Error("partial file was not removed")
+	}
+}
+
+func Test_RemoveStalePartialFiles(t *testing.T) {
+	cacheDir, dir := t.TempDir(), t.TempDir()
+	stale := filepath.Join(dir, "a.mp3"+partialDownloadSuffix)
+	// only files with the partial suffix are removed
+	other := filepath.Join(dir, "b.mp3")
+	for _, p := range []string{stale, other} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, _ := json.Marshal([]string{stale, other})
+	if err := os.WriteFile(filepath.Join(cacheDir, partialDownloadsFile), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	NewDownloadManager(&ServerManager{}, &DownloadConfig{}, cacheDir)
+	if _, err := os.Stat(stale); err == nil {
+		t.Error("stale partial file was not removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("file without the partial suffix was removed")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, partialDownloadsFile)); err == nil {
+		t.Error("partial downloads file was not removed")
+	}
+}
+
+func Test_RetryJobWithFinishedHook(t *testing.T) {
+	server := &fakeDownloadServer{stalled: make(chan *io.PipeWriter, 1)}
+	d, finished := newTestDownloadManager(t, server)
+	hookCalls := make(chan struct{}, 2)
+	files := []DownloadFile{{Track: &mediaprovider.Track{ID: "stall"}, Path: "a.mp3"}}
+	id, err := d.EnqueueFiles("sync", t.TempDir(), files, DownloadOptions{}, func(DownloadJob) {
+		hookCalls <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := <-server.stalled
+	defer w.Close()
+	d.Cancel(id)
+	job := waitJobFinished(t, finished)
+	<-hookCalls
+
+	if job.CanRetry() {
+		t.Error("got a job with a finished hook that can be retried")
+	}
+	// retrying does nothing, so the hook is not run again
+	d.RetryFailed(id)
+	if s := d.Jobs()[0].Items[0].Status; s != DownloadCanceled {
+		t.Errorf("got status %v after retrying, want %v", s, DownloadCanceled)
+	}
+	select {
+	case <-hookCalls:
+		t.Error("finished hook ran again")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/parametriceq"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+var ErrBuiltinEqualizerPreset = errors.New("a built-in preset with this name exists")
+
+// EqualizerPreset is a named set of equalizer settings.
+type EqualizerPreset struct {
+	Name string
+	// Type of the equalizer, "ISO15Band" or "Parametric"
+	Type            string
+	Preamp          float64
+	GraphicBands    []float64
+	ParametricBands []ParametricEqualizerBand
+}
+
+// BuiltinEqualizerPresets are genre curves for the 15-band graphic equalizer.
+var BuiltinEqualizerPresets = []EqualizerPreset{
+	graphicPreset("Flat", 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0),
+	graphicPreset("Bass Boost", -6, 6, 6, 5, 4, 3, 1.5, 0, 0, 0, 0, 0, 0, 0, 0, 0),
+	graphicPreset("Treble Boost", -5.5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3.5, 4.5, 5, 5.5),
+	graphicPreset("Loudness", -5, 5, 4, 3, 1.5, 0, 0, -0.5, -0.5, 0, 0, 0.5, 1.5, 3, 4, 4.5),
+	graphicPreset("Rock", -4.5, 4.5, 4, 3, 2, 0.5, -1, -1.5, -1, 0, 1, 2.5, 3.5, 4, 4, 4),
+	graphicPreset("Pop", -3.5, -1, -0.5, 0, 1.5, 3, 3.5, 3, 1.5, 0, -0.5, -1, -1, -1, -0.5, -0.5),
+	graphicPreset("Jazz", -3, 3, 2.5, 2, 1.5, 1, -1, -1.5, -1, 0, 1, 1.5, 2, 2.5, 3, 3),
+	graphicPreset("Classical", -3.5, 3.5, 3, 2.5, 2, 1, 0, 0, 0, 0, 0, 0, 1, 2, 2.5, 3),
+	graphicPreset("Electronic", -5, 5, 4.5, 3.5, 1.5, 0, -1, -1.5, -0.5, 0, 1, 2, 3, 3.5, 4, 4),
+	graphicPreset("Hip-Hop", -5.5, 5.5, 5, 4, 3, 1.5, 0, -1, -0.5, 0, 0.5, 1, 0.5, 1, 1.5, 2),
+	graphicPreset("Vocal", -4, -2, -2, -1.5, -1, 0, 1.5, 3, 4, 4, 3.5, 2, 1, 0, -0.5, -1),
+}
+
+func graphicPreset(name string, preamp float64, gains ...float64) EqualizerPreset {
+	return EqualizerPreset{Name: name, Type: "ISO15Band", Preamp: preamp, GraphicBands: gains}
+}
+
+// IsBuiltinEqualizerPreset returns true if name is the name of a built-in preset.
+func IsBuiltinEqualizerPreset(name string) bool {
+	return slices.ContainsFunc(BuiltinEqualizerPresets, func(p EqualizerPreset) bool {
+		return strings.EqualFold(p.Name, name)
+	})
+}
+
+// ParametricEqualizer returns the preamp and bands of the parametric equalizer.
+func (c *LocalPlaybackConfig) ParametricEqualizer() *parametriceq.Equalizer {
+	eq := &parametriceq.Equalizer{Preamp: c.ParametricEqualizerPreamp}
+	for _, b := range c.ParametricEqualizerBands {
+		eq.Bands = append(eq.Bands, b.eqBand())
+	}
+	return eq
+}
+
+// SetParametricEqualizer sets the preamp and bands of the parametric equalizer.
+func (c *LocalPlaybackConfig) SetParametricEqualizer(eq *parametriceq.Equalizer) {
+	c.ParametricEqualizerPreamp = eq.Preamp
+	c.ParametricEqualizerBands = make([]ParametricEqualizerBand, len(eq.Bands))
+	for i, b := range eq.Bands {
+		c.ParametricEqualizerBands[i] = ParametricEqualizerBand{
+			Type:      string(b.Type),
+			Frequency: b.Frequency,
+			Gain:      b.Gain,
+			Q:         b.Q,
+		}
+	}
+}
+
+// returns the band with its frequency clamped to the supported range,
+// as the config file may have been edited by hand
+func (b ParametricEqualizerBand) eqBand() parametriceq.Band {
+	return parametriceq.Band{
+		Type:      parametriceq.FilterType(b.Type),
+		Frequency: parametriceq.ClampFrequency(b.Frequency),
+		Gain:      b.Gain,
+		Q:         b.Q,
+	}
+}
+
+// AllEqualizerPresets returns the built-in presets followed by the user-saved presets.
+func (c *LocalPlaybackConfig) AllEqualizerPresets() []EqualizerPreset {
+	return append(slices.Clone(BuiltinEqualizerPresets), c.EqualizerPresets...)
+}
+
+// FindEqualizerPreset returns the preset with the given name, or nil if none exists.
+func (c *LocalPlaybackConfig) FindEqualizerPreset(name string) *EqualizerPreset {
+	for _, p := range c.AllEqualizerPresets() {
+		if strings.EqualFold(p.Name, name) {
+			return &p
+		}
+	}
+	return nil
+}
+
+// ApplyEqualizerPreset sets the equalizer settings from the preset.
+// The equalizer is enabled and the type switched to that of the preset.
+func (c *LocalPlaybackConfig) ApplyEqualizerPreset(p *EqualizerPreset) {
+	c.EqualizerPreset = p.Name
+	c.EqualizerEnabled = true
+	c.EqualizerType = p.Type
+	if p.Type == (*mpv.ParametricEqualizer)(nil).Type() {
+		c.ParametricEqualizerPreamp = p.Preamp
+		c.ParametricEqualizerBands = slices.Clone(p.ParametricBands)
+		return
+	}
+	c.EqualizerPreamp = p.Preamp
+	c.GraphicEqualizerBands = make([]float64, 15)
+	copy(c.GraphicEqualizerBands, p.GraphicBands)
+}
+
+// SaveEqualizerPreset saves the current equalizer settings as a user preset,
+// replacing any user preset of the same name.
+func (c *LocalPlaybackConfig) SaveEqualizerPreset(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("preset name is empty")
+	}
+	if IsBuiltinEqualizerPreset(name) {
+		return ErrBuiltinEqualizerPreset
+	}
+	p := EqualizerPreset{Name: name, Type: c.EqualizerType}
+	if p.Type == (*mpv.ParametricEqualizer)(nil).Type() {
+		p.Preamp = c.ParametricEqualizerPreamp
+		p.ParametricBands = slices.Clone(c.ParametricEqualizerBands)
+	} else {
+		p.Preamp = c.EqualizerPreamp
+		p.GraphicBands = slices.Clone(c.GraphicEqualizerBands)
+	}
+	c.deleteUserEqualizerPreset(name)
+	c.EqualizerPresets = append(c.EqualizerPresets, p)
+	c.EqualizerPreset = name
+	return nil
+}
+
+// DeleteEqualizerPreset deletes the user preset and removes it from the device mappings.
+func (c *LocalPlaybackConfig) DeleteEqualizerPreset(name string) {
+	c.deleteUserEqualizerPreset(name)
+	for dev, preset := range c.DeviceEqualizerPresets {
+		if strings.EqualFold(preset, name) {
+			delete(c.DeviceEqualizerPresets, dev)
+		}
+	}
+	if strings.EqualFold(c.EqualizerPreset, name) {
+		c.EqualizerPreset = ""
+	}
+}
+
+func (c *LocalPlaybackConfig) deleteUserEqualizerPreset(name string) {
+	c.EqualizerPresets = slices.DeleteFunc(c.EqualizerPresets, func(p EqualizerPreset) bool {
+		return strings.EqualFold(p.Name, name)
+	})
+}
+
+// ApplyDeviceEqualizerPreset applies the preset mapped to the audio device,
+// and returns true if there is one.
+func (c *LocalPlaybackConfig) ApplyDeviceEqualizerPreset(device string) bool {
+	name, ok := c.DeviceEqualizerPresets[device]
+	if !ok {
+		return false
+	}
+	p := c.FindEqualizerPreset(name)
+	if p == nil {
+		return false
+	}
+	c.ApplyEqualizerPreset(p)
+	return true
+}
+
+// SetDeviceEqualizerPreset sets the preset applied when the output switches
+// to the audio device. An empty preset name removes the mapping.
+func (c *LocalPlaybackConfig) SetDeviceEqualizerPreset(device, preset string) {
+	if preset == "" {
+		delete(c.DeviceEqualizerPresets, device)
+		return
+	}
+	if c.DeviceEqualizerPresets == nil {
+		c.DeviceEqualizerPresets = make(map[string]string)
+	}
+	c.DeviceEqualizerPresets[device] = preset
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+var parametricEQType = (*mpv.ParametricEqualizer)(nil).Type()
+
+func Test_ApplyEqualizerPreset(t *testing.T) {
+	c := &LocalPlaybackConfig{EqualizerType: parametricEQType}
+	c.ApplyEqualizerPreset(c.FindEqualizerPreset("bass boost"))
+	if c.EqualizerPreset != "Bass Boost" || !c.EqualizerEnabled || c.EqualizerType != "ISO15Band" {
+		t.Errorf("got preset %q, enabled %v, type %q", c.EqualizerPreset, c.EqualizerEnabled, c.EqualizerType)
+	}
+	if c.EqualizerPreamp != -6 || len(c.GraphicEqualizerBands) != 15 || c.GraphicEqualizerBands[0] != 6 {
+		t.Errorf("got preamp %v, bands %v", c.EqualizerPreamp, c.GraphicEqualizerBands)
+	}
+
+	p := &EqualizerPreset{Name: "Headphones", Type: parametricEQType, Preamp: -3,
+		ParametricBands: []ParametricEqualizerBand{{Type: "PK", Frequency: 100, Gain: 3, Q: 1}}}
+	c.ApplyEqualizerPreset(p)
+	if c.EqualizerType != parametricEQType || c.ParametricEqualizerPreamp != -3 ||
+		!slices.Equal(c.ParametricEqualizerBands, p.ParametricBands) {
+		t.Errorf("got type %q, preamp %v, bands %v", c.EqualizerType, c.ParametricEqualizerPreamp, c.ParametricEqualizerBands)
+	}
+	// the preset is not modified by later edits
+	c.ParametricEqualizerBands[0].Gain = 6
+	if p.ParametricBands[0].Gain != 3 {
+		t.Error("editing the applied bands modified the preset")
+	}
+}
+
+func Test_SaveEqualizerPreset(t *testing.T) {
+	c := &LocalPlaybackConfig{EqualizerType: "ISO15Band", EqualizerPreamp: -2, GraphicEqualizerBands: make([]float64, 15)}
+	if err := c.SaveEqualizerPreset("  "); err == nil {
+		t.Error("expected an error saving a preset without a name")
+	}
+	if err := c.SaveEqualizerPreset("rock"); !errors.Is(err, ErrBuiltinEqualizerPreset) {
+		t.Errorf("got error %v, want %v", err, ErrBuiltinEqualizerPreset)
+	}
+
+	if err := c.SaveEqualizerPreset(" Mine "); err != nil {
+		t.Fatal(err)
+	}
+	c.EqualizerPreamp = -4
+	// saving with the same name replaces the preset
+	if err := c.SaveEqualizerPreset("mine"); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.EqualizerPresets) != 1 || c.EqualizerPresets[0].Name != "mine" || c.EqualizerPresets[0].Preamp != -4 {
+		t.Errorf("got presets %+v", c.EqualizerPresets)
+	}
+	if c.EqualizerPreset != "mine" {
+		t.Errorf("got current preset %q, want %q", c.EqualizerPreset, "mine")
+	}
+	if p := c.FindEqualizerPreset("MINE"); p == nil || p.Preamp != -4 {
+		t.Errorf("got preset %+v", p)
+	}
+}
+
+func Test_DeleteEqualizerPreset(t *testing.T) {
+	c := &LocalPlaybackConfig{
+		EqualizerPreset:  "Mine",
+		EqualizerPresets: []EqualizerPreset{{Name: "Mine"}, {Name: "Other"}},
+	}
+	c.SetDeviceEqualizerPreset("headphones", "Mine")
+	c.SetDeviceEqualizerPreset("speakers", "Other")
+
+	c.DeleteEqualizerPreset("mine")
+	if len(c.EqualizerPresets) != 1 || c.EqualizerPresets[0].Name != "Other" {
+		t.Errorf("got presets %+v", c.EqualizerPresets)
+	}
+	if c.EqualizerPreset != "" {
+		t.Errorf("got current preset %q, want none", c.EqualizerPreset)
+	}
+	if _, ok := c.DeviceEqualizerPresets["headphones"]; ok {
+		t.Error("the mapping to the deleted preset was kept")
+	}
+	if c.DeviceEqualizerPresets["speakers"] != "Other" {
+		t.Error("the mapping to another preset was removed")
+	}
+}
+
+func Test_ApplyDeviceEqualizerPreset(t *testing.T) {
+	c := &LocalPlaybackConfig{EqualizerPresets: []EqualizerPreset{{Name: "Mine", Type: "ISO15Band", Preamp: -1}}}
+	c.SetDeviceEqualizerPreset("headphones", "Mine")
+	c.SetDeviceEqualizerPreset("speakers", "Jazz")
+	c.SetDeviceEqualizerPreset("deleted", "Gone")
+
+	for _, tc := range []struct {
+		device     string
+		wantOK     bool
+		wantPreset string
+	}{
+		{device: "headphones", wantOK: true, wantPreset: "Mine"},
+		{device: "speakers", wantOK: true, wantPreset: "Jazz"},
+		{device: "unmapped", wantOK: false, wantPreset: "Jazz"},
+		{device: "deleted", wantOK: false, wantPreset: "Jazz"},
+	} {
+		ok := c.ApplyDeviceEqualizerPreset(tc.device)
+		if ok != tc.wantOK || c.EqualizerPreset != tc.wantPreset {
+			t.Errorf("%s: got %v, preset %q, want %v, preset %q", tc.device, ok, c.EqualizerPreset, tc.wantOK, tc.wantPreset)
+		}
+	}
+
+	c.SetDeviceEqualizerPreset("headphones", "")
+	if _, ok := c.DeviceEqualizerPresets["headphones"]; ok {
+		t.Error("an empty preset name did not remove the mapping")
+	}
+}
@@ -0,0 +1,332 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/foldersync"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
+	"github.com/dweymouth/supersonic/backend/playlistio"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var (
+	ErrFolderSyncInProgress  = errors.New("a sync to this folder is already in progress")
+	ErrFolderSyncOtherServer = errors.New("the folder was synced from another server")
+)
+
+// FolderSyncPlan is the set of changes a sync will make to the sync folder.
+type FolderSyncPlan struct {
+	Dir       string
+	Changes   foldersync.Plan
+	Playlists []string
+	// file names of the playlists which are not written because
+	// a file not created by a sync exists at their path
+	PlaylistConflicts []string
+	// IDs of selected playlists and names of selected albums which were
+	// not found on the server, and whose tracks are no longer synced
+	Missing []string
+
+	conf      FolderSyncConfig
+	serverID  string
+	manifest  *foldersync.Manifest
+	tracks    map[string]*mediaprovider.Track
+	want      map[string]string
+	playlists []folderSyncPlaylist
+}
+
+type folderSyncPlaylist struct {
+	name     string
+	fileName string
+	trackIDs []string
+}
+
+// IsEmpty returns true if no tracks need to be copied or deleted.
+func (p *FolderSyncPlan) IsEmpty() bool {
+	return len(p.Changes.Download) == 0 && len(p.Changes.Delete) == 0
+}
+
+// FolderSyncManager syncs the selected playlists, albums and favorites of
+// the current server to a folder, such as that of a portable player.
+// Only missing tracks are downloaded and tracks no longer selected are deleted.
+// A manifest in the folder records the synced files; other files are left alone.
+type FolderSyncManager struct {
+	sm   *ServerManager
+	dm   *DownloadManager
+	conf *FolderSyncConfig
+
+	lock    sync.Mutex
+	running bool
+}
+
+func NewFolderSyncManager(sm *ServerManager, dm *DownloadManager, conf *FolderSyncConfig) *FolderSyncManager {
+	return &FolderSyncManager{sm: sm, dm: dm, conf: conf}
+}
+
+// AddAlbum adds the album of the current server to the albums to sync.
+func (f *FolderSyncManager) AddAlbum(id, name string) {
+	serverID := f.sm.ServerID.String()
+	if f.conf.Albums == nil {
+		f.conf.Albums = make(map[string]map[string]string)
+	}
+	if f.conf.Albums[serverID] == nil {
+		f.conf.Albums[serverID] = make(map[string]string)
+	}
+	f.conf.Albums[serverID][id] = name
+}
+
+// IsRunning returns true if a sync is downloading tracks.
+func (f *FolderSyncManager) IsRunning() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.running
+}
+
+// Plan reads the selected items from the server and compares them to the
+// contents of the sync folder. onProgress, if non-nil, is called with a
+// description of each step. Selected items which the server reports as not
+// found are synced as if deselected, but any other error reading an item
+// fails the plan, so that its tracks are not deleted from the folder.
+func (f *FolderSyncManager) Plan(ctx context.Context, onProgress func(string)) (*FolderSyncPlan, error) {
+	mp := f.sm.Server
+	if mp == nil {
+		return nil, errors.New("not connected to a server")
+	}
+	conf := *f.conf
+	if conf.Destination == "" {
+		return nil, errors.New("no sync folder chosen")
+	}
+	if err := pathtemplate.Validate(conf.FolderTemplate); err != nil {
+		return nil, err
+	}
+	if _, ok := mp.(mediaprovider.SupportsTranscodedDownload); conf.TranscodeFormat != "" && !ok {
+		return nil, ErrTranscodedDownloadUnsupported
+	}
+	progress := func(step string) {
+		if onProgress != nil {
+			onProgress(step)
+		}
+	}
+
+	plan := &FolderSyncPlan{
+		Dir:      conf.Destination,
+		conf:     conf,
+		serverID: f.sm.ServerID.String(),
+		tracks:   make(map[string]*mediaprovider.Track),
+	}
+	albums := conf.Albums[plan.serverID]
+	var ordered []*mediaprovider.Track
+	addTracks := func(tracks []*mediaprovider.Track) {
+		for _, tr := range tracks {
+			if _, ok := plan.tracks[tr.ID]; !ok && tr.ID != "" {
+				plan.tracks[tr.ID] = tr
+				ordered = append(ordered, tr)
+			}
+		}
+	}
+
+	progress("Reading playlists")
+	for _, id := range conf.PlaylistIDs[plan.serverID] {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		pl, err := mp.GetPlaylist(id)
+		if errors.Is(err, mediaprovider.ErrNotFound) {
+			log.Printf("folder sync: playlist %s not found", id)
+			plan.Missing = append(plan.Missing, id)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading playlist %s: %w", id, err)
+		}
+		addTracks(pl.Tracks)
+		plan.playlists = append(plan.playlists, folderSyncPlaylist{name: pl.Name, trackIDs: sharedutil.TracksToIDs(pl.Tracks)})
+	}
+
+	progress("Reading albums")
+	albumIDs := make([]string, 0, len(albums))
+	for id := range albums {
+		albumIDs = append(albumIDs, id)
+	}
+	slices.Sort(albumIDs)
+	if conf.FavoriteTracks || conf.FavoriteAlbums {
+		progress("Reading favorites")
+		favs, err := mp.GetFavorites()
+		if err != nil {
+			return nil, err
+		}
+		if conf.FavoriteTracks {
+			addTracks(favs.Tracks)
+			plan.playlists = append(plan.playlists, folderSyncPlaylist{name: "Favorites", trackIDs: sharedutil.TracksToIDs(favs.Tracks)})
+		}
+		if conf.FavoriteAlbums {
+			for _, al := range favs.Albums {
+				if _, ok := albums[al.ID]; !ok {
+					albumIDs = append(albumIDs, al.ID)
+				}
+			}
+		}
+	}
+	for _, id := range albumIDs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		al, err := mp.GetAlbum(id)
+		if errors.Is(err, mediaprovider.ErrNotFound) {
+			log.Printf("folder sync: album %s not found", id)
+			if name, ok := albums[id]; ok {
+				plan.Missing = append(plan.Missing, name)
+			}
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading album %s: %w", id, err)
+		}
+		addTracks(al.Tracks)
+	}
+
+	progress("Comparing files")
+	manifest, err := foldersync.ReadManifest(conf.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.ServerID != "" && manifest.ServerID != plan.serverID {
+		return nil, ErrFolderSyncOtherServer
+	}
+	plan.manifest = manifest
+
+	paths := pathtemplate.RenderAll(conf.FolderTemplate, ordered, conf.TranscodeFormat)
+	plan.want = make(map[string]string, len(ordered))
+	for i, tr := range ordered {
+		plan.want[tr.ID] = paths[i]
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(conf.Destination, filepath.FromSlash(path)))
+		return err == nil
+	}
+	redownload := len(manifest.Tracks) > 0 &&
+		(manifest.Format != conf.TranscodeFormat || manifest.BitRate != conf.TranscodeBitRate)
+	plan.Changes = foldersync.Diff(manifest, plan.want, exists, redownload)
+	playlists := plan.playlists[:0]
+	for _, pl := range plan.playlists {
+		pl.fileName = pathtemplate.SanitizeFileName(pl.name) + playlistio.FormatM3U8.FileExtension()
+		if exists(pl.fileName) && !slices.Contains(manifest.Playlists, pl.fileName) {
+			plan.PlaylistConflicts = append(plan.PlaylistConflicts, pl.fileName)
+			continue
+		}
+		playlists = append(playlists, pl)
+		plan.Playlists = append(plan.Playlists, pl.name)
+	}
+	plan.playlists = playlists
+	return plan, nil
+}
+
+// Apply queues the downloads of the plan with the download manager as a job
+// with the given name. When they have finished, the tracks no longer selected
+// are deleted, the playlist files and manifest are written and onFinished,
+// if non-nil, is called.
+func (f *FolderSyncManager) Apply(plan *FolderSyncPlan, jobName string, onFinished func(error)) error {
+	f.lock.Lock()
+	if f.running {
+		f.lock.Unlock()
+		return ErrFolderSyncInProgress
+	}
+	f.running = true
+	f.lock.Unlock()
+
+	files := make([]DownloadFile, len(plan.Changes.Download))
+	for i, e := range plan.Changes.Download {
+		files[i] = DownloadFile{Track: plan.tracks[e.TrackID], Path: e.Path}
+	}
+	opts := DownloadOptions{
+		TranscodeFormat:  plan.conf.TranscodeFormat,
+		TranscodeBitRate: plan.conf.TranscodeBitRate,
+	}
+	_, err := f.dm.EnqueueFiles(jobName, plan.Dir, files, opts, func(job DownloadJob) {
+		err := f.finish(plan, job)
+		f.lock.Lock()
+		f.running = false
+		f.lock.Unlock()
+		if onFinished != nil {
+			onFinished(err)
+		}
+	})
+	if err != nil {
+		f.lock.Lock()
+		f.running = false
+		f.lock.Unlock()
+	}
+	return err
+}
+
+func (f *FolderSyncManager) finish(plan *FolderSyncPlan, job DownloadJob) error {
+	m := plan.manifest
+	failed := false
+	// items are in the order of the plan's downloads
+	downloaded := make([]bool, len(job.Items))
+	for i, it := range job.Items {
+		downloaded[i] = it.Status == DownloadDone
+		failed = failed || !downloaded[i]
+	}
+
+	var errs []error
+	if err := m.Apply(plan.Dir, plan.Changes, plan.want, downloaded); err != nil {
+		errs = append(errs, err)
+	}
+
+	var written []string
+	for _, pl := range plan.playlists {
+		if err := writeSyncedPlaylist(plan, pl); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		written = append(written, pl.fileName)
+	}
+	for _, old := range m.Playlists {
+		if !slices.Contains(written, old) {
+			if err := foldersync.RemoveFile(plan.Dir, old); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	m.Playlists = written
+
+	m.ServerID = plan.serverID
+	if !failed {
+		// otherwise tracks not yet transcoded to the new format are redownloaded next time
+		m.Format, m.BitRate = plan.conf.TranscodeFormat, plan.conf.TranscodeBitRate
+	}
+	m.LastSync = time.Now()
+	if err := m.Write(plan.Dir); err != nil {
+		errs = append(errs, err)
+	}
+	if failed {
+		errs = append(errs, errors.New("some tracks could not be downloaded"))
+	}
+	return errors.Join(errs...)
+}
+
+// writes the playlist with the tracks which are present in the sync folder
+func writeSyncedPlaylist(plan *FolderSyncPlan, pl folderSyncPlaylist) error {
+	out := &playlistio.Playlist{Name: pl.name}
+	for _, id := range pl.trackIDs {
+		if path, ok := plan.manifest.Tracks[id]; ok {
+			out.Entries = append(out.Entries, playlistio.EntryFromTrack(plan.tracks[id], path))
+		}
+	}
+	f, err := os.Create(filepath.Join(plan.Dir, pl.fileName))
+	if err != nil {
+		return err
+	}
+	if err := playlistio.Write(f, playlistio.FormatM3U8, out); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
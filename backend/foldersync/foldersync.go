@@ -0,0 +1,162 @@
+// Package foldersync keeps track of the tracks copied to a folder, such as
+// the music folder of a portable player, so that later syncs only copy the
+// tracks which are missing and delete the ones no longer selected.
+package foldersync
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest file in the root of the sync folder.
+const ManifestFileName = ".supersonic-sync.json"
+
+// Manifest records what was synced to a folder. Files not recorded
+// in the manifest are never modified or deleted by a sync: a track or
+// playlist whose path is taken by such a file is not copied.
+type Manifest struct {
+	ServerID string `json:"serverID"`
+	// Format and bit rate the tracks were transcoded to, if any
+	Format  string `json:"format,omitempty"`
+	BitRate int    `json:"bitRate,omitempty"`
+	// track ID -> file path relative to the sync folder, slash-separated
+	Tracks map[string]string `json:"tracks"`
+	// playlist files written to the sync folder, relative paths
+	Playlists []string  `json:"playlists,omitempty"`
+	LastSync  time.Time `json:"lastSync"`
+}
+
+// Entry is a track file in the sync folder.
+type Entry struct {
+	TrackID string
+	Path    string
+}
+
+// Plan is the set of changes to bring a sync folder up to date.
+type Plan struct {
+	// tracks to be copied to the folder
+	Download []Entry
+	// files to be deleted once the tracks replacing them, if any, are copied
+	Delete []Entry
+	// tracks not copied because a file not recorded in the manifest exists at their path
+	Conflicts []Entry
+	// number of tracks already up to date
+	Unchanged int
+}
+
+// ReadManifest reads the manifest of the sync folder,
+// or returns an empty manifest if the folder was never synced.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{Tracks: make(map[string]string)}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m.Tracks == nil {
+		m.Tracks = make(map[string]string)
+	}
+	return &m, nil
+}
+
+// Write writes the manifest to the sync folder.
+func (m *Manifest) Write(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFileName), b, 0644)
+}
+
+// Diff compares the wanted tracks, track ID -> relative path, to those
+// recorded in the manifest. A track is downloaded if it is not in the manifest,
+// its path changed, its file no longer exists or redownload is true, e.g.
+// because the transcoding settings changed, unless a file not recorded in the
+// manifest exists at its path. Recorded tracks which are no longer wanted at
+// their path are deleted.
+func Diff(m *Manifest, want map[string]string, exists func(path string) bool, redownload bool) Plan {
+	var plan Plan
+	managed := make(map[string]struct{}, len(m.Tracks))
+	for _, path := range m.Tracks {
+		managed[path] = struct{}{}
+	}
+	wantedPaths := make(map[string]struct{}, len(want))
+	for id, path := range want {
+		wantedPaths[path] = struct{}{}
+		_, isManaged := managed[path]
+		if old, ok := m.Tracks[id]; ok && old == path && !redownload && exists(path) {
+			plan.Unchanged++
+		} else if !isManaged && exists(path) {
+			plan.Conflicts = append(plan.Conflicts, Entry{TrackID: id, Path: path})
+		} else {
+			plan.Download = append(plan.Download, Entry{TrackID: id, Path: path})
+		}
+	}
+	for id, path := range m.Tracks {
+		if want[id] == path {
+			continue // unchanged or redownloaded in place
+		}
+		if _, ok := wantedPaths[path]; ok {
+			continue // overwritten by another wanted track
+		}
+		plan.Delete = append(plan.Delete, Entry{TrackID: id, Path: path})
+	}
+	sortEntries(plan.Download)
+	sortEntries(plan.Delete)
+	sortEntries(plan.Conflicts)
+	return plan
+}
+
+// Apply records the tracks of the plan which were copied to dir, downloaded[i]
+// being true if plan.Download[i] was, and deletes the files of the plan. The old
+// file of a wanted track is kept until the track is copied to its new path, so
+// that a failed download doesn't remove the track from the folder.
+func (m *Manifest) Apply(dir string, plan Plan, want map[string]string, downloaded []bool) error {
+	for i, e := range plan.Download {
+		if downloaded[i] {
+			m.Tracks[e.TrackID] = e.Path
+		}
+	}
+	var errs []error
+	for _, e := range plan.Delete {
+		if path, wanted := want[e.TrackID]; wanted && m.Tracks[e.TrackID] != path {
+			continue // keep the old file until its replacement is downloaded
+		}
+		if err := RemoveFile(dir, e.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if m.Tracks[e.TrackID] == e.Path {
+			delete(m.Tracks, e.TrackID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RemoveFile deletes the file at the path relative to dir, and then any of its
+// parent directories within dir which are left empty.
+func RemoveFile(dir, path string) error {
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	root := filepath.Clean(dir)
+	for parent := filepath.Dir(full); parent != root && len(parent) > len(root); parent = filepath.Dir(parent) {
+		if os.Remove(parent) != nil {
+			break // not empty
+		}
+	}
+	return nil
+}
+
+func sortEntries(e []Entry) {
+	sort.Slice(e, func(i, j int) bool { return e[i].Path < e[j].Path })
+}
@@ -0,0 +1,132 @@
+package foldersync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+	m := &Manifest{Tracks: map[string]string{
+		"1": "A/01 One.flac",
+		"2": "A/02 Two.flac",
+		"3": "B/01 Three.flac", // no longer wanted
+		"4": "B/02 Four.flac",  // renamed
+	}}
+	want := map[string]string{
+		"1": "A/01 One.flac",
+		"2": "A/02 Two.flac", // deleted from the folder by the user
+		"4": "B/02 Four (Remix).flac",
+		"5": "C/01 Five.flac",
+	}
+	// files copied by the last sync, except the one deleted by the user
+	exists := func(path string) bool {
+		for _, p := range m.Tracks {
+			if p == path {
+				return path != "A/02 Two.flac"
+			}
+		}
+		return false
+	}
+
+	plan := Diff(m, want, exists, false)
+	if plan.Unchanged != 1 {
+		t.Errorf("expected 1 unchanged track, got %d", plan.Unchanged)
+	}
+	checkEntries(t, "Download", plan.Download, []string{"A/02 Two.flac", "B/02 Four (Remix).flac", "C/01 Five.flac"})
+	checkEntries(t, "Delete", plan.Delete, []string{"B/01 Three.flac", "B/02 Four.flac"})
+
+	plan = Diff(m, want, exists, true)
+	if plan.Unchanged != 0 || len(plan.Download) != 4 {
+		t.Errorf("expected all tracks to be redownloaded, got %+v", plan)
+	}
+	checkEntries(t, "Delete", plan.Delete, []string{"B/01 Three.flac", "B/02 Four.flac"})
+}
+
+func Test_DiffConflicts(t *testing.T) {
+	m := &Manifest{Tracks: map[string]string{
+		"1": "A/01 One.flac",
+		"2": "A/02 Two.flac",
+	}}
+	want := map[string]string{
+		"1": "A/01 One.flac",
+		"2": "A/02 Two (Live).flac", // renamed onto a file of the user
+		"3": "B/01 Three.flac",      // file of the user
+		"4": "C/01 Four.flac",
+	}
+	userFiles := map[string]bool{"A/02 Two (Live).flac": true, "B/01 Three.flac": true}
+	exists := func(path string) bool { return userFiles[path] || path == "A/01 One.flac" }
+
+	// the transcoding change doesn't make a sync overwrite the user's files
+	plan := Diff(m, want, exists, true)
+	checkEntries(t, "Download", plan.Download, []string{"A/01 One.flac", "C/01 Four.flac"})
+	checkEntries(t, "Conflicts", plan.Conflicts, []string{"A/02 Two (Live).flac", "B/01 Three.flac"})
+	checkEntries(t, "Delete", plan.Delete, []string{"A/02 Two.flac"})
+}
+
+func Test_ManifestApply(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"A/old1.mp3", "A/old2.mp3", "A/gone.mp3", "A/new1.mp3", "B/new3.mp3"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0755)
+		os.WriteFile(filepath.Join(dir, filepath.FromSlash(p)), nil, 0644)
+	}
+	m := &Manifest{Tracks: map[string]string{
+		"1": "A/old1.mp3", // renamed and copied
+		"2": "A/old2.mp3", // renamed, but failed to copy
+		"3": "A/gone.mp3", // no longer wanted
+	}}
+	want := map[string]string{"1": "A/new1.mp3", "2": "A/new2.mp3", "4": "B/new3.mp3"}
+	plan := Diff(m, want, func(path string) bool { return false }, false)
+	downloaded := make([]bool, len(plan.Download))
+	for i, e := range plan.Download {
+		downloaded[i] = e.TrackID != "2"
+	}
+
+	if err := m.Apply(dir, plan, want, downloaded); err != nil {
+		t.Fatal(err)
+	}
+	wantTracks := map[string]string{"1": "A/new1.mp3", "2": "A/old2.mp3", "4": "B/new3.mp3"}
+	if len(m.Tracks) != len(wantTracks) {
+		t.Errorf("got tracks %v, want %v", m.Tracks, wantTracks)
+	}
+	for id, path := range wantTracks {
+		if m.Tracks[id] != path {
+			t.Errorf("track %s: got path %q, want %q", id, m.Tracks[id], path)
+		}
+	}
+	for p, wantExists := range map[string]bool{"A/old1.mp3": false, "A/old2.mp3": true, "A/gone.mp3": false} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p)))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s: got exists %v, want %v", p, exists, wantExists)
+		}
+	}
+}
+
+func Test_RemoveFile(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "A", "B"), 0755)
+	os.WriteFile(filepath.Join(dir, "A", "B", "x.mp3"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "A", "y.mp3"), nil, 0644)
+
+	if err := RemoveFile(dir, "A/B/x.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "A", "B")); !os.IsNotExist(err) {
+		t.Error("expected empty directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "A", "y.mp3")); err != nil {
+		t.Error("expected non-empty directory to be kept")
+	}
+}
+
+func checkEntries(t *testing.T, name string, entries []Entry, want []string) {
+	t.Helper()
+	if len(entries) != len(want) {
+		t.Fatalf("%s: got %v, want %v", name, entries, want)
+	}
+	for i := range entries {
+		if entries[i].Path != want[i] {
+			t.Errorf("%s: got %v, want %v", name, entries, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
+	"github.com/google/uuid"
+)
+
+// fakeFolderSyncServer serves the playlists by ID, failing with err for the others
+type fakeFolderSyncServer struct {
+	mediaprovider.MediaProvider
+
+	playlists map[string]*mediaprovider.PlaylistWithTracks
+	err       error
+}
+
+func (f *fakeFolderSyncServer) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
+	if pl, ok := f.playlists[playlistID]; ok {
+		return pl, nil
+	}
+	return nil, f.err
+}
+
+func Test_FolderSyncPlanUnreadablePlaylist(t *testing.T) {
+	mp := &fakeFolderSyncServer{playlists: map[string]*mediaprovider.PlaylistWithTracks{
+		"1": {Playlist: mediaprovider.Playlist{Name: "Mix"}, Tracks: []*mediaprovider.Track{{ID: "a", Title: "A"}}},
+	}}
+	sm := &ServerManager{Server: mp, ServerID: uuid.New()}
+	conf := &FolderSyncConfig{
+		Destination:    t.TempDir(),
+		PlaylistIDs:    map[string][]string{sm.ServerID.String(): {"1", "2"}},
+		FolderTemplate: pathtemplate.DefaultTemplate,
+	}
+	f := NewFolderSyncManager(sm, nil, conf)
+
+	// a playlist deleted on the server is no longer synced
+	mp.err = fmt.Errorf("%w: playlist 2", mediaprovider.ErrNotFound)
+	plan, err := f.Plan(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(plan.Missing, []string{"2"}) || !slices.Equal(plan.Playlists, []string{"Mix"}) {
+		t.Errorf("got missing %v, playlists %v, want [2], [Mix]", plan.Missing, plan.Playlists)
+	}
+
+	// other errors fail the plan rather than deleting the playlist's tracks
+	mp.err = errors.New("connection reset")
+	if _, err := f.Plan(context.Background(), nil); !errors.Is(err, mp.err) {
+		t.Errorf("got error %v, want %v", err, mp.err)
+	}
+}
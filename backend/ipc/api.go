@@ -15,6 +15,10 @@ const (
 	VolumePath    = "/volume"            // ?v=<vol>
 	ShowPath      = "/window/show"
 	QuitPath      = "/window/quit"
+
+	ABLoopAPath     = "/transport/ab-loop/a"
+	ABLoopBPath     = "/transport/ab-loop/b"
+	ABLoopClearPath = "/transport/ab-loop/clear"
 )
 
 type Response struct {
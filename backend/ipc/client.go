@@ -64,6 +64,18 @@ func (c *Client) SeekBySeconds(secs float64) error {
 	return c.sendRequest(SeekBySecondsPath(secs))
 }
 
+func (c *Client) SetABLoopA() error {
+	return c.sendRequest(ABLoopAPath)
+}
+
+func (c *Client) SetABLoopB() error {
+	return c.sendRequest(ABLoopBPath)
+}
+
+func (c *Client) ClearABLoop() error {
+	return c.sendRequest(ABLoopClearPath)
+}
+
 func (c *Client) SetVolume(vol int) error {
 	return c.sendRequest(SetVolumePath(vol))
 }
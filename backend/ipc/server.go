@@ -17,6 +17,9 @@ type PlaybackHandler interface {
 	SeekNext() error
 	SeekSeconds(float64) error
 	SeekBySeconds(float64) error
+	SetABLoopA() error
+	SetABLoopB() error
+	ClearABLoop() error
 	Volume() int
 	SetVolume(int) error
 }
@@ -74,6 +77,9 @@ func (s *serverImpl) createHandler() http.Handler {
 	m.HandleFunc(NextPath, s.makeSimpleEndpointHandler(s.pbHandler.SeekNext))
 	m.HandleFunc(TimePosPath, s.makeFloatEndpointHandler(s.pbHandler.SeekSeconds, "s"))
 	m.HandleFunc(SeekByPath, s.makeFloatEndpointHandler(s.pbHandler.SeekBySeconds, "s"))
+	m.HandleFunc(ABLoopAPath, s.makeSimpleEndpointHandler(s.pbHandler.SetABLoopA))
+	m.HandleFunc(ABLoopBPath, s.makeSimpleEndpointHandler(s.pbHandler.SetABLoopB))
+	m.HandleFunc(ABLoopClearPath, s.makeSimpleEndpointHandler(s.pbHandler.ClearABLoop))
 	m.HandleFunc(VolumePath, func(w http.ResponseWriter, r *http.Request) {
 		v := r.URL.Query().Get("v")
 		if vol, err := strconv.Atoi(v); err == nil {
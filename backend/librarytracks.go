@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// the full library track list is expensive to fetch, so cache it for this long
+const libraryTracksCacheTTL = 10 * time.Minute
+
+// libraryTrackCache caches the full track list of the current server's library
+// for the features which match against all tracks, such as smart playlists
+// and playlist import.
+type libraryTrackCache struct {
+	sm *ServerManager
+
+	lock     sync.Mutex
+	tracks   []*mediaprovider.Track
+	cachedAt time.Time
+	// the read of the library in progress, if any, shared by all readers
+	fetch *libraryFetch
+}
+
+type libraryFetch struct {
+	done   chan struct{}
+	tracks []*mediaprovider.Track
+	err    error
+}
+
+func newLibraryTrackCache(sm *ServerManager) *libraryTrackCache {
+	c := &libraryTrackCache{sm: sm}
+	sm.OnServerConnected(c.invalidate)
+	sm.OnLogout(c.invalidate)
+	return c
+}
+
+// Drops the cached track list so that it is re-fetched on the next read.
+// A read in progress completes, but its result is not cached.
+func (c *libraryTrackCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.tracks = nil
+	c.fetch = nil
+}
+
+// Returns all the tracks of the library, failing if they could not all be read.
+// The returned tracks are deep copies of the cached tracks and may be freely modified.
+func (c *libraryTrackCache) getTracks() ([]*mediaprovider.Track, error) {
+	c.lock.Lock()
+	if c.tracks != nil && time.Since(c.cachedAt) < libraryTracksCacheTTL {
+		tracks := c.tracks
+		c.lock.Unlock()
+		return copyTracks(tracks), nil
+	}
+	fetch := c.fetch
+	if fetch != nil {
+		// wait for the read started by another caller
+		c.lock.Unlock()
+		<-fetch.done
+	} else {
+		server := c.sm.Server
+		if server == nil {
+			c.lock.Unlock()
+			return nil, errors.New("not connected to a server")
+		}
+		fetch = &libraryFetch{done: make(chan struct{})}
+		c.fetch = fetch
+		c.lock.Unlock()
+
+		fetch.tracks, fetch.err = readAllTracks(context.Background(), server, mediaprovider.TrackFilterOptions{})
+
+		c.lock.Lock()
+		// a partial library must not be cached, since smart playlists
+		// evaluated against it are saved to server playlists
+		if c.fetch == fetch {
+			c.fetch = nil
+			if fetch.err == nil {
+				c.tracks, c.cachedAt = fetch.tracks, time.Now()
+			}
+		}
+		c.lock.Unlock()
+		close(fetch.done)
+	}
+
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+	return copyTracks(fetch.tracks), nil
+}
+
+func copyTracks(tracks []*mediaprovider.Track) []*mediaprovider.Track {
+	copies := make([]*mediaprovider.Track, len(tracks))
+	for i, tr := range tracks {
+		t := *tr
+		t.Genres = slices.Clone(tr.Genres)
+		t.ArtistIDs = slices.Clone(tr.ArtistIDs)
+		t.ArtistNames = slices.Clone(tr.ArtistNames)
+		t.ComposerIDs = slices.Clone(tr.ComposerIDs)
+		t.ComposerNames = slices.Clone(tr.ComposerNames)
+		t.AlbumArtistNames = slices.Clone(tr.AlbumArtistNames)
+		copies[i] = &t
+	}
+	return copies
+}
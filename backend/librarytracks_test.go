@@ -0,0 +1,27 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+func Test_LibraryTrackCacheReturnsCopies(t *testing.T) {
+	mp := &fakeSyncServer{tracks: []*mediaprovider.Track{{ID: "a", Title: "A", Genres: []string{"Rock"}}, {ID: "b", Title: "B"}}}
+	c := newLibraryTrackCache(&ServerManager{Server: mp, ServerID: uuid.New()})
+
+	tracks, err := c.getTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks[0].Title = "changed"
+	tracks[0].Genres[0] = "changed"
+	tracks, err = c.getTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 2 || tracks[0].Title != "A" || tracks[0].Genres[0] != "Rock" {
+		t.Errorf("got tracks %+v, want the cached tracks unchanged", tracks)
+	}
+}
@@ -0,0 +1,116 @@
+// Package loudness stores the integrated loudness of tracks measured during
+// playback, so that tracks without ReplayGain info can be normalized on later plays.
+package loudness
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ReferenceLoudness is the loudness in LUFS that tracks are normalized to,
+// the reference level of ReplayGain 2.0.
+const ReferenceLoudness = -18.0
+
+// SilenceLoudness is the loudness reported by the EBU R128
+// measurement before any audio has passed its gate.
+const SilenceLoudness = -70.0
+
+// Gain returns the gain in dB to normalize a track of the given
+// integrated loudness in LUFS to the reference loudness.
+func Gain(lufs float64) float64 {
+	return ReferenceLoudness - lufs
+}
+
+// IsValid returns true if lufs is a plausible integrated loudness measurement.
+func IsValid(lufs float64) bool {
+	return lufs > SilenceLoudness && lufs < 0
+}
+
+// Measurement is the loudness of a track measured during playback.
+type Measurement struct {
+	// integrated loudness in LUFS
+	Loudness float64
+	// true peak as a linear amplitude, as the ReplayGain peak,
+	// or 0 if it was not measured
+	Peak float64
+}
+
+// UnmarshalJSON also reads the bare loudness values stored by earlier versions.
+func (m *Measurement) UnmarshalJSON(b []byte) error {
+	var lufs float64
+	if err := json.Unmarshal(b, &lufs); err == nil {
+		*m = Measurement{Loudness: lufs}
+		return nil
+	}
+	type plain Measurement
+	return json.Unmarshal(b, (*plain)(m))
+}
+
+// Cache is a set of loudness measurements keyed by track ID,
+// stored as a JSON file.
+type Cache struct {
+	path string
+
+	lock     sync.Mutex
+	loudness map[string]Measurement
+}
+
+// Open reads the cache from the file at path, or returns an empty cache if the
+// file does not exist or is corrupt, in which case it is replaced on the next Put.
+func Open(path string) (*Cache, error) {
+	c := &Cache{path: path, loudness: make(map[string]Measurement)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.loudness); err != nil {
+		log.Printf("discarding corrupt loudness cache %s: %s", path, err.Error())
+		c.loudness = make(map[string]Measurement)
+	}
+	return c, nil
+}
+
+// Get returns the loudness measured for the track, if any.
+func (c *Cache) Get(trackID string) (Measurement, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	m, ok := c.loudness[trackID]
+	return m, ok
+}
+
+// Put stores the loudness measured for the track and writes the cache file.
+// The file is replaced atomically, so it is never left partially written.
+func (c *Cache) Put(trackID string, m Measurement) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.loudness[trackID] = m
+	b, err := json.Marshal(c.loudness)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
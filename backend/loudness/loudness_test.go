@@ -0,0 +1,84 @@
+package loudness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Cache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server", "loudness.json")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected empty cache")
+	}
+	want := Measurement{Loudness: -9.5, Peak: 1.05}
+	if err := c.Put("1", want); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := c.Get("1"); !ok || m != want {
+		t.Errorf("got %+v, %v; want %+v, true", m, ok, want)
+	}
+}
+
+func Test_CacheWithoutPeaks(t *testing.T) {
+	// caches written before the peak was stored
+	path := filepath.Join(t.TempDir(), "loudness.json")
+	if err := os.WriteFile(path, []byte(`{"1":-9.5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := c.Get("1"); !ok || m != (Measurement{Loudness: -9.5}) {
+		t.Errorf("got %+v, %v; want loudness -9.5 without a peak", m, ok)
+	}
+}
+
+func Test_CacheCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loudness.json")
+	if err := os.WriteFile(path, []byte(`{"1":{"loudne`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the corrupt cache to be empty")
+	}
+	want := Measurement{Loudness: -7}
+	if err := c.Put("2", want); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := c.Get("2"); !ok || m != want {
+		t.Errorf("got %+v, %v; want %+v, true", m, ok, want)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("got %d files in the cache dir, want only the cache file", len(entries))
+	}
+}
+
+func Test_Gain(t *testing.T) {
+	if g := Gain(-9.5); g != -8.5 {
+		t.Errorf("got %v, want -8.5", g)
+	}
+	for lufs, want := range map[float64]bool{-70: false, -23: true, -5: true, 0: false} {
+		if IsValid(lufs) != want {
+			t.Errorf("IsValid(%v) != %v", lufs, want)
+		}
+	}
+}
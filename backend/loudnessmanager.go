@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/loudness"
+	"github.com/google/uuid"
+)
+
+const loudnessCacheFile = "loudness.json"
+
+// LoudnessManager stores the loudness of the tracks of the current server
+// measured during playback, in the server's cache directory.
+type LoudnessManager struct {
+	s            *ServerManager
+	baseCacheDir string
+
+	lock     sync.Mutex
+	serverID uuid.UUID
+	cache    *loudness.Cache
+}
+
+func NewLoudnessManager(s *ServerManager, baseCacheDir string) *LoudnessManager {
+	return &LoudnessManager{s: s, baseCacheDir: baseCacheDir}
+}
+
+// Get returns the loudness measured for the track, if any.
+func (l *LoudnessManager) Get(trackID string) (loudness.Measurement, bool) {
+	c := l.serverCache()
+	if c == nil {
+		return loudness.Measurement{}, false
+	}
+	return c.Get(trackID)
+}
+
+// Put stores the loudness measured for the track.
+func (l *LoudnessManager) Put(trackID string, m loudness.Measurement) {
+	c := l.serverCache()
+	if c == nil {
+		return
+	}
+	if err := c.Put(trackID, m); err != nil {
+		log.Printf("error saving loudness cache: %s", err.Error())
+	}
+}
+
+// returns the cache of the current server, opening it if the server changed
+func (l *LoudnessManager) serverCache() *loudness.Cache {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	serverID := l.s.ServerID
+	if serverID == uuid.Nil {
+		return nil
+	}
+	if l.cache != nil && l.serverID == serverID {
+		return l.cache
+	}
+	c, err := loudness.Open(filepath.Join(l.baseCacheDir, serverID.String(), loudnessCacheFile))
+	if err != nil {
+		log.Printf("error reading loudness cache: %s", err.Error())
+		return nil
+	}
+	l.serverID, l.cache = serverID, c
+	return c
+}
@@ -0,0 +1,176 @@
+// Package lrc parses synced lyrics in the LRC format, including
+// the enhanced (A2) extension with word-level timestamps.
+package lrc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+var ErrNoLyrics = errors.New("no synced lyric lines found")
+
+var (
+	// [mm:ss], [mm:ss.xx], [mm:ss.xxx], and the nonstandard [mm:ss:xx]
+	timeTagRegex = regexp.MustCompile(`^(\d+):(\d{1,2})(?:[.:](\d{1,3}))?$`)
+	// enhanced LRC word timestamp
+	wordTagRegex = regexp.MustCompile(`<(\d+:\d{1,2}(?:[.:]\d{1,3})?)>`)
+	idTagRegex   = regexp.MustCompile(`^([a-zA-Z#]+):(.*)$`)
+)
+
+// Parse parses LRC formatted lyrics. Lines with several timestamps are
+// expanded to one line per timestamp, and the [offset:] tag is applied
+// to all timestamps. The [ti:] and [ar:] tags set the title and artist.
+func Parse(lrc string) (*mediaprovider.Lyrics, error) {
+	lyrics := &mediaprovider.Lyrics{Synced: true}
+	var offset float64
+	for _, line := range strings.Split(lrc, "\n") {
+		line = strings.TrimSpace(line)
+		var times []float64
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			tag := line[1:end]
+			if t, ok := parseTimestamp(tag); ok {
+				times = append(times, t)
+			} else if m := idTagRegex.FindStringSubmatch(tag); m != nil && len(times) == 0 {
+				value := strings.TrimSpace(m[2])
+				switch strings.ToLower(m[1]) {
+				case "ti":
+					lyrics.Title = value
+				case "ar":
+					lyrics.Artist = value
+				case "offset":
+					// positive offset shifts lyrics to appear sooner
+					if ms, err := strconv.Atoi(strings.TrimPrefix(value, "+")); err == nil {
+						offset = float64(ms) / 1000
+					}
+				}
+			} else {
+				break // start of lyric text
+			}
+			line = line[end+1:]
+		}
+		if len(times) == 0 {
+			continue // tag-only or malformed line
+		}
+
+		text, words := ParseWords(line)
+		for _, t := range times {
+			lyricLine := mediaprovider.LyricLine{Text: text, Start: t}
+			if len(words) > 0 {
+				// word timestamps are relative to the first occurrence of a repeated line
+				lyricLine.Words = make([]mediaprovider.LyricWord, len(words))
+				for i, w := range words {
+					lyricLine.Words[i] = mediaprovider.LyricWord{Text: w.Text, Start: w.Start + t - times[0]}
+				}
+			}
+			lyrics.Lines = append(lyrics.Lines, lyricLine)
+		}
+	}
+	if len(lyrics.Lines) == 0 {
+		return nil, ErrNoLyrics
+	}
+
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Start < lyrics.Lines[j].Start
+	})
+	for i := range lyrics.Lines {
+		line := &lyrics.Lines[i]
+		line.Start = max(0, line.Start-offset)
+		for j := range line.Words {
+			line.Words[j].Start = max(0, line.Words[j].Start-offset)
+		}
+	}
+	return lyrics, nil
+}
+
+// ParseWords strips enhanced LRC word timestamps (<mm:ss.xx>) from the text
+// of a lyric line, returning the plain text and the timed words.
+// If the text contains no word timestamps, words will be nil.
+func ParseWords(text string) (plain string, words []mediaprovider.LyricWord) {
+	tags := wordTagRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(tags) == 0 {
+		return strings.TrimSpace(text), nil
+	}
+	var sb strings.Builder
+	// untimed text before the first timestamp is sung with the first word
+	pending := text[:tags[0][0]]
+	for i, tag := range tags {
+		end := len(text)
+		if i < len(tags)-1 {
+			end = tags[i+1][0]
+		}
+		wordText := pending + text[tag[1]:end]
+		pending = ""
+		sb.WriteString(wordText)
+		if wordText == "" {
+			continue // e.g. trailing timestamp marking the end of the last word
+		}
+		start, _ := parseTimestamp(text[tag[2]:tag[3]])
+		words = append(words, mediaprovider.LyricWord{Text: wordText, Start: start})
+	}
+	plain = sb.String()
+	if trimmed := strings.TrimSpace(plain); trimmed != plain {
+		// keep the words consistent with the trimmed text
+		plain = trimmed
+		if len(words) > 0 {
+			words[0].Text = strings.TrimLeft(words[0].Text, " \t")
+			words[len(words)-1].Text = strings.TrimRight(words[len(words)-1].Text, " \t")
+		}
+	}
+	return plain, words
+}
+
+// parses a "mm:ss.xx" timestamp into seconds
+func parseTimestamp(s string) (float64, bool) {
+	m := timeTagRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	mins, _ := strconv.Atoi(m[1])
+	sec, _ := strconv.Atoi(m[2])
+	secs := float64(mins*60 + sec)
+	if frac := m[3]; frac != "" {
+		f, _ := strconv.Atoi(frac)
+		secs += float64(f) / math.Pow10(len(frac))
+	}
+	return secs, true
+}
+
+// Format formats synced lyrics as LRC, including enhanced LRC
+// word timestamps for lines that have word timings.
+func Format(lyrics *mediaprovider.Lyrics) string {
+	var sb strings.Builder
+	if lyrics.Title != "" {
+		fmt.Fprintf(&sb, "[ti:%s]\n", lyrics.Title)
+	}
+	if lyrics.Artist != "" {
+		fmt.Fprintf(&sb, "[ar:%s]\n", lyrics.Artist)
+	}
+	for _, line := range lyrics.Lines {
+		fmt.Fprintf(&sb, "[%s]", FormatTimestamp(line.Start))
+		if len(line.Words) == 0 {
+			sb.WriteString(line.Text)
+		}
+		for _, w := range line.Words {
+			fmt.Fprintf(&sb, "<%s>%s", FormatTimestamp(w.Start), w.Text)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// FormatTimestamp formats seconds as an LRC "mm:ss.xx" timestamp.
+func FormatTimestamp(secs float64) string {
+	centis := int(math.Round(max(0, secs) * 100))
+	return fmt.Sprintf("%02d:%02d.%02d", centis/6000, centis/100%60, centis%100)
+}
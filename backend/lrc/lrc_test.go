@@ -0,0 +1,58 @@
+package lrc
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	lyrics, err := Parse(`[ti:Song]
+[ar:Artist]
+[offset:+500]
+[00:12.00][01:40.00]chorus
+[00:05]intro
+[00:20.5]<00:20.50>Some <00:21.00>words <00:22.25>here<00:23.00>
+not a lyric line
+[00:30.00]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyrics.Title != "Song" || lyrics.Artist != "Artist" || !lyrics.Synced {
+		t.Errorf("unexpected metadata: %+v", lyrics)
+	}
+	want := []struct {
+		text  string
+		start float64
+	}{
+		{"intro", 4.5}, {"chorus", 11.5}, {"Some words here", 20}, {"", 29.5}, {"chorus", 99.5},
+	}
+	if len(lyrics.Lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(lyrics.Lines))
+	}
+	for i, w := range want {
+		if l := lyrics.Lines[i]; l.Text != w.text || math.Abs(l.Start-w.start) > 0.001 {
+			t.Errorf("line %d: got %q at %v, want %q at %v", i, l.Text, l.Start, w.text, w.start)
+		}
+	}
+
+	words := lyrics.Lines[2].Words
+	if len(words) != 3 || words[0].Text != "Some " || words[2].Text != "here" ||
+		math.Abs(words[1].Start-20.5) > 0.001 {
+		t.Errorf("unexpected word timings: %+v", words)
+	}
+
+	if _, err := Parse("plain lyrics\nwith no timestamps"); err == nil {
+		t.Error("expected error for unsynced lyrics")
+	}
+}
+
+func Test_FormatRoundTrip(t *testing.T) {
+	in := "[ti:Song]\n[ar:Artist]\n[00:04.50]intro\n[01:39.50]<01:39.50>Some <01:40.25>words\n"
+	lyrics, err := Parse(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := Format(lyrics); out != in {
+		t.Errorf("got %q, want %q", out, in)
+	}
+}
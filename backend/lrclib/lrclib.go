@@ -0,0 +1,231 @@
+// Package lrclib is a client for the LRCLIB lyrics database API.
+package lrclib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/lrc"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const DefaultBaseURL = "https://lrclib.net"
+
+var ErrNotFound = errors.New("lrclib lyrics not found")
+
+type Client struct {
+	// BaseURL is the root URL of the LRCLIB instance, without the /api path
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the LRCLIB instance at baseURL,
+// or the public instance if baseURL is empty.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// GetLyrics searches for and fetches the lyrics of a track
+func (c *Client) GetLyrics(name, artist, album string, durationSecs int) (*mediaprovider.Lyrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/get", nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("track_name", name)
+	q.Add("artist_name", artist)
+	q.Add("album_name", album)
+	q.Add("duration", strconv.Itoa(durationSecs))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return parseLrcLibResponse(resp)
+}
+
+// PublishRequest is the track metadata and lyrics to publish to LRCLIB.
+type PublishRequest struct {
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+// Publish uploads lyrics to LRCLIB. Publishing requires solving a
+// proof-of-work challenge from the server, which may take some time.
+func (c *Client) Publish(ctx context.Context, pub PublishRequest) error {
+	token, err := c.obtainPublishToken(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(pub)
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/publish", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Publish-Token", token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// PublishRequestFromLyrics creates a PublishRequest for synced lyrics of the track.
+func PublishRequestFromLyrics(track *mediaprovider.Track, lyrics *mediaprovider.Lyrics) PublishRequest {
+	var artist string
+	if len(track.ArtistNames) > 0 {
+		artist = track.ArtistNames[0]
+	}
+	plain := make([]string, 0, len(lyrics.Lines))
+	for _, line := range lyrics.Lines {
+		plain = append(plain, line.Text)
+	}
+	return PublishRequest{
+		TrackName:    track.Title,
+		ArtistName:   artist,
+		AlbumName:    track.Album,
+		Duration:     float64(track.Duration),
+		PlainLyrics:  strings.Join(plain, "\n"),
+		SyncedLyrics: lrc.Format(&mediaprovider.Lyrics{Synced: true, Lines: lyrics.Lines}),
+	}
+}
+
+type challenge struct {
+	Prefix string `json:"prefix"`
+	Target string `json:"target"`
+}
+
+// requests and solves a publish challenge, returning the publish token
+func (c *Client) obtainPublishToken(ctx context.Context) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/request-challenge", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	var ch challenge
+	if err := json.NewDecoder(resp.Body).Decode(&ch); err != nil {
+		return "", fmt.Errorf("failed to decode lrclib challenge: %w", err)
+	}
+	nonce, err := solveChallenge(ctx, ch)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%d", ch.Prefix, nonce), nil
+}
+
+// finds a nonce such that sha256(prefix + nonce) <= target
+func solveChallenge(ctx context.Context, ch challenge) (int, error) {
+	target, err := hex.DecodeString(ch.Target)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lrclib challenge target: %w", err)
+	}
+	for nonce := 0; ; nonce++ {
+		if nonce%100_000 == 0 && ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		hash := sha256.Sum256([]byte(ch.Prefix + strconv.Itoa(nonce)))
+		if bytes.Compare(hash[:], target) <= 0 {
+			return nonce, nil
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("User-Agent", "Supersonic")
+	return req, nil
+}
+
+func responseError(resp *http.Response) error {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Message != "" {
+		return fmt.Errorf("error from lrclib: %s", e.Message)
+	}
+	return fmt.Errorf("error from lrclib: status %d", resp.StatusCode)
+}
+
+func parseLrcLibResponse(resp *http.Response) (*mediaprovider.Lyrics, error) {
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error from lrclib: status %d", resp.StatusCode)
+	}
+
+	var parsedResponse lrcLibResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsedResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode lrclib response: %w", err)
+	}
+	lrcs := &mediaprovider.Lyrics{
+		Title:  parsedResponse.TrackName,
+		Artist: parsedResponse.ArtistName,
+	}
+	if parsedResponse.SyncedLyrics != "" {
+		synced, err := lrc.Parse(parsedResponse.SyncedLyrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse synced lyrics: %w", err)
+		}
+		lrcs.Synced = true
+		lrcs.Lines = synced.Lines
+	} else {
+		for _, line := range strings.Split(parsedResponse.PlainLyrics, "\n") {
+			lrcs.Lines = append(lrcs.Lines, mediaprovider.LyricLine{Text: line})
+		}
+	}
+	return lrcs, nil
+}
+
+type lrcLibResponse struct {
+	ID           int     `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	Instrumental bool    `json:"instrumental"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
@@ -0,0 +1,55 @@
+package lrclib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// a local stand-in for the LRCLIB publish API
+func Test_Publish(t *testing.T) {
+	var published PublishRequest
+	var token string
+	// easy target so the challenge is solved quickly
+	target := "00ff" + strings.Repeat("f", 60)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/request-challenge":
+			json.NewEncoder(w).Encode(challenge{Prefix: "abc", Target: target})
+		case "/api/publish":
+			token = r.Header.Get("X-Publish-Token")
+			if err := json.NewDecoder(r.Body).Decode(&published); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	pub := PublishRequest{TrackName: "Song", ArtistName: "Artist", Duration: 180, SyncedLyrics: "[00:01.00]line"}
+	if err := NewClient(srv.URL).Publish(context.Background(), pub); err != nil {
+		t.Fatal(err)
+	}
+	if published != pub {
+		t.Errorf("published %+v, want %+v", published, pub)
+	}
+	prefix, nonce, _ := strings.Cut(token, ":")
+	if prefix != "abc" || nonce == "" {
+		t.Errorf("unexpected publish token %q", token)
+	}
+	// the nonce must solve the proof of work challenge
+	hash := sha256.Sum256([]byte(prefix + nonce))
+	want, _ := hex.DecodeString(target)
+	if bytes.Compare(hash[:], want) > 0 {
+		t.Errorf("hash %x of publish token %q is above the target %s", hash, token, target)
+	}
+}
@@ -0,0 +1,390 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/20after4/configdir"
+	"github.com/dweymouth/supersonic/backend/lrc"
+	"github.com/dweymouth/supersonic/backend/lrclib"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/util"
+	"github.com/google/uuid"
+)
+
+// Sources which lyrics can be fetched from
+const (
+	LyricsSourceServer  = "Server"
+	LyricsSourceSidecar = "Sidecar"
+	LyricsSourceFolder  = "Lyrics folder"
+	LyricsSourceLrcLib  = "LRCLIB"
+)
+
+var SupportedLyricsSources = []string{
+	LyricsSourceServer,
+	LyricsSourceSidecar,
+	LyricsSourceFolder,
+	LyricsSourceLrcLib,
+}
+
+// LyricsResult is a set of lyrics along with the source it was fetched from.
+type LyricsResult struct {
+	Source string
+	Lyrics *mediaprovider.Lyrics
+}
+
+// LyricsManager resolves lyrics for tracks by querying the configured
+// chain of lyrics sources in order, caching the results on disk.
+type LyricsManager struct {
+	sm            *ServerManager
+	downloads     *DownloadManager
+	conf          *LyricsConfig
+	appConf       *AppConfig
+	baseCacheDir  string
+	defaultFolder string
+
+	cacheLock sync.Mutex
+}
+
+type lyricsCacheEntry struct {
+	FetchedAt time.Time
+	// Source is empty if no source had lyrics for the track
+	Source string
+	// Pinned entries were chosen by the user and never expire
+	Pinned bool
+	Lyrics *mediaprovider.Lyrics
+}
+
+func NewLyricsManager(sm *ServerManager, downloads *DownloadManager, conf *LyricsConfig, appConf *AppConfig, baseCacheDir, configDir string) *LyricsManager {
+	return &LyricsManager{
+		sm:            sm,
+		downloads:     downloads,
+		conf:          conf,
+		appConf:       appConf,
+		baseCacheDir:  baseCacheDir,
+		defaultFolder: filepath.Join(configDir, "lyrics"),
+	}
+}
+
+// LyricsFolder returns the user lyrics folder, which is searched
+// for .lrc and .txt files named after the track.
+func (l *LyricsManager) LyricsFolder() string {
+	if l.conf.LyricsFolder != "" {
+		return l.conf.LyricsFolder
+	}
+	return l.defaultFolder
+}
+
+// GetLyrics returns the lyrics for the track from the first source in the
+// configured chain that has them, or nil if no source does.
+// Results, including the absence of lyrics, are cached on disk.
+func (l *LyricsManager) GetLyrics(track *mediaprovider.Track) *LyricsResult {
+	entry := l.readCache(track.ID)
+	if entry != nil && entry.Pinned {
+		return &LyricsResult{Source: entry.Source, Lyrics: entry.Lyrics}
+	}
+	// otherwise, lyrics synced by the user are preferred over all sources
+	if p := l.userSyncedFilePath(track); p != "" {
+		if lyrics, _ := l.loadFirstLyricsFile(track, []string{p}); lyrics != nil {
+			return &LyricsResult{Source: LyricsSourceFolder, Lyrics: lyrics}
+		}
+	}
+	if entry != nil {
+		if entry.Source == "" {
+			return nil
+		}
+		return &LyricsResult{Source: entry.Source, Lyrics: entry.Lyrics}
+	}
+
+	var result *LyricsResult
+	var fetchFailed bool
+	for _, source := range l.enabledSources() {
+		lyrics, err := l.fetchFromSource(source, track)
+		if err != nil {
+			log.Printf("Error fetching lyrics from %s: %v", source, err)
+			fetchFailed = true
+			continue
+		}
+		if lyrics != nil {
+			result = &LyricsResult{Source: source, Lyrics: lyrics}
+			break
+		}
+	}
+	// don't cache a negative result that may be due to a transient error
+	if result != nil || !fetchFailed {
+		entry = &lyricsCacheEntry{FetchedAt: time.Now()}
+		if result != nil {
+			entry.Source, entry.Lyrics = result.Source, result.Lyrics
+		}
+		l.writeCache(track.ID, entry)
+	}
+	return result
+}
+
+// GetAllLyrics queries all enabled sources for the track's lyrics,
+// bypassing the cache, so the user can choose between them.
+func (l *LyricsManager) GetAllLyrics(track *mediaprovider.Track) []LyricsResult {
+	sources := l.enabledSources()
+	results := make([]*mediaprovider.Lyrics, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			lyrics, err := l.fetchFromSource(source, track)
+			if err != nil {
+				log.Printf("Error fetching lyrics from %s: %v", source, err)
+			}
+			results[i] = lyrics
+		}(i, source)
+	}
+	wg.Wait()
+
+	var all []LyricsResult
+	for i, lyrics := range results {
+		if lyrics != nil {
+			all = append(all, LyricsResult{Source: sources[i], Lyrics: lyrics})
+		}
+	}
+	return all
+}
+
+// SetChosenLyrics pins the lyrics chosen by the user for the track,
+// to be returned by GetLyrics until ClearCachedLyrics is called.
+func (l *LyricsManager) SetChosenLyrics(trackID string, result LyricsResult) {
+	l.writeCache(trackID, &lyricsCacheEntry{
+		FetchedAt: time.Now(),
+		Source:    result.Source,
+		Pinned:    true,
+		Lyrics:    result.Lyrics,
+	})
+}
+
+// ClearCachedLyrics removes the cached lyrics for the track,
+// including a choice pinned by the user.
+func (l *LyricsManager) ClearCachedLyrics(trackID string) {
+	if p := l.cacheFilePath(trackID); p != "" {
+		l.cacheLock.Lock()
+		defer l.cacheLock.Unlock()
+		_ = os.Remove(p)
+	}
+}
+
+// SaveUserLyrics saves synced lyrics as an .lrc file in the user lyrics
+// folder, which will be preferred over all other sources for the track.
+func (l *LyricsManager) SaveUserLyrics(track *mediaprovider.Track, lyrics *mediaprovider.Lyrics) error {
+	p := l.userSyncedFilePath(track)
+	if p == "" {
+		return errors.New("not connected to a server")
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, []byte(lrc.Format(lyrics)), 0644); err != nil {
+		return err
+	}
+	l.ClearCachedLyrics(track.ID)
+	return nil
+}
+
+// PublishToLrcLib uploads synced lyrics for the track to LRCLIB.
+func (l *LyricsManager) PublishToLrcLib(ctx context.Context, track *mediaprovider.Track, lyrics *mediaprovider.Lyrics) error {
+	return lrclib.NewClient(l.conf.LrcLibURL).Publish(ctx, lrclib.PublishRequestFromLyrics(track, lyrics))
+}
+
+// PruneExpiredCache deletes the cached lyrics of all servers which
+// have expired and were not pinned by the user.
+func (l *LyricsManager) PruneExpiredCache() {
+	dirs, _ := filepath.Glob(filepath.Join(l.baseCacheDir, "*", "lyrics"))
+	l.cacheLock.Lock()
+	defer l.cacheLock.Unlock()
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, p := range files {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				continue
+			}
+			var entry lyricsCacheEntry
+			if err := json.Unmarshal(b, &entry); err != nil || l.isExpired(&entry) {
+				os.Remove(p)
+			}
+		}
+	}
+}
+
+func (l *LyricsManager) enabledSources() []string {
+	var sources []string
+	for _, s := range l.conf.Sources {
+		if !slices.Contains(SupportedLyricsSources, s) || slices.Contains(sources, s) {
+			continue
+		}
+		if s == LyricsSourceLrcLib && !l.appConf.EnableLrcLib {
+			continue
+		}
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+// fetchFromSource returns nil, nil if the source has no lyrics for the track
+func (l *LyricsManager) fetchFromSource(source string, track *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
+	switch source {
+	case LyricsSourceServer:
+		if lp, ok := l.sm.Server.(mediaprovider.LyricsProvider); ok {
+			lyrics, err := lp.GetLyrics(track)
+			if lyrics != nil && len(lyrics.Lines) == 0 {
+				lyrics = nil
+			}
+			return lyrics, err
+		}
+	case LyricsSourceSidecar:
+		return l.loadFirstLyricsFile(track, l.sidecarFilePaths(track))
+	case LyricsSourceFolder:
+		return l.loadFirstLyricsFile(track, l.lyricsFolderFilePaths(track))
+	case LyricsSourceLrcLib:
+		var artist string
+		if len(track.ArtistNames) > 0 {
+			artist = track.ArtistNames[0]
+		}
+		lyrics, err := lrclib.NewClient(l.conf.LrcLibURL).GetLyrics(track.Title, artist, track.Album, track.Duration)
+		if errors.Is(err, lrclib.ErrNotFound) {
+			return nil, nil
+		}
+		return lyrics, err
+	}
+	return nil, nil
+}
+
+// lyrics files next to the track's audio file, if it is accessible locally,
+// and next to the files the track was downloaded to
+func (l *LyricsManager) sidecarFilePaths(track *mediaprovider.Track) []string {
+	var audioPaths []string
+	if track.FilePath != "" && filepath.IsAbs(track.FilePath) {
+		if _, err := os.Stat(track.FilePath); err == nil {
+			audioPaths = append(audioPaths, track.FilePath)
+		}
+	}
+	if l.downloads != nil {
+		audioPaths = append(audioPaths, l.downloads.DownloadedFilePaths(track)...)
+	}
+
+	var paths []string
+	for _, p := range audioPaths {
+		base := strings.TrimSuffix(p, filepath.Ext(p))
+		paths = append(paths, base+".lrc", base+".txt")
+	}
+	return paths
+}
+
+// lyrics synced by the user are stored in a folder per server,
+// since track IDs are only unique within a server
+func (l *LyricsManager) userSyncedFilePath(track *mediaprovider.Track) string {
+	if l.sm.ServerID == uuid.Nil {
+		return ""
+	}
+	return filepath.Join(l.LyricsFolder(), l.sm.ServerID.String(), util.SanitizeFileName(track.ID)+".lrc")
+}
+
+func (l *LyricsManager) lyricsFolderFilePaths(track *mediaprovider.Track) []string {
+	dir := l.LyricsFolder()
+	names := []string{util.SanitizeFileName(track.ID)}
+	title := util.SanitizeFileName(track.Title)
+	if len(track.ArtistNames) > 0 {
+		names = append(names, util.SanitizeFileName(track.ArtistNames[0])+" - "+title)
+	}
+	names = append(names, title)
+
+	var paths []string
+	for _, ext := range []string{".lrc", ".txt"} {
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name+ext))
+		}
+	}
+	return paths
+}
+
+func (l *LyricsManager) loadFirstLyricsFile(track *mediaprovider.Track, paths []string) (*mediaprovider.Lyrics, error) {
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		lyrics, err := lrc.Parse(string(b))
+		if err != nil {
+			// not synced - treat as plain text lyrics
+			lyrics = &mediaprovider.Lyrics{}
+			for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+				lyrics.Lines = append(lyrics.Lines, mediaprovider.LyricLine{Text: strings.TrimSpace(line)})
+			}
+		}
+		if lyrics.Title == "" {
+			lyrics.Title = track.Title
+		}
+		if lyrics.Artist == "" && len(track.ArtistNames) > 0 {
+			lyrics.Artist = track.ArtistNames[0]
+		}
+		return lyrics, nil
+	}
+	return nil, nil
+}
+
+func (l *LyricsManager) readCache(trackID string) *lyricsCacheEntry {
+	p := l.cacheFilePath(trackID)
+	if p == "" {
+		return nil
+	}
+	l.cacheLock.Lock()
+	defer l.cacheLock.Unlock()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	var entry lyricsCacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil || l.isExpired(&entry) {
+		return nil
+	}
+	return &entry
+}
+
+func (l *LyricsManager) isExpired(entry *lyricsCacheEntry) bool {
+	ttl := time.Duration(l.conf.CacheTTLDays) * 24 * time.Hour
+	return !entry.Pinned && time.Since(entry.FetchedAt) > ttl
+}
+
+func (l *LyricsManager) writeCache(trackID string, entry *lyricsCacheEntry) {
+	p := l.cacheFilePath(trackID)
+	if p == "" || l.conf.CacheTTLDays <= 0 && !entry.Pinned {
+		return
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.cacheLock.Lock()
+	defer l.cacheLock.Unlock()
+	if err := os.WriteFile(p, b, 0644); err != nil {
+		log.Printf("failed to write lyrics cache: %v", err)
+	}
+}
+
+func (l *LyricsManager) cacheFilePath(trackID string) string {
+	// if user logged out with pending fetches in progress,
+	// make sure we don't write to nil (00000000-*0) cache directory
+	if l.sm.ServerID == uuid.Nil {
+		return ""
+	}
+	dir := filepath.Join(l.baseCacheDir, l.sm.ServerID.String(), "lyrics")
+	configdir.MakePath(dir)
+	return filepath.Join(dir, util.SanitizeFileName(trackID)+".json")
+}
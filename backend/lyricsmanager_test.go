@@ -0,0 +1,183 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+// fakeLyricsServer returns lyrics for the tracks whose ID is in lyrics,
+// and fails with err, if set, for the others.
+type fakeLyricsServer struct {
+	mediaprovider.MediaProvider
+
+	lyrics map[string]string
+	err    error
+	calls  int
+}
+
+func (f *fakeLyricsServer) GetLyrics(track *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
+	f.calls++
+	if text, ok := f.lyrics[track.ID]; ok {
+		return &mediaprovider.Lyrics{Lines: []mediaprovider.LyricLine{{Text: text}}}, nil
+	}
+	return nil, f.err
+}
+
+func newTestLyricsManager(t *testing.T, server *fakeLyricsServer, sources ...string) *LyricsManager {
+	sm := &ServerManager{Server: server, ServerID: uuid.New()}
+	conf := &LyricsConfig{Sources: sources, LyricsFolder: t.TempDir(), CacheTTLDays: 7}
+	return NewLyricsManager(sm, nil, conf, &AppConfig{}, t.TempDir(), t.TempDir())
+}
+
+func writeLyricsFile(t *testing.T, path, text string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lyricsText(r *LyricsResult) string {
+	if r == nil || r.Lyrics == nil || len(r.Lyrics.Lines) == 0 {
+		return ""
+	}
+	return r.Lyrics.Lines[0].Text
+}
+
+func Test_GetLyricsSourceOrder(t *testing.T) {
+	track := &mediaprovider.Track{ID: "1", Title: "Song"}
+	for _, tc := range []struct {
+		sources    []string
+		wantSource string
+		wantText   string
+	}{
+		{sources: []string{LyricsSourceFolder, LyricsSourceServer}, wantSource: LyricsSourceFolder, wantText: "from folder"},
+		{sources: []string{LyricsSourceServer, LyricsSourceFolder}, wantSource: LyricsSourceServer, wantText: "from server"},
+		// sources without lyrics are skipped
+		{sources: []string{LyricsSourceSidecar, LyricsSourceServer}, wantSource: LyricsSourceServer, wantText: "from server"},
+		// LRCLIB is not queried unless enabled
+		{sources: []string{LyricsSourceLrcLib, LyricsSourceFolder}, wantSource: LyricsSourceFolder, wantText: "from folder"},
+	} {
+		l := newTestLyricsManager(t, &fakeLyricsServer{lyrics: map[string]string{"1": "from server"}}, tc.sources...)
+		writeLyricsFile(t, filepath.Join(l.LyricsFolder(), "Song.txt"), "from folder")
+
+		r := l.GetLyrics(track)
+		if r == nil || r.Source != tc.wantSource || lyricsText(r) != tc.wantText {
+			t.Errorf("%v: got %+v, want source %s with %q", tc.sources, r, tc.wantSource, tc.wantText)
+		}
+	}
+}
+
+func Test_GetLyricsNegativeCache(t *testing.T) {
+	track := &mediaprovider.Track{ID: "1", Title: "Song"}
+	server := &fakeLyricsServer{}
+	l := newTestLyricsManager(t, server, LyricsSourceServer, LyricsSourceFolder)
+
+	if r := l.GetLyrics(track); r != nil {
+		t.Fatalf("got %+v, want no lyrics", r)
+	}
+	// the absence of lyrics is cached, so sources are not queried again
+	writeLyricsFile(t, filepath.Join(l.LyricsFolder(), "Song.lrc"), "new lyrics")
+	if r := l.GetLyrics(track); r != nil || server.calls != 1 {
+		t.Errorf("got %+v after %d server calls, want no lyrics after 1", r, server.calls)
+	}
+	// until the cache entry expires
+	l.writeCache(track.ID, &lyricsCacheEntry{FetchedAt: time.Now().Add(-8 * 24 * time.Hour)})
+	if r := l.GetLyrics(track); lyricsText(r) != "new lyrics" {
+		t.Errorf("got %+v, want the new lyrics", r)
+	}
+
+	// a negative result due to an error is not cached
+	track2 := &mediaprovider.Track{ID: "2", Title: "Other Song"}
+	server.err = errors.New("connection refused")
+	l.GetLyrics(track2)
+	if e := l.readCache(track2.ID); e != nil {
+		t.Errorf("got cache entry %+v after an error, want none", e)
+	}
+}
+
+func Test_GetLyricsCacheTTL(t *testing.T) {
+	track := &mediaprovider.Track{ID: "1", Title: "Song"}
+	server := &fakeLyricsServer{lyrics: map[string]string{"1": "from server"}}
+	l := newTestLyricsManager(t, server, LyricsSourceServer)
+	l.conf.CacheTTLDays = 0
+
+	// with no TTL, nothing is cached except pinned lyrics
+	l.GetLyrics(track)
+	l.GetLyrics(track)
+	if server.calls != 2 {
+		t.Errorf("got %d server calls, want 2", server.calls)
+	}
+	l.SetChosenLyrics(track.ID, LyricsResult{Source: LyricsSourceFolder,
+		Lyrics: &mediaprovider.Lyrics{Lines: []mediaprovider.LyricLine{{Text: "chosen"}}}})
+	if r := l.GetLyrics(track); r == nil || r.Source != LyricsSourceFolder || lyricsText(r) != "chosen" {
+		t.Errorf("got %+v, want the chosen lyrics", r)
+	}
+}
+
+func Test_SidecarLyricsOfDownloadedFile(t *testing.T) {
+	dir := t.TempDir()
+	track := &mediaprovider.Track{ID: "1", Title: "Song", FilePath: "/music/Artist/Song.flac"}
+	dl := NewDownloadManager(&ServerManager{}, &DownloadConfig{FolderTemplate: "{title}", LastDestination: dir}, t.TempDir())
+	l := newTestLyricsManager(t, &fakeLyricsServer{}, LyricsSourceSidecar)
+	l.downloads = dl
+
+	writeLyricsFile(t, filepath.Join(dir, "Song.flac"), "audio")
+	writeLyricsFile(t, filepath.Join(dir, "Song.lrc"), "[00:01.00]sidecar")
+	if r := l.GetLyrics(track); r == nil || r.Source != LyricsSourceSidecar || lyricsText(r) != "sidecar" {
+		t.Errorf("got %+v, want the lyrics next to the downloaded file", r)
+	}
+}
+
+func Test_PruneExpiredLyricsCache(t *testing.T) {
+	l := newTestLyricsManager(t, &fakeLyricsServer{})
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	l.writeCache("expired", &lyricsCacheEntry{FetchedAt: old})
+	l.writeCache("pinned", &lyricsCacheEntry{FetchedAt: old, Pinned: true})
+	l.writeCache("fresh", &lyricsCacheEntry{FetchedAt: time.Now()})
+	// entries of other servers are pruned too
+	other := filepath.Join(l.baseCacheDir, uuid.NewString(), "lyrics", "other.json")
+	b, _ := json.Marshal(&lyricsCacheEntry{FetchedAt: old})
+	writeLyricsFile(t, other, string(b))
+
+	l.PruneExpiredCache()
+	for _, tc := range []struct {
+		path       string
+		wantExists bool
+	}{
+		{path: l.cacheFilePath("expired"), wantExists: false},
+		{path: l.cacheFilePath("pinned"), wantExists: true},
+		{path: l.cacheFilePath("fresh"), wantExists: true},
+		{path: other, wantExists: false},
+	} {
+		if _, err := os.Stat(tc.path); (err == nil) != tc.wantExists {
+			t.Errorf("%s: got exists %v, want %v", tc.path, err == nil, tc.wantExists)
+		}
+	}
+}
+
+func Test_UserLyricsPerServer(t *testing.T) {
+	track := &mediaprovider.Track{ID: "123", Title: "Song"}
+	l := newTestLyricsManager(t, &fakeLyricsServer{}, LyricsSourceServer)
+	lyrics := &mediaprovider.Lyrics{Synced: true, Lines: []mediaprovider.LyricLine{{Text: "synced by user"}}}
+	if err := l.SaveUserLyrics(track, lyrics); err != nil {
+		t.Fatal(err)
+	}
+	if r := l.GetLyrics(track); lyricsText(r) != "synced by user" {
+		t.Fatalf("got %+v, want the lyrics synced by the user", r)
+	}
+
+	// a track with the same ID on another server
+	l.sm.ServerID = uuid.New()
+	if r := l.GetLyrics(track); r != nil {
+		t.Errorf("got %+v for the track of another server, want no lyrics", r)
+	}
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deluan/sanitize"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// Minimum FuzzyScore for a name to be considered a close match to a query
+const FuzzyMatchThreshold = 0.7
+
+const maxFuzzyFallbackQueries = 3
+
+// FuzzyScore returns how closely the name matches the query, ignoring case and accents,
+// from 0 (nothing in common) to 1 (the name contains the query).
+// To tolerate typos, the query is compared against the whole name and each run of
+// consecutive words in the name with the same number of words as the query,
+// by the greater of the normalized edit distance and the trigram similarity.
+func FuzzyScore(name, query string) float64 {
+	name, query = normalizeFuzzy(name), normalizeFuzzy(query)
+	if query == "" {
+		return 0
+	}
+	if strings.Contains(name, query) {
+		return 1
+	}
+	best := similarity(name, query)
+	nameWords := strings.Fields(name)
+	n := len(strings.Fields(query))
+	for i := 0; i+n <= len(nameWords); i++ {
+		best = max(best, similarity(strings.Join(nameWords[i:i+n], " "), query))
+	}
+	return best
+}
+
+// FuzzyFallbackQueries returns the queries to search for instead when the given query
+// returns no results, in case it contains a typo: the leading part of each longer word,
+// since most servers match search terms by word prefix.
+func FuzzyFallbackQueries(query string) []string {
+	var queries []string
+	for _, word := range strings.Fields(normalizeFuzzy(query)) {
+		r := []rune(word)
+		if len(r) < 4 {
+			continue
+		}
+		if prefix := string(r[:max(3, len(r)/2)]); !slices.Contains(queries, prefix) {
+			queries = append(queries, prefix)
+		}
+		if len(queries) == maxFuzzyFallbackQueries {
+			break
+		}
+	}
+	return queries
+}
+
+// FuzzySearch suggests close matches for a query that returned no results.
+// search is invoked for each of the FuzzyFallbackQueries, and the results that
+// are close matches to the original query are returned, best first.
+func FuzzySearch(query string, maxResults int, search func(string) ([]*mediaprovider.SearchResult, error)) ([]*mediaprovider.SearchResult, error) {
+	var candidates []*mediaprovider.SearchResult
+	seen := make(map[string]bool)
+	for _, q := range FuzzyFallbackQueries(query) {
+		res, err := search(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			if key := strconv.Itoa(int(r.Type)) + r.ID; !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, r)
+			}
+		}
+	}
+	results := FuzzyMatchResults(candidates, query)
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results, nil
+}
+
+// FuzzyMatchResults returns the results which are close matches to the query, best first.
+func FuzzyMatchResults(results []*mediaprovider.SearchResult, query string) []*mediaprovider.SearchResult {
+	scores := make(map[*mediaprovider.SearchResult]float64, len(results))
+	matches := sharedutil.FilterSlice(results, func(r *mediaprovider.SearchResult) bool {
+		score := FuzzyScore(r.Name, query)
+		if r.ArtistName != "" {
+			score = max(score, FuzzyScore(r.ArtistName+" "+r.Name, query))
+		}
+		scores[r] = score
+		return score >= FuzzyMatchThreshold
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] > scores[matches[j]]
+	})
+	return matches
+}
+
+func normalizeFuzzy(s string) string {
+	return strings.ToLower(sanitize.Accents(s))
+}
+
+func similarity(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	l := max(len(ar), len(br))
+	if l == 0 {
+		return 1
+	}
+	editSimilarity := 1 - float64(editDistance(ar, br))/float64(l)
+	return max(editSimilarity, trigramSimilarity(a, b))
+}
+
+// Levenshtein distance
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Jaccard similarity of the sets of trigrams of a and b
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	if total := len(ta) + len(tb) - shared; total > 0 {
+		return float64(shared) / float64(total)
+	}
+	return 0
+}
+
+func trigrams(s string) map[string]struct{} {
+	// pad so that the start and end of the string form trigrams of their own
+	r := []rune("  " + s + " ")
+	set := make(map[string]struct{}, len(r))
+	for i := 0; i+3 <= len(r); i++ {
+		set[string(r[i:i+3])] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import "testing"
+
+func Test_FuzzyScore(t *testing.T) {
+	for _, tc := range []struct {
+		name, query string
+		match       bool
+	}{
+		{"Radiohead", "radiohed", true},
+		{"The Beatles", "beatls", true},
+		{"Björk", "bjork", true},
+		{"Sigur Rós", "sigur ross", true},
+		{"OK Computer", "computr", true},
+		{"Radiohead", "metallica", false},
+		{"The Beatles", "beach", false},
+	} {
+		if got := FuzzyScore(tc.name, tc.query) >= FuzzyMatchThreshold; got != tc.match {
+			t.Errorf("FuzzyScore(%q, %q) = %v, want match %v",
+				tc.name, tc.query, FuzzyScore(tc.name, tc.query), tc.match)
+		}
+	}
+}
+
+func Test_FuzzyFallbackQueries(t *testing.T) {
+	got := FuzzyFallbackQueries("radiohed ok computr")
+	want := []string{"radi", "com"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
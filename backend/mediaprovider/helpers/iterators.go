@@ -15,6 +15,7 @@ type baseIter[M, F any] struct {
 	prefetched    []*M
 	prefetchedPos int
 	done          bool
+	err           error
 }
 
 type AlbumFetchFn func(offset, limit int) ([]*mediaprovider.Album, error)
@@ -39,14 +40,40 @@ func NewArtistIterator(fetchFn ArtistFetchFn, filter mediaprovider.ArtistFilter,
 
 type TrackFetchFn func(offset, limit int) ([]*mediaprovider.Track, error)
 
-func NewTrackIterator(fetchFn TrackFetchFn, cb func(string)) mediaprovider.TrackIterator {
-	return &baseIter[mediaprovider.Track, nilFilterOptions]{
+func NewTrackIterator(fetchFn TrackFetchFn, filter mediaprovider.TrackFilter, cb func(string)) mediaprovider.TrackIterator {
+	return &baseIter[mediaprovider.Track, mediaprovider.TrackFilterOptions]{
 		prefetchCB: func(a *mediaprovider.Track) { cb(a.CoverArtID) },
-		filter:     nilFilter[mediaprovider.Track]{},
+		filter:     filter,
 		fetcher:    fetchFn,
 	}
 }
 
+// NewFilteredTrackIterator wraps a track iterator to return only the tracks matched by the filter.
+func NewFilteredTrackIterator(iter mediaprovider.TrackIterator, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if filter.IsNil() {
+		return iter
+	}
+	return &filteredIter[mediaprovider.Track]{
+		iter:    iter,
+		matches: filter.Matches,
+	}
+}
+
+// NewQueryFilteredTrackIterator wraps a track iterator to return only the tracks matched by the search query.
+func NewQueryFilteredTrackIterator(iter mediaprovider.TrackIterator, query mediaprovider.SearchQuery) mediaprovider.TrackIterator {
+	return &filteredIter[mediaprovider.Track]{iter: iter, matches: query.MatchesTrack}
+}
+
+// NewQueryFilteredAlbumIterator wraps an album iterator to return only the albums matched by the search query.
+func NewQueryFilteredAlbumIterator(iter mediaprovider.AlbumIterator, query mediaprovider.SearchQuery) mediaprovider.AlbumIterator {
+	return &filteredIter[mediaprovider.Album]{iter: iter, matches: query.MatchesAlbum}
+}
+
+// NewQueryFilteredArtistIterator wraps an artist iterator to return only the artists matched by the search query.
+func NewQueryFilteredArtistIterator(iter mediaprovider.ArtistIterator, query mediaprovider.SearchQuery) mediaprovider.ArtistIterator {
+	return &filteredIter[mediaprovider.Artist]{iter: iter, matches: query.MatchesArtist}
+}
+
 func (r *baseIter[M, F]) Next() *M {
 	if r.done {
 		return nil
@@ -61,6 +88,7 @@ func (r *baseIter[M, F]) Next() *M {
 		items, err := r.fetcher(r.serverPos, 20)
 		if err != nil {
 			log.Printf("error fetching items: %s", err.Error())
+			r.err = err
 			items = nil
 		}
 		if len(items) == 0 {
@@ -85,6 +113,28 @@ func (r *baseIter[M, F]) Next() *M {
 	return r.prefetched[0]
 }
 
+func (r *baseIter[M, F]) Err() error {
+	return r.err
+}
+
+type filteredIter[M any] struct {
+	iter    mediaprovider.MediaIterator[M]
+	matches func(*M) bool
+}
+
+func (f *filteredIter[M]) Next() *M {
+	for item := f.iter.Next(); item != nil; item = f.iter.Next() {
+		if f.matches(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+func (f *filteredIter[M]) Err() error {
+	return mediaprovider.IteratorErr(f.iter)
+}
+
 type randomAlbumIter struct {
 	filter        mediaprovider.AlbumFilter
 	prefetchCB    func(coverArtID string)
@@ -189,17 +239,3 @@ func (r *randomAlbumIter) Next() *mediaprovider.Album {
 
 	return nil
 }
-
-type nilFilterOptions struct{}
-
-type nilFilter[M any] struct{}
-
-func (n nilFilter[M]) IsNil() bool { return true }
-
-func (n nilFilter[M]) Matches(*M) bool { return true }
-
-func (n nilFilter[M]) Clone() mediaprovider.MediaFilter[M, nilFilterOptions] { return n }
-
-func (n nilFilter[M]) Options() nilFilterOptions { return nilFilterOptions{} }
-
-func (n nilFilter[M]) SetOptions(options nilFilterOptions) {}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"log"
 	"sort"
 	"strings"
 
@@ -8,6 +9,21 @@ import (
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
 
+// QueryTrackFilterOptions returns the filter options further restricted by the parts of
+// the search query that can be pushed down to the server, fetching the library's genres
+// with getGenres if the query has genre terms.
+func QueryTrackFilterOptions(q mediaprovider.SearchQuery, opts mediaprovider.TrackFilterOptions, getGenres func() ([]*mediaprovider.Genre, error)) mediaprovider.TrackFilterOptions {
+	var genres []*mediaprovider.Genre
+	if q.HasGenreTerms() {
+		var err error
+		// without the genres, the genre terms are still checked client-side
+		if genres, err = getGenres(); err != nil {
+			log.Printf("error fetching genres: %s", err.Error())
+		}
+	}
+	return q.ApplyToTrackFilterOptions(opts, genres)
+}
+
 // name and terms should be pre-converted to the same case
 func AllTermsMatch(name string, terms []string) bool {
 	for _, t := range terms {
@@ -32,6 +48,15 @@ func RankSearchResults(results []*mediaprovider.SearchResult, fullQuery string,
 		sanitizeMemo[s] = x
 		return x
 	}
+	fuzzyMemo := make(map[string]float64, len(results))
+	fuzzyScore := func(name string) float64 {
+		if x, ok := fuzzyMemo[name]; ok {
+			return x
+		}
+		x := FuzzyScore(name, fullQuery)
+		fuzzyMemo[name] = x
+		return x
+	}
 
 	sort.Slice(results, func(i, j int) bool {
 		a, b := results[i], results[j]
@@ -61,6 +86,10 @@ func RankSearchResults(results []*mediaprovider.SearchResult, fullQuery string,
 				return false // item B matches first
 			}
 		}
+		// Compare by similarity to the full query, to rank close matches above poor ones
+		if fuzzyA, fuzzyB := fuzzyScore(aName), fuzzyScore(bName); fuzzyA != fuzzyB {
+			return fuzzyA > fuzzyB
+		}
 		// Defer to item type for priority order
 		return a.Type < b.Type
 	})
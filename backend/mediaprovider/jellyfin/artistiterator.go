@@ -83,6 +83,11 @@ func (j *jellyfinMediaProvider) SearchArtists(searchQuery string, filter mediapr
 	// )
 	// return helpers.NewArtistIterator(fetcher, filter, j.prefetchCoverCB)
 
+	q := mediaprovider.ParseSearchQuery(searchQuery)
+	if !q.IsPlain() {
+		searchQuery = q.ServerQuery()
+	}
+
 	modifiedFilter := filter.Clone()
 	modifiedOptions := modifiedFilter.Options()
 	modifiedOptions.SearchQuery = searchQuery
@@ -100,7 +105,12 @@ func (j *jellyfinMediaProvider) SearchArtists(searchQuery string, filter mediapr
 		},
 		nil,
 	)
-	return helpers.NewArtistIterator(fetcher, modifiedFilter, j.prefetchCoverCB)
+	iter := helpers.NewArtistIterator(fetcher, modifiedFilter, j.prefetchCoverCB)
+	if !q.IsPlain() {
+		// check the parts of the query the name filter can't express client-side
+		return helpers.NewQueryFilteredArtistIterator(iter, q)
+	}
+	return iter
 }
 
 func makeArtistFetchFn(
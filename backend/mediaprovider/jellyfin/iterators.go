@@ -73,6 +73,18 @@ func (j *jellyfinMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 }
 
 func (j *jellyfinMediaProvider) SearchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
+	if q := mediaprovider.ParseSearchQuery(searchQuery); !q.IsPlain() {
+		// push down what can be expressed as an album filter, and check the rest client-side
+		filter = mediaprovider.NewAlbumFilter(q.ApplyToAlbumFilterOptions(filter.Options()))
+		if serverQuery := q.ServerQuery(); serverQuery != "" {
+			return helpers.NewQueryFilteredAlbumIterator(j.searchAlbums(serverQuery, filter), q)
+		}
+		return helpers.NewQueryFilteredAlbumIterator(j.IterateAlbums("", filter), q)
+	}
+	return j.searchAlbums(searchQuery, filter)
+}
+
+func (j *jellyfinMediaProvider) searchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
 	fetcher := func(offs, limit int) ([]*mediaprovider.Album, error) {
 		sr, err := j.client.Search(searchQuery, jellyfin.TypeAlbum, jellyfin.Paging{StartIndex: offs, Limit: limit})
 		if err != nil {
@@ -83,12 +95,24 @@ func (j *jellyfinMediaProvider) SearchAlbums(searchQuery string, filter mediapro
 	return helpers.NewAlbumIterator(fetcher, filter, j.prefetchCoverCB)
 }
 
-func (j *jellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.TrackIterator {
+func (j *jellyfinMediaProvider) IterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if q := mediaprovider.ParseSearchQuery(searchQuery); !q.IsPlain() {
+		// push down what can be expressed as a track filter, and check the rest client-side
+		filter = mediaprovider.NewTrackFilter(helpers.QueryTrackFilterOptions(q, filter.Options(), j.GetGenres))
+		return helpers.NewQueryFilteredTrackIterator(j.iterateTracks(q.ServerQuery(), filter), q)
+	}
+	return j.iterateTracks(searchQuery, filter)
+}
+
+func (j *jellyfinMediaProvider) iterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
 	var fetcher helpers.TrackFetchFn
 	if searchQuery == "" {
+		jfFilt, modifiedFilter := jfTrackFilterFromFilter(filter)
+		filter = modifiedFilter
 		fetcher = func(offs, limit int) ([]*mediaprovider.Track, error) {
 			var opts jellyfin.QueryOpts
 			opts.Paging = jellyfin.Paging{StartIndex: offs, Limit: limit}
+			opts.Filter = jfFilt
 			s, err := j.client.GetSongs(opts)
 			if err != nil {
 				return nil, err
@@ -104,7 +128,7 @@ func (j *jellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 			return sharedutil.MapSlice(sr.Songs, toTrack), nil
 		}
 	}
-	return helpers.NewTrackIterator(fetcher, j.prefetchCoverCB)
+	return helpers.NewTrackIterator(fetcher, filter, j.prefetchCoverCB)
 }
 
 // Creates the Jellyfin filter to implement the given mediaprovider filter,
@@ -139,3 +163,41 @@ func jfFilterFromFilter(filter mediaprovider.AlbumFilter) (jellyfin.Filter, medi
 	modifiedFilter.SetOptions(filterOptions)
 	return jfFilt, modifiedFilter
 }
+
+// Creates the Jellyfin filter to implement the given track filter,
+// and returns a modified track filter, with now-unneeded fields zeroed out.
+func jfTrackFilterFromFilter(filter mediaprovider.TrackFilter) (jellyfin.Filter, mediaprovider.TrackFilter) {
+	var jfFilt jellyfin.Filter
+
+	// Clone the original filter to not modify its options, which are used for the UI.
+	modifiedFilter := filter.Clone()
+	filterOptions := modifiedFilter.Options()
+
+	if filterOptions.ExcludeUnfavorited {
+		jfFilt.Favorite = true
+		filterOptions.ExcludeUnfavorited = false
+	}
+	if filterOptions.ExcludePlayed {
+		jfFilt.FilterPlayed = jellyfin.FilterIsNotPlayed
+		filterOptions.ExcludePlayed = false
+	} else if filterOptions.MinPlayCount == 1 {
+		jfFilt.FilterPlayed = jellyfin.FilterIsPlayed
+		filterOptions.MinPlayCount = 0
+	}
+	if filterOptions.MinYear > 0 || filterOptions.MaxYear > 0 {
+		minYear, maxYear := filterOptions.MinYear, filterOptions.MaxYear
+		if minYear == 0 {
+			minYear = 1900
+		}
+		if maxYear == 0 {
+			maxYear = time.Now().Year()
+		}
+		jfFilt.YearRange = [2]int{minYear, maxYear}
+		filterOptions.MinYear, filterOptions.MaxYear = 0, 0
+	}
+	jfFilt.Genres = filterOptions.Genres
+	filterOptions.Genres = nil
+
+	modifiedFilter.SetOptions(filterOptions)
+	return jfFilt, modifiedFilter
+}
@@ -1,14 +1,18 @@
 package jellyfin
 
 import (
+	"fmt"
 	"image"
 	"io"
 	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/lrc"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
@@ -86,7 +90,7 @@ func (j *jellyfinMediaProvider) RemovePlaylistTracks(playlistID string, removeId
 func (j *jellyfinMediaProvider) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
 	pl, err := j.client.GetPlaylist(playlistID)
 	if err != nil {
-		return err
+		return wrapNotFound(err)
 	}
 	allIndexes := make([]int, pl.SongCount)
 	for i := range allIndexes {
@@ -101,7 +105,7 @@ func (j *jellyfinMediaProvider) ReplacePlaylistTracks(playlistID string, trackID
 func (j *jellyfinMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
 	al, err := j.client.GetAlbum(albumID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 	var opts jellyfin.QueryOpts
 	opts.Filter.ParentID = albumID
@@ -167,11 +171,22 @@ func (j *jellyfinMediaProvider) GetArtistInfo(artistID string) (*mediaprovider.A
 func (j *jellyfinMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
 	tr, err := j.client.GetSong(trackID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 	return toTrack(tr), nil
 }
 
+// wrapNotFound wraps mediaprovider.ErrNotFound in the error if it is for a
+// request which failed with 404 Not Found. The client library exposes the HTTP
+// status only in its error messages, as "code: 404 Not Found"; the wording
+// is pinned by the tests against the client.
+func wrapNotFound(err error) error {
+	if strings.Contains(err.Error(), "code: "+strconv.Itoa(http.StatusNotFound)+" ") {
+		return fmt.Errorf("%w: %s", mediaprovider.ErrNotFound, err.Error())
+	}
+	return err
+}
+
 func (j *jellyfinMediaProvider) GetTopTracks(artist mediaprovider.Artist, limit int) ([]*mediaprovider.Track, error) {
 	var opts jellyfin.QueryOpts
 	opts.Paging.Limit = limit
@@ -284,11 +299,11 @@ func (j *jellyfinMediaProvider) GetPlaylists() ([]*mediaprovider.Playlist, error
 func (j *jellyfinMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
 	tr, err := j.client.GetPlaylistSongs(playlistID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 	pl, err := j.client.GetPlaylist(playlistID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err)
 	}
 
 	playlist := &mediaprovider.PlaylistWithTracks{
@@ -378,9 +393,12 @@ func (j *jellyfinMediaProvider) GetLyrics(tr *mediaprovider.Track) (*mediaprovid
 }
 
 func toLyricLine(ll jellyfin.LyricLine) mediaprovider.LyricLine {
+	// the server may pass through enhanced LRC word timestamps
+	text, words := lrc.ParseWords(ll.Text)
 	return mediaprovider.LyricLine{
-		Text:  ll.Text,
+		Text:  text,
 		Start: float64(ll.Start) / float64(runTimeTicksPerSecond),
+		Words: words,
 	}
 }
 
@@ -0,0 +1,38 @@
+package jellyfin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// checks the detection of not found errors against the errors of the client library
+func Test_GetTrackNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		status       int
+		wantNotFound bool
+	}{
+		{status: http.StatusNotFound, wantNotFound: true},
+		{status: http.StatusInternalServerError, wantNotFound: false},
+		{status: http.StatusUnauthorized, wantNotFound: false},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+		}))
+		cli, err := jellyfin.NewClient(srv.URL, "test", "1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = newJellyfinMediaProvider(cli).GetTrack("1")
+		srv.Close()
+		if err == nil {
+			t.Errorf("%d: got no error", tc.status)
+		} else if errors.Is(err, mediaprovider.ErrNotFound) != tc.wantNotFound {
+			t.Errorf("%d: got error %v, want not found %v", tc.status, err, tc.wantNotFound)
+		}
+	}
+}
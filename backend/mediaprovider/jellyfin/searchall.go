@@ -12,64 +12,143 @@ import (
 )
 
 func (s *jellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([]*mediaprovider.SearchResult, error) {
-	limit := maxResults / 3
 	var wg sync.WaitGroup
-	var albums []*jellyfin.Album
-	var artists []*jellyfin.Artist
-	var songs []*jellyfin.Song
-	var genres []jellyfin.NameID
-	var playlists []*jellyfin.Playlist
-
+	var lists searchLists
 	wg.Add(1)
 	go func() {
-		albumResult, _ := s.client.Search(searchQuery, jellyfin.TypeAlbum, jellyfin.Paging{Limit: limit})
-		albums = albumResult.Albums
+		lists = s.fetchSearchLists()
 		wg.Done()
 	}()
+	q := mediaprovider.ParseSearchQuery(searchQuery)
+	albums, artists, songs := s.searchServer(q, maxResults)
+	wg.Wait()
+
+	results := searchResults(q, albums, artists, songs, lists, maxResults)
+	if len(results) == 0 && q.IsPlain() {
+		// the query may contain a typo - suggest close matches instead,
+		// re-running only the server search for the corrected queries
+		return helpers.FuzzySearch(searchQuery, maxResults, func(query string) ([]*mediaprovider.SearchResult, error) {
+			q := mediaprovider.ParseSearchQuery(query)
+			albums, artists, songs := s.searchServer(q, maxResults)
+			return searchResults(q, albums, artists, songs, lists, maxResults), nil
+		})
+	}
+	return results, nil
+}
+
+// the playlists and genres which are searched client-side
+type searchLists struct {
+	playlists []*jellyfin.Playlist
+	genres    []jellyfin.NameID
+}
+
+// fetches the searchLists, leaving out the lists that fail to fetch
+func (s *jellyfinMediaProvider) fetchSearchLists() searchLists {
+	var wg sync.WaitGroup
+	var lists searchLists
+
 	wg.Add(1)
 	go func() {
-		artistResult, _ := s.client.Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{Limit: limit})
-		artists = artistResult.Artists
+		lists.playlists, _ = s.client.GetPlaylists()
 		wg.Done()
 	}()
+
 	wg.Add(1)
 	go func() {
-		songResult, _ := s.client.Search(searchQuery, jellyfin.TypeSong, jellyfin.Paging{Limit: limit})
-		songs = songResult.Songs
+		lists.genres, _ = s.client.GetGenres(jellyfin.Paging{})
 		wg.Done()
 	}()
 
-	querySanitized := strings.ToLower(sanitize.Accents(searchQuery))
-	queryLowerWords := strings.Fields(querySanitized)
+	wg.Wait()
+	return lists
+}
 
-	wg.Add(1)
-	go func() {
-		p, e := s.client.GetPlaylists()
-		if e == nil {
-			playlists = sharedutil.FilterSlice(p, func(p *jellyfin.Playlist) bool {
-				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(p.Name)), queryLowerWords)
-			})
-		}
-		wg.Done()
-	}()
+// searches the albums, artists and songs on the server
+func (s *jellyfinMediaProvider) searchServer(q mediaprovider.SearchQuery, maxResults int) ([]*jellyfin.Album, []*jellyfin.Artist, []*jellyfin.Song) {
+	serverQuery := q.ServerQuery()
+	limit := maxResults / 3
+	if !q.IsPlain() {
+		// request extra results since some will be filtered out client-side
+		limit = maxResults
+	}
+	var wg sync.WaitGroup
+	var albums []*jellyfin.Album
+	var artists []*jellyfin.Artist
+	var songs []*jellyfin.Song
 
-	wg.Add(1)
-	go func() {
-		g, e := s.client.GetGenres(jellyfin.Paging{})
-		if e == nil {
-			genres = sharedutil.FilterSlice(g, func(g jellyfin.NameID) bool {
-				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(g.Name)), queryLowerWords)
+	if serverQuery == "" && !q.IsPlain() {
+		// nothing to search for by name - browse the library with the
+		// parts of the query that the Jellyfin API can filter by
+		jfFilt, _ := jfTrackFilterFromFilter(mediaprovider.NewTrackFilter(
+			helpers.QueryTrackFilterOptions(q, mediaprovider.TrackFilterOptions{}, s.GetGenres)))
+		opts := jellyfin.QueryOpts{Filter: jfFilt, Paging: jellyfin.Paging{Limit: limit}}
+		wg.Add(3)
+		go func() {
+			albums, _ = s.client.GetAlbums(opts)
+			wg.Done()
+		}()
+		go func() {
+			artists, _ = s.client.GetAlbumArtists(jellyfin.QueryOpts{
+				Filter: jellyfin.Filter{Favorite: q.Favorite},
+				Paging: opts.Paging,
 			})
-		}
-		wg.Done()
-	}()
+			wg.Done()
+		}()
+		go func() {
+			songs, _ = s.client.GetSongs(opts)
+			wg.Done()
+		}()
+	} else {
+		wg.Add(3)
+		go func() {
+			albumResult, _ := s.client.Search(serverQuery, jellyfin.TypeAlbum, jellyfin.Paging{Limit: limit})
+			albums = albumResult.Albums
+			wg.Done()
+		}()
+		go func() {
+			artistResult, _ := s.client.Search(serverQuery, jellyfin.TypeArtist, jellyfin.Paging{Limit: limit})
+			artists = artistResult.Artists
+			wg.Done()
+		}()
+		go func() {
+			songResult, _ := s.client.Search(serverQuery, jellyfin.TypeSong, jellyfin.Paging{Limit: limit})
+			songs = songResult.Songs
+			wg.Done()
+		}()
+	}
 
 	wg.Wait()
+	return albums, artists, songs
+}
 
+// merges the server search results with the lists matched by the query, best matches first
+func searchResults(
+	q mediaprovider.SearchQuery,
+	albums []*jellyfin.Album,
+	artists []*jellyfin.Artist,
+	songs []*jellyfin.Song,
+	lists searchLists,
+	maxResults int,
+) []*mediaprovider.SearchResult {
+	playlists := sharedutil.FilterSlice(lists.playlists, func(p *jellyfin.Playlist) bool {
+		return q.MatchesName(p.Name, mediaprovider.SearchFieldAny)
+	})
+	genres := sharedutil.FilterSlice(lists.genres, func(g jellyfin.NameID) bool {
+		return q.MatchesGenre(g.Name)
+	})
+
+	if !q.IsPlain() {
+		albums = sharedutil.FilterSlice(albums, func(al *jellyfin.Album) bool { return q.MatchesAlbum(toAlbum(al)) })
+		artists = sharedutil.FilterSlice(artists, func(ar *jellyfin.Artist) bool { return q.MatchesArtist(toArtist(ar)) })
+		songs = sharedutil.FilterSlice(songs, func(s *jellyfin.Song) bool { return q.MatchesTrack(toTrack(s)) })
+	}
 	results := mergeResults(albums, artists, songs, playlists, genres)
-	helpers.RankSearchResults(results, searchQuery, queryLowerWords)
-
-	return results, nil
+	querySanitized := strings.ToLower(sanitize.Accents(q.ServerQuery()))
+	helpers.RankSearchResults(results, querySanitized, strings.Fields(querySanitized))
+	if !q.IsPlain() && len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results
 }
 
 func mergeResults(
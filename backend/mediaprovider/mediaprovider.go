@@ -1,6 +1,7 @@
 package mediaprovider
 
 import (
+	"errors"
 	"image"
 	"io"
 	"net/url"
@@ -32,6 +33,21 @@ type MediaIterator[M any] interface {
 	Next() *M
 }
 
+// FallibleIterator is implemented by iterators which fetch from the server
+// as they go, to report the error which ended the iteration early, if any.
+type FallibleIterator interface {
+	Err() error
+}
+
+// IteratorErr returns the error which ended the iteration early,
+// or nil if there was none or the iterator does not report errors.
+func IteratorErr[M any](iter MediaIterator[M]) error {
+	if f, ok := iter.(FallibleIterator); ok {
+		return f.Err()
+	}
+	return nil
+}
+
 type ArtistIterator = MediaIterator[Artist]
 type AlbumIterator = MediaIterator[Album]
 type TrackIterator = MediaIterator[Track]
@@ -53,6 +69,11 @@ type AlbumFilterOptions struct {
 
 	ExcludeFavorited   bool // mut. exc. with ExcludeUnfavorited
 	ExcludeUnfavorited bool // mut. exc. with ExcludeFavorited
+
+	// Match only albums having at least one of these release types. 0 == unset/match any
+	ReleaseTypes ReleaseTypes
+	// Match only albums having none of these release types
+	ExcludeReleaseTypes ReleaseTypes
 }
 
 // Clone returns a deep copy of the filter options
@@ -60,14 +81,29 @@ func (o AlbumFilterOptions) Clone() AlbumFilterOptions {
 	genres := make([]string, len(o.Genres))
 	copy(genres, o.Genres)
 	return AlbumFilterOptions{
-		MinYear:            o.MinYear,
-		MaxYear:            o.MaxYear,
-		Genres:             genres,
-		ExcludeFavorited:   o.ExcludeFavorited,
-		ExcludeUnfavorited: o.ExcludeUnfavorited,
+		MinYear:             o.MinYear,
+		MaxYear:             o.MaxYear,
+		Genres:              genres,
+		ExcludeFavorited:    o.ExcludeFavorited,
+		ExcludeUnfavorited:  o.ExcludeUnfavorited,
+		ReleaseTypes:        o.ReleaseTypes,
+		ExcludeReleaseTypes: o.ExcludeReleaseTypes,
 	}
 }
 
+// Returns true if an album with the given release types
+// passes the release type include/exclude options.
+// Albums with no release type information are treated as regular albums.
+func (o AlbumFilterOptions) ReleaseTypesMatch(releaseTypes ReleaseTypes) bool {
+	if releaseTypes == 0 {
+		releaseTypes = ReleaseTypeAlbum
+	}
+	if o.ReleaseTypes != 0 && releaseTypes&o.ReleaseTypes == 0 {
+		return false
+	}
+	return releaseTypes&o.ExcludeReleaseTypes == 0
+}
+
 type albumFilter struct {
 	options AlbumFilterOptions
 }
@@ -93,7 +129,8 @@ func (a albumFilter) Clone() AlbumFilter {
 func (a albumFilter) IsNil() bool {
 	return a.options.MinYear == 0 && a.options.MaxYear == 0 &&
 		len(a.options.Genres) == 0 &&
-		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited
+		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited &&
+		a.options.ReleaseTypes == 0 && a.options.ExcludeReleaseTypes == 0
 }
 
 func (f albumFilter) Matches(album *Album) bool {
@@ -109,6 +146,9 @@ func (f albumFilter) Matches(album *Album) bool {
 	if y := album.Year; y < f.options.MinYear || (f.options.MaxYear > 0 && y > f.options.MaxYear) {
 		return false
 	}
+	if !f.options.ReleaseTypesMatch(album.ReleaseTypes) {
+		return false
+	}
 	if len(f.options.Genres) == 0 {
 		return true
 	}
@@ -167,6 +207,107 @@ func (f artistFilter) Matches(artist *Artist) bool {
 	return true
 }
 
+type TrackFilter = MediaFilter[Track, TrackFilterOptions]
+
+type TrackFilterOptions struct {
+	MinYear int
+	MaxYear int      // 0 == unset/match any
+	Genres  []string // len(0) == unset/match any
+
+	MinRating int // 0 == unset/match any
+	MaxRating int // 0 == unset/match any
+
+	ExcludeFavorited   bool // mut. exc. with ExcludeUnfavorited
+	ExcludeUnfavorited bool // mut. exc. with ExcludeFavorited
+
+	MinPlayCount int
+	MaxPlayCount int // 0 == unset/match any; use ExcludePlayed to match only unplayed tracks
+	// match only tracks that have never been played
+	ExcludePlayed bool
+
+	MinBitRate int // kbps, 0 == unset/match any
+
+	// Matched case-insensitively as a substring of the track's content type,
+	// e.g. "flac" matches "audio/flac" and "audio/x-flac". len(0) == unset/match any
+	ContentTypes []string
+}
+
+// Clone returns a deep copy of the filter options
+func (o TrackFilterOptions) Clone() TrackFilterOptions {
+	c := o
+	c.Genres = make([]string, len(o.Genres))
+	copy(c.Genres, o.Genres)
+	c.ContentTypes = make([]string, len(o.ContentTypes))
+	copy(c.ContentTypes, o.ContentTypes)
+	return c
+}
+
+type trackFilter struct {
+	options TrackFilterOptions
+}
+
+func NewTrackFilter(options TrackFilterOptions) *trackFilter {
+	return &trackFilter{options}
+}
+
+func (t trackFilter) Options() TrackFilterOptions {
+	return t.options
+}
+
+func (t *trackFilter) SetOptions(options TrackFilterOptions) {
+	t.options = options
+}
+
+// Clone returns a deep copy of the filter
+func (t trackFilter) Clone() TrackFilter {
+	return NewTrackFilter(t.options.Clone())
+}
+
+// Returns true if the filter is the nil filter - i.e. matches everything
+func (t trackFilter) IsNil() bool {
+	o := t.options
+	return o.MinYear == 0 && o.MaxYear == 0 &&
+		len(o.Genres) == 0 && o.MinRating == 0 && o.MaxRating == 0 &&
+		!o.ExcludeFavorited && !o.ExcludeUnfavorited &&
+		o.MinPlayCount == 0 && o.MaxPlayCount == 0 && !o.ExcludePlayed &&
+		o.MinBitRate == 0 && len(o.ContentTypes) == 0
+}
+
+func (f trackFilter) Matches(track *Track) bool {
+	if track == nil {
+		return false
+	}
+	o := f.options
+	if o.ExcludeFavorited && track.Favorite {
+		return false
+	}
+	if o.ExcludeUnfavorited && !track.Favorite {
+		return false
+	}
+	if y := track.Year; y < o.MinYear || (o.MaxYear > 0 && y > o.MaxYear) {
+		return false
+	}
+	if r := track.Rating; r < o.MinRating || (o.MaxRating > 0 && r > o.MaxRating) {
+		return false
+	}
+	if p := track.PlayCount; p < o.MinPlayCount || (o.MaxPlayCount > 0 && p > o.MaxPlayCount) {
+		return false
+	}
+	if o.ExcludePlayed && track.PlayCount > 0 {
+		return false
+	}
+	if track.BitRate < o.MinBitRate {
+		return false
+	}
+	if len(o.ContentTypes) > 0 && !contentTypeMatches(o.ContentTypes, track.ContentType) {
+		return false
+	}
+	if len(o.Genres) == 0 {
+		return true
+	}
+	return genresMatch(o.Genres, track.Genres)
+}
+
 type RatingFavoriteParameters struct {
 	AlbumIDs  []string
 	ArtistIDs []string
@@ -184,6 +325,10 @@ type LoginResponse struct {
 	IsAuthError bool
 }
 
+// ErrNotFound is wrapped by the error returned by GetTrack, GetAlbum, GetPlaylist
+// and ReplacePlaylistTracks when the server reports that the item does not exist.
+var ErrNotFound = errors.New("not found")
+
 type Server interface {
 	Login(username, password string) LoginResponse
 	MediaProvider() MediaProvider
@@ -212,7 +357,7 @@ type MediaProvider interface {
 
 	IterateAlbums(sortOrder string, filter AlbumFilter) AlbumIterator
 
-	IterateTracks(searchQuery string) TrackIterator
+	IterateTracks(searchQuery string, filter TrackFilter) TrackIterator
 
 	SearchAlbums(searchQuery string, filter AlbumFilter) AlbumIterator
 
@@ -274,6 +419,18 @@ type SupportsRating interface {
 	SetRating(params RatingFavoriteParameters, rating int) error
 }
 
+type SupportsCreatePlaylistWithID interface {
+	// Creates a playlist like CreatePlaylist, returning the ID of the new playlist,
+	// or "" if the server did not report it.
+	CreatePlaylistWithID(name string, trackIDs []string) (string, error)
+}
+
+type SupportsTranscodedDownload interface {
+	// Downloads the track transcoded by the server to the given format,
+	// e.g. "mp3" or "opus", at up to the given bit rate (0 for the server default).
+	DownloadTrackTranscoded(trackID, format string, maxBitRateKbps int) (io.Reader, error)
+}
+
 type SupportsSharing interface {
 	CreateShareURL(id string) (*url.URL, error)
 	CanShareArtists() bool
@@ -322,3 +479,13 @@ func genresMatch(filterGenres, albumGenres []string) bool {
 	}
 	return false
 }
+
+func contentTypeMatches(filterTypes []string, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range filterTypes {
+		if t != "" && strings.Contains(contentType, strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
@@ -111,6 +111,10 @@ type Track struct {
 	Comment       string
 	BPM           int
 	ReplayGain    ReplayGainInfo
+	MusicBrainzID string
+
+	// Names of the album artists, if known
+	AlbumArtistNames []string
 }
 
 type ReplayGainInfo struct {
@@ -146,6 +150,15 @@ type Lyrics struct {
 type LyricLine struct {
 	Text  string
 	Start float64 // seconds
+
+	// Optional word-level timings (enhanced LRC).
+	// The concatenated Text of the words equals the line Text.
+	Words []LyricWord
+}
+
+type LyricWord struct {
+	Text  string  // including any trailing whitespace
+	Start float64 // seconds
 }
 
 type SavedPlayQueue struct {
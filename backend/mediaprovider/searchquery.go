@@ -0,0 +1,463 @@
+package mediaprovider
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/deluan/sanitize"
+)
+
+// Fields which can qualify a text term in the advanced search syntax
+const (
+	SearchFieldAny    = ""
+	SearchFieldArtist = "artist"
+	SearchFieldAlbum  = "album"
+	SearchFieldGenre  = "genre"
+	SearchFieldTitle  = "title"
+
+	searchFieldYear   = "year"
+	searchFieldRating = "rating"
+	searchFieldIs     = "is"
+)
+
+// A single text term of a search query.
+type SearchTerm struct {
+	Field  string // one of the SearchField* constants
+	Text   string // as entered by the user
+	Negate bool
+
+	normalized string
+}
+
+// SearchQuery is the structured form of a search string, which may use the advanced search syntax:
+//
+//	artist:"pink floyd" year:1970..1979 rating:>=4 is:favorite -live
+//
+// Bare words and "quoted phrases" match against any name. A term may be qualified
+// with artist:, album:, genre: or title: to match only that field, and negated
+// with a leading '-'. year: takes a single year or a range (1990..1999, 1990.. or ..1999),
+// rating: takes a rating optionally preceded by <, <=, >, >= or =, and
+// is:favorite (or -is:favorite) matches favorited (or unfavorited) items.
+// A negated year: or rating: term excludes the items in its range.
+type SearchQuery struct {
+	Terms []SearchTerm
+
+	MinYear   int // 0 == unset
+	MaxYear   int // 0 == unset
+	MinRating int // 0 == unset
+	MaxRating int // 0 == unset
+
+	// items with a year or rating in any of these ranges don't match
+	ExcludedYears   []SearchRange
+	ExcludedRatings []SearchRange
+
+	Favorite    bool // mut. exc. with NotFavorite
+	NotFavorite bool // mut. exc. with Favorite
+
+	hasQuotes bool
+}
+
+// An inclusive range of years or ratings, where 0 means unbounded.
+type SearchRange struct {
+	Min, Max int
+}
+
+func (r SearchRange) contains(n int) bool {
+	return n >= r.Min && (r.Max == 0 || n <= r.Max)
+}
+
+type searchToken struct {
+	negate bool
+	quoted bool
+	field  string
+	value  string
+}
+
+// ParseSearchQuery parses the search string into a structured query.
+// Malformed year:, rating: and is: terms are treated as plain text.
+func ParseSearchQuery(query string) SearchQuery {
+	var q SearchQuery
+	for _, tok := range tokenizeSearchQuery(query) {
+		q.hasQuotes = q.hasQuotes || tok.quoted
+		q.addToken(tok)
+	}
+	return q
+}
+
+func tokenizeSearchQuery(query string) []searchToken {
+	var tokens []searchToken
+	r := []rune(query)
+	for i := 0; i < len(r); {
+		if unicode.IsSpace(r[i]) {
+			i++
+			continue
+		}
+		var tok searchToken
+		if r[i] == '-' && i+1 < len(r) && !unicode.IsSpace(r[i+1]) {
+			tok.negate = true
+			i++
+		}
+		// optional field qualifier
+		j := i
+		for j < len(r) && unicode.IsLetter(r[j]) {
+			j++
+		}
+		if j > i && j < len(r) && r[j] == ':' {
+			if field := strings.ToLower(string(r[i:j])); isSearchField(field) {
+				tok.field = field
+				i = j + 1
+			}
+		}
+		// value - either a quoted phrase or up to the next whitespace
+		if i < len(r) && r[i] == '"' {
+			end := i + 1
+			for end < len(r) && r[end] != '"' {
+				end++
+			}
+			tok.value = string(r[i+1 : end])
+			tok.quoted = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(r) && !unicode.IsSpace(r[end]) {
+				end++
+			}
+			tok.value = string(r[i:end])
+			i = end
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+func isSearchField(field string) bool {
+	switch field {
+	case SearchFieldArtist, SearchFieldAlbum, SearchFieldGenre, SearchFieldTitle,
+		searchFieldYear, searchFieldRating, searchFieldIs:
+		return true
+	}
+	return false
+}
+
+func (q *SearchQuery) addToken(tok searchToken) {
+	switch tok.field {
+	case searchFieldYear:
+		if lo, hi, ok := parseYearRange(tok.value); ok && tok.negate {
+			q.ExcludedYears = append(q.ExcludedYears, SearchRange{Min: lo, Max: hi})
+			return
+		} else if ok {
+			q.MinYear, q.MaxYear = intersectRange(q.MinYear, q.MaxYear, lo, hi)
+			return
+		}
+	case searchFieldRating:
+		if lo, hi, ok := parseRatingRange(tok.value); ok && tok.negate {
+			q.ExcludedRatings = append(q.ExcludedRatings, SearchRange{Min: lo, Max: hi})
+			return
+		} else if ok {
+			q.MinRating, q.MaxRating = intersectRange(q.MinRating, q.MaxRating, lo, hi)
+			return
+		}
+	case searchFieldIs:
+		switch strings.ToLower(tok.value) {
+		case "favorite", "favourite", "fav", "starred":
+			q.Favorite = !tok.negate
+			q.NotFavorite = tok.negate
+			return
+		}
+	}
+
+	field, text := tok.field, tok.value
+	switch field {
+	case searchFieldYear, searchFieldRating, searchFieldIs:
+		// malformed - match literally
+		field, text = SearchFieldAny, field+":"+text
+	}
+	if normalized := normalizeSearchText(text); normalized != "" {
+		q.Terms = append(q.Terms, SearchTerm{Field: field, Text: text, Negate: tok.negate, normalized: normalized})
+	}
+}
+
+// intersects the ranges [min1, max1] and [min2, max2], where 0 means unbounded
+func intersectRange(min1, max1, min2, max2 int) (int, int) {
+	if max2 > 0 && (max1 == 0 || max2 < max1) {
+		max1 = max2
+	}
+	return max(min1, min2), max1
+}
+
+// parses a year or year range (1990, 1990..1999, 1990.., ..1999)
+func parseYearRange(s string) (int, int, bool) {
+	loStr, hiStr, isRange := strings.Cut(s, "..")
+	if !isRange {
+		y, err := strconv.Atoi(s)
+		return y, y, err == nil && y > 0
+	}
+	var lo, hi int
+	var err error
+	if loStr != "" {
+		if lo, err = strconv.Atoi(loStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	if hiStr != "" {
+		if hi, err = strconv.Atoi(hiStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	return lo, hi, lo > 0 || hi > 0
+}
+
+// parses a rating comparison (4, =4, >=4, >3, <=2, <3)
+func parseRatingRange(s string) (int, int, bool) {
+	op := strings.TrimRight(s, "0123456789")
+	r, err := strconv.Atoi(s[len(op):])
+	if err != nil || r < 0 || r > 5 {
+		return 0, 0, false
+	}
+	switch op {
+	case "", "=":
+		return r, r, r > 0
+	case ">=":
+		return r, 0, r > 0
+	case ">":
+		return r + 1, 0, r < 5
+	case "<=":
+		return 0, r, r > 0
+	case "<":
+		return 0, r - 1, r > 1
+	}
+	return 0, 0, false
+}
+
+func normalizeSearchText(s string) string {
+	return strings.ToLower(sanitize.Accents(strings.TrimSpace(s)))
+}
+
+// IsPlain returns true if the query consists only of bare words,
+// and can thus be handled entirely by a server's full-text search.
+func (q SearchQuery) IsPlain() bool {
+	if q.hasQuotes || q.MinYear > 0 || q.MaxYear > 0 || q.MinRating > 0 || q.MaxRating > 0 ||
+		len(q.ExcludedYears) > 0 || len(q.ExcludedRatings) > 0 || q.Favorite || q.NotFavorite {
+		return false
+	}
+	for _, t := range q.Terms {
+		if t.Field != SearchFieldAny || t.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerQuery returns the text to submit to a server's full-text search.
+// The results must then be further checked with the Matches* functions,
+// unless the query IsPlain. An empty string means that the query has
+// no text to search for, and results should be fetched by other means.
+func (q SearchQuery) ServerQuery() string {
+	var terms []string
+	for _, t := range q.Terms {
+		if t.Field == SearchFieldAny && !t.Negate {
+			terms = append(terms, t.Text)
+		}
+	}
+	if len(terms) > 0 {
+		return strings.Join(terms, " ")
+	}
+	// search by the longest name-qualified term
+	var longest string
+	for _, t := range q.Terms {
+		if t.Negate || t.Field == SearchFieldGenre {
+			continue
+		}
+		if len(t.Text) > len(longest) {
+			longest = t.Text
+		}
+	}
+	return longest
+}
+
+// HasGenreTerms returns true if the query requires a genre: term to match,
+// in which case ApplyToTrackFilterOptions needs the library's genres.
+func (q SearchQuery) HasGenreTerms() bool {
+	for _, t := range q.Terms {
+		if t.Field == SearchFieldGenre && !t.Negate {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyToTrackFilterOptions returns a copy of the filter options further restricted
+// by the parts of the query that can be expressed as a TrackFilter, so they may be pushed
+// down to the server by a MediaProvider's filter implementation.
+// A genre: term matches part of a genre name, so it is pushed down as the names of the
+// libraryGenres that it matches. libraryGenres may be nil if the query has no genre terms.
+func (q SearchQuery) ApplyToTrackFilterOptions(opts TrackFilterOptions, libraryGenres []*Genre) TrackFilterOptions {
+	opts = opts.Clone()
+	opts.MinYear, opts.MaxYear = intersectRange(opts.MinYear, opts.MaxYear, q.MinYear, q.MaxYear)
+	opts.MinRating, opts.MaxRating = intersectRange(opts.MinRating, opts.MaxRating, q.MinRating, q.MaxRating)
+	opts.ExcludeUnfavorited = opts.ExcludeUnfavorited || q.Favorite
+	opts.ExcludeFavorited = opts.ExcludeFavorited || q.NotFavorite
+	// a track matching both the existing genres and a genre term can't be
+	// expressed as a single set of genres, so only push down into an unset filter
+	if len(opts.Genres) == 0 {
+		opts.Genres = q.matchingGenres(libraryGenres)
+	}
+	return opts
+}
+
+// returns the names of the genres matching the first genre: term of the query
+// that matches any, which are thus a superset of the genres of all matching tracks
+func (q SearchQuery) matchingGenres(libraryGenres []*Genre) []string {
+	for _, t := range q.Terms {
+		if t.Field != SearchFieldGenre || t.Negate {
+			continue
+		}
+		var genres []string
+		for _, g := range libraryGenres {
+			if strings.Contains(normalizeSearchText(g.Name), t.normalized) {
+				genres = append(genres, g.Name)
+			}
+		}
+		if len(genres) > 0 {
+			return genres
+		}
+	}
+	return nil
+}
+
+// ApplyToAlbumFilterOptions returns a copy of the filter options further restricted
+// by the parts of the query that can be expressed as an AlbumFilter.
+func (q SearchQuery) ApplyToAlbumFilterOptions(opts AlbumFilterOptions) AlbumFilterOptions {
+	opts = opts.Clone()
+	opts.MinYear, opts.MaxYear = intersectRange(opts.MinYear, opts.MaxYear, q.MinYear, q.MaxYear)
+	opts.ExcludeUnfavorited = opts.ExcludeUnfavorited || q.Favorite
+	opts.ExcludeFavorited = opts.ExcludeFavorited || q.NotFavorite
+	return opts
+}
+
+// MatchesTrack returns true if the track satisfies every part of the query.
+func (q SearchQuery) MatchesTrack(t *Track) bool {
+	if t == nil || !q.yearMatches(t.Year, true) || !q.ratingMatches(t.Rating, true) || !q.favoriteMatches(t.Favorite) {
+		return false
+	}
+	return q.termsMatch(func(field string) ([]string, bool) {
+		switch field {
+		case SearchFieldArtist:
+			return t.ArtistNames, true
+		case SearchFieldAlbum:
+			return []string{t.Album}, true
+		case SearchFieldGenre:
+			return t.Genres, true
+		case SearchFieldTitle:
+			return []string{t.Title}, true
+		}
+		return append([]string{t.Title, t.Album}, t.ArtistNames...), true
+	})
+}
+
+// MatchesAlbum returns true if the album satisfies every part of the query.
+// Albums have no rating, so never match a query with a rating: term.
+func (q SearchQuery) MatchesAlbum(a *Album) bool {
+	if a == nil || !q.yearMatches(a.Year, true) || !q.ratingMatches(0, false) || !q.favoriteMatches(a.Favorite) {
+		return false
+	}
+	return q.termsMatch(func(field string) ([]string, bool) {
+		switch field {
+		case SearchFieldArtist:
+			return a.ArtistNames, true
+		case SearchFieldAlbum:
+			return []string{a.Name}, true
+		case SearchFieldGenre:
+			return a.Genres, true
+		case SearchFieldTitle:
+			return nil, false
+		}
+		return append([]string{a.Name}, a.ArtistNames...), true
+	})
+}
+
+// MatchesArtist returns true if the artist satisfies every part of the query.
+func (q SearchQuery) MatchesArtist(a *Artist) bool {
+	if a == nil || !q.yearMatches(0, false) || !q.ratingMatches(0, false) || !q.favoriteMatches(a.Favorite) {
+		return false
+	}
+	return q.termsMatch(func(field string) ([]string, bool) {
+		if field == SearchFieldAny || field == SearchFieldArtist {
+			return []string{a.Name}, true
+		}
+		return nil, false
+	})
+}
+
+// MatchesGenre returns true if a genre with the given name satisfies every part of the query.
+func (q SearchQuery) MatchesGenre(name string) bool {
+	return q.MatchesName(name, SearchFieldGenre)
+}
+
+// MatchesName returns true if an item that has only a name, such as a playlist or
+// radio station, satisfies every part of the query. If field is not SearchFieldAny,
+// terms qualified with that field are also matched against the name.
+func (q SearchQuery) MatchesName(name, field string) bool {
+	if !q.yearMatches(0, false) || !q.ratingMatches(0, false) || q.Favorite || q.NotFavorite {
+		return false
+	}
+	return q.termsMatch(func(f string) ([]string, bool) {
+		if f == SearchFieldAny || f == field {
+			return []string{name}, true
+		}
+		return nil, false
+	})
+}
+
+// Returns true if all text terms match. fieldValues returns the values of
+// the given field for the item being matched, or false if it has no such field.
+func (q SearchQuery) termsMatch(fieldValues func(field string) ([]string, bool)) bool {
+	for _, t := range q.Terms {
+		values, ok := fieldValues(t.Field)
+		if !ok {
+			if t.Negate {
+				continue
+			}
+			return false
+		}
+		found := false
+		for _, v := range values {
+			if strings.Contains(normalizeSearchText(v), t.normalized) {
+				found = true
+				break
+			}
+		}
+		if found == t.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// hasYear is false if the item being matched has no year,
+// in which case it is never excluded by a negated year: term
+func (q SearchQuery) yearMatches(year int, hasYear bool) bool {
+	return rangeMatches(q.MinYear, q.MaxYear, q.ExcludedYears, year, hasYear)
+}
+
+// hasRating is false if the item being matched cannot be rated
+func (q SearchQuery) ratingMatches(rating int, hasRating bool) bool {
+	return rangeMatches(q.MinRating, q.MaxRating, q.ExcludedRatings, rating, hasRating)
+}
+
+func rangeMatches(min, max int, excluded []SearchRange, n int, hasValue bool) bool {
+	if !hasValue {
+		return min == 0 && max == 0
+	}
+	for _, r := range excluded {
+		if r.contains(n) {
+			return false
+		}
+	}
+	return SearchRange{Min: min, Max: max}.contains(n)
+}
+
+func (q SearchQuery) favoriteMatches(favorite bool) bool {
+	return !(q.Favorite && !favorite) && !(q.NotFavorite && favorite)
+}
@@ -0,0 +1,126 @@
+package mediaprovider
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_ParseSearchQuery(t *testing.T) {
+	q := ParseSearchQuery(`artist:"Pink Floyd" year:1970..1979 rating:>=4 is:favorite -live dark`)
+	if q.MinYear != 1970 || q.MaxYear != 1979 {
+		t.Errorf("year range: got %d..%d", q.MinYear, q.MaxYear)
+	}
+	if q.MinRating != 4 || q.MaxRating != 0 {
+		t.Errorf("rating range: got %d..%d", q.MinRating, q.MaxRating)
+	}
+	if !q.Favorite || q.NotFavorite {
+		t.Error("expected favorite")
+	}
+	want := []SearchTerm{
+		{Field: SearchFieldArtist, Text: "Pink Floyd"},
+		{Field: SearchFieldAny, Text: "live", Negate: true},
+		{Field: SearchFieldAny, Text: "dark"},
+	}
+	if len(q.Terms) != len(want) {
+		t.Fatalf("expected %d terms, got %d", len(want), len(q.Terms))
+	}
+	for i, w := range want {
+		if g := q.Terms[i]; g.Field != w.Field || g.Text != w.Text || g.Negate != w.Negate {
+			t.Errorf("term %d: got %+v, want %+v", i, g, w)
+		}
+	}
+	if s := q.ServerQuery(); s != "dark" {
+		t.Errorf("ServerQuery: got %q", s)
+	}
+	if q.IsPlain() {
+		t.Error("expected advanced query")
+	}
+
+	// malformed advanced terms are matched as plain text
+	q = ParseSearchQuery("year:abc re:zero")
+	if q.MinYear != 0 || len(q.Terms) != 2 || q.Terms[0].Text != "year:abc" || q.Terms[1].Text != "re:zero" {
+		t.Errorf("malformed terms: got %+v", q)
+	}
+
+	if !ParseSearchQuery("dark side").IsPlain() {
+		t.Error("expected plain query")
+	}
+
+	// negated year and rating terms exclude their ranges
+	q = ParseSearchQuery("-year:1990..1999 -rating:<=2 -year:2005 -year:abc")
+	if want := []SearchRange{{1990, 1999}, {2005, 2005}}; !slices.Equal(q.ExcludedYears, want) {
+		t.Errorf("excluded years: got %v, want %v", q.ExcludedYears, want)
+	}
+	if want := []SearchRange{{0, 2}}; !slices.Equal(q.ExcludedRatings, want) {
+		t.Errorf("excluded ratings: got %v, want %v", q.ExcludedRatings, want)
+	}
+	if q.MinYear != 0 || q.MaxYear != 0 || q.MinRating != 0 || q.MaxRating != 0 {
+		t.Errorf("negated terms set the ranges: got %+v", q)
+	}
+	if len(q.Terms) != 1 || q.Terms[0].Text != "year:abc" || !q.Terms[0].Negate {
+		t.Errorf("malformed negated term: got %+v", q.Terms)
+	}
+	if q.IsPlain() {
+		t.Error("expected advanced query")
+	}
+}
+
+func Test_SearchQueryMatchesTrack(t *testing.T) {
+	track := &Track{
+		Title:       "Time",
+		Album:       "The Dark Side of the Moon",
+		ArtistNames: []string{"Pink Floyd"},
+		Genres:      []string{"Progressive Rock"},
+		Year:        1973,
+		Rating:      5,
+		Favorite:    true,
+	}
+	for query, want := range map[string]bool{
+		`artist:floyd`:                  true,
+		`artist:"pink floyd" title:tim`: true,
+		`album:"dark side" year:1973`:   true,
+		`year:1980..`:                   false,
+		`year:..1975 rating:>4`:         true,
+		`rating:<5`:                     false,
+		`is:favorite genre:progressive`: true,
+		`-is:favorite`:                  false,
+		`-moon`:                         false,
+		`-artist:queen time`:            true,
+		`-year:1970..1979`:              false,
+		`-year:1980.. -rating:<3`:       true,
+		`-rating:5`:                     false,
+		`"side of"`:                     true,
+		`"of side"`:                     false,
+	} {
+		if got := ParseSearchQuery(query).MatchesTrack(track); got != want {
+			t.Errorf("%s: got %v, want %v", query, got, want)
+		}
+	}
+}
+
+func Test_SearchQueryApplyToTrackFilterOptions(t *testing.T) {
+	genres := []*Genre{{Name: "Progressive Rock"}, {Name: "Rock"}, {Name: "Jazz"}}
+	for _, tt := range []struct {
+		query      string
+		opts       TrackFilterOptions
+		wantGenres []string
+		wantMin    int
+		wantMax    int
+	}{
+		{query: `genre:rock`, wantGenres: []string{"Progressive Rock", "Rock"}},
+		{query: `genre:blues genre:jazz`, wantGenres: []string{"Jazz"}},
+		{query: `-genre:jazz`},
+		{query: `genre:jazz`, opts: TrackFilterOptions{Genres: []string{"Rock"}}, wantGenres: []string{"Rock"}},
+		{query: `rating:>1`, wantMin: 2},
+		{query: `rating:<4`, wantMax: 3},
+		{query: `rating:>=2`, opts: TrackFilterOptions{MinRating: 3}, wantMin: 3},
+	} {
+		opts := ParseSearchQuery(tt.query).ApplyToTrackFilterOptions(tt.opts, genres)
+		if !slices.Equal(opts.Genres, tt.wantGenres) {
+			t.Errorf("%s: got genres %v, want %v", tt.query, opts.Genres, tt.wantGenres)
+		}
+		if opts.MinRating != tt.wantMin || opts.MaxRating != tt.wantMax {
+			t.Errorf("%s: got rating %d..%d, want %d..%d", tt.query, opts.MinRating, opts.MaxRating, tt.wantMin, tt.wantMax)
+		}
+	}
+}
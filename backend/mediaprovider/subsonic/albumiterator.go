@@ -38,6 +38,13 @@ func filterAlbumMatches(f mediaprovider.AlbumFilter, album *subsonic.AlbumID3, i
 	if y := album.Year; y < filterOptions.MinYear || (filterOptions.MaxYear > 0 && y > filterOptions.MaxYear) {
 		return false
 	}
+	releaseTypes := normalizeReleaseTypes(album.ReleaseTypes)
+	if album.IsCompilation {
+		releaseTypes |= mediaprovider.ReleaseTypeCompilation
+	}
+	if !filterOptions.ReleaseTypesMatch(releaseTypes) {
+		return false
+	}
 	if ignoreGenre || len(filterOptions.Genres) == 0 {
 		return true
 	}
@@ -109,7 +116,16 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 }
 
 func (s *subsonicMediaProvider) SearchAlbums(searchQuery string, filter mediaprovider.AlbumFilter) mediaprovider.AlbumIterator {
-	return s.newSearchAlbumIter(searchQuery, filter, s.prefetchCoverCB)
+	q := mediaprovider.ParseSearchQuery(searchQuery)
+	if q.IsPlain() {
+		return s.newSearchAlbumIter(searchQuery, filter, s.prefetchCoverCB)
+	}
+	// push down what can be expressed as an album filter, and check the rest client-side
+	filter = mediaprovider.NewAlbumFilter(q.ApplyToAlbumFilterOptions(filter.Options()))
+	if serverQuery := q.ServerQuery(); serverQuery != "" {
+		return helpers.NewQueryFilteredAlbumIterator(s.newSearchAlbumIter(serverQuery, filter, s.prefetchCoverCB), q)
+	}
+	return helpers.NewQueryFilteredAlbumIterator(s.IterateAlbums("", filter), q)
 }
 
 type searchAlbumIter struct {
@@ -78,7 +78,14 @@ func (s *subsonicMediaProvider) IterateArtists(sortOrder string, filter mediapro
 }
 
 func (s *subsonicMediaProvider) SearchArtists(searchQuery string, filter mediaprovider.ArtistFilter) mediaprovider.ArtistIterator {
-	return s.newSearchArtistIter(searchQuery, filter, s.prefetchCoverCB)
+	q := mediaprovider.ParseSearchQuery(searchQuery)
+	if q.IsPlain() {
+		return s.newSearchArtistIter(searchQuery, filter, s.prefetchCoverCB)
+	}
+	if serverQuery := q.ServerQuery(); serverQuery != "" {
+		return helpers.NewQueryFilteredArtistIterator(s.newSearchArtistIter(serverQuery, filter, s.prefetchCoverCB), q)
+	}
+	return helpers.NewQueryFilteredArtistIterator(s.IterateArtists("", filter), q)
 }
 
 type searchArtistIter struct {
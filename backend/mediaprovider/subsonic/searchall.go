@@ -14,75 +14,128 @@ import (
 
 func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([]*mediaprovider.SearchResult, error) {
 	var wg sync.WaitGroup
-	var err error // only set by Search3
-	var result *subsonic.SearchResult3
-	var playlists []*subsonic.Playlist
-	var genres []*subsonic.Genre
-	var radios []*mediaprovider.RadioStation
-
+	var lists searchLists
 	wg.Add(1)
 	go func() {
-		count := strconv.Itoa(maxResults / 3)
-		res, e := s.client.Search3(searchQuery, map[string]string{
-			"artistCount": count,
-			"albumCount":  count,
-			"songCount":   count,
-		})
-		if e != nil {
-			err = e
-		} else {
-			result = res
-		}
+		lists = s.fetchSearchLists()
 		wg.Done()
 	}()
+	q := mediaprovider.ParseSearchQuery(searchQuery)
+	result, err := s.searchServer(q, maxResults)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	results := searchResults(q, result, lists, maxResults)
+	if len(results) == 0 && q.IsPlain() {
+		// the query may contain a typo - suggest close matches instead,
+		// re-running only the server search for the corrected queries
+		return helpers.FuzzySearch(searchQuery, maxResults, func(query string) ([]*mediaprovider.SearchResult, error) {
+			q := mediaprovider.ParseSearchQuery(query)
+			result, err := s.searchServer(q, maxResults)
+			if err != nil {
+				return nil, err
+			}
+			return searchResults(q, result, lists, maxResults), nil
+		})
+	}
+	return results, nil
+}
 
-	querySanitized := strings.ToLower(sanitize.Accents(searchQuery))
-	queryLowerWords := strings.Fields(querySanitized)
+// the playlists, genres and radio stations which are searched client-side
+type searchLists struct {
+	playlists []*subsonic.Playlist
+	genres    []*subsonic.Genre
+	radios    []*mediaprovider.RadioStation
+}
+
+// fetches the searchLists, leaving out the lists that fail to fetch
+func (s *subsonicMediaProvider) fetchSearchLists() searchLists {
+	var wg sync.WaitGroup
+	var lists searchLists
 
 	wg.Add(1)
 	go func() {
-		p, e := s.client.GetPlaylists(nil)
-		if e == nil {
-			playlists = sharedutil.FilterSlice(p, func(p *subsonic.Playlist) bool {
-				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(p.Name)), queryLowerWords)
-			})
-		}
+		lists.playlists, _ = s.client.GetPlaylists(nil)
 		wg.Done()
 	}()
 
 	wg.Add(1)
 	go func() {
-		g, e := s.client.GetGenres()
-		if e == nil {
-			genres = sharedutil.FilterSlice(g, func(g *subsonic.Genre) bool {
-				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(g.Name)), queryLowerWords)
-			})
-		}
+		lists.genres, _ = s.client.GetGenres()
 		wg.Done()
 	}()
 
 	wg.Add(1)
 	go func() {
-		r, e := s.GetRadioStations()
-		if e == nil {
-			radios = sharedutil.FilterSlice(r, func(r *mediaprovider.RadioStation) bool {
-				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(r.Name)), queryLowerWords)
-			})
-		}
+		lists.radios, _ = s.GetRadioStations()
 		wg.Done()
 	}()
 
 	wg.Wait()
-	if err != nil {
-		return nil, err
+	return lists
+}
+
+// searches the albums, artists and songs on the server
+func (s *subsonicMediaProvider) searchServer(q mediaprovider.SearchQuery, maxResults int) (*subsonic.SearchResult3, error) {
+	serverQuery := q.ServerQuery()
+	count := maxResults / 3
+	if !q.IsPlain() {
+		// request extra results since some will be filtered out client-side
+		count = maxResults
+	}
+
+	if serverQuery == "" && q.Favorite {
+		// nothing to search for by name - start from the user's favorites
+		starred, err := s.client.GetStarred2(nil)
+		if err != nil {
+			return nil, err
+		}
+		return &subsonic.SearchResult3{Artist: starred.Artist, Album: starred.Album, Song: starred.Song}, nil
 	}
+	return s.client.Search3(serverQuery, map[string]string{
+		"artistCount": strconv.Itoa(count),
+		"albumCount":  strconv.Itoa(count),
+		"songCount":   strconv.Itoa(count),
+	})
+}
 
+// merges the server search result with the lists matched by the query, best matches first
+func searchResults(q mediaprovider.SearchQuery, result *subsonic.SearchResult3, lists searchLists, maxResults int) []*mediaprovider.SearchResult {
+	playlists := sharedutil.FilterSlice(lists.playlists, func(p *subsonic.Playlist) bool {
+		return q.MatchesName(p.Name, mediaprovider.SearchFieldAny)
+	})
+	genres := sharedutil.FilterSlice(lists.genres, func(g *subsonic.Genre) bool {
+		return q.MatchesGenre(g.Name)
+	})
+	radios := sharedutil.FilterSlice(lists.radios, func(r *mediaprovider.RadioStation) bool {
+		return q.MatchesName(r.Name, mediaprovider.SearchFieldAny)
+	})
+
+	if !q.IsPlain() {
+		filterSearchResult(result, q)
+	}
 	results := mergeResults(result, playlists, genres, radios)
-	helpers.RankSearchResults(results, querySanitized, queryLowerWords)
+	querySanitized := strings.ToLower(sanitize.Accents(q.ServerQuery()))
+	helpers.RankSearchResults(results, querySanitized, strings.Fields(querySanitized))
 	if len(results) > maxResults {
 		results = results[:maxResults]
 	}
-	return results, nil
+	return results
+}
+
+// removes the albums, artists and songs not matched by the query
+func filterSearchResult(result *subsonic.SearchResult3, q mediaprovider.SearchQuery) {
+	result.Album = sharedutil.FilterSlice(result.Album, func(al *subsonic.AlbumID3) bool {
+		return q.MatchesAlbum(toAlbum(al))
+	})
+	result.Artist = sharedutil.FilterSlice(result.Artist, func(ar *subsonic.ArtistID3) bool {
+		return q.MatchesArtist(toArtistFromID3(ar))
+	})
+	result.Song = sharedutil.FilterSlice(result.Song, func(ch *subsonic.Child) bool {
+		return q.MatchesTrack(toTrack(ch))
+	})
 }
 
 func mergeResults(
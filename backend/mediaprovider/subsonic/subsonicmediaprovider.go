@@ -1,7 +1,9 @@
 package subsonic
 
 import (
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"image"
 	"io"
 	"math"
@@ -12,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dweymouth/supersonic/backend/lrc"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
@@ -23,6 +26,9 @@ const (
 	cacheValidDurationSeconds         = 120 // genres and radios aren't expected to change as much
 )
 
+// the Subsonic API error code for "the requested data was not found"
+const errorCodeDataNotFound = 70
+
 type subsonicMediaProvider struct {
 	client          *subsonic.Client
 	prefetchCoverCB func(coverArtID string)
@@ -46,8 +52,43 @@ func (s *subsonicMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID stri
 }
 
 func (s *subsonicMediaProvider) CreatePlaylist(name string, trackIDs []string) error {
+	_, err := s.CreatePlaylistWithID(name, trackIDs)
+	return err
+}
+
+func (s *subsonicMediaProvider) CreatePlaylistWithID(name string, trackIDs []string) (string, error) {
 	s.playlistsCached = nil
-	return s.client.CreatePlaylistWithTracks(trackIDs, map[string]string{"name": name})
+	// the client library discards the response, which contains the new playlist
+	parsed, err := s.request("createPlaylist", url.Values{"name": {name}, "songId": trackIDs})
+	if err != nil {
+		return "", err
+	}
+	if parsed.Playlist == nil {
+		return "", nil // servers implementing API versions before 1.14 return no playlist
+	}
+	return parsed.Playlist.ID, nil
+}
+
+// request makes a Subsonic API request and parses the response. Unlike the
+// client library, which reports API errors only as formatted messages, it
+// checks the error code and wraps mediaprovider.ErrNotFound for missing items.
+func (s *subsonicMediaProvider) request(endpoint string, params url.Values) (*subsonic.Response, error) {
+	resp, err := s.client.Request("GET", endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var parsed subsonic.Response
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	if e := parsed.Error; e != nil {
+		if e.Code == errorCodeDataNotFound {
+			return nil, fmt.Errorf("%w: Error #%d: %s", mediaprovider.ErrNotFound, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("Error #%d: %s", e.Code, e.Message)
+	}
+	return &parsed, nil
 }
 
 func (s *subsonicMediaProvider) DeletePlaylist(id string) error {
@@ -79,18 +120,25 @@ func (s *subsonicMediaProvider) RemovePlaylistTracks(id string, removeIdxs []int
 }
 
 func (s *subsonicMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
-	tr, err := s.client.GetSong(trackID)
+	resp, err := s.request("getSong", url.Values{"id": {trackID}})
 	if err != nil {
 		return nil, err
 	}
-	return toTrack(tr), nil
+	if resp.Song == nil {
+		return nil, fmt.Errorf("%w: track %s", mediaprovider.ErrNotFound, trackID)
+	}
+	return toTrack(resp.Song), nil
 }
 
 func (s *subsonicMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
-	al, err := s.client.GetAlbum(albumID)
+	resp, err := s.request("getAlbum", url.Values{"id": {albumID}})
 	if err != nil {
 		return nil, err
 	}
+	al := resp.Album
+	if al == nil {
+		return nil, fmt.Errorf("%w: album %s", mediaprovider.ErrNotFound, albumID)
+	}
 	album := &mediaprovider.AlbumWithTracks{
 		Tracks: sharedutil.MapSlice(al.Song, toTrack),
 	}
@@ -189,10 +237,14 @@ func (s *subsonicMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
 }
 
 func (s *subsonicMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
-	pl, err := s.client.GetPlaylist(playlistID)
+	resp, err := s.request("getPlaylist", url.Values{"id": {playlistID}})
 	if err != nil {
 		return nil, err
 	}
+	pl := resp.Playlist
+	if pl == nil {
+		return nil, fmt.Errorf("%w: playlist %s", mediaprovider.ErrNotFound, playlistID)
+	}
 	playlist := &mediaprovider.PlaylistWithTracks{
 		Tracks: sharedutil.MapSlice(pl.Entry, toTrack),
 	}
@@ -263,7 +315,8 @@ func (s *subsonicMediaProvider) GetTopTracks(artist mediaprovider.Artist, count
 
 func (s *subsonicMediaProvider) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
 	s.playlistsCached = nil
-	return s.client.CreatePlaylistWithTracks(trackIDs, map[string]string{"playlistId": playlistID})
+	_, err := s.request("createPlaylist", url.Values{"playlistId": {playlistID}, "songId": trackIDs})
+	return err
 }
 
 func (s *subsonicMediaProvider) ClientDecidesScrobble() bool { return true }
@@ -348,6 +401,16 @@ func (s *subsonicMediaProvider) DownloadTrack(trackID string) (io.Reader, error)
 	return s.client.Download(trackID)
 }
 
+var _ mediaprovider.SupportsTranscodedDownload = (*subsonicMediaProvider)(nil)
+
+func (s *subsonicMediaProvider) DownloadTrackTranscoded(trackID, format string, maxBitRateKbps int) (io.Reader, error) {
+	params := map[string]string{"format": format}
+	if maxBitRateKbps > 0 {
+		params["maxBitRate"] = strconv.Itoa(maxBitRateKbps)
+	}
+	return s.client.Stream(trackID, params)
+}
+
 func (s *subsonicMediaProvider) RescanLibrary() error {
 	_, err := s.client.StartScan()
 	return err
@@ -380,10 +443,15 @@ func (s *subsonicMediaProvider) GetLyrics(track *mediaprovider.Track) (*mediapro
 			if text == "" {
 				text = line.Text
 			}
-			mpLyrics.Lines = append(mpLyrics.Lines, mediaprovider.LyricLine{
+			lyricLine := mediaprovider.LyricLine{
 				Text:  text,
 				Start: float64(line.Start) / 1000,
-			})
+			}
+			if lyric.Synced {
+				// servers may pass through enhanced LRC word timestamps
+				lyricLine.Text, lyricLine.Words = lrc.ParseWords(text)
+			}
+			mpLyrics.Lines = append(mpLyrics.Lines, lyricLine)
 		}
 		return mpLyrics, nil
 	}
@@ -519,6 +587,14 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		}
 	}
 
+	var albumArtists []string
+	for _, a := range ch.AlbumArtists {
+		albumArtists = append(albumArtists, a.Name)
+	}
+	if len(albumArtists) == 0 && ch.DisplayAlbumArtist != "" {
+		albumArtists = []string{ch.DisplayAlbumArtist}
+	}
+
 	return &mediaprovider.Track{
 		ID:            ch.ID,
 		CoverArtID:    ch.CoverArt,
@@ -546,6 +622,9 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		Comment:       ch.Comment,
 		BPM:           ch.BPM,
 		ReplayGain:    rGain,
+		MusicBrainzID: ch.MusicBrainzID,
+
+		AlbumArtistNames: albumArtists,
 	}
 }
 
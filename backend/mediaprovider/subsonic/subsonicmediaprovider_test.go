@@ -0,0 +1,79 @@
+package subsonic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/supersonic-app/go-subsonic/subsonic"
+)
+
+func Test_GetTrackNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		response     string
+		wantNotFound bool
+	}{
+		{
+			name:         "data not found",
+			response:     `<subsonic-response status="failed" version="1.16.1"><error code="70" message="Song not found"/></subsonic-response>`,
+			wantNotFound: true,
+		},
+		{
+			name:         "other error",
+			response:     `<subsonic-response status="failed" version="1.16.1"><error code="0" message="Internal error"/></subsonic-response>`,
+			wantNotFound: false,
+		},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tc.response))
+		}))
+		s := &subsonicMediaProvider{client: &subsonic.Client{Client: srv.Client(), BaseUrl: srv.URL, ClientName: "test"}}
+		_, err := s.GetTrack("1")
+		srv.Close()
+		if err == nil {
+			t.Errorf("%s: got no error", tc.name)
+		} else if errors.Is(err, mediaprovider.ErrNotFound) != tc.wantNotFound {
+			t.Errorf("%s: got error %v, want not found %v", tc.name, err, tc.wantNotFound)
+		}
+	}
+}
+
+func Test_FuzzySearchFetchesListsOnce(t *testing.T) {
+	var lock sync.Mutex
+	calls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimSuffix(path.Base(r.URL.Path), ".view")
+		lock.Lock()
+		calls[endpoint]++
+		lock.Unlock()
+		// an empty result for each endpoint
+		elem := map[string]string{
+			"search3":                  "searchResult3",
+			"getPlaylists":             "playlists",
+			"getGenres":                "genres",
+			"getInternetRadioStations": "internetRadioStations",
+		}[endpoint]
+		w.Write([]byte(`<subsonic-response status="ok" version="1.16.1"><` + elem + `/></subsonic-response>`))
+	}))
+	defer srv.Close()
+	s := &subsonicMediaProvider{client: &subsonic.Client{Client: srv.Client(), BaseUrl: srv.URL, ClientName: "test"}}
+
+	if _, err := s.SearchAll("radiohed computr", 30); err != nil {
+		t.Fatal(err)
+	}
+	// the query and its two corrected queries
+	if calls["search3"] != 3 {
+		t.Errorf("got %d server searches, want 3", calls["search3"])
+	}
+	for _, endpoint := range []string{"getPlaylists", "getGenres", "getInternetRadioStations"} {
+		if calls[endpoint] != 1 {
+			t.Errorf("got %d calls to %s, want 1", calls[endpoint], endpoint)
+		}
+	}
+}
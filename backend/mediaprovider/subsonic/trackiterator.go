@@ -2,27 +2,98 @@ package subsonic
 
 import (
 	"log"
+	"strconv"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/supersonic-app/go-subsonic/subsonic"
 )
 
-func (s *subsonicMediaProvider) IterateTracks(searchQuery string) mediaprovider.TrackIterator {
-	if searchQuery == "" {
-		return &allTracksIterator{
-			s: s,
-			albumIter: s.IterateAlbums(
-				mediaprovider.AlbumSortRecentlyAdded,
-				mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}),
-			),
+func (s *subsonicMediaProvider) IterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if q := mediaprovider.ParseSearchQuery(searchQuery); !q.IsPlain() {
+		// push down what can be expressed as a track filter, and check the rest client-side
+		filter = mediaprovider.NewTrackFilter(helpers.QueryTrackFilterOptions(q, filter.Options(), s.GetGenres))
+		return helpers.NewQueryFilteredTrackIterator(s.iterateTracks(q.ServerQuery(), filter), q)
+	}
+	return s.iterateTracks(searchQuery, filter)
+}
+
+func (s *subsonicMediaProvider) iterateTracks(searchQuery string, filter mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	if searchQuery != "" {
+		return helpers.NewFilteredTrackIterator(&searchTracksIterator{
+			searchIterBase: searchIterBase{
+				s:     s.client,
+				query: searchQuery,
+			},
+			trackIDset: make(map[string]bool),
+		}, filter)
+	}
+
+	// Subsonic has no general track filtering API, but the genre and
+	// favorite filters can be served by dedicated endpoints
+	opts := filter.Options()
+	if len(opts.Genres) > 0 {
+		return helpers.NewTrackIterator(s.makeSongsByGenreFetchFn(opts.Genres), filter, s.prefetchCoverCB)
+	}
+	if opts.ExcludeUnfavorited {
+		return helpers.NewTrackIterator(s.makeStarredSongsFetchFn(), filter, s.prefetchCoverCB)
+	}
+	return helpers.NewFilteredTrackIterator(&allTracksIterator{
+		s: s,
+		albumIter: s.IterateAlbums(
+			mediaprovider.AlbumSortRecentlyAdded,
+			mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}),
+		),
+	}, filter)
+}
+
+// Returns a fetch function that pages through the songs of each
+// of the given genres in turn, skipping songs that were already returned.
+func (s *subsonicMediaProvider) makeSongsByGenreFetchFn(genres []string) helpers.TrackFetchFn {
+	var genreIdx, genreOffset int
+	seen := make(map[string]bool)
+	return func(_, limit int) ([]*mediaprovider.Track, error) {
+		for genreIdx < len(genres) {
+			songs, err := s.client.GetSongsByGenre(genres[genreIdx], map[string]string{
+				"offset": strconv.Itoa(genreOffset),
+				"count":  strconv.Itoa(limit),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(songs) == 0 {
+				genreIdx++
+				genreOffset = 0
+				continue
+			}
+			genreOffset += len(songs)
+			tracks := sharedutil.FilterMapSlice(songs, func(ch *subsonic.Child) (*mediaprovider.Track, bool) {
+				if seen[ch.ID] {
+					return nil, false
+				}
+				seen[ch.ID] = true
+				return toTrack(ch), true
+			})
+			if len(tracks) > 0 {
+				return tracks, nil
+			}
 		}
+		return nil, nil
 	}
-	return &searchTracksIterator{
-		searchIterBase: searchIterBase{
-			s:     s.client,
-			query: searchQuery,
-		},
-		trackIDset: make(map[string]bool),
+}
+
+// Returns a fetch function that returns all starred songs in a single batch.
+func (s *subsonicMediaProvider) makeStarredSongsFetchFn() helpers.TrackFetchFn {
+	return func(offset, _ int) ([]*mediaprovider.Track, error) {
+		if offset > 0 {
+			return nil, nil
+		}
+		starred, err := s.client.GetStarred2(nil)
+		if err != nil {
+			return nil, err
+		}
+		return sharedutil.MapSlice(starred.Song, toTrack), nil
 	}
 }
 
@@ -32,6 +103,7 @@ type allTracksIterator struct {
 	curAlbum    *mediaprovider.AlbumWithTracks
 	curTrackIdx int
 	done        bool
+	err         error
 }
 
 func (a *allTracksIterator) Next() *mediaprovider.Track {
@@ -44,11 +116,15 @@ func (a *allTracksIterator) Next() *mediaprovider.Track {
 		al := a.albumIter.Next()
 		if al == nil {
 			a.done = true
+			a.err = mediaprovider.IteratorErr(a.albumIter)
 			return nil
 		}
 		alWithTracks, err := a.s.GetAlbum(al.ID)
 		if err != nil {
 			log.Printf("error fetching album: %s", err.Error())
+			a.done = true
+			a.err = err
+			return nil
 		}
 		if len(alWithTracks.Tracks) == 0 {
 			// in the unlikely case of an album with zero tracks,
@@ -64,6 +140,10 @@ func (a *allTracksIterator) Next() *mediaprovider.Track {
 	return tr
 }
 
+func (a *allTracksIterator) Err() error {
+	return a.err
+}
+
 type searchTracksIterator struct {
 	searchIterBase
 
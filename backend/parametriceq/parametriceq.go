@@ -0,0 +1,217 @@
+// Package parametriceq reads and writes parametric equalizer settings in the
+// EqualizerAPO format, such as the ParametricEQ.txt files of AutoEQ, and
+// computes the frequency response of the equalizer bands.
+package parametriceq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// sample rate assumed when computing the frequency response of the bands
+const responseSampleRate = 48000
+
+// Range of the band frequencies in Hz. The maximum is kept below the
+// Nyquist frequency of the common sample rates, at which the filters are unstable.
+const (
+	MinFrequency = 1
+	MaxFrequency = 20000
+)
+
+var ErrNoFilters = errors.New("no supported filters found")
+
+// FilterType is the type of filter of an equalizer band,
+// named as in EqualizerAPO and AutoEQ parametric EQ files.
+type FilterType string
+
+const (
+	FilterTypePeaking   FilterType = "PK"
+	FilterTypeLowShelf  FilterType = "LSC"
+	FilterTypeHighShelf FilterType = "HSC"
+	FilterTypeLowPass   FilterType = "LPQ"
+	FilterTypeHighPass  FilterType = "HPQ"
+)
+
+// FilterTypes lists the supported filter types.
+var FilterTypes = []FilterType{FilterTypePeaking, FilterTypeLowShelf, FilterTypeHighShelf, FilterTypeLowPass, FilterTypeHighPass}
+
+// Band is a band of a parametric equalizer.
+type Band struct {
+	// The type of filter; peaking if empty
+	Type      FilterType
+	Frequency int
+	Gain      float64
+	Q         float64
+}
+
+// Equalizer is the preamp and bands of a parametric equalizer.
+type Equalizer struct {
+	Preamp float64
+	Bands  []Band
+}
+
+// ClampFrequency returns the frequency limited to the supported range.
+func ClampFrequency(hz int) int {
+	return min(max(hz, MinFrequency), MaxFrequency)
+}
+
+// FormatFrequency formats a frequency in Hz for display, e.g. "63" or "1.6k".
+func FormatFrequency(hz int) string {
+	if hz < 1000 {
+		return strconv.Itoa(hz)
+	}
+	return strconv.FormatFloat(float64(hz)/1000, 'f', -1, 64) + "k"
+}
+
+// Parse parses a parametric EQ file in the EqualizerAPO format, e.g.:
+//
+//	Preamp: -6.2 dB
+//	Filter 1: ON LSC Fc 105 Hz Gain 5.5 dB Q 0.70
+//	Filter 2: ON PK Fc 172 Hz Gain -3.1 dB Q 0.39
+//
+// Filters which are off or of unsupported types are skipped,
+// and frequencies are clamped to the supported range.
+func Parse(r io.Reader) (*Equalizer, error) {
+	eq := &Equalizer{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		switch {
+		case strings.EqualFold(name, "Preamp"):
+			if len(fields) > 0 {
+				if g, err := strconv.ParseFloat(fields[0], 64); err == nil {
+					eq.Preamp += g
+				}
+			}
+		case strings.HasPrefix(strings.ToLower(name), "filter"):
+			if band, ok := parseFilter(fields); ok {
+				eq.Bands = append(eq.Bands, band)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(eq.Bands) == 0 {
+		return nil, ErrNoFilters
+	}
+	return eq, nil
+}
+
+// parses the fields of a filter line after the colon, e.g. "ON PK Fc 172 Hz Gain -3.1 dB Q 0.39"
+func parseFilter(fields []string) (Band, bool) {
+	band := Band{Q: 1 / math.Sqrt2}
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "ON") {
+		return band, false
+	}
+	switch strings.ToUpper(fields[1]) {
+	case "PK", "PEQ":
+		band.Type = FilterTypePeaking
+	case "LS", "LSC":
+		band.Type = FilterTypeLowShelf
+	case "HS", "HSC":
+		band.Type = FilterTypeHighShelf
+	case "LP", "LPQ":
+		band.Type = FilterTypeLowPass
+	case "HP", "HPQ":
+		band.Type = FilterTypeHighPass
+	default:
+		return band, false
+	}
+	haveFreq := false
+	for i := 2; i+1 < len(fields); i++ {
+		v, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(fields[i]) {
+		case "fc":
+			band.Frequency = ClampFrequency(int(math.Round(v)))
+			haveFreq = v > 0
+		case "gain":
+			band.Gain = v
+		case "q":
+			if v > 0 {
+				band.Q = v
+			}
+		}
+	}
+	return band, haveFreq
+}
+
+// Write writes the equalizer in the format read by Parse.
+func Write(w io.Writer, eq *Equalizer) error {
+	if _, err := fmt.Fprintf(w, "Preamp: %0.1f dB\n", eq.Preamp); err != nil {
+		return err
+	}
+	for i, b := range eq.Bands {
+		t := b.Type
+		if t == "" {
+			t = FilterTypePeaking
+		}
+		if _, err := fmt.Fprintf(w, "Filter %d: ON %s Fc %d Hz Gain %0.1f dB Q %0.2f\n",
+			i+1, t, b.Frequency, b.Gain, b.Q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Response returns the gain in dB of the bands at each of the frequencies.
+func Response(bands []Band, freqs []float64) []float64 {
+	resp := make([]float64, len(freqs))
+	for _, band := range bands {
+		b, a := band.coefficients()
+		for i, f := range freqs {
+			z := cmplx.Exp(complex(0, -2*math.Pi*f/responseSampleRate))
+			h := (b[0] + b[1]*z + b[2]*z*z) / (a[0] + a[1]*z + a[2]*z*z)
+			resp[i] += 20 * math.Log10(cmplx.Abs(h))
+		}
+	}
+	return resp
+}
+
+// returns the biquad coefficients of the band's filter, from the Audio EQ Cookbook
+func (e Band) coefficients() (b, a [3]complex128) {
+	A := math.Pow(10, e.Gain/40)
+	w0 := 2 * math.Pi * float64(ClampFrequency(e.Frequency)) / responseSampleRate
+	q := e.Q
+	if q <= 0 {
+		q = 1 / math.Sqrt2
+	}
+	cosW, alpha := math.Cos(w0), math.Sin(w0)/(2*q)
+	sqA := 2 * math.Sqrt(A) * alpha
+	var bb, aa [3]float64
+	switch e.Type {
+	case FilterTypeLowShelf:
+		bb = [3]float64{A * ((A + 1) - (A-1)*cosW + sqA), 2 * A * ((A - 1) - (A+1)*cosW), A * ((A + 1) - (A-1)*cosW - sqA)}
+		aa = [3]float64{(A + 1) + (A-1)*cosW + sqA, -2 * ((A - 1) + (A+1)*cosW), (A + 1) + (A-1)*cosW - sqA}
+	case FilterTypeHighShelf:
+		bb = [3]float64{A * ((A + 1) + (A-1)*cosW + sqA), -2 * A * ((A - 1) + (A+1)*cosW), A * ((A + 1) + (A-1)*cosW - sqA)}
+		aa = [3]float64{(A + 1) - (A-1)*cosW + sqA, 2 * ((A - 1) - (A+1)*cosW), (A + 1) - (A-1)*cosW - sqA}
+	case FilterTypeLowPass:
+		bb = [3]float64{(1 - cosW) / 2, 1 - cosW, (1 - cosW) / 2}
+		aa = [3]float64{1 + alpha, -2 * cosW, 1 - alpha}
+	case FilterTypeHighPass:
+		bb = [3]float64{(1 + cosW) / 2, -(1 + cosW), (1 + cosW) / 2}
+		aa = [3]float64{1 + alpha, -2 * cosW, 1 - alpha}
+	default: // peaking
+		bb = [3]float64{1 + alpha*A, -2 * cosW, 1 - alpha*A}
+		aa = [3]float64{1 + alpha/A, -2 * cosW, 1 - alpha/A}
+	}
+	for i := range bb {
+		b[i], a[i] = complex(bb[i], 0), complex(aa[i], 0)
+	}
+	return b, a
+}
@@ -0,0 +1,87 @@
+package parametriceq
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	input := `Preamp: -6.2 dB
+Filter 1: ON LSC Fc 105 Hz Gain 5.5 dB Q 0.70
+Filter 2: ON PK Fc 172 Hz Gain -3.1 dB Q 0.39
+Filter 3: OFF PK Fc 500 Hz Gain 2.0 dB Q 1.00
+Filter 4: ON BP Fc 800 Hz Q 1.00
+Filter 5: ON HSC Fc 30000 Hz Gain 2.0 dB
+Filter 6: ON HP Fc 20 Hz Q 0.71
+`
+	eq, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq.Preamp != -6.2 {
+		t.Errorf("got preamp %v, want -6.2", eq.Preamp)
+	}
+	want := []Band{
+		{Type: FilterTypeLowShelf, Frequency: 105, Gain: 5.5, Q: 0.7},
+		{Type: FilterTypePeaking, Frequency: 172, Gain: -3.1, Q: 0.39},
+		// clamped below the Nyquist frequency, with the default Q
+		{Type: FilterTypeHighShelf, Frequency: MaxFrequency, Gain: 2, Q: 1 / math.Sqrt2},
+		{Type: FilterTypeHighPass, Frequency: 20, Q: 0.71},
+	}
+	if len(eq.Bands) != len(want) {
+		t.Fatalf("got bands %v, want %v", eq.Bands, want)
+	}
+	for i := range want {
+		if eq.Bands[i] != want[i] {
+			t.Errorf("band %d: got %+v, want %+v", i, eq.Bands[i], want[i])
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("Preamp: -3 dB\nFilter 1: OFF PK Fc 100 Hz Gain 1 dB Q 1")); !errors.Is(err, ErrNoFilters) {
+		t.Errorf("got error %v, want %v", err, ErrNoFilters)
+	}
+}
+
+func Test_WriteParseRoundTrip(t *testing.T) {
+	eq := &Equalizer{
+		Preamp: -4.5,
+		Bands: []Band{
+			{Type: FilterTypeLowShelf, Frequency: 105, Gain: 5.5, Q: 0.7},
+			{Type: FilterTypePeaking, Frequency: 1600, Gain: -3.1, Q: 1.41},
+			{Type: FilterTypeHighShelf, Frequency: 10000, Gain: 2, Q: 0.71},
+			{Type: FilterTypeLowPass, Frequency: 18000, Q: 0.5},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, eq); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Preamp != eq.Preamp {
+		t.Errorf("got preamp %v, want %v", got.Preamp, eq.Preamp)
+	}
+	if len(got.Bands) != len(eq.Bands) {
+		t.Fatalf("got bands %v, want %v", got.Bands, eq.Bands)
+	}
+	for i := range eq.Bands {
+		if got.Bands[i] != eq.Bands[i] {
+			t.Errorf("band %d: got %+v, want %+v", i, got.Bands[i], eq.Bands[i])
+		}
+	}
+}
+
+func Test_Response(t *testing.T) {
+	bands := []Band{{Type: FilterTypePeaking, Frequency: 1000, Gain: 6, Q: 1}}
+	resp := Response(bands, []float64{20, 1000, 20000})
+	for i, want := range []float64{0, 6, 0} {
+		if math.Abs(resp[i]-want) > 0.1 {
+			t.Errorf("response %d: got %0.2f dB, want %0.2f dB", i, resp[i], want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package pathtemplate builds file paths for tracks from templates such as
+// "{albumartist}/{year} - {album}/{disc}-{track} {title}".
+package pathtemplate
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// DefaultTemplate is the default template for the paths of downloaded tracks.
+const DefaultTemplate = "{albumartist}/{year} - {album}/{disc}-{track} {title}"
+
+// Fields lists the fields which can be used in templates.
+var Fields = []string{"albumartist", "artist", "album", "year", "disc", "track", "title", "genre"}
+
+var fieldRegex = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// characters not allowed in file names on common file systems
+var invalidCharsReplacer = strings.NewReplacer(
+	"/", "-", "\\", "-", ":", "-", "*", "", "?", "", "\"", "'", "<", "", ">", "", "|", "-")
+
+// separators left dangling by empty fields, e.g. " - Album" when the year is unknown
+const danglingSeparators = " -_."
+
+var extensionForContentType = map[string]string{
+	"audio/mpeg":   ".mp3",
+	"audio/mp3":    ".mp3",
+	"audio/flac":   ".flac",
+	"audio/x-flac": ".flac",
+	"audio/ogg":    ".ogg",
+	"audio/opus":   ".opus",
+	"audio/mp4":    ".m4a",
+	"audio/aac":    ".aac",
+	"audio/wav":    ".wav",
+	"audio/x-wav":  ".wav",
+	"audio/x-aiff": ".aiff",
+}
+
+// Validate returns an error if the template uses unknown fields or is empty.
+func Validate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("empty template")
+	}
+	for _, m := range fieldRegex.FindAllStringSubmatch(template, -1) {
+		if fieldValue(m[1], &mediaprovider.Track{}) == nil {
+			return fmt.Errorf("unknown field: {%s}", m[1])
+		}
+	}
+	return nil
+}
+
+// Render returns the relative path, with slash separators, for the track
+// according to the template, followed by ext. Each path component is made
+// safe for use as a file name, and components which are empty because
+// of unknown values are removed.
+func Render(template string, tr *mediaprovider.Track, ext string) string {
+	var components []string
+	for _, comp := range strings.Split(template, "/") {
+		comp = fieldRegex.ReplaceAllStringFunc(comp, func(f string) string {
+			if v := fieldValue(f[1:len(f)-1], tr); v != nil {
+				return invalidCharsReplacer.Replace(*v)
+			}
+			return f
+		})
+		comp = strings.Trim(strings.Join(strings.Fields(comp), " "), danglingSeparators)
+		if comp != "" {
+			components = append(components, comp)
+		}
+	}
+	if len(components) == 0 {
+		components = []string{tr.ID}
+	}
+	return path.Join(components...) + ext
+}
+
+// RenderAll renders the paths of the tracks, with the extension for the given
+// transcoding format (see Extension). Tracks rendering to the same path
+// are disambiguated with a numeric suffix.
+func RenderAll(template string, tracks []*mediaprovider.Track, format string) []string {
+	paths := make([]string, len(tracks))
+	used := make(map[string]int, len(tracks))
+	for i, tr := range tracks {
+		p := Render(template, tr, "")
+		if n := used[p]; n > 0 {
+			used[p] = n + 1
+			p = fmt.Sprintf("%s (%d)", p, n+1)
+		} else {
+			used[p] = 1
+		}
+		paths[i] = p + Extension(tr, format)
+	}
+	return paths
+}
+
+// SanitizeFileName returns the name with characters which are not allowed in file names replaced.
+func SanitizeFileName(name string) string {
+	return strings.TrimSpace(invalidCharsReplacer.Replace(name))
+}
+
+// Extension returns the file extension of the track, including the leading dot,
+// from its file path or else its content type. If format is not empty,
+// the track is transcoded to it and the extension is the format's.
+func Extension(tr *mediaprovider.Track, format string) string {
+	if format != "" {
+		return "." + strings.ToLower(format)
+	}
+	if ext := filepath.Ext(tr.FilePath); ext != "" {
+		return strings.ToLower(ext)
+	}
+	return extensionForContentType[tr.ContentType]
+}
+
+// returns the value of the field for the track, or nil if the field is unknown
+func fieldValue(field string, tr *mediaprovider.Track) *string {
+	var v string
+	switch field {
+	case "albumartist":
+		if len(tr.AlbumArtistNames) > 0 {
+			v = strings.Join(tr.AlbumArtistNames, ", ")
+		} else {
+			v = strings.Join(tr.ArtistNames, ", ")
+		}
+	case "artist":
+		v = strings.Join(tr.ArtistNames, ", ")
+	case "album":
+		v = tr.Album
+	case "year":
+		if tr.Year > 0 {
+			v = fmt.Sprint(tr.Year)
+		}
+	case "disc":
+		if tr.DiscNumber > 0 {
+			v = fmt.Sprint(tr.DiscNumber)
+		}
+	case "track":
+		if tr.TrackNumber > 0 {
+			v = fmt.Sprintf("%02d", tr.TrackNumber)
+		}
+	case "title":
+		v = tr.Title
+	case "genre":
+		if len(tr.Genres) > 0 {
+			v = tr.Genres[0]
+		}
+	default:
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,55 @@
+package pathtemplate
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_Render(t *testing.T) {
+	tr := &mediaprovider.Track{
+		ID:          "1",
+		Title:       "What/Ever?",
+		ArtistNames: []string{"Artist", "Guest"},
+		Album:       "Album",
+		Year:        1999,
+		DiscNumber:  1,
+		TrackNumber: 3,
+		FilePath:    "music/Artist/Album/03 What Ever.FLAC",
+	}
+	tr.AlbumArtistNames = []string{"Artist"}
+
+	ext := Extension(tr, "")
+	if ext != ".flac" {
+		t.Errorf("Extension: got %q", ext)
+	}
+	if got, want := Render(DefaultTemplate, tr, ext), "Artist/1999 - Album/1-03 What-Ever.flac"; got != want {
+		t.Errorf("Render: got %q, want %q", got, want)
+	}
+
+	// unknown values leave no dangling separators or empty folders
+	tr.Year, tr.DiscNumber, tr.Album = 0, 0, ""
+	if got, want := Render(DefaultTemplate, tr, ".mp3"), "Artist/03 What-Ever.mp3"; got != want {
+		t.Errorf("Render: got %q, want %q", got, want)
+	}
+}
+
+func Test_RenderAll(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "1", Title: "Intro", ArtistNames: []string{"A"}},
+		{ID: "2", Title: "Intro", ArtistNames: []string{"A"}},
+	}
+	paths := RenderAll("{artist}/{title}", tracks, "opus")
+	if paths[0] != "A/Intro.opus" || paths[1] != "A/Intro (2).opus" {
+		t.Errorf("RenderAll: got %v", paths)
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	if err := Validate(DefaultTemplate); err != nil {
+		t.Errorf("Validate: unexpected error %v", err)
+	}
+	if err := Validate("{artist}/{nope}"); err == nil {
+		t.Error("Validate: expected error for unknown field")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/dweymouth/supersonic/backend/loudness"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/util"
@@ -28,6 +29,9 @@ const (
 	Append
 )
 
+// minimum percent of a track which must be played to store its measured loudness
+const minLoudnessAnalysisPercent = 50
+
 // The playback loop mode (LoopNone, LoopAll, LoopOne).
 type LoopMode int
 
@@ -54,16 +58,28 @@ type playbackEngine struct {
 	wasStopped    bool // true iff player was stopped before handleOnTrackChange invocation
 	loopMode      LoopMode
 
+	// A-B loop points within the current track in seconds, or -1 if unset.
+	// Playback loops between them when both are set.
+	abLoopA float64
+	abLoopB float64
+
 	// to pass to onSongChange listeners; clear once listeners have been called
 	lastScrobbled *mediaprovider.Track
 	scrobbleCfg   *ScrobbleConfig
 	transcodeCfg  *TranscodingConfig
 	replayGainCfg ReplayGainConfig
 
+	loudness *LoudnessManager
+	// ID of the current track if its loudness is being analyzed
+	loudnessTrackID string
+	// loudness and true peak of the current track measured so far
+	curLoudness loudness.Measurement
+
 	// registered callbacks
 	onSongChange     []func(nowPlaying mediaprovider.MediaItem, justScrobbledIfAny *mediaprovider.Track)
 	onPlayTimeUpdate []func(float64, float64, bool)
 	onLoopModeChange []func(LoopMode)
+	onABLoopChange   []func(float64, float64)
 	onVolumeChange   []func(int)
 	onSeek           []func()
 	onPaused         []func()
@@ -90,6 +106,8 @@ func NewPlaybackEngine(
 		transcodeCfg:  transcodeCfg,
 		nowPlayingIdx: -1,
 		wasStopped:    true,
+		abLoopA:       -1,
+		abLoopB:       -1,
 	}
 	p.OnTrackChange(pm.handleOnTrackChange)
 	p.OnSeek(func() {
@@ -150,6 +168,65 @@ func (p *playbackEngine) GetLoopMode() LoopMode {
 	return p.loopMode
 }
 
+// Sets the A point of the A-B loop to the current playback position.
+// The B point is cleared if it is not after the new A point.
+func (p *playbackEngine) SetABLoopA() error {
+	if p.isRadio || p.NowPlaying() == nil {
+		return nil
+	}
+	a, b := p.player.GetStatus().TimePos, p.abLoopB
+	if b >= 0 && b <= a {
+		b = -1
+	}
+	return p.setABLoop(a, b)
+}
+
+// Sets the B point of the A-B loop to the current playback position.
+// If the A point is unset, the loop starts at the beginning of the track.
+func (p *playbackEngine) SetABLoopB() error {
+	if p.isRadio || p.NowPlaying() == nil {
+		return nil
+	}
+	a, b := max(0, p.abLoopA), p.player.GetStatus().TimePos
+	if b <= a {
+		return errors.New("A-B loop end must be after the start")
+	}
+	return p.setABLoop(a, b)
+}
+
+func (p *playbackEngine) ClearABLoop() error {
+	if p.abLoopA < 0 && p.abLoopB < 0 {
+		return nil
+	}
+	return p.setABLoop(-1, -1)
+}
+
+func (p *playbackEngine) GetABLoop() (float64, float64) {
+	return p.abLoopA, p.abLoopB
+}
+
+func (p *playbackEngine) setABLoop(a, b float64) error {
+	abP, ok := p.player.(player.ABLoopPlayer)
+	if !ok && (a >= 0 || b >= 0) {
+		return errors.New("player does not support A-B loop")
+	}
+	if ok {
+		// only loop once both points are set
+		loopA, loopB := a, b
+		if a < 0 || b < 0 {
+			loopA, loopB = -1, -1
+		}
+		if err := abP.SetABLoop(loopA, loopB); err != nil {
+			return err
+		}
+	}
+	p.abLoopA, p.abLoopB = a, b
+	for _, cb := range p.onABLoopChange {
+		cb(a, b)
+	}
+	return nil
+}
+
 func (p *playbackEngine) PlayerStatus() player.Status {
 	return p.player.GetStatus()
 }
@@ -398,6 +475,9 @@ func (p *playbackEngine) SetReplayGainOptions(config ReplayGainConfig) {
 	}
 
 	p.replayGainCfg = config
+	if la, ok := p.player.(player.LoudnessAnalyzer); ok {
+		la.SetLoudnessAnalysisEnabled(config.AnalyzeLoudness && config.Mode != ReplayGainNone)
+	}
 	mode := player.ReplayGainNone
 	switch config.Mode {
 	case ReplayGainAuto:
@@ -412,6 +492,7 @@ func (p *playbackEngine) SetReplayGainOptions(config ReplayGainConfig) {
 		Mode:            mode,
 		PreventClipping: config.PreventClipping,
 		PreampGain:      config.PreampGainDB,
+		FallbackGain:    config.FallbackGainDB,
 	})
 }
 
@@ -424,11 +505,13 @@ func (p *playbackEngine) SetReplayGainMode(mode player.ReplayGainMode) {
 	rGainPlayer.SetReplayGainOptions(player.ReplayGainOptions{
 		PreventClipping: p.replayGainCfg.PreventClipping,
 		PreampGain:      p.replayGainCfg.PreampGainDB,
+		FallbackGain:    p.replayGainCfg.FallbackGainDB,
 		Mode:            mode,
 	})
 }
 
 func (p *playbackEngine) handleOnTrackChange() {
+	p.storeLoudness()
 	p.checkScrobble() // scrobble the previous song if needed
 	if p.player.GetStatus().State == player.Playing {
 		p.playTimeStopwatch.Start()
@@ -444,6 +527,12 @@ func (p *playbackEngine) handleOnTrackChange() {
 	p.isRadio = isRadio
 	p.wasStopped = false
 	p.curTrackDuration = float64(nowPlaying.Metadata().Duration)
+	p.loudnessTrackID = ""
+	if p.needsLoudnessAnalysis(nowPlaying) {
+		p.loudnessTrackID = nowPlaying.Metadata().ID
+	}
+	p.curLoudness = loudness.Measurement{Loudness: loudness.SilenceLoudness}
+	p.ClearABLoop()
 	p.sendNowPlayingScrobble() // Must come before invokeOnChangeCallbacks b/c track may immediately be scrobbled
 	p.invokeOnSongChangeCallbacks()
 	p.doUpdateTimePos(false)
@@ -452,6 +541,9 @@ func (p *playbackEngine) handleOnTrackChange() {
 
 func (p *playbackEngine) handleOnStopped() {
 	p.playTimeStopwatch.Stop()
+	p.storeLoudness()
+	p.loudnessTrackID = ""
+	p.ClearABLoop()
 	p.checkScrobble()
 	p.stopPollTimePos()
 	p.doUpdateTimePos(false)
@@ -505,11 +597,13 @@ func (p *playbackEngine) setNextTrackAfterQueueUpdate() {
 func (p *playbackEngine) setTrack(idx int, next bool) error {
 	if urlP, ok := p.player.(player.URLPlayer); ok {
 		url := ""
+		var rgain mediaprovider.ReplayGainInfo
 		if idx >= 0 {
 			var err error
 			item := p.playQueue[idx]
 			if tr, ok := item.(*mediaprovider.Track); ok {
 				url, err = p.sm.Server.GetStreamURL(tr.ID, p.transcodeCfg.ForceRawFile)
+				rgain = p.replayGainInfo(tr)
 			} else {
 				url = item.(*mediaprovider.RadioStation).StreamURL
 			}
@@ -517,6 +611,12 @@ func (p *playbackEngine) setTrack(idx int, next bool) error {
 				return err
 			}
 		}
+		if rgP, ok := urlP.(player.ReplayGainMetadataPlayer); ok {
+			if next {
+				return rgP.SetNextFileWithReplayGain(url, rgain)
+			}
+			return rgP.PlayFileWithReplayGain(url, rgain)
+		}
 		if next {
 			return urlP.SetNextFile(url)
 		}
@@ -537,6 +637,64 @@ func (p *playbackEngine) setTrack(idx int, next bool) error {
 	panic("Unsupported player type")
 }
 
+// returns the ReplayGain info of the track from the server, or, if it has none,
+// the gain from the loudness measured when it was last played, if any
+func (p *playbackEngine) replayGainInfo(tr *mediaprovider.Track) mediaprovider.ReplayGainInfo {
+	if tr.ReplayGain != (mediaprovider.ReplayGainInfo{}) || p.loudness == nil || !p.replayGainCfg.AnalyzeLoudness {
+		return tr.ReplayGain
+	}
+	m, ok := p.loudness.Get(tr.ID)
+	if !ok {
+		return tr.ReplayGain
+	}
+	gain := loudness.Gain(m.Loudness)
+	if m.Peak <= 0 && p.replayGainCfg.PreventClipping {
+		// measured before peaks were stored, so clipping can't be prevented
+		gain = min(gain, 0)
+	}
+	return mediaprovider.ReplayGainInfo{TrackGain: gain, AlbumGain: gain, TrackPeak: m.Peak, AlbumPeak: m.Peak}
+}
+
+func (p *playbackEngine) needsLoudnessAnalysis(item mediaprovider.MediaItem) bool {
+	tr, ok := item.(*mediaprovider.Track)
+	if !ok || p.loudness == nil || !p.replayGainCfg.AnalyzeLoudness ||
+		p.replayGainCfg.Mode == ReplayGainNone || tr.ReplayGain != (mediaprovider.ReplayGainInfo{}) {
+		return false
+	}
+	_, ok = p.player.(player.LoudnessAnalyzer)
+	if !ok {
+		return false
+	}
+	_, measured := p.loudness.Get(tr.ID)
+	return !measured
+}
+
+// reads the loudness of the current track measured so far
+func (p *playbackEngine) sampleLoudness() {
+	if p.loudnessTrackID == "" {
+		return
+	}
+	if la, ok := p.player.(player.LoudnessAnalyzer); ok {
+		if lufs, err := la.GetIntegratedLoudness(); err == nil && loudness.IsValid(lufs) {
+			p.curLoudness.Loudness = lufs
+		}
+		if peak, err := la.GetTruePeak(); err == nil && peak > 0 {
+			p.curLoudness.Peak = peak
+		}
+	}
+}
+
+// call BEFORE checkScrobble, which resets the play time.
+// Stores the loudness of the current track if enough of it was played to measure it.
+func (p *playbackEngine) storeLoudness() {
+	if p.loudnessTrackID == "" || !loudness.IsValid(p.curLoudness.Loudness) || p.curTrackDuration < 0.1 {
+		return
+	}
+	if p.playTimeStopwatch.Elapsed().Seconds()/p.curTrackDuration*100 >= minLoudnessAnalysisPercent {
+		go p.loudness.Put(p.loudnessTrackID, p.curLoudness)
+	}
+}
+
 func (p *playbackEngine) setNextTrack(idx int) error {
 	return p.setTrack(idx, true)
 }
@@ -639,6 +797,7 @@ func (p *playbackEngine) startPollTimePos() {
 				return
 			case <-pollingTick.C:
 				p.doUpdateTimePos(false)
+				p.sampleLoudness()
 			}
 		}
 	}()
@@ -70,6 +70,12 @@ func (p *PlaybackManager) OnLoopModeChange(cb func(LoopMode)) {
 	p.engine.onLoopModeChange = append(p.engine.onLoopModeChange, cb)
 }
 
+// Registers a callback that is notified whenever the A-B loop points change.
+// The points are in seconds, or -1 if unset.
+func (p *PlaybackManager) OnABLoopChange(cb func(a, b float64)) {
+	p.engine.onABLoopChange = append(p.engine.onABLoopChange, cb)
+}
+
 // Registers a callback that is notified whenever the volume changes.
 func (p *PlaybackManager) OnVolumeChange(cb func(int)) {
 	p.engine.onVolumeChange = append(p.engine.onVolumeChange, cb)
@@ -280,6 +286,11 @@ func (p *PlaybackManager) SetReplayGainOptions(config ReplayGainConfig) {
 	p.engine.SetReplayGainOptions(config)
 }
 
+// Sets the LoudnessManager storing the loudness of tracks analyzed during playback.
+func (p *PlaybackManager) SetLoudnessManager(l *LoudnessManager) {
+	p.engine.loudness = l
+}
+
 func (p *PlaybackManager) SetReplayGainMode(mode player.ReplayGainMode) {
 	p.engine.SetReplayGainMode(mode)
 }
@@ -306,6 +317,28 @@ func (p *PlaybackManager) GetLoopMode() LoopMode {
 	return p.engine.loopMode
 }
 
+// Sets the start of the A-B loop within the current track to the playback position.
+// The A-B loop is independent of the loop mode, and is cleared when the track changes.
+func (p *PlaybackManager) SetABLoopA() error {
+	return p.engine.SetABLoopA()
+}
+
+// Sets the end of the A-B loop within the current track to the playback position.
+// Playback loops between A and B once both are set.
+func (p *PlaybackManager) SetABLoopB() error {
+	return p.engine.SetABLoopB()
+}
+
+// Clears the A-B loop points, resuming normal playback.
+func (p *PlaybackManager) ClearABLoop() error {
+	return p.engine.ClearABLoop()
+}
+
+// Returns the A-B loop points in seconds, or -1 if unset.
+func (p *PlaybackManager) GetABLoop() (float64, float64) {
+	return p.engine.GetABLoop()
+}
+
 func (p *PlaybackManager) PlayerStatus() player.Status {
 	return p.engine.PlayerStatus()
 }
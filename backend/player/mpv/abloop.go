@@ -0,0 +1,23 @@
+package mpv
+
+import (
+	"github.com/supersonic-app/go-mpv"
+)
+
+// Sets the start and end in seconds of the section of the current file
+// to play repeatedly. Negative values disable the loop.
+func (p *Player) SetABLoop(a, b float64) error {
+	if !p.initialized {
+		return ErrUnitialized
+	}
+	if a < 0 || b < 0 {
+		if err := p.mpv.SetPropertyString("ab-loop-a", "no"); err != nil {
+			return err
+		}
+		return p.mpv.SetPropertyString("ab-loop-b", "no")
+	}
+	if err := p.mpv.SetProperty("ab-loop-a", mpv.FORMAT_DOUBLE, a); err != nil {
+		return err
+	}
+	return p.mpv.SetProperty("ab-loop-b", mpv.FORMAT_DOUBLE, b)
+}
@@ -0,0 +1,39 @@
+package mpv
+
+import (
+	"log"
+
+	"github.com/supersonic-app/go-mpv"
+)
+
+// reply userdata identifying the audio-device-list property observation
+const audioDeviceListObserverID = 1
+
+// Registers a callback which is invoked with the new list of audio devices
+// when an audio device is connected or disconnected.
+func (p *Player) OnAudioDevicesChanged(cb func([]AudioDevice)) {
+	p.onAudioDevicesChanged = append(p.onAudioDevicesChanged, cb)
+}
+
+// Registers a callback which is invoked with the device name
+// when SetAudioDevice has switched the output to the device.
+func (p *Player) OnAudioDeviceSet(cb func(deviceName string)) {
+	p.onAudioDeviceSet = append(p.onAudioDeviceSet, cb)
+}
+
+// mpv only monitors the system for audio device changes while the
+// audio-device-list property is observed
+func (p *Player) observeAudioDevices() error {
+	return p.mpv.ObserveProperty(audioDeviceListObserverID, "audio-device-list", mpv.FORMAT_NONE)
+}
+
+func (p *Player) handleAudioDevicesChanged() {
+	devs, err := p.ListAudioDevices()
+	if err != nil {
+		log.Printf("error listing audio devices: %s", err.Error())
+		return
+	}
+	for _, cb := range p.onAudioDevicesChanged {
+		cb(devs)
+	}
+}
@@ -0,0 +1,59 @@
+package mpv
+
+// #include <mpv/client.h>
+import "C"
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/supersonic-app/go-mpv"
+)
+
+// DecodeToPCMFile decodes the audio of the media file or URL to raw
+// signed 16-bit little-endian mono PCM at the given sample rate, written
+// to outFile as fast as possible. The file can be read while it is being
+// written. Blocks until decoding ends or the context is cancelled.
+func DecodeToPCMFile(ctx context.Context, url, outFile string, sampleRate int) error {
+	m := mpv.Create()
+	defer m.TerminateDestroy()
+
+	for _, opt := range [][2]string{
+		{"idle", "yes"},
+		{"video", "no"},
+		{"audio-display", "no"},
+		{"terminal", "no"},
+		{"replaygain", "no"},
+		{"ao", "pcm"},
+		{"ao-pcm-file", outFile},
+		{"ao-pcm-waveheader", "no"},
+		{"audio-format", "s16"},
+		{"audio-channels", "mono"},
+		{"audio-samplerate", strconv.Itoa(sampleRate)},
+		{"untimed", "yes"},
+	} {
+		if err := m.SetOptionString(opt[0], opt[1]); err != nil {
+			return err
+		}
+	}
+	if err := m.Initialize(); err != nil {
+		return err
+	}
+	if err := m.Command([]string{"loadfile", url}); err != nil {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		e := m.WaitEvent(0.25 /*timeout seconds*/)
+		switch e.Event_Id {
+		case mpv.EVENT_END_FILE:
+			if ef := (*C.mpv_event_end_file)(e.Data); ef.reason == C.MPV_END_FILE_REASON_ERROR {
+				return mpv.NewError(int(ef.error))
+			}
+			return nil
+		case mpv.EVENT_SHUTDOWN:
+			return errors.New("mpv shut down while decoding")
+		}
+	}
+	return ctx.Err()
+}
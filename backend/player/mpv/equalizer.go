@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math"
 	"strings"
+
+	"github.com/dweymouth/supersonic/backend/parametriceq"
 )
 
 type Equalizer interface {
@@ -78,6 +80,8 @@ type EqualizerBand struct {
 	Gain      float64
 	Width     float64
 	WidthType WidthType
+	// The type of filter; peaking if empty
+	Type parametriceq.FilterType
 }
 
 type EqualizerCurve []EqualizerBand
@@ -98,11 +102,27 @@ func (e EqualizerCurve) String() string {
 }
 
 func (e EqualizerBand) String() string {
+	switch e.Type {
+	case parametriceq.FilterTypeLowPass, parametriceq.FilterTypeHighPass:
+		filter := "lowpass"
+		if e.Type == parametriceq.FilterTypeHighPass {
+			filter = "highpass"
+		}
+		return fmt.Sprintf("%s=f=%d:t=%s:w=%0.2f",
+			filter, e.Frequency, e.WidthType.String(), e.Width)
+	}
 	if math.Abs(e.Gain) < 0.02 {
 		return ""
 	}
-	return fmt.Sprintf("equalizer=f=%d:g=%0.2f:t=%s:w=%0.2f",
-		e.Frequency, e.Gain, e.WidthType.String(), e.Width)
+	filter := "equalizer"
+	switch e.Type {
+	case parametriceq.FilterTypeLowShelf:
+		filter = "lowshelf"
+	case parametriceq.FilterTypeHighShelf:
+		filter = "highshelf"
+	}
+	return fmt.Sprintf("%s=f=%d:g=%0.2f:t=%s:w=%0.2f",
+		filter, e.Frequency, e.Gain, e.WidthType.String(), e.Width)
 }
 
 func (w WidthType) String() string {
@@ -0,0 +1,15 @@
+package mpv
+
+// #include <mpv/client.h>
+import "C"
+import (
+	"github.com/supersonic-app/go-mpv"
+)
+
+// Returns the playlist entry ID of the file started, from a start-file event.
+func startFileEntryID(e *mpv.Event) int64 {
+	if e.Data == nil {
+		return 0
+	}
+	return int64((*C.mpv_event_start_file)(e.Data).playlist_entry_id)
+}
@@ -0,0 +1,192 @@
+package mpv
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/supersonic-app/go-mpv"
+)
+
+// The fades are applied as a gain on the mpv software volume, even when
+// software volume is disabled and the user volume is applied to the audio
+// device instead, so that they never ramp the volume of the system mixer.
+
+const fadeStepInterval = 10 * time.Millisecond
+
+// Sets the duration of the volume fades applied when pausing, resuming
+// or stopping playback, and when playing a new file while playing.
+// A duration of zero disables the fades.
+// Unlike most Player functions, SetFadeDuration can be called before Init.
+func (p *Player) SetFadeDuration(d time.Duration) {
+	p.volLock.Lock()
+	p.fadeDuration = max(d, 0)
+	p.volLock.Unlock()
+}
+
+// Runs action once the volume has faded out, if playing with fades enabled.
+// The fade and the action then run in the background, so an error from the
+// action is logged rather than returned. Actions passed while the fade is
+// running, eg. when skipping several tracks in quick succession, are queued
+// to run after it. Otherwise, runs the action immediately and returns its error.
+func (p *Player) fadeOutThen(action func() error) error {
+	if p.getFadeDuration() == 0 || p.status.State != player.Playing || p.fadingOut() {
+		return p.afterFade(action)
+	}
+	p.stopFade()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.volLock.Lock()
+	p.fadeCancel, p.fadeDone = cancel, done
+	p.fadeQueue = []func() error{action}
+	p.fadeInOnRestart = false
+	p.volLock.Unlock()
+	go func() {
+		defer close(done)
+		p.rampFadeGain(ctx, 0)
+		for {
+			p.volLock.Lock()
+			if len(p.fadeQueue) == 0 {
+				p.volLock.Unlock()
+				return
+			}
+			a := p.fadeQueue[0]
+			p.volLock.Unlock()
+			if err := a(); err != nil {
+				log.Printf("error after fading out: %s", err.Error())
+			}
+			// dequeue only once run, so that actions passed
+			// in the meantime keep their order
+			p.volLock.Lock()
+			p.fadeQueue = p.fadeQueue[1:]
+			p.volLock.Unlock()
+		}
+	}()
+	return nil
+}
+
+// Runs action after the actions queued by fadeOutThen, or immediately if
+// there are none, to keep the order of the commands sent to mpv.
+func (p *Player) afterFade(action func() error) error {
+	p.volLock.Lock()
+	if len(p.fadeQueue) > 0 {
+		p.fadeQueue = append(p.fadeQueue, action)
+		p.volLock.Unlock()
+		return nil
+	}
+	p.volLock.Unlock()
+	return action()
+}
+
+func (p *Player) fadingOut() bool {
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
+	return len(p.fadeQueue) > 0
+}
+
+// Fades the volume in from its current level in the background.
+func (p *Player) fadeIn() {
+	p.setFadeInOnRestart(false)
+	p.stopFade()
+	if p.getFadeDuration() == 0 {
+		p.setFadeGain(1)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.volLock.Lock()
+	p.fadeCancel, p.fadeDone = cancel, done
+	p.volLock.Unlock()
+	go func() {
+		defer close(done)
+		p.rampFadeGain(ctx, 1)
+	}()
+}
+
+// Stops the running fade, if any, and waits for the actions queued after it.
+func (p *Player) stopFade() {
+	p.volLock.Lock()
+	cancel, done := p.fadeCancel, p.fadeDone
+	p.fadeCancel, p.fadeDone = nil, nil
+	p.volLock.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Silences the output until the next fade in, if fades are enabled.
+func (p *Player) muteForFadeIn() {
+	if p.getFadeDuration() > 0 {
+		p.setFadeGain(0)
+	} else {
+		p.setFadeGain(1)
+	}
+}
+
+// ramps the fade gain from its current value to the target
+func (p *Player) rampFadeGain(ctx context.Context, target float64) {
+	from := p.getFadeGain()
+	steps := fadeSteps(from, target, p.getFadeDuration())
+	if steps == 0 {
+		p.setFadeGain(target)
+		return
+	}
+	t := time.NewTicker(fadeStepInterval)
+	defer t.Stop()
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			p.setFadeGain(from + (target-from)*float64(i)/float64(steps))
+		}
+	}
+}
+
+// Returns the number of steps of a fade between the gains. A full fade takes
+// the fade duration, and a fade which interrupts another one, eg. pausing while
+// still fading in, takes the fraction of the duration needed to reach the target.
+func fadeSteps(from, target float64, duration time.Duration) int {
+	return int(math.Round(math.Abs(target-from) * float64(duration/fadeStepInterval)))
+}
+
+func (p *Player) getFadeDuration() time.Duration {
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
+	return p.fadeDuration
+}
+
+func (p *Player) getFadeGain() float64 {
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
+	return p.fadeGain
+}
+
+func (p *Player) setFadeInOnRestart(fadeIn bool) {
+	p.volLock.Lock()
+	p.fadeInOnRestart = fadeIn
+	p.volLock.Unlock()
+}
+
+func (p *Player) setFadeGain(gain float64) {
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
+	p.fadeGain = gain
+	if err := p.mpv.SetProperty("volume", mpv.FORMAT_DOUBLE, p.softVolume()); err != nil {
+		log.Printf("error setting fade volume: %s", err.Error())
+	}
+}
+
+// Returns the mpv software volume: the user volume, or 100 if it is
+// applied to the audio device, scaled by the fade gain.
+// Must be called with volLock held.
+func (p *Player) softVolume() float64 {
+	vol := p.vol
+	if p.softVolumeDisabled {
+		vol = 100
+	}
+	return float64(vol) * p.fadeGain
+}
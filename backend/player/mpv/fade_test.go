@@ -0,0 +1,28 @@
+package mpv
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_FadeSteps(t *testing.T) {
+	const d = 250 * time.Millisecond
+	for _, tt := range []struct {
+		name         string
+		from, target float64
+		want         int
+	}{
+		{"full fade out", 1, 0, 25},
+		{"full fade in", 0, 1, 25},
+		{"fade out interrupting a fade in", 0.4, 0, 10},
+		{"fade in interrupting a fade out", 0.4, 1, 15},
+		{"already at target", 0.7, 0.7, 0},
+	} {
+		if got := fadeSteps(tt.from, tt.target, d); got != tt.want {
+			t.Errorf("%s: got %d steps, want %d", tt.name, got, tt.want)
+		}
+	}
+	if got := fadeSteps(1, 0, 0); got != 0 {
+		t.Errorf("disabled fade: got %d steps, want 0", got)
+	}
+}
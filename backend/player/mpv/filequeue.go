@@ -0,0 +1,131 @@
+package mpv
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+)
+
+// fileQueue tracks the files loaded into the mpv playlist and their
+// ReplayGain info. It is updated both by the player commands, which run on
+// the fade goroutine when queued after a fade out, and by the mpv event loop,
+// so every access takes its lock. The mpv commands which change the playlist
+// are sent with the lock held, so that the playlist entry IDs recorded for
+// the files are those mpv reports in its start-file events.
+type fileQueue struct {
+	lock sync.Mutex
+
+	// ReplayGain info from the metadata of the current and next files,
+	// applied as the mpv replaygain-fallback for streams without tags
+	rgainCur  mediaprovider.ReplayGainInfo
+	rgainNext mediaprovider.ReplayGainInfo
+	rgainOpts player.ReplayGainOptions
+
+	// mpv playlist entry IDs of the current and next files, or 0 if none
+	curID  int64
+	nextID int64
+
+	curPos int64
+	length int64
+
+	// sends a command to mpv
+	command func(args []string) error
+	// returns the mpv playlist entry ID of the file at the playlist position
+	entryID func(pos int64) (int64, error)
+	// sets the gain mpv applies to the current file if it has no ReplayGain tags
+	setGain func(gain float64) error
+}
+
+// Replaces the playlist with the file, applying its ReplayGain info.
+func (q *fileQueue) replace(url string, rgain mediaprovider.ReplayGainInfo) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rgainCur, q.rgainNext = rgain, mediaprovider.ReplayGainInfo{}
+	q.applyGainLocked()
+	q.curID, q.nextID = 0, 0
+	if err := q.command([]string{"loadfile", url, "replace"}); err != nil {
+		return err
+	}
+	q.length = 1
+	var err error
+	q.curID, err = q.entryID(0)
+	return err
+}
+
+// Sets the file to play after the current one, or removes the next file if url is empty.
+func (q *fileQueue) setNext(url string, rgain mediaprovider.ReplayGainInfo) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rgainNext = rgain
+	if q.length > q.curPos+1 {
+		if err := q.command([]string{"playlist-remove", strconv.Itoa(int(q.curPos) + 1)}); err != nil {
+			return err
+		}
+		q.length--
+		q.nextID = 0
+	}
+	if url == "" {
+		return nil
+	}
+
+	if err := q.command([]string{"loadfile", url, "append"}); err != nil {
+		return err
+	}
+	q.length++
+	var err error
+	q.nextID, err = q.entryID(q.length - 1)
+	return err
+}
+
+// Runs stop, which clears the mpv playlist, and empties the queue if it succeeds.
+func (q *fileQueue) clear(stop func() error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	err := stop()
+	if err == nil {
+		q.length = 0
+		q.curID, q.nextID = 0, 0
+	}
+	return err
+}
+
+// Sets the ReplayGain options and re-applies the gain of the current file.
+func (q *fileQueue) setReplayGainOptions(opts player.ReplayGainOptions) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rgainOpts = opts
+	return q.applyGainLocked()
+}
+
+// Handles the mpv start-file event for the playlist entry.
+func (q *fileQueue) startFile(entryID int64) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	switch entryID {
+	case q.curID:
+		// file loaded by replace, gain already set
+	case q.nextID:
+		// advanced to the next file
+		q.rgainCur, q.rgainNext = q.rgainNext, mediaprovider.ReplayGainInfo{}
+		q.curID, q.nextID = q.nextID, 0
+		q.applyGainLocked()
+	default:
+		// a file which was replaced or removed before the event was handled
+	}
+}
+
+// Handles the mpv file-loaded event for the file at the playlist position.
+func (q *fileQueue) fileLoaded(pos int64) {
+	q.lock.Lock()
+	q.curPos = pos
+	q.lock.Unlock()
+}
+
+// mpv applies neither the preamp nor clipping prevention to the fallback gain,
+// so they are included in the gain computed from the metadata.
+// Must be called with lock held.
+func (q *fileQueue) applyGainLocked() error {
+	return q.setGain(q.rgainOpts.GainFromMetadata(q.rgainCur))
+}
@@ -0,0 +1,161 @@
+package mpv
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+)
+
+// fakePlaylist stands in for the mpv playlist, assigning
+// entry IDs to the loaded files the way mpv does.
+type fakePlaylist struct {
+	lock    sync.Mutex
+	ids     []int64
+	lastID  int64
+	applied float64
+	// receives the entry ID of each file loaded by replace
+	replaced chan int64
+}
+
+func newTestFileQueue() (*fileQueue, *fakePlaylist) {
+	f := &fakePlaylist{replaced: make(chan int64, 10)}
+	q := &fileQueue{
+		rgainOpts: player.ReplayGainOptions{Mode: player.ReplayGainTrack},
+		command:   f.command,
+		entryID:   f.entryID,
+		setGain:   f.setGain,
+	}
+	return q, f
+}
+
+func (f *fakePlaylist) command(args []string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch {
+	case args[0] == "loadfile":
+		f.lastID++
+		if args[2] == "replace" {
+			f.ids = nil
+			f.replaced <- f.lastID
+		}
+		f.ids = append(f.ids, f.lastID)
+	case args[0] == "playlist-remove":
+		i, _ := strconv.Atoi(args[1])
+		f.ids = slices.Delete(f.ids, i, i+1)
+	case args[0] == "stop":
+		f.ids = nil
+	}
+	return nil
+}
+
+func (f *fakePlaylist) entryID(pos int64) (int64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if pos >= int64(len(f.ids)) {
+		return 0, fmt.Errorf("no playlist entry at %d", pos)
+	}
+	return f.ids[pos], nil
+}
+
+func (f *fakePlaylist) setGain(gain float64) error {
+	f.lock.Lock()
+	f.applied = gain
+	f.lock.Unlock()
+	return nil
+}
+
+func (f *fakePlaylist) appliedGain() float64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.applied
+}
+
+// Run with -race: a track change queued after a fade out runs on the fade
+// goroutine while the mpv event loop still handles the start-file event of
+// the file played before it, eg. when skipping tracks in quick succession.
+func Test_FileQueueTrackChangeDuringStartFile(t *testing.T) {
+	prev := mediaprovider.ReplayGainInfo{TrackGain: -1}
+	next := mediaprovider.ReplayGainInfo{TrackGain: -2}
+	played := mediaprovider.ReplayGainInfo{TrackGain: -3}
+
+	for i := 0; i < 100; i++ {
+		q, f := newTestFileQueue()
+		if err := q.replace("prev", prev); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.setNext("next", next); err != nil {
+			t.Fatal(err)
+		}
+		prevID := <-f.replaced
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { // the mpv event loop
+			defer wg.Done()
+			// the start-file of prev, unordered with the track change
+			q.startFile(prevID)
+			q.fileLoaded(0)
+			q.startFile(<-f.replaced)
+			q.fileLoaded(0)
+		}()
+		go func() { // the fade goroutine
+			defer wg.Done()
+			if err := q.replace("played", played); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Wait()
+
+		if q.rgainCur != played || f.appliedGain() != played.TrackGain {
+			t.Fatalf("got current gain %v and applied %v, want %v", q.rgainCur, f.appliedGain(), played)
+		}
+		if q.length != 1 || q.rgainNext != (mediaprovider.ReplayGainInfo{}) {
+			t.Fatalf("got %d files and next gain %v, want only the played file", q.length, q.rgainNext)
+		}
+	}
+}
+
+func Test_FileQueueAdvanceAfterStop(t *testing.T) {
+	stopped := mediaprovider.ReplayGainInfo{TrackGain: -1}
+	played := mediaprovider.ReplayGainInfo{TrackGain: -2}
+	next := mediaprovider.ReplayGainInfo{TrackGain: -3}
+
+	q, f := newTestFileQueue()
+	// stopped before mpv started the file
+	if err := q.replace("stopped", stopped); err != nil {
+		t.Fatal(err)
+	}
+	<-f.replaced
+	if err := q.clear(func() error { return f.command([]string{"stop"}) }); err != nil {
+		t.Fatal(err)
+	}
+
+	// skipped several times before mpv started a file
+	var playedID int64
+	for _, url := range []string{"skipped1", "skipped2", "played"} {
+		if err := q.replace(url, played); err != nil {
+			t.Fatal(err)
+		}
+		playedID = <-f.replaced
+	}
+	if err := q.setNext("next", next); err != nil {
+		t.Fatal(err)
+	}
+	q.startFile(playedID)
+	q.fileLoaded(0)
+	if f.appliedGain() != played.TrackGain {
+		t.Fatalf("got gain %v for the played file, want %v", f.appliedGain(), played.TrackGain)
+	}
+
+	// gapless advance to the next file
+	q.startFile(playedID + 1)
+	q.fileLoaded(1)
+	if q.rgainCur != next || f.appliedGain() != next.TrackGain {
+		t.Errorf("got current gain %v and applied %v after advancing, want %v", q.rgainCur, f.appliedGain(), next)
+	}
+}
@@ -0,0 +1,115 @@
+package mpv
+
+import (
+	"github.com/supersonic-app/go-mpv"
+)
+
+// ResamplerQuality is a quality preset of the resampler
+// used when the output sample rate differs from the source.
+type ResamplerQuality string
+
+const (
+	ResamplerQualityDefault  ResamplerQuality = "Default"
+	ResamplerQualityLow      ResamplerQuality = "Low"
+	ResamplerQualityHigh     ResamplerQuality = "High"
+	ResamplerQualityVeryHigh ResamplerQuality = "Very high"
+)
+
+var ResamplerQualities = []ResamplerQuality{
+	ResamplerQualityDefault, ResamplerQualityLow, ResamplerQualityHigh, ResamplerQualityVeryHigh,
+}
+
+// returns the mpv audio-resample-filter-size and audio-resample-phase-shift for the preset
+func (r ResamplerQuality) filterSizeAndPhaseShift() (int64, int64) {
+	switch r {
+	case ResamplerQualityLow:
+		return 8, 8
+	case ResamplerQualityHigh:
+		return 32, 12
+	case ResamplerQualityVeryHigh:
+		return 64, 14
+	}
+	return 16, 10 // mpv defaults
+}
+
+// Options for the format of the audio sent to the audio device.
+type OutputOptions struct {
+	// Output sample rate in Hz, or 0 to match the source
+	SampleRate int
+	// Output sample format, e.g. "s16", "s32" or "float", or "" to choose automatically
+	SampleFormat     string
+	ResamplerQuality ResamplerQuality
+	// Control the volume of the audio device instead of scaling the samples
+	DisableSoftwareVolume bool
+}
+
+// Information about the format of the audio sent to the audio device.
+type OutputInfo struct {
+	// The sample format, using the same names as MediaInfo.
+	Format string
+
+	// Audio samplerate.
+	Samplerate int
+
+	// The number of channels.
+	ChannelCount int
+}
+
+// Sets the options for the format of the audio sent to the audio device.
+func (p *Player) SetOutputOptions(opts OutputOptions) error {
+	if !p.initialized {
+		return ErrUnitialized
+	}
+	if err := p.mpv.SetProperty("audio-samplerate", mpv.FORMAT_INT64, int64(opts.SampleRate)); err != nil {
+		return err
+	}
+	format := opts.SampleFormat
+	if format == "" {
+		format = "no"
+	}
+	if err := p.mpv.SetPropertyString("audio-format", format); err != nil {
+		return err
+	}
+	filterSize, phaseShift := opts.ResamplerQuality.filterSizeAndPhaseShift()
+	if err := p.mpv.SetProperty("audio-resample-filter-size", mpv.FORMAT_INT64, filterSize); err != nil {
+		return err
+	}
+	if err := p.mpv.SetProperty("audio-resample-phase-shift", mpv.FORMAT_INT64, phaseShift); err != nil {
+		return err
+	}
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
+	if opts.DisableSoftwareVolume == p.softVolumeDisabled {
+		return nil
+	}
+	p.softVolumeDisabled = opts.DisableSoftwareVolume
+	if p.softVolumeDisabled {
+		// leave the samples unscaled, except by fades, and apply the volume to the device instead
+		if err := p.mpv.SetProperty("ao-volume", mpv.FORMAT_INT64, p.vol); err != nil {
+			return err
+		}
+	} else {
+		// restore the device volume, which the user volume was applied to
+		if err := p.mpv.SetProperty("ao-volume", mpv.FORMAT_INT64, 100); err != nil {
+			return err
+		}
+	}
+	return p.mpv.SetProperty("volume", mpv.FORMAT_DOUBLE, p.softVolume())
+}
+
+// Returns information about the format of the audio sent to the audio device.
+func (p *Player) GetOutputInfo() (OutputInfo, error) {
+	var info OutputInfo
+	if !p.initialized {
+		return info, ErrUnitialized
+	}
+	n, err := p.mpv.GetProperty("audio-out-params", mpv.FORMAT_NODE)
+	if err != nil {
+		return info, err
+	}
+	nodeMap := n.(*mpv.Node).Data.(map[string]*mpv.Node)
+	info.Format = nodeMap["format"].Data.(string)
+	info.Samplerate = int(nodeMap["samplerate"].Data.(int64))
+	info.ChannelCount = int(nodeMap["channel-count"].Data.(int64))
+	return info, nil
+}
@@ -0,0 +1,39 @@
+package mpv
+
+import "github.com/dweymouth/supersonic/backend/parametriceq"
+
+// ParametricEqualizer is an equalizer with any number of bands,
+// each with its own filter type, frequency, gain and Q.
+type ParametricEqualizer struct {
+	Disabled bool
+	EQPreamp float64
+	Bands    []EqualizerBand
+}
+
+var _ Equalizer = (*ParametricEqualizer)(nil)
+
+func (p *ParametricEqualizer) IsEnabled() bool {
+	return !p.Disabled
+}
+
+func (p *ParametricEqualizer) Preamp() float64 {
+	return p.EQPreamp
+}
+
+func (p *ParametricEqualizer) Curve() EqualizerCurve {
+	curve := make(EqualizerCurve, len(p.Bands))
+	copy(curve, p.Bands)
+	return curve
+}
+
+func (p *ParametricEqualizer) BandFrequencies() []string {
+	ret := make([]string, len(p.Bands))
+	for i, b := range p.Bands {
+		ret[i] = parametriceq.FormatFrequency(b.Frequency)
+	}
+	return ret
+}
+
+func (*ParametricEqualizer) Type() string {
+	return "Parametric"
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/supersonic-app/go-mpv"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/player"
 )
 
@@ -46,6 +51,8 @@ type MediaInfo struct {
 }
 
 var _ player.URLPlayer = (*Player)(nil)
+var _ player.ReplayGainMetadataPlayer = (*Player)(nil)
+var _ player.LoudnessAnalyzer = (*Player)(nil)
 
 // Player encapsulates the mpv instance and provides functions
 // to control it and to check its status.
@@ -58,13 +65,31 @@ type Player struct {
 	audioExclusive bool
 	status         player.Status
 	seeking        bool
-	curPlaylistPos int64
-	lenPlaylist    int64
+	files          fileQueue
 	prePausedState player.State
 	clientName     string
 	equalizer      Equalizer
 	peaksEnabled   bool
 
+	loudnessEnabled bool
+	spectrumEnabled bool
+	// channel layout of the audio the spectrum filter was built for
+	spectrumLayout spectrumLayout
+
+	softVolumeDisabled bool
+
+	// volume fades around pause, resume, stop and file changes,
+	// applied as a gain on top of the user volume.
+	// volLock guards vol, softVolumeDisabled and the fade state,
+	// which are also accessed by the fade goroutines
+	volLock         sync.Mutex
+	fadeDuration    time.Duration
+	fadeGain        float64
+	fadeCancel      context.CancelFunc
+	fadeDone        chan struct{}
+	fadeQueue       []func() error
+	fadeInOnRestart bool
+
 	bgCancel context.CancelFunc
 
 	// callbacks
@@ -73,6 +98,9 @@ type Player struct {
 	onPlaying     []func()
 	onSeek        []func()
 	onTrackChange []func()
+
+	onAudioDevicesChanged []func([]AudioDevice)
+	onAudioDeviceSet      []func(string)
 }
 
 // Returns a new player.
@@ -84,10 +112,17 @@ func New() *Player {
 // Same as New, but sets the application name that mpv
 // reports to the system audio API.
 func NewWithClientName(c string) *Player {
-	return &Player{
+	p := &Player{
 		vol:        -1, // use 100 in Init
 		clientName: c,
+		fadeGain:   1,
 	}
+	p.files.command = func(args []string) error { return p.mpv.Command(args) }
+	p.files.entryID = func(pos int64) (int64, error) {
+		return p.getInt64Property(fmt.Sprintf("playlist/%d/id", pos))
+	}
+	p.files.setGain = p.setReplayGainFallback
+	return p
 }
 
 // Initializes the Player and makes it ready for playback.
@@ -129,6 +164,9 @@ func (p *Player) Init(maxCacheMB int) error {
 		}
 
 		p.mpv = m
+		if err := p.observeAudioDevices(); err != nil {
+			log.Printf("error observing audio devices: %s", err.Error())
+		}
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	go p.eventHandler(ctx)
@@ -139,12 +177,24 @@ func (p *Player) Init(maxCacheMB int) error {
 
 // Plays the specified file, clearing the previous play queue, if any.
 func (p *Player) PlayFile(url string) error {
+	return p.PlayFileWithReplayGain(url, mediaprovider.ReplayGainInfo{})
+}
+
+// Same as PlayFile, but applies the ReplayGain info if the stream has no ReplayGain tags.
+func (p *Player) PlayFileWithReplayGain(url string, rgain mediaprovider.ReplayGainInfo) error {
 	if !p.initialized {
 		return ErrUnitialized
 	}
-	err := p.mpv.Command([]string{"loadfile", url, "replace"})
+	err := p.fadeOutThen(func() error {
+		p.muteForFadeIn()
+		if err := p.files.replace(url, rgain); err != nil {
+			p.setFadeGain(1)
+			return err
+		}
+		p.setFadeInOnRestart(true)
+		return nil
+	})
 	if err == nil {
-		p.lenPlaylist = 1
 		if p.status.State == player.Paused {
 			return p.Continue()
 		}
@@ -158,38 +208,38 @@ func (p *Player) Stop() error {
 	if !p.initialized {
 		return ErrUnitialized
 	}
-	var err error
-	if p.status.State == player.Stopped {
-		err = p.mpv.Command([]string{"playlist-clear"})
-	} else {
-		if err = p.mpv.Command([]string{"stop"}); err == nil {
+	stopped := p.status.State == player.Stopped
+	err := p.fadeOutThen(func() error {
+		err := p.files.clear(func() error {
+			if stopped {
+				return p.mpv.Command([]string{"playlist-clear"})
+			}
+			if err := p.mpv.Command([]string{"stop"}); err != nil {
+				return err
+			}
 			// if player was paused, stop command actually doesn't clear pause state
-			err = p.setPaused(false)
-		}
-	}
+			return p.setPaused(false)
+		})
+		p.setFadeInOnRestart(false)
+		p.setFadeGain(1)
+		return err
+	})
 	if err == nil {
-		p.lenPlaylist = 0
 		p.setState(player.Stopped)
 	}
 	return err
 }
 
 func (p *Player) SetNextFile(url string) error {
-	if p.lenPlaylist > p.curPlaylistPos+1 {
-		if err := p.mpv.Command([]string{"playlist-remove", strconv.Itoa(int(p.curPlaylistPos) + 1)}); err != nil {
-			return err
-		}
-		p.lenPlaylist--
-	}
-	if url == "" {
-		return nil
-	}
+	return p.SetNextFileWithReplayGain(url, mediaprovider.ReplayGainInfo{})
+}
 
-	err := p.mpv.Command([]string{"loadfile", url, "append"})
-	if err == nil {
-		p.lenPlaylist++
-	}
-	return err
+// Same as SetNextFile, but applies the ReplayGain info if the stream has no ReplayGain tags.
+func (p *Player) SetNextFileWithReplayGain(url string, rgain mediaprovider.ReplayGainInfo) error {
+	// after the file loaded by a PlayFile which is still fading out the current one
+	return p.afterFade(func() error {
+		return p.files.setNext(url, rgain)
+	})
 }
 
 // Seeks within the currently playing track.
@@ -199,9 +249,10 @@ func (p *Player) SeekSeconds(secs float64) error {
 		return ErrUnitialized
 	}
 	target := fmt.Sprintf("%0.1f", secs)
-	p.seeking = true
-	err := p.mpv.Command([]string{"seek", target, "absolute"})
-	return err
+	return p.afterFade(func() error {
+		p.seeking = true
+		return p.mpv.Command([]string{"seek", target, "absolute"})
+	})
 }
 
 // Sets the volume of the player (0-100).
@@ -213,8 +264,15 @@ func (p *Player) SetVolume(vol int) error {
 	} else if vol < 0 {
 		vol = 0
 	}
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
 	if p.initialized {
-		err := p.mpv.SetProperty("volume", mpv.FORMAT_INT64, vol)
+		var err error
+		if p.softVolumeDisabled {
+			err = p.mpv.SetProperty("ao-volume", mpv.FORMAT_INT64, vol)
+		} else {
+			err = p.mpv.SetProperty("volume", mpv.FORMAT_DOUBLE, float64(vol)*p.fadeGain)
+		}
 		if err == nil {
 			p.vol = vol
 		}
@@ -253,7 +311,16 @@ func (p *Player) SetReplayGainOptions(options player.ReplayGainOptions) error {
 			return err
 		}
 	}
-	return nil
+	// the fallback gain is computed from the options on the mpv event loop
+	return p.files.setReplayGainOptions(options)
+}
+
+// sets the gain mpv applies to the current file if it has no ReplayGain tags.
+func (p *Player) setReplayGainFallback(gain float64) error {
+	if !p.initialized {
+		return nil
+	}
+	return p.mpv.SetProperty("replaygain-fallback", mpv.FORMAT_DOUBLE, gain)
 }
 
 // Sets the audio exclusive option of the player.
@@ -272,6 +339,8 @@ func (p *Player) SetAudioExclusive(tf bool) {
 
 // Gets the current volume of the player.
 func (p *Player) GetVolume() int {
+	p.volLock.Lock()
+	defer p.volLock.Unlock()
 	return p.vol
 }
 
@@ -295,7 +364,9 @@ func (p *Player) Pause() error {
 	if p.status.State != player.Playing {
 		return nil
 	}
-	err := p.setPaused(true)
+	err := p.fadeOutThen(func() error {
+		return p.setPaused(true)
+	})
 	if err == nil {
 		p.prePausedState = p.status.State
 		p.setState(player.Paused)
@@ -306,10 +377,14 @@ func (p *Player) Pause() error {
 // Continue playback and update the player state
 func (p *Player) Continue() error {
 	if p.status.State == player.Paused {
+		// let a pause which is still fading out complete first
+		p.stopFade()
+		p.muteForFadeIn()
 		err := p.setPaused(false)
 		if err == nil {
 			p.setState(p.prePausedState)
 		}
+		p.fadeIn()
 		return err
 	}
 
@@ -352,7 +427,13 @@ func (p *Player) ListAudioDevices() ([]AudioDevice, error) {
 }
 
 func (p *Player) SetAudioDevice(deviceName string) error {
-	return p.mpv.SetPropertyString("audio-device", deviceName)
+	if err := p.mpv.SetPropertyString("audio-device", deviceName); err != nil {
+		return err
+	}
+	for _, cb := range p.onAudioDeviceSet {
+		cb(deviceName)
+	}
+	return nil
 }
 
 func (p *Player) SetEqualizer(eq Equalizer) error {
@@ -436,6 +517,7 @@ func (p *Player) Destroy() {
 		p.bgCancel()
 	}
 	if p.initialized {
+		p.stopFade()
 		p.mpv.Command([]string{"stop"})
 		p.mpv.TerminateDestroy()
 		p.initialized = false
@@ -450,6 +532,47 @@ func (p *Player) SetPeaksEnabled(enabled bool) error {
 	return p.setAF()
 }
 
+// Enables the EBU R128 loudness measurement of the playing media.
+// mpv recreates the audio filters for each file, so the measurement
+// starts over when a new file begins playing.
+func (p *Player) SetLoudnessAnalysisEnabled(enabled bool) error {
+	if p.loudnessEnabled == enabled {
+		return nil
+	}
+	p.loudnessEnabled = enabled
+	return p.setAF()
+}
+
+// Returns the integrated loudness in LUFS of the current file measured so far.
+func (p *Player) GetIntegratedLoudness() (float64, error) {
+	if !p.initialized {
+		return 0, ErrUnitialized
+	}
+	if !p.loudnessEnabled {
+		return 0, errors.New("loudness analysis not enabled")
+	}
+	i, err := p.mpv.GetProperty("af-metadata/ebur128/lavfi.r128.I", mpv.FORMAT_STRING)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(i.(string), 64)
+}
+
+// Returns the true peak of the current file measured so far, as a linear amplitude.
+func (p *Player) GetTruePeak() (float64, error) {
+	if !p.initialized {
+		return 0, ErrUnitialized
+	}
+	if !p.loudnessEnabled {
+		return 0, errors.New("loudness analysis not enabled")
+	}
+	peak, err := p.mpv.GetProperty("af-metadata/ebur128/lavfi.r128.true_peak", mpv.FORMAT_STRING)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(peak.(string), 64)
+}
+
 func (p *Player) GetPeaks() (float64, float64, float64, float64) {
 	nInf := math.Inf(-1)
 	if p.status.State != player.Playing {
@@ -488,23 +611,28 @@ func (p *Player) setState(s player.State) {
 }
 
 func (p *Player) setAF() error {
-	af := ""
-	if p.peaksEnabled {
-		af = "@astats:astats=metadata=1:reset=1:measure_overall=none"
+	var filters []string
+	if p.loudnessEnabled {
+		// measure before the equalizer so the loudness is that of the track
+		filters = append(filters, "@ebur128:ebur128=metadata=1:peak=true")
 	}
-	eq := p.equalizer
-	if eq == nil || !eq.IsEnabled() {
-		return p.mpv.SetPropertyString("af", af)
-	} else if p.peaksEnabled {
-		af = af + ","
+	if p.peaksEnabled {
+		filters = append(filters, "@astats:astats=metadata=1:reset=1:measure_overall=none")
 	}
-	if math.Abs(eq.Preamp()) > 0.01 {
-		af = fmt.Sprintf("%svolume=volume=%0.1fdB", af, eq.Preamp())
+	if eq := p.equalizer; eq != nil && eq.IsEnabled() {
+		if math.Abs(eq.Preamp()) > 0.01 {
+			filters = append(filters, fmt.Sprintf("volume=volume=%0.1fdB", eq.Preamp()))
+		}
+		if eqAF := eq.Curve().String(); eqAF != "" {
+			filters = append(filters, eqAF)
+		}
 	}
-	if eqAF := eq.Curve().String(); eqAF != "" {
-		af = fmt.Sprintf("%s,%s", af, eqAF)
+	if p.spectrumEnabled {
+		// analyze what is heard, after the equalizer
+		p.spectrumLayout = p.decodedLayout()
+		filters = append(filters, spectrumFilter(p.spectrumLayout))
 	}
-	return p.mpv.SetPropertyString("af", af)
+	return p.mpv.SetPropertyString("af", strings.Join(filters, ","))
 }
 
 func (p *Player) eventHandler(ctx context.Context) {
@@ -522,12 +650,21 @@ func (p *Player) eventHandler(ctx context.Context) {
 				if p.seeking {
 					p.seeking = false
 				}
+				p.volLock.Lock()
+				fadeIn := p.fadeInOnRestart
+				p.volLock.Unlock()
+				if fadeIn {
+					p.fadeIn()
+				}
 			case mpv.EVENT_SEEK:
 				for _, cb := range p.onSeek {
 					cb()
 				}
+			case mpv.EVENT_START_FILE:
+				p.files.startFile(startFileEntryID(e))
 			case mpv.EVENT_FILE_LOADED:
-				p.curPlaylistPos, _ = p.getInt64Property("playlist-pos")
+				pos, _ := p.getInt64Property("playlist-pos")
+				p.files.fileLoaded(pos)
 				if p.status.State == player.Paused {
 					// seek while paused switches to a new file
 					// mpv does not fire seek event in this case
@@ -538,6 +675,15 @@ func (p *Player) eventHandler(ctx context.Context) {
 				for _, cb := range p.onTrackChange {
 					cb()
 				}
+			case mpv.EVENT_AUDIO_RECONFIG:
+				if p.spectrumEnabled && p.decodedLayout() != p.spectrumLayout {
+					// the spectrum filter passes through the channels it was built for
+					p.setAF()
+				}
+			case mpv.EVENT_PROPERTY_CHANGE:
+				if e.Reply_Userdata == audioDeviceListObserverID {
+					p.handleAudioDevicesChanged()
+				}
 			case mpv.EVENT_IDLE:
 				p.status.Duration = 0
 				p.status.TimePos = 0
@@ -0,0 +1,37 @@
+#include <mpv/client.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+// Reads the RMS levels of the spectrum analyzer bands, which astats reports
+// as channels first_channel..first_channel+n-1 (one-based) of the spectrum filter.
+int mpv_get_band_levels(mpv_handle* handle, double* levels, int first_channel, int n) {
+    mpv_node result;
+    int ret = mpv_get_property(handle, "af-metadata/spectrum", MPV_FORMAT_NODE, &result);
+    if (ret != MPV_ERROR_SUCCESS) {
+        return ret;
+    }
+    if (result.format != MPV_FORMAT_NODE_MAP) {
+        mpv_free_node_contents(&result);
+        return MPV_ERROR_PROPERTY_FORMAT;
+    }
+
+    for (int i = 0; i < result.u.list->num; i++) {
+        int ch, end = 0;
+        if (sscanf(result.u.list->keys[i], "lavfi.astats.%d.RMS_level%n", &ch, &end) != 1 ||
+            result.u.list->keys[i][end] != '\0') {
+            continue;
+        }
+        ch -= first_channel;
+        if (ch < 0 || ch >= n) {
+            continue;
+        }
+        if (result.u.list->values[i].format != MPV_FORMAT_STRING) {
+            ret = MPV_ERROR_PROPERTY_FORMAT;
+            break;
+        }
+        levels[ch] = atof(result.u.list->values[i].u.string);
+    }
+
+    mpv_free_node_contents(&result);
+    return ret;
+}
@@ -0,0 +1,125 @@
+package mpv
+
+// #include <mpv/client.h>
+// int mpv_get_band_levels(mpv_handle* handle, double* levels, int first_channel, int n);
+import "C"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unsafe"
+
+	"github.com/supersonic-app/go-mpv"
+)
+
+// SpectrumBandFrequencies are the center frequencies in Hz of the bands
+// measured by the spectrum analyzer, the ISO 1/3 octave bands.
+var SpectrumBandFrequencies = []float64{
+	20, 25, 31.5, 40, 50, 63, 80, 100, 125, 160, 200, 250, 315, 400, 500, 630,
+	800, 1000, 1250, 1600, 2000, 2500, 3150, 4000, 5000, 6300, 8000, 10000, 12500, 16000, 20000,
+}
+
+// Q of a band pass filter one third of an octave wide
+const thirdOctaveQ = 4.318
+
+// channel layout names which mpv and ffmpeg share, such as "stereo" or "5.1(side)".
+// mpv names other layouts by their speakers, or "unknown<channels>",
+// which ffmpeg can't parse.
+var layoutNameRegex = regexp.MustCompile(`^[a-z0-9.()]+$`)
+
+// spectrumLayout is the channel layout of the played audio,
+// which the spectrum filter passes through unchanged.
+type spectrumLayout struct {
+	// the layout name, or "" if ffmpeg does not know it
+	name     string
+	channels int
+}
+
+// ffmpeg channel layout of the played audio, by name or else by channel count
+func (l spectrumLayout) String() string {
+	if l.name != "" {
+		return l.name
+	}
+	return fmt.Sprintf("%dc", l.channels)
+}
+
+// Enables the spectrum analysis read by GetSpectrum. The analysis runs on a
+// copy of the audio, so the played audio keeps its channels, but enabling it
+// recreates the audio filters, and playback is not bit-perfect while enabled.
+func (p *Player) SetSpectrumEnabled(enabled bool) error {
+	if p.spectrumEnabled == enabled {
+		return nil
+	}
+	p.spectrumEnabled = enabled
+	return p.setAF()
+}
+
+// Returns whether the spectrum analysis is enabled.
+func (p *Player) SpectrumEnabled() bool {
+	return p.spectrumEnabled
+}
+
+// Fills levels with the RMS level in dB of each of the SpectrumBandFrequencies
+// of the most recently played audio.
+func (p *Player) GetSpectrum(levels []float64) error {
+	if !p.initialized {
+		return ErrUnitialized
+	}
+	n := min(len(levels), len(SpectrumBandFrequencies))
+	if n == 0 {
+		return nil
+	}
+	ret := int(C.mpv_get_band_levels((*C.mpv_handle)(p.mpv.MPVHandle()),
+		(*C.double)(unsafe.Pointer(&levels[0])),
+		C.int(p.spectrumLayout.channels+1) /*after the played channels*/, C.int(n)))
+	return mpv.NewError(ret)
+}
+
+// returns the channel layout of the audio output by the decoder,
+// or stereo if no audio is playing
+func (p *Player) decodedLayout() spectrumLayout {
+	channels, err := p.getInt64Property("audio-params/channel-count")
+	if err != nil || channels <= 0 {
+		return spectrumLayout{name: "stereo", channels: 2}
+	}
+	l := spectrumLayout{channels: int(channels)}
+	if name, err := p.mpv.GetProperty("audio-params/channels", mpv.FORMAT_STRING); err == nil {
+		if s := name.(string); layoutNameRegex.MatchString(s) && !strings.HasPrefix(s, "unknown") {
+			l.name = s
+		}
+	}
+	return l
+}
+
+// Returns the filter measuring the spectrum of audio with the given layout.
+//
+// This deviates from an FFT analyzer: mpv exposes no tap of the samples to run
+// an FFT on, and no ffmpeg filter reports FFT bins as frame metadata, so the
+// spectrum is measured with a bank of band pass filters instead.
+//
+// The audio is split first; the copy is mixed to mono and filtered into the
+// bands. The bands are merged as extra channels after the played audio so
+// astats can measure them, and removed again with pan, which maps the played
+// channels through unchanged and keeps the frame metadata.
+func spectrumFilter(layout spectrumLayout) string {
+	n := len(SpectrumBandFrequencies)
+	var g strings.Builder
+	fmt.Fprintf(&g, "asplit[main][an];[an]aformat=channel_layouts=mono,asplit=%d", n)
+	for i := range SpectrumBandFrequencies {
+		fmt.Fprintf(&g, "[s%d]", i)
+	}
+	for i, f := range SpectrumBandFrequencies {
+		fmt.Fprintf(&g, ";[s%d]bandpass=f=%g:width_type=q:w=%g[b%d]", i, f, thirdOctaveQ, i)
+	}
+	g.WriteString(";[main]")
+	for i := range SpectrumBandFrequencies {
+		fmt.Fprintf(&g, "[b%d]", i)
+	}
+	fmt.Fprintf(&g, "amerge=inputs=%d,astats=metadata=1:reset=1:measure_perchannel=RMS_level:measure_overall=none,pan=%s", n+1, layout)
+	for c := 0; c < layout.channels; c++ {
+		fmt.Fprintf(&g, "|c%d=c%d", c, c)
+	}
+	// %len% quoting, since the graph contains characters special to mpv's option parser
+	graph := g.String()
+	return fmt.Sprintf("@spectrum:lavfi=graph=%%%d%%%s", len(graph), graph)
+}
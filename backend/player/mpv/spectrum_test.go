@@ -0,0 +1,27 @@
+package mpv
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_SpectrumFilterPassesThroughChannels(t *testing.T) {
+	for _, tt := range []struct {
+		layout  spectrumLayout
+		wantPan string
+	}{
+		{spectrumLayout{name: "stereo", channels: 2}, ",pan=stereo|c0=c0|c1=c1"},
+		{spectrumLayout{name: "5.1(side)", channels: 6}, ",pan=5.1(side)|c0=c0|c1=c1|c2=c2|c3=c3|c4=c4|c5=c5"},
+		{spectrumLayout{channels: 3}, ",pan=3c|c0=c0|c1=c1|c2=c2"},
+	} {
+		f := spectrumFilter(tt.layout)
+		if !strings.HasSuffix(f, tt.wantPan) {
+			t.Errorf("%v: got %q, want the suffix %q", tt.layout, f, tt.wantPan)
+		}
+		// the audio is split before any format change, which only the analyzed copy gets
+		_, graph, _ := strings.Cut(f, "%asplit[main][an];[an]aformat=")
+		if graph == "" || strings.Count(f, "aformat") != 1 {
+			t.Errorf("%v: got %q, want the played audio split off first", tt.layout, f)
+		}
+	}
+}
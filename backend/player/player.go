@@ -1,6 +1,10 @@
 package player
 
-import "github.com/dweymouth/supersonic/backend/mediaprovider"
+import (
+	"math"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
 
 type URLPlayer interface {
 	BasePlayer
@@ -39,6 +43,32 @@ type ReplayGainPlayer interface {
 	SetReplayGainOptions(ReplayGainOptions) error
 }
 
+// A player which can measure the EBU R128 integrated loudness of the playing media.
+type LoudnessAnalyzer interface {
+	SetLoudnessAnalysisEnabled(bool) error
+	// Returns the integrated loudness in LUFS of the current media
+	// measured since it started playing.
+	GetIntegratedLoudness() (float64, error)
+	// Returns the true peak of the current media measured since it
+	// started playing, as a linear amplitude like the ReplayGain peak.
+	GetTruePeak() (float64, error)
+}
+
+// A URLPlayer which can apply ReplayGain from the track metadata to streams
+// which have no ReplayGain tags of their own, such as those transcoded by the server.
+type ReplayGainMetadataPlayer interface {
+	URLPlayer
+	PlayFileWithReplayGain(url string, rgain mediaprovider.ReplayGainInfo) error
+	SetNextFileWithReplayGain(url string, rgain mediaprovider.ReplayGainInfo) error
+}
+
+// A player which can repeatedly play a section of the current media.
+type ABLoopPlayer interface {
+	// Sets the start and end in seconds of the section to loop.
+	// Negative values disable the loop.
+	SetABLoop(a, b float64) error
+}
+
 // The playback state (Stopped, Paused, or Playing).
 type State int
 
@@ -69,7 +99,31 @@ type ReplayGainOptions struct {
 	Mode            ReplayGainMode
 	PreampGain      float64
 	PreventClipping bool
-	// Fallback gain intentionally omitted
+	// Gain in dB applied to streams with neither ReplayGain tags
+	// nor ReplayGain info in the track metadata
+	FallbackGain float64
+}
+
+// GainFromMetadata returns the gain in dB to apply to a stream which has no
+// ReplayGain tags, computed from the ReplayGain info of the track metadata
+// with the preamp gain and clipping prevention applied.
+// If the info has no gain for the mode, FallbackGain is returned.
+func (r ReplayGainOptions) GainFromMetadata(info mediaprovider.ReplayGainInfo) float64 {
+	if r.Mode == ReplayGainNone {
+		return 0
+	}
+	gain, peak := info.TrackGain, info.TrackPeak
+	if r.Mode == ReplayGainAlbum && (info.AlbumGain != 0 || info.AlbumPeak != 0) {
+		gain, peak = info.AlbumGain, info.AlbumPeak
+	}
+	if gain == 0 && peak == 0 {
+		return r.FallbackGain
+	}
+	gain += r.PreampGain
+	if r.PreventClipping && peak > 0 {
+		gain = math.Min(gain, -20*math.Log10(peak))
+	}
+	return gain
 }
 
 func (r ReplayGainMode) String() string {
@@ -0,0 +1,33 @@
+package player
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_GainFromMetadata(t *testing.T) {
+	info := mediaprovider.ReplayGainInfo{TrackGain: -6, TrackPeak: 0.5, AlbumGain: -4, AlbumPeak: 0.9}
+	for _, tt := range []struct {
+		name string
+		opts ReplayGainOptions
+		info mediaprovider.ReplayGainInfo
+		want float64
+	}{
+		{"none", ReplayGainOptions{Mode: ReplayGainNone, FallbackGain: -3}, info, 0},
+		{"track", ReplayGainOptions{Mode: ReplayGainTrack}, info, -6},
+		{"album", ReplayGainOptions{Mode: ReplayGainAlbum}, info, -4},
+		{"album falls back to track", ReplayGainOptions{Mode: ReplayGainAlbum},
+			mediaprovider.ReplayGainInfo{TrackGain: -2}, -2},
+		{"preamp", ReplayGainOptions{Mode: ReplayGainTrack, PreampGain: 3}, info, -3},
+		// peak 0.5 allows at most ~6.02 dB of gain
+		{"clipping allowed", ReplayGainOptions{Mode: ReplayGainTrack, PreampGain: 15}, info, 9},
+		{"clipping prevented", ReplayGainOptions{Mode: ReplayGainTrack, PreampGain: 15, PreventClipping: true}, info, -20 * math.Log10(0.5)},
+		{"no info", ReplayGainOptions{Mode: ReplayGainTrack, PreampGain: 3, FallbackGain: -5}, mediaprovider.ReplayGainInfo{}, -5},
+	} {
+		if got := tt.opts.GainFromMetadata(tt.info); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
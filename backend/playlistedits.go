@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/trackmatch"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// number of concurrent requests made when checking playlist entries
+const brokenTrackCheckWorkers = 4
+
+// PlaylistConflictError is returned when a playlist edit was not made because
+// the playlist's tracks were changed on the server since they were loaded.
+type PlaylistConflictError struct {
+	// The current version of the playlist on the server
+	Server *mediaprovider.PlaylistWithTracks
+	// Track IDs with the local changes applied to the server version
+	Merged []string
+	// Number of tracks added and removed on the server
+	ServerAdded, ServerRemoved int
+}
+
+func (e *PlaylistConflictError) Error() string {
+	return fmt.Sprintf("playlist %q was changed on the server", e.Server.Name)
+}
+
+// SafeReplacePlaylistTracks replaces the tracks of the playlist with ours,
+// if the tracks on the server are still those of base, the track IDs as
+// they were loaded. Otherwise it returns a *PlaylistConflictError.
+// Servers offer no atomic compare-and-set, so a concurrent edit made between
+// the check and the write can still be overwritten.
+func SafeReplacePlaylistTracks(mp mediaprovider.MediaProvider, playlistID string, base, ours []string) error {
+	if err := checkPlaylistUnchanged(mp, playlistID, base, ours); err != nil {
+		return err
+	}
+	return mp.ReplacePlaylistTracks(playlistID, ours)
+}
+
+// SafeRemovePlaylistTracks removes the tracks at the given indexes of base,
+// the track IDs as they were loaded, if the tracks on the server are unchanged
+// so that the indexes still refer to the same tracks.
+// Otherwise it returns a *PlaylistConflictError.
+func SafeRemovePlaylistTracks(mp mediaprovider.MediaProvider, playlistID string, base []string, idxs []int) error {
+	ours := make([]string, 0, len(base))
+	for i, id := range base {
+		if !slices.Contains(idxs, i) {
+			ours = append(ours, id)
+		}
+	}
+	if err := checkPlaylistUnchanged(mp, playlistID, base, ours); err != nil {
+		return err
+	}
+	return mp.RemovePlaylistTracks(playlistID, idxs)
+}
+
+func checkPlaylistUnchanged(mp mediaprovider.MediaProvider, playlistID string, base, ours []string) error {
+	current, err := mp.GetPlaylist(playlistID)
+	if err != nil {
+		return err
+	}
+	theirs := sharedutil.TracksToIDs(current.Tracks)
+	if slices.Equal(theirs, base) {
+		return nil
+	}
+	conflict := &PlaylistConflictError{
+		Server: current,
+		Merged: sharedutil.MergeLists(base, ours, theirs),
+	}
+	baseSet, theirSet := sharedutil.ToSet(base), sharedutil.ToSet(theirs)
+	for _, id := range theirs {
+		if _, ok := baseSet[id]; !ok {
+			conflict.ServerAdded++
+		}
+	}
+	for _, id := range base {
+		if _, ok := theirSet[id]; !ok {
+			conflict.ServerRemoved++
+		}
+	}
+	return conflict
+}
+
+// FindDuplicatePlaylistTracks returns the indexes of the tracks which duplicate
+// an earlier track: the same track or, if byArtistTitle, a track with the
+// same artist and title, such as the same song from another release.
+func FindDuplicatePlaylistTracks(tracks []*mediaprovider.Track, byArtistTitle bool) []int {
+	seen := make(map[string]struct{}, len(tracks))
+	var dups []int
+	for i, tr := range tracks {
+		key := tr.ID
+		if byArtistTitle {
+			key = trackmatch.Normalize(strings.Join(tr.ArtistNames, " ")) + "\n" + trackmatch.Normalize(tr.Title)
+		}
+		if _, ok := seen[key]; ok {
+			dups = append(dups, i)
+		} else {
+			seen[key] = struct{}{}
+		}
+	}
+	return dups
+}
+
+// BrokenPlaylistTracks are the entries of a playlist which the server can
+// no longer resolve, e.g. because the tracks were removed from the library.
+type BrokenPlaylistTracks struct {
+	// indexes of the listed tracks which no longer exist
+	Idxs []int
+	// number of entries which the server counts in the playlist's track count
+	// but omits from its tracks. They are dropped when the tracks are saved.
+	Unlisted int
+}
+
+// FindBrokenPlaylistTracks finds the entries of the playlist which the server
+// can no longer resolve. Only tracks for which the server reports that they
+// don't exist are counted; other errors abort the check and are returned.
+// onProgress, if non-nil, is called as each track is checked.
+func FindBrokenPlaylistTracks(ctx context.Context, mp mediaprovider.MediaProvider, playlist *mediaprovider.PlaylistWithTracks, onProgress func(done, total int)) (BrokenPlaylistTracks, error) {
+	tracks := playlist.Tracks
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		lock     sync.Mutex
+		broken   BrokenPlaylistTracks
+		firstErr error
+		done     int
+		wg       sync.WaitGroup
+	)
+	broken.Unlisted = max(playlist.TrackCount-len(tracks), 0)
+	idxs := make(chan int)
+	for w := 0; w < brokenTrackCheckWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxs {
+				var err error
+				if tracks[i].ID != "" {
+					_, err = mp.GetTrack(tracks[i].ID)
+				}
+				lock.Lock()
+				if tracks[i].ID == "" || errors.Is(err, mediaprovider.ErrNotFound) {
+					broken.Idxs = append(broken.Idxs, i)
+				} else if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				done++
+				if onProgress != nil {
+					onProgress(done, len(tracks))
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for i := range tracks {
+		if ctx.Err() != nil {
+			break
+		}
+		idxs <- i
+	}
+	close(idxs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return BrokenPlaylistTracks{}, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return BrokenPlaylistTracks{}, err
+	}
+	slices.Sort(broken.Idxs)
+	return broken, nil
+}
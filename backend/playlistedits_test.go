@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_FindDuplicatePlaylistTracks(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "1", Title: "Song", ArtistNames: []string{"Artist"}},
+		{ID: "2", Title: "Other Song", ArtistNames: []string{"Artist"}},
+		{ID: "1", Title: "Song", ArtistNames: []string{"Artist"}},
+		{ID: "3", Title: "song ", ArtistNames: []string{"ARTIST"}}, // other release
+		{ID: "4", Title: "Song", ArtistNames: []string{"Another Artist"}},
+		{ID: "2", Title: "Other Song", ArtistNames: []string{"Artist"}},
+	}
+	for _, tc := range []struct {
+		name          string
+		byArtistTitle bool
+		want          []int
+	}{
+		{name: "by ID", byArtistTitle: false, want: []int{2, 5}},
+		{name: "by artist and title", byArtistTitle: true, want: []int{2, 3, 5}},
+	} {
+		if got := FindDuplicatePlaylistTracks(tracks, tc.byArtistTitle); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// fakeTrackServer resolves the tracks whose ID is in tracks,
+// and fails with err, if set, for the others.
+type fakeTrackServer struct {
+	mediaprovider.MediaProvider
+
+	tracks map[string]bool
+	err    error
+}
+
+func (f *fakeTrackServer) GetTrack(trackID string) (*mediaprovider.Track, error) {
+	if f.tracks[trackID] {
+		return &mediaprovider.Track{ID: trackID}, nil
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return nil, fmt.Errorf("%w: track %s", mediaprovider.ErrNotFound, trackID)
+}
+
+func Test_FindBrokenPlaylistTracks(t *testing.T) {
+	playlist := &mediaprovider.PlaylistWithTracks{
+		Tracks: []*mediaprovider.Track{{ID: "1"}, {ID: "2"}, {ID: ""}, {ID: "4"}},
+	}
+	playlist.TrackCount = 6 // two entries not listed by the server
+	mp := &fakeTrackServer{tracks: map[string]bool{"1": true, "4": true}}
+
+	var progress int
+	broken, err := FindBrokenPlaylistTracks(context.Background(), mp, playlist, func(done, total int) {
+		progress = done
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2}; !slices.Equal(broken.Idxs, want) {
+		t.Errorf("got broken tracks %v, want %v", broken.Idxs, want)
+	}
+	if broken.Unlisted != 2 {
+		t.Errorf("got %d unlisted entries, want 2", broken.Unlisted)
+	}
+	if progress != len(playlist.Tracks) {
+		t.Errorf("got progress %d, want %d", progress, len(playlist.Tracks))
+	}
+
+	// errors other than not found, e.g. a connection problem, don't mark tracks as broken
+	connErr := errors.New("connection refused")
+	mp.err = connErr
+	if _, err := FindBrokenPlaylistTracks(context.Background(), mp, playlist, nil); !errors.Is(err, connErr) {
+		t.Errorf("got error %v, want %v", err, connErr)
+	}
+}
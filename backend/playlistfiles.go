@@ -0,0 +1,200 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/playlistio"
+	"github.com/dweymouth/supersonic/backend/trackmatch"
+)
+
+// number of search results to consider when matching a playlist entry by metadata
+const playlistImportSearchResults = 20
+
+// PlaylistImportMatch is a playlist file entry along with the
+// library track it was matched to, if any.
+type PlaylistImportMatch struct {
+	Entry playlistio.Entry
+	Track *mediaprovider.Track
+}
+
+// PlaylistFileManager imports and exports playlist files
+// to and from the playlists of the current server.
+type PlaylistFileManager struct {
+	sm      *ServerManager
+	library *libraryTrackCache
+}
+
+func NewPlaylistFileManager(sm *ServerManager, library *libraryTrackCache) *PlaylistFileManager {
+	return &PlaylistFileManager{sm: sm, library: library}
+}
+
+// ExportPlaylist writes the playlist to a file in the given format. If useStreamURLs is true,
+// entries are written as stream URLs of the server, without the user's credentials,
+// otherwise as file paths relative to the tracks' common directory.
+func (p *PlaylistFileManager) ExportPlaylist(playlist *mediaprovider.PlaylistWithTracks, format playlistio.Format, useStreamURLs bool, filePath string) error {
+	locations := make([]string, len(playlist.Tracks))
+	for i, tr := range playlist.Tracks {
+		if useStreamURLs {
+			u, err := p.sm.Server.GetStreamURL(tr.ID, false)
+			if err != nil {
+				return err
+			}
+			locations[i] = playlistio.RemoveURLCredentials(u)
+		} else {
+			locations[i] = tr.FilePath
+		}
+	}
+	if !useStreamURLs {
+		locations = playlistio.RelativePaths(locations)
+	}
+
+	pl := &playlistio.Playlist{Name: playlist.Name}
+	for i, tr := range playlist.Tracks {
+		pl.Entries = append(pl.Entries, playlistio.EntryFromTrack(tr, locations[i]))
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := playlistio.Write(f, format, pl); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// MatchEntries matches the playlist entries to tracks in the library: first by file path,
+// then by the track ID of a stream URL, and last by searching for the artist and title.
+// onProgress, if non-nil, is called after each entry is processed.
+func (p *PlaylistFileManager) MatchEntries(ctx context.Context, entries []playlistio.Entry, onProgress func(done, total int)) ([]PlaylistImportMatch, error) {
+	server := p.sm.Server
+	if server == nil {
+		return nil, errors.New("not connected to a server")
+	}
+
+	var pathIdx *trackmatch.PathIndex
+	for _, e := range entries {
+		if e.FilePath() != "" {
+			tracks, err := p.library.getTracks()
+			if err != nil {
+				return nil, err
+			}
+			pathIdx = trackmatch.NewPathIndex(tracks)
+			break
+		}
+	}
+
+	matches := make([]PlaylistImportMatch, len(entries))
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		matches[i].Entry = e
+		matches[i].Track = p.matchEntry(server, pathIdx, e)
+		if onProgress != nil {
+			onProgress(i+1, len(entries))
+		}
+	}
+	return matches, nil
+}
+
+func (p *PlaylistFileManager) matchEntry(server mediaprovider.MediaProvider, pathIdx *trackmatch.PathIndex, e playlistio.Entry) *mediaprovider.Track {
+	if filePath := e.FilePath(); filePath != "" && pathIdx != nil {
+		if tr := pathIdx.Lookup(filePath); tr != nil {
+			return tr
+		}
+	}
+	if id := e.TrackIDFromURL(); id != "" {
+		if tr, err := server.GetTrack(id); err == nil && tr != nil {
+			return tr
+		}
+	}
+
+	artist, title := e.SearchTerms()
+	if title == "" {
+		return nil
+	}
+	query := strings.TrimSpace(artist + " " + title)
+	results, err := server.SearchAll(query, playlistImportSearchResults)
+	if err == nil && artist != "" && playlistio.BestSearchMatch(e, results) == "" {
+		// the server may not match the artist and title together
+		results, err = server.SearchAll(title, playlistImportSearchResults)
+	}
+	if err != nil {
+		return nil
+	}
+	if id := playlistio.BestSearchMatch(e, results); id != "" {
+		if tr, err := server.GetTrack(id); err == nil {
+			return tr
+		}
+	}
+	return nil
+}
+
+// CreatePlaylist creates a server playlist from the matched tracks, skipping
+// unmatched entries, and returns the ID of the new playlist if it can be found.
+func (p *PlaylistFileManager) CreatePlaylist(name string, matches []PlaylistImportMatch) (string, error) {
+	var trackIDs []string
+	for _, m := range matches {
+		if m.Track != nil {
+			trackIDs = append(trackIDs, m.Track.ID)
+		}
+	}
+	return createServerPlaylist(p.sm.Server, name, trackIDs)
+}
+
+// createServerPlaylist creates a playlist and returns its ID. If the server does
+// not report the ID of new playlists, it is that of the one playlist with the given
+// name which was not listed before the playlist was created, or "" if there is none.
+func createServerPlaylist(mp mediaprovider.MediaProvider, name string, trackIDs []string) (string, error) {
+	if c, ok := mp.(mediaprovider.SupportsCreatePlaylistWithID); ok {
+		id, err := c.CreatePlaylistWithID(name, trackIDs)
+		if err != nil || id != "" {
+			return id, err
+		}
+		// servers of older API versions don't report the ID; the playlist was
+		// created, so failing to find it is not an error
+		id, err = newPlaylistID(mp, name, nil)
+		if err != nil {
+			log.Printf("error finding the created playlist %q: %s", name, err.Error())
+		}
+		return id, nil
+	}
+
+	before, err := mp.GetPlaylists()
+	if err != nil {
+		return "", err
+	}
+	if err := mp.CreatePlaylist(name, trackIDs); err != nil {
+		return "", err
+	}
+	return newPlaylistID(mp, name, before)
+}
+
+// returns the ID of the playlist with the given name which is not among before,
+// or "" if there is not exactly one such playlist
+func newPlaylistID(mp mediaprovider.MediaProvider, name string, before []*mediaprovider.Playlist) (string, error) {
+	existing := make(map[string]struct{}, len(before))
+	for _, pl := range before {
+		existing[pl.ID] = struct{}{}
+	}
+	after, err := mp.GetPlaylists()
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	for _, pl := range after {
+		if _, ok := existing[pl.ID]; !ok && pl.Name == name {
+			if id != "" {
+				return "", nil
+			}
+			id = pl.ID
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// fakePlaylistServer creates playlists with sequential IDs,
+// listing them after existing playlists of the same name.
+type fakePlaylistServer struct {
+	mediaprovider.MediaProvider
+
+	playlists []*mediaprovider.Playlist
+	// returned by GetPlaylists and ReplacePlaylistTracks if set
+	listErr, replaceErr error
+}
+
+func (f *fakePlaylistServer) GetPlaylists() ([]*mediaprovider.Playlist, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return f.playlists, nil
+}
+
+func (f *fakePlaylistServer) CreatePlaylist(name string, trackIDs []string) error {
+	id := strconv.Itoa(len(f.playlists) + 1)
+	f.playlists = append(f.playlists, &mediaprovider.Playlist{ID: id, Name: name, TrackCount: len(trackIDs)})
+	return nil
+}
+
+func (f *fakePlaylistServer) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
+	if f.replaceErr != nil {
+		return f.replaceErr
+	}
+	for _, pl := range f.playlists {
+		if pl.ID == playlistID {
+			pl.TrackCount = len(trackIDs)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: playlist %s", mediaprovider.ErrNotFound, playlistID)
+}
+
+// fakePlaylistIDServer also reports the IDs of the playlists it creates
+type fakePlaylistIDServer struct {
+	fakePlaylistServer
+}
+
+func (f *fakePlaylistIDServer) CreatePlaylistWithID(name string, trackIDs []string) (string, error) {
+	f.CreatePlaylist(name, trackIDs)
+	return f.playlists[len(f.playlists)-1].ID, nil
+}
+
+func Test_CreateServerPlaylist(t *testing.T) {
+	// an existing playlist with the same name and track count
+	existing := []*mediaprovider.Playlist{{ID: "1", Name: "Mix", TrackCount: 2}}
+	for _, tc := range []struct {
+		name string
+		mp   mediaprovider.MediaProvider
+	}{
+		{name: "found by listing", mp: &fakePlaylistServer{playlists: existing}},
+		{name: "reported by server", mp: &fakePlaylistIDServer{fakePlaylistServer{playlists: existing}}},
+		// the playlists are not listed when the server reports the ID
+		{name: "reported by server, listing fails", mp: &fakePlaylistIDServer{
+			fakePlaylistServer{playlists: existing, listErr: errors.New("connection reset")}}},
+	} {
+		id, err := createServerPlaylist(tc.mp, "Mix", []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "2" {
+			t.Errorf("%s: got ID %q, want 2", tc.name, id)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"slices"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/playlistfolders"
+)
+
+// PlaylistFolderManager organizes the playlists of the current server into
+// client-side folders. A playlist's folder is the one assigned by the user,
+// if any, otherwise the one inferred from its name.
+type PlaylistFolderManager struct {
+	sm   *ServerManager
+	conf *PlaylistsPageConfig
+}
+
+func NewPlaylistFolderManager(sm *ServerManager, conf *PlaylistsPageConfig) *PlaylistFolderManager {
+	return &PlaylistFolderManager{sm: sm, conf: conf}
+}
+
+// FolderOf returns the folder path of the playlist, or "" for the top level.
+func (p *PlaylistFolderManager) FolderOf(pl *mediaprovider.Playlist) string {
+	if folder, ok := p.assignments()[pl.ID]; ok {
+		return folder
+	}
+	if p.conf.InferFoldersFromNames {
+		folder, _ := playlistfolders.Split(pl.Name)
+		return folder
+	}
+	return ""
+}
+
+// DisplayName returns the name of the playlist to show within its folder.
+func (p *PlaylistFolderManager) DisplayName(pl *mediaprovider.Playlist) string {
+	if _, ok := p.assignments()[pl.ID]; !ok && p.conf.InferFoldersFromNames {
+		_, leaf := playlistfolders.Split(pl.Name)
+		return leaf
+	}
+	return pl.Name
+}
+
+// SetFolder moves the playlist to the given folder path, or to the top level if "".
+func (p *PlaylistFolderManager) SetFolder(playlistID, folder string) {
+	serverID := p.sm.ServerID.String()
+	if p.conf.PlaylistFolders == nil {
+		p.conf.PlaylistFolders = make(map[string]map[string]string)
+	}
+	if p.conf.PlaylistFolders[serverID] == nil {
+		p.conf.PlaylistFolders[serverID] = make(map[string]string)
+	}
+	p.conf.PlaylistFolders[serverID][playlistID] = playlistfolders.Clean(folder)
+}
+
+// Tree returns the folder tree of the playlists.
+func (p *PlaylistFolderManager) Tree(playlists []*mediaprovider.Playlist) *playlistfolders.Folder {
+	return playlistfolders.Tree(playlists, p.FolderOf)
+}
+
+func (p *PlaylistFolderManager) IsCollapsed(path string) bool {
+	return slices.Contains(p.conf.CollapsedFolders[p.sm.ServerID.String()], path)
+}
+
+func (p *PlaylistFolderManager) SetCollapsed(path string, collapsed bool) {
+	serverID := p.sm.ServerID.String()
+	if p.conf.CollapsedFolders == nil {
+		p.conf.CollapsedFolders = make(map[string][]string)
+	}
+	folders := p.conf.CollapsedFolders[serverID]
+	idx := slices.Index(folders, path)
+	if collapsed && idx < 0 {
+		p.conf.CollapsedFolders[serverID] = append(folders, path)
+	} else if !collapsed && idx >= 0 {
+		p.conf.CollapsedFolders[serverID] = slices.Delete(folders, idx, idx+1)
+	}
+}
+
+// returns the folder assignments for the current server
+func (p *PlaylistFolderManager) assignments() map[string]string {
+	return p.conf.PlaylistFolders[p.sm.ServerID.String()]
+}
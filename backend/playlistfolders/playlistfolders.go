@@ -0,0 +1,167 @@
+// Package playlistfolders organizes playlists into a client-side folder
+// hierarchy, which can be assigned by the user or inferred from playlist
+// names containing separators, such as "Work / Focus / Deep".
+package playlistfolders
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// Separator separates the folder names of a folder path. It is also
+// recognized in playlist names to infer the folder of the playlist.
+const Separator = " / "
+
+// matches a separator with any amount of surrounding whitespace
+var separatorRegex = regexp.MustCompile(`\s+/\s+`)
+
+// Split splits a playlist name into the folder path inferred from it and
+// the name of the playlist within the folder, recognizing the same
+// separators as Clean. E.g. "Work / Focus / Deep" is split into
+// "Work / Focus" and "Deep".
+func Split(name string) (folder, leaf string) {
+	locs := separatorRegex.FindAllStringIndex(name, -1)
+	if len(locs) == 0 {
+		return "", name
+	}
+	last := locs[len(locs)-1]
+	folder, leaf = Clean(name[:last[0]]), strings.TrimSpace(name[last[1]:])
+	if folder == "" || leaf == "" {
+		return "", name
+	}
+	return folder, leaf
+}
+
+// Clean returns the folder path with the folder names trimmed, empty names
+// removed and separated by Separator. Folder names may be separated by a
+// slash with any amount of surrounding whitespace, but a slash without
+// surrounding whitespace is part of the name, as in "AC/DC".
+func Clean(path string) string {
+	var names []string
+	for _, n := range separatorRegex.Split(" "+path+" ", -1) {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return strings.Join(names, Separator)
+}
+
+// Base returns the last folder name of the path.
+func Base(path string) string {
+	if idx := strings.LastIndex(path, Separator); idx >= 0 {
+		return path[idx+len(Separator):]
+	}
+	return path
+}
+
+// IsWithin returns true if path is the folder, or a subfolder of it.
+// Every path is within the root folder "".
+func IsWithin(path, folder string) bool {
+	return folder == "" || path == folder || strings.HasPrefix(path, folder+Separator)
+}
+
+// Folder is a node of the folder tree.
+type Folder struct {
+	// Path of the folder, or "" for the root
+	Path      string
+	Name      string
+	Depth     int
+	Folders   []*Folder
+	Playlists []*mediaprovider.Playlist
+}
+
+// Item is an entry of a flattened folder tree,
+// which is either a folder or a playlist.
+type Item struct {
+	Folder   *Folder
+	Playlist *mediaprovider.Playlist
+	// nesting depth for indentation; 0 for top level folders and playlists
+	Depth int
+}
+
+// Tree builds the folder tree of the playlists, given the folder path of each.
+// Playlists are kept in the given order and subfolders are sorted by name.
+func Tree(playlists []*mediaprovider.Playlist, folderOf func(*mediaprovider.Playlist) string) *Folder {
+	root := &Folder{}
+	folders := map[string]*Folder{"": root}
+	var getFolder func(path string) *Folder
+	getFolder = func(path string) *Folder {
+		if f, ok := folders[path]; ok {
+			return f
+		}
+		parentPath := ""
+		if idx := strings.LastIndex(path, Separator); idx >= 0 {
+			parentPath = path[:idx]
+		}
+		parent := getFolder(parentPath)
+		f := &Folder{Path: path, Name: Base(path), Depth: parent.Depth + 1}
+		if parent == root {
+			f.Depth = 0
+		}
+		parent.Folders = append(parent.Folders, f)
+		folders[path] = f
+		return f
+	}
+	for _, pl := range playlists {
+		f := getFolder(folderOf(pl))
+		f.Playlists = append(f.Playlists, pl)
+	}
+	root.sortFolders()
+	return root
+}
+
+func (f *Folder) sortFolders() {
+	sort.SliceStable(f.Folders, func(i, j int) bool {
+		return strings.ToLower(f.Folders[i].Name) < strings.ToLower(f.Folders[j].Name)
+	})
+	for _, sub := range f.Folders {
+		sub.sortFolders()
+	}
+}
+
+// Count returns the number of playlists in the folder and its subfolders.
+func (f *Folder) Count() int {
+	n := len(f.Playlists)
+	for _, sub := range f.Folders {
+		n += sub.Count()
+	}
+	return n
+}
+
+// Paths returns the paths of all folders in the tree, in display order.
+func (f *Folder) Paths() []string {
+	var paths []string
+	for _, sub := range f.Folders {
+		paths = append(paths, sub.Path)
+		paths = append(paths, sub.Paths()...)
+	}
+	return paths
+}
+
+// Flatten returns the folders and playlists of the tree in display order,
+// with subfolders before the playlists of each folder.
+// The contents of folders for which collapsed returns true are omitted.
+func (f *Folder) Flatten(collapsed func(path string) bool) []Item {
+	var items []Item
+	f.flatten(collapsed, &items)
+	return items
+}
+
+func (f *Folder) flatten(collapsed func(path string) bool, items *[]Item) {
+	depth := 0
+	if f.Path != "" {
+		depth = f.Depth + 1
+	}
+	for _, sub := range f.Folders {
+		*items = append(*items, Item{Folder: sub, Depth: sub.Depth})
+		if collapsed == nil || !collapsed(sub.Path) {
+			sub.flatten(collapsed, items)
+		}
+	}
+	for _, pl := range f.Playlists {
+		*items = append(*items, Item{Playlist: pl, Depth: depth})
+	}
+}
@@ -0,0 +1,93 @@
+package playlistfolders
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_Split(t *testing.T) {
+	for _, tt := range []struct {
+		name, folder, leaf string
+	}{
+		{"Work / Focus / Deep", "Work / Focus", "Deep"},
+		{"Work\t/  Focus  /\tDeep", "Work / Focus", "Deep"},
+		{"Chill", "", "Chill"},
+		{"AC/DC Best Of", "", "AC/DC Best Of"},
+		{" / Untitled", "", " / Untitled"},
+	} {
+		folder, leaf := Split(tt.name)
+		if folder != tt.folder || leaf != tt.leaf {
+			t.Errorf("Split(%q) = %q, %q; want %q, %q", tt.name, folder, leaf, tt.folder, tt.leaf)
+		}
+	}
+	for path, want := range map[string]string{
+		" Work  /\tFocus / Deep /": "Work / Focus / Deep",
+		"Artists / AC/DC":          "Artists / AC/DC",
+		"/ Work":                   "Work",
+	} {
+		if got := Clean(path); got != want {
+			t.Errorf("Clean(%q): got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func Test_TreeFlatten(t *testing.T) {
+	playlists := []*mediaprovider.Playlist{
+		{ID: "1", Name: "Work / Focus / Deep"},
+		{ID: "2", Name: "Road trip"},
+		{ID: "3", Name: "Work / Meetings"},
+		{ID: "4", Name: "Chill"},
+	}
+	folderOf := func(pl *mediaprovider.Playlist) string {
+		if pl.ID == "4" {
+			return "Ambient" // assigned by the user
+		}
+		f, _ := Split(pl.Name)
+		return f
+	}
+	tree := Tree(playlists, folderOf)
+	if n := tree.Count(); n != 4 {
+		t.Fatalf("expected 4 playlists in tree, got %d", n)
+	}
+	wantPaths := []string{"Ambient", "Work", "Work / Focus"}
+	paths := tree.Paths()
+	if len(paths) != len(wantPaths) {
+		t.Fatalf("got folder paths %v", paths)
+	}
+	for i := range paths {
+		if paths[i] != wantPaths[i] {
+			t.Errorf("got folder paths %v", paths)
+		}
+	}
+
+	type flat struct {
+		id    string // playlist ID or folder path
+		depth int
+	}
+	check := func(items []Item, want []flat) {
+		t.Helper()
+		if len(items) != len(want) {
+			t.Fatalf("got %d items, want %d", len(items), len(want))
+		}
+		for i, it := range items {
+			var id string
+			if it.Playlist != nil {
+				id = it.Playlist.ID
+			} else {
+				id = it.Folder.Path
+			}
+			if id != want[i].id || it.Depth != want[i].depth {
+				t.Errorf("item %d: got %s at depth %d, want %s at depth %d", i, id, it.Depth, want[i].id, want[i].depth)
+			}
+		}
+	}
+	check(tree.Flatten(nil), []flat{
+		{"Ambient", 0}, {"4", 1},
+		{"Work", 0}, {"Work / Focus", 1}, {"1", 2}, {"3", 1},
+		{"2", 0},
+	})
+	check(tree.Flatten(func(p string) bool { return p == "Work" }), []flat{
+		{"Ambient", 0}, {"4", 1}, {"Work", 0}, {"2", 0},
+	})
+}
@@ -0,0 +1,120 @@
+package playlistio
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/trackmatch"
+)
+
+// leading track numbers of file names, e.g. "01 - ", "1-02. ", "03 "
+var trackNumPrefixRegex = regexp.MustCompile(`^\d{1,3}([-.]\d{1,3})?[\s.\-_]+`)
+
+// FilePath returns the local file path of the entry, or "" if its location is a stream URL.
+func (e Entry) FilePath() string {
+	if e.Location == "" || isURL(e.Location) && !strings.HasPrefix(e.Location, "file://") {
+		return ""
+	}
+	return uriToLocation(e.Location)
+}
+
+// TrackIDFromURL returns the track ID from the location if it is a
+// stream URL, such as those written when exporting with stream URLs.
+func (e Entry) TrackIDFromURL() string {
+	if !isURL(e.Location) {
+		return ""
+	}
+	u, err := url.Parse(e.Location)
+	if err != nil {
+		return ""
+	}
+	if id := u.Query().Get("id"); id != "" {
+		return id // Subsonic stream URL
+	}
+	// Jellyfin: /Audio/<id>/universal
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, p := range parts {
+		if strings.EqualFold(p, "Audio") && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// credentialParams are the query parameters of Subsonic and Jellyfin stream URLs
+// which authenticate the user
+var credentialParams = []string{"u", "p", "t", "s", "api_key", "apikey"}
+
+// RemoveURLCredentials returns the URL without the query parameters which authenticate
+// the user, so that the URL can be shared without giving access to the server.
+// The track ID is kept, so the URL can still be matched by TrackIDFromURL.
+func RemoveURLCredentials(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	q := u.Query()
+	for key := range q {
+		for _, p := range credentialParams {
+			if strings.EqualFold(key, p) {
+				q.Del(key)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// SearchTerms returns the artist and title to search for the entry, from its
+// metadata or, if it has none, guessed from its file name.
+func (e Entry) SearchTerms() (artist, title string) {
+	if e.Title != "" {
+		return e.Artist, e.Title
+	}
+	p := e.FilePath()
+	if p == "" {
+		return "", ""
+	}
+	name := path.Base(strings.ReplaceAll(p, `\`, "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = trackNumPrefixRegex.ReplaceAllString(name, "")
+	return splitDisplayTitle(name)
+}
+
+// BestSearchMatch returns the ID of the track search result which best matches
+// the entry's title, artist and duration, or "" if none match closely enough.
+func BestSearchMatch(e Entry, results []*mediaprovider.SearchResult) string {
+	artist, title := e.SearchTerms()
+	if title == "" {
+		return ""
+	}
+
+	var bestID string
+	bestScore := 0.0
+	for _, r := range results {
+		if r.Type != mediaprovider.ContentTypeTrack {
+			continue
+		}
+		score := float64(trackmatch.TitleMatchScore(r.Name, title))
+		if score == 0 {
+			continue
+		}
+		if artist != "" && r.ArtistName != "" {
+			if !trackmatch.ArtistsMatch(r.ArtistName, artist) {
+				continue
+			}
+			score += 2
+		}
+		d := trackmatch.DurationScore(r.Size, e.Duration)
+		if d < 0 {
+			continue
+		}
+		if score += d; score > bestScore {
+			bestID, bestScore = r.ID, score
+		}
+	}
+	return bestID
+}
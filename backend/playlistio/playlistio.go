@@ -0,0 +1,386 @@
+// Package playlistio reads and writes playlist files in the
+// M3U8, XSPF and PLS formats.
+package playlistio
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/trackmatch"
+)
+
+type Format string
+
+const (
+	FormatM3U8 Format = "M3U8"
+	FormatXSPF Format = "XSPF"
+	FormatPLS  Format = "PLS"
+)
+
+var Formats = []Format{FormatM3U8, FormatXSPF, FormatPLS}
+
+var ErrUnknownFormat = errors.New("unknown playlist format")
+
+// Playlist is the contents of a playlist file
+type Playlist struct {
+	Name    string
+	Entries []Entry
+}
+
+// Entry is a single playlist file entry. Only Location is required;
+// the metadata is used to match the entry to a library track.
+type Entry struct {
+	// File path or URL
+	Location string
+	Title    string
+	Artist   string
+	Album    string
+	Duration int // seconds, 0 if unknown
+}
+
+// FileExtension returns the file extension, including the dot, for the format.
+func (f Format) FileExtension() string {
+	return "." + strings.ToLower(string(f))
+}
+
+// FormatForFileName returns the playlist format of the file, based on its extension.
+func FormatForFileName(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".m3u", ".m3u8":
+		return FormatM3U8, nil
+	case ".xspf":
+		return FormatXSPF, nil
+	case ".pls":
+		return FormatPLS, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// FileExtensions returns the extensions of all playlist files that can be read.
+func FileExtensions() []string {
+	return []string{".m3u", ".m3u8", ".xspf", ".pls"}
+}
+
+// EntryFromTrack creates a playlist entry for the track with the given location.
+func EntryFromTrack(tr *mediaprovider.Track, location string) Entry {
+	return Entry{
+		Location: location,
+		Title:    tr.Title,
+		Artist:   strings.Join(tr.ArtistNames, ", "),
+		Album:    tr.Album,
+		Duration: tr.Duration,
+	}
+}
+
+// RelativePaths returns the file paths relative to their deepest common
+// directory, so that a playlist saved at the root of a copy of the
+// music library can find its tracks. Paths that are already relative
+// are assumed to be relative to the library root and are unchanged.
+func RelativePaths(paths []string) []string {
+	var common []string
+	first := true
+	for _, p := range paths {
+		if !isAbsPath(p) {
+			continue
+		}
+		dir := trackmatch.SplitPath(p)
+		dir = dir[:len(dir)-1]
+		if first {
+			common, first = dir, false
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	rel := make([]string, len(paths))
+	for i, p := range paths {
+		if !isAbsPath(p) {
+			rel[i] = p
+			continue
+		}
+		rel[i] = strings.Join(trackmatch.SplitPath(p)[len(common):], "/")
+	}
+	return rel
+}
+
+// Write writes the playlist to w in the given format.
+func Write(w io.Writer, format Format, pl *Playlist) error {
+	switch format {
+	case FormatM3U8:
+		return writeM3U8(w, pl)
+	case FormatXSPF:
+		return writeXSPF(w, pl)
+	case FormatPLS:
+		return writePLS(w, pl)
+	}
+	return ErrUnknownFormat
+}
+
+// Read parses a playlist file in the given format.
+func Read(r io.Reader, format Format) (*Playlist, error) {
+	switch format {
+	case FormatM3U8:
+		return readM3U8(r)
+	case FormatXSPF:
+		return readXSPF(r)
+	case FormatPLS:
+		return readPLS(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func writeM3U8(w io.Writer, pl *Playlist) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+	if pl.Name != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", pl.Name)
+	}
+	for _, e := range pl.Entries {
+		dur := e.Duration
+		if dur == 0 {
+			dur = -1 // unknown
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", dur, displayTitle(e))
+		if e.Album != "" {
+			fmt.Fprintf(bw, "#EXTALB:%s\n", e.Album)
+		}
+		fmt.Fprintf(bw, "%s\n", e.Location)
+	}
+	return bw.Flush()
+}
+
+func readM3U8(r io.Reader) (*Playlist, error) {
+	pl := &Playlist{}
+	var pending Entry
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			pl.Name = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			durStr, title, _ := strings.Cut(info, ",")
+			// strip any attributes, e.g. #EXTINF:123 tvg-id="..",Title
+			durStr, _, _ = strings.Cut(durStr, " ")
+			if d, err := strconv.ParseFloat(durStr, 64); err == nil && d > 0 {
+				pending.Duration = int(d + 0.5)
+			}
+			pending.Artist, pending.Title = splitDisplayTitle(title)
+		case strings.HasPrefix(line, "#EXTALB:"):
+			pending.Album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#EXTART:"):
+			pending.Artist = strings.TrimSpace(strings.TrimPrefix(line, "#EXTART:"))
+		case strings.HasPrefix(line, "#"):
+			continue // other directive or comment
+		default:
+			pending.Location = line
+			pl.Entries = append(pl.Entries, pending)
+			pending = Entry{}
+		}
+	}
+	return pl, sc.Err()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	Duration int    `xml:"duration,omitempty"` // milliseconds
+}
+
+func writeXSPF(w io.Writer, pl *Playlist) error {
+	x := xspfPlaylist{Version: "1", Title: pl.Name}
+	for _, e := range pl.Entries {
+		x.Tracks = append(x.Tracks, xspfTrack{
+			Location: locationToURI(e.Location),
+			Title:    e.Title,
+			Creator:  e.Artist,
+			Album:    e.Album,
+			Duration: e.Duration * 1000,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(x); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func readXSPF(r io.Reader) (*Playlist, error) {
+	var x xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("failed to parse XSPF playlist: %w", err)
+	}
+	pl := &Playlist{Name: strings.TrimSpace(x.Title)}
+	for _, t := range x.Tracks {
+		pl.Entries = append(pl.Entries, Entry{
+			Location: uriToLocation(strings.TrimSpace(t.Location)),
+			Title:    strings.TrimSpace(t.Title),
+			Artist:   strings.TrimSpace(t.Creator),
+			Album:    strings.TrimSpace(t.Album),
+			Duration: (t.Duration + 500) / 1000,
+		})
+	}
+	return pl, nil
+}
+
+func writePLS(w io.Writer, pl *Playlist) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[playlist]\n")
+	for i, e := range pl.Entries {
+		n := i + 1
+		fmt.Fprintf(bw, "File%d=%s\n", n, e.Location)
+		fmt.Fprintf(bw, "Title%d=%s\n", n, displayTitle(e))
+		dur := e.Duration
+		if dur == 0 {
+			dur = -1
+		}
+		fmt.Fprintf(bw, "Length%d=%d\n", n, dur)
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\nVersion=2\n", len(pl.Entries))
+	return bw.Flush()
+}
+
+func readPLS(r io.Reader) (*Playlist, error) {
+	entries := make(map[int]*Entry)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(key)
+		var field string
+		for _, f := range []string{"file", "title", "length"} {
+			if strings.HasPrefix(key, f) {
+				field = f
+				break
+			}
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(key, field))
+		if field == "" || err != nil {
+			continue
+		}
+		e, ok := entries[n]
+		if !ok {
+			e = &Entry{}
+			entries[n] = e
+		}
+		switch field {
+		case "file":
+			e.Location = value
+		case "title":
+			e.Artist, e.Title = splitDisplayTitle(value)
+		case "length":
+			if d, err := strconv.Atoi(value); err == nil && d > 0 {
+				e.Duration = d
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	nums := make([]int, 0, len(entries))
+	for n, e := range entries {
+		if e.Location != "" {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	pl := &Playlist{}
+	for _, n := range nums {
+		pl.Entries = append(pl.Entries, *entries[n])
+	}
+	return pl, nil
+}
+
+// "Artist - Title", as commonly used in M3U and PLS titles
+func displayTitle(e Entry) string {
+	if e.Artist == "" {
+		return e.Title
+	}
+	return e.Artist + " - " + e.Title
+}
+
+func splitDisplayTitle(s string) (artist, title string) {
+	s = strings.TrimSpace(s)
+	if a, t, ok := strings.Cut(s, " - "); ok {
+		return strings.TrimSpace(a), strings.TrimSpace(t)
+	}
+	return "", s
+}
+
+var urlSchemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]+://`)
+
+func isURL(location string) bool {
+	return urlSchemeRegex.MatchString(location)
+}
+
+func isAbsPath(p string) bool {
+	// also recognize Windows paths when running on other platforms
+	return filepath.IsAbs(p) || strings.HasPrefix(p, "/") ||
+		len(p) > 2 && p[1] == ':' && (p[2] == '\\' || p[2] == '/')
+}
+
+func locationToURI(location string) string {
+	if isURL(location) {
+		return location
+	}
+	p := filepath.ToSlash(location)
+	if isAbsPath(location) {
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p // Windows drive path
+		}
+		return (&url.URL{Scheme: "file", Path: p}).String()
+	}
+	return (&url.URL{Path: p}).String()
+}
+
+func uriToLocation(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	switch {
+	case u.Scheme == "file":
+		p := u.Path
+		if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+			p = p[1:] // Windows drive path
+		}
+		return p
+	case u.Scheme == "" && u.Host == "":
+		return u.Path
+	}
+	return uri
+}
@@ -0,0 +1,106 @@
+package playlistio
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_RoundTrip(t *testing.T) {
+	pl := &Playlist{
+		Name: "Road Trip",
+		Entries: []Entry{
+			{Location: "Artist/Album/01 - Song.flac", Title: "Song", Artist: "Artist", Album: "Album", Duration: 245},
+			{Location: "https://example.com/rest/stream?id=abc&c=test", Title: "Other & Co", Duration: 0},
+		},
+	}
+	for _, f := range Formats {
+		var buf bytes.Buffer
+		if err := Write(&buf, f, pl); err != nil {
+			t.Fatalf("%s: write: %v", f, err)
+		}
+		got, err := Read(&buf, f)
+		if err != nil {
+			t.Fatalf("%s: read: %v", f, err)
+		}
+		want := *pl
+		if f == FormatPLS {
+			want.Name = "" // not supported by the format
+			want.Entries = []Entry{pl.Entries[0], pl.Entries[1]}
+			want.Entries[0].Album = ""
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("%s: got %+v, want %+v", f, *got, want)
+		}
+	}
+}
+
+func Test_ReadM3U(t *testing.T) {
+	pl, err := Read(strings.NewReader("\ufeff#EXTM3U\r\n#EXTINF:123.4,A - B - C\r\n/music/a.mp3\r\n# comment\r\nb.mp3\r\n"), FormatM3U8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{{Location: "/music/a.mp3", Artist: "A", Title: "B - C", Duration: 123}, {Location: "b.mp3"}}
+	if !reflect.DeepEqual(pl.Entries, want) {
+		t.Errorf("got %+v, want %+v", pl.Entries, want)
+	}
+}
+
+func Test_RelativePaths(t *testing.T) {
+	got := RelativePaths([]string{"/srv/music/A/x.flac", "/srv/music/B/C/y.flac", "Z/z.flac"})
+	want := []string{"A/x.flac", "B/C/y.flac", "Z/z.flac"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_EntryFilePath(t *testing.T) {
+	tests := map[string]string{
+		"/music/a.mp3": "/music/a.mp3",
+		"file:///home/me/Other/Album2/01%20Intro.flac": "/home/me/Other/Album2/01 Intro.flac",
+		"https://example.com/02 Song.flac":             "",
+	}
+	for loc, want := range tests {
+		if got := (Entry{Location: loc}).FilePath(); got != want {
+			t.Errorf("%s: got %q, want %q", loc, got, want)
+		}
+	}
+}
+
+func Test_BestSearchMatch(t *testing.T) {
+	results := []*mediaprovider.SearchResult{
+		{ID: "album", Name: "Song", Type: mediaprovider.ContentTypeAlbum},
+		{ID: "live", Name: "Song (Live)", ArtistName: "Artist", Size: 300, Type: mediaprovider.ContentTypeTrack},
+		{ID: "cover", Name: "Song", ArtistName: "Someone Else", Size: 200, Type: mediaprovider.ContentTypeTrack},
+		{ID: "orig", Name: "Sóng", ArtistName: "The Artist", Size: 201, Type: mediaprovider.ContentTypeTrack},
+	}
+	if id := BestSearchMatch(Entry{Title: "song", Artist: "Artist", Duration: 200}, results); id != "orig" {
+		t.Errorf("got %q, want orig", id)
+	}
+	// title guessed from file name
+	if id := BestSearchMatch(Entry{Location: "/x/03 - Artist - Song (Live).mp3"}, results); id != "live" {
+		t.Errorf("got %q, want live", id)
+	}
+	if id := BestSearchMatch(Entry{Title: "Song", Duration: 100}, results); id != "" {
+		t.Errorf("got %q, want no match", id)
+	}
+}
+
+func Test_RemoveURLCredentials(t *testing.T) {
+	tests := map[string]string{
+		"https://music.example.com/rest/stream?c=app&id=42&s=salt&t=token&u=me&v=1.8.0": "https://music.example.com/rest/stream?c=app&id=42&v=1.8.0",
+		"https://jf.example.com/audio/42/stream?api_key=secret&static=true":             "https://jf.example.com/audio/42/stream?static=true",
+	}
+	for loc, want := range tests {
+		got := RemoveURLCredentials(loc)
+		if got != want {
+			t.Errorf("%s: got %q, want %q", loc, got, want)
+		}
+		if id := (Entry{Location: got}).TrackIDFromURL(); id != "42" {
+			t.Errorf("%s: got track ID %q, want 42", got, id)
+		}
+	}
+}
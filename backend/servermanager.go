@@ -60,6 +60,30 @@ func (s *ServerManager) ConnectToServer(conf *ServerConfig, password string) err
 	return nil
 }
 
+// ConnectAdditional connects to a server without making it the current server,
+// for operations that span several servers. If the server is already the
+// current server, its existing connection is returned.
+func (s *ServerManager) ConnectAdditional(conf *ServerConfig, password string) (mediaprovider.MediaProvider, error) {
+	if conf.ID == s.ServerID && s.Server != nil {
+		return s.Server, nil
+	}
+	cli, err := s.connect(conf.ServerConnection, password)
+	if err != nil {
+		return nil, err
+	}
+	return cli.MediaProvider(), nil
+}
+
+// GetServer returns the configuration of the server with the given ID, or nil.
+func (s *ServerManager) GetServer(serverID uuid.UUID) *ServerConfig {
+	for _, srv := range s.config.Servers {
+		if srv.ID == serverID {
+			return srv
+		}
+	}
+	return nil
+}
+
 func (s *ServerManager) TestConnectionAndAuth(
 	ctx context.Context, connection ServerConnection, password string,
 ) error {
@@ -0,0 +1,472 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/trackmatch"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/google/uuid"
+)
+
+// how often to check whether a recurring server sync is due
+const serverSyncCheckInterval = 10 * time.Minute
+
+var ErrServerSyncInProgress = errors.New("a server sync is already in progress")
+
+// ServerSyncEndpoint is a server connected to for a sync.
+type ServerSyncEndpoint struct {
+	Server   *ServerConfig
+	Provider mediaprovider.MediaProvider
+}
+
+// ServerSyncPlan is the set of changes a one-way sync will make on the target server.
+type ServerSyncPlan struct {
+	Playlists         []PlaylistSyncPlan
+	FavoriteTrackIDs  []string
+	FavoriteAlbumIDs  []string
+	FavoriteArtistIDs []string
+	// target track ID -> rating
+	Ratings map[string]int
+	// True if ratings were requested but the target server does not support them
+	RatingsUnsupported bool
+	// descriptions of items on the source server that could not be found on the target
+	Unmatched []string
+}
+
+// PlaylistSyncPlan is a source playlist to be copied to the target server.
+type PlaylistSyncPlan struct {
+	Name string
+	// existing playlist on the target to update, or "" to create one
+	TargetID string
+	// the tracks of the target playlist when the plan was made
+	BaseTrackIDs []string
+	// the matched source tracks in order, followed by the
+	// tracks of the existing target playlist not among them
+	TrackIDs          []string
+	MatchedTrackCount int
+	SourceTrackCount  int
+}
+
+// IsEmpty returns true if the sync would make no changes.
+func (p *ServerSyncPlan) IsEmpty() bool {
+	return len(p.Playlists) == 0 && len(p.FavoriteTrackIDs) == 0 &&
+		len(p.FavoriteAlbumIDs) == 0 && len(p.FavoriteArtistIDs) == 0 && len(p.Ratings) == 0
+}
+
+// ServerSyncManager copies playlists, favorites and ratings from one
+// configured server to another, matching tracks by MusicBrainz ID,
+// file path or metadata. It can also run the sync periodically.
+// Syncs are one-way and additive: nothing is removed from the target.
+// Tracks of a synced playlist which are only on the target, including those
+// whose source track could not be matched, are kept after the source tracks.
+type ServerSyncManager struct {
+	sm   *ServerManager
+	conf *ServerSyncConfig
+
+	syncLock sync.Mutex
+	// guards conf, which is written by the recurring sync's goroutine
+	confLock sync.Mutex
+}
+
+func NewServerSyncManager(sm *ServerManager, conf *ServerSyncConfig) *ServerSyncManager {
+	return &ServerSyncManager{sm: sm, conf: conf}
+}
+
+// Config returns a copy of the sync settings.
+func (s *ServerSyncManager) Config() ServerSyncConfig {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	return *s.conf
+}
+
+// SetConfig updates the sync settings, keeping the time of the last recurring sync.
+func (s *ServerSyncManager) SetConfig(conf ServerSyncConfig) {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	conf.LastAutoSync = s.conf.LastAutoSync
+	*s.conf = conf
+}
+
+// Start runs the recurring sync, if configured, until ctx is canceled.
+func (s *ServerSyncManager) Start(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(serverSyncCheckInterval)
+		defer t.Stop()
+		for {
+			s.runAutoSyncIfDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (s *ServerSyncManager) runAutoSyncIfDue(ctx context.Context) {
+	conf := s.Config()
+	interval := time.Duration(conf.AutoSyncIntervalHours) * time.Hour
+	if interval <= 0 || time.Since(conf.LastAutoSync) < interval {
+		return
+	}
+	source, err := s.connectWithSavedPassword(conf.SourceServerID)
+	if err != nil {
+		log.Printf("server sync: failed to connect to source server: %v", err)
+		return
+	}
+	target, err := s.connectWithSavedPassword(conf.TargetServerID)
+	if err != nil {
+		log.Printf("server sync: failed to connect to target server: %v", err)
+		return
+	}
+	plan, err := s.Plan(ctx, source, target, conf, nil)
+	if err == nil {
+		err = s.Apply(target, plan)
+	}
+	if err != nil {
+		log.Printf("server sync failed: %v", err)
+		return
+	}
+	s.confLock.Lock()
+	s.conf.LastAutoSync = time.Now()
+	s.confLock.Unlock()
+	log.Printf("server sync completed: %d playlists, %d favorites, %d ratings, %d unmatched", len(plan.Playlists),
+		len(plan.FavoriteTrackIDs)+len(plan.FavoriteAlbumIDs)+len(plan.FavoriteArtistIDs), len(plan.Ratings), len(plan.Unmatched))
+}
+
+func (s *ServerSyncManager) connectWithSavedPassword(serverID string) (*ServerSyncEndpoint, error) {
+	id, err := uuid.Parse(serverID)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := s.sm.GetServerPassword(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Connect(id, pass)
+}
+
+// Connect connects to the configured server with the given ID for a sync.
+func (s *ServerSyncManager) Connect(serverID uuid.UUID, password string) (*ServerSyncEndpoint, error) {
+	conf := s.sm.GetServer(serverID)
+	if conf == nil {
+		return nil, errors.New("server not found")
+	}
+	mp, err := s.sm.ConnectAdditional(conf, password)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerSyncEndpoint{Server: conf, Provider: mp}, nil
+}
+
+// Plan determines the changes needed to sync the target server with the source,
+// according to which kinds of items are enabled in opts, without making them.
+// onProgress, if non-nil, is called with a description of each step.
+func (s *ServerSyncManager) Plan(ctx context.Context, source, target *ServerSyncEndpoint, opts ServerSyncConfig, onProgress func(step string)) (*ServerSyncPlan, error) {
+	if !s.syncLock.TryLock() {
+		return nil, ErrServerSyncInProgress
+	}
+	defer s.syncLock.Unlock()
+	progress := func(step string) {
+		if onProgress != nil {
+			onProgress(step)
+		}
+	}
+
+	progress("Reading target library")
+	targetTracks, err := readAllTracks(ctx, target.Provider, mediaprovider.TrackFilterOptions{})
+	if err != nil {
+		// matching against part of the library would leave tracks unmatched
+		return nil, fmt.Errorf("reading target library: %w", err)
+	}
+	p := &serverSyncPlanner{
+		ctx:          ctx,
+		source:       source,
+		target:       target,
+		plan:         &ServerSyncPlan{Ratings: make(map[string]int)},
+		index:        trackmatch.NewIndex(targetTracks),
+		targets:      targetTracks,
+		unmatchedSet: make(map[string]struct{}),
+	}
+
+	if opts.SyncPlaylists {
+		progress("Matching playlists")
+		if err := p.planPlaylists(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SyncFavorites {
+		progress("Matching favorites")
+		if err := p.planFavorites(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SyncRatings {
+		progress("Matching ratings")
+		if err := p.planRatings(); err != nil {
+			return nil, err
+		}
+	}
+	return p.plan, ctx.Err()
+}
+
+// Apply makes the changes of the plan on the target server.
+func (s *ServerSyncManager) Apply(target *ServerSyncEndpoint, plan *ServerSyncPlan) error {
+	if !s.syncLock.TryLock() {
+		return ErrServerSyncInProgress
+	}
+	defer s.syncLock.Unlock()
+
+	mp := target.Provider
+	var errs []error
+	for _, pl := range plan.Playlists {
+		var err error
+		if pl.TargetID != "" {
+			err = SafeReplacePlaylistTracks(mp, pl.TargetID, pl.BaseTrackIDs, pl.TrackIDs)
+		} else {
+			err = mp.CreatePlaylist(pl.Name, pl.TrackIDs)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("playlist %s: %w", pl.Name, err))
+		}
+	}
+	if len(plan.FavoriteTrackIDs)+len(plan.FavoriteAlbumIDs)+len(plan.FavoriteArtistIDs) > 0 {
+		if err := mp.SetFavorite(mediaprovider.RatingFavoriteParameters{
+			TrackIDs:  plan.FavoriteTrackIDs,
+			AlbumIDs:  plan.FavoriteAlbumIDs,
+			ArtistIDs: plan.FavoriteArtistIDs,
+		}, true); err != nil {
+			errs = append(errs, fmt.Errorf("favorites: %w", err))
+		}
+	}
+	if r, ok := mp.(mediaprovider.SupportsRating); ok && len(plan.Ratings) > 0 {
+		byRating := make(map[int][]string)
+		for id, rating := range plan.Ratings {
+			byRating[rating] = append(byRating[rating], id)
+		}
+		for rating, ids := range byRating {
+			if err := r.SetRating(mediaprovider.RatingFavoriteParameters{TrackIDs: ids}, rating); err != nil {
+				errs = append(errs, fmt.Errorf("ratings: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type serverSyncPlanner struct {
+	ctx            context.Context
+	source, target *ServerSyncEndpoint
+	plan           *ServerSyncPlan
+	index          *trackmatch.Index
+	targets        []*mediaprovider.Track
+	unmatchedSet   map[string]struct{}
+
+	// built from the target library tracks when matching favorites
+	albums  map[string][]targetAlbum // by normalized name
+	artists map[string]string        // normalized name -> ID
+}
+
+type targetAlbum struct {
+	id      string
+	artists string
+}
+
+func (p *serverSyncPlanner) planPlaylists() error {
+	sourcePls, err := p.source.Provider.GetPlaylists()
+	if err != nil {
+		return err
+	}
+	targetPls, err := p.target.Provider.GetPlaylists()
+	if err != nil {
+		return err
+	}
+	for _, spl := range sourcePls {
+		if spl.Owner != p.source.Server.Username {
+			continue // only copy the user's own playlists
+		}
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+		full, err := p.source.Provider.GetPlaylist(spl.ID)
+		if err != nil {
+			return err
+		}
+		plPlan := PlaylistSyncPlan{Name: spl.Name, SourceTrackCount: len(full.Tracks)}
+		for _, tr := range full.Tracks {
+			if m := p.index.Match(tr); m != nil {
+				plPlan.TrackIDs = append(plPlan.TrackIDs, m.ID)
+			} else {
+				p.unmatched("Track", sharedutil.DescribeTrack(tr)+" ("+spl.Name+")")
+			}
+		}
+		plPlan.MatchedTrackCount = len(plPlan.TrackIDs)
+
+		for _, tpl := range targetPls {
+			if tpl.Name == spl.Name && tpl.Owner == p.target.Server.Username {
+				plPlan.TargetID = tpl.ID
+				break
+			}
+		}
+		if plPlan.TargetID != "" {
+			existing, err := p.target.Provider.GetPlaylist(plPlan.TargetID)
+			if err != nil {
+				return err
+			}
+			plPlan.BaseTrackIDs = sharedutil.TracksToIDs(existing.Tracks)
+			plPlan.TrackIDs = appendTargetOnlyTracks(plPlan.TrackIDs, plPlan.BaseTrackIDs)
+			if slices.Equal(plPlan.BaseTrackIDs, plPlan.TrackIDs) {
+				continue // already in sync
+			}
+		}
+		p.plan.Playlists = append(p.plan.Playlists, plPlan)
+	}
+	return nil
+}
+
+func (p *serverSyncPlanner) planFavorites() error {
+	sourceFavs, err := p.source.Provider.GetFavorites()
+	if err != nil {
+		return err
+	}
+	targetFavs, err := p.target.Provider.GetFavorites()
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range sourceFavs.Tracks {
+		if m := p.index.Match(tr); m == nil {
+			p.unmatched("Track", sharedutil.DescribeTrack(tr))
+		} else if !m.Favorite {
+			p.plan.FavoriteTrackIDs = append(p.plan.FavoriteTrackIDs, m.ID)
+		}
+	}
+
+	p.buildAlbumArtistIndexes()
+	favAlbums := sharedutil.ToSet(sharedutil.MapSlice(targetFavs.Albums, func(a *mediaprovider.Album) string { return a.ID }))
+	for _, al := range sourceFavs.Albums {
+		if id := p.matchAlbum(al); id == "" {
+			p.unmatched("Album", strings.Join(al.ArtistNames, ", ")+" - "+al.Name)
+		} else if _, ok := favAlbums[id]; !ok {
+			p.plan.FavoriteAlbumIDs = append(p.plan.FavoriteAlbumIDs, id)
+			favAlbums[id] = struct{}{}
+		}
+	}
+
+	favArtists := sharedutil.ToSet(sharedutil.MapSlice(targetFavs.Artists, func(a *mediaprovider.Artist) string { return a.ID }))
+	for _, ar := range sourceFavs.Artists {
+		if id := p.matchArtist(ar.Name); id == "" {
+			p.unmatched("Artist", ar.Name)
+		} else if _, ok := favArtists[id]; !ok {
+			p.plan.FavoriteArtistIDs = append(p.plan.FavoriteArtistIDs, id)
+			favArtists[id] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// appends the tracks of the target playlist which are not among the
+// matched source tracks, so that syncing does not remove them
+func appendTargetOnlyTracks(matched, target []string) []string {
+	matchedSet := sharedutil.ToSet(matched)
+	for _, id := range target {
+		if _, ok := matchedSet[id]; !ok {
+			matched = append(matched, id)
+		}
+	}
+	return matched
+}
+
+func (p *serverSyncPlanner) planRatings() error {
+	if _, ok := p.target.Provider.(mediaprovider.SupportsRating); !ok {
+		p.plan.RatingsUnsupported = true
+		return nil
+	}
+	rated, err := readAllTracks(p.ctx, p.source.Provider, mediaprovider.TrackFilterOptions{MinRating: 1})
+	if err != nil {
+		return fmt.Errorf("reading rated tracks: %w", err)
+	}
+	for _, tr := range rated {
+		if m := p.index.Match(tr); m == nil {
+			p.unmatched("Track", sharedutil.DescribeTrack(tr))
+		} else if m.Rating != tr.Rating {
+			p.plan.Ratings[m.ID] = tr.Rating
+		}
+	}
+	return nil
+}
+
+// indexes the albums and artists of the target library tracks by normalized name
+func (p *serverSyncPlanner) buildAlbumArtistIndexes() {
+	p.albums = make(map[string][]targetAlbum)
+	p.artists = make(map[string]string)
+	seenAlbums := make(map[targetAlbum]struct{})
+	for _, tr := range p.targets {
+		if tr.AlbumID != "" {
+			al := targetAlbum{id: tr.AlbumID, artists: strings.Join(tr.ArtistNames, " ")}
+			if _, ok := seenAlbums[al]; !ok {
+				seenAlbums[al] = struct{}{}
+				name := trackmatch.Normalize(tr.Album)
+				p.albums[name] = append(p.albums[name], al)
+			}
+		}
+		for i, a := range tr.ArtistNames {
+			if i >= len(tr.ArtistIDs) {
+				break
+			}
+			if name := trackmatch.Normalize(a); p.artists[name] == "" {
+				p.artists[name] = tr.ArtistIDs[i]
+			}
+		}
+	}
+}
+
+// matches an album by name and artist against the albums of the target library tracks
+func (p *serverSyncPlanner) matchAlbum(al *mediaprovider.Album) string {
+	artist := strings.Join(al.ArtistNames, " ")
+	for _, t := range p.albums[trackmatch.Normalize(al.Name)] {
+		if artist == "" || t.artists == "" || trackmatch.ArtistsMatch(artist, t.artists) {
+			return t.id
+		}
+	}
+	return ""
+}
+
+func (p *serverSyncPlanner) matchArtist(name string) string {
+	return p.artists[trackmatch.Normalize(name)]
+}
+
+func (p *serverSyncPlanner) unmatched(kind, desc string) {
+	item := kind + ": " + desc
+	if _, ok := p.unmatchedSet[item]; !ok {
+		p.unmatchedSet[item] = struct{}{}
+		p.plan.Unmatched = append(p.plan.Unmatched, item)
+	}
+}
+
+// reads all the tracks matching opts, failing if the iteration was ended early
+// by an error, since a partial read would make the sync plan incomplete
+func readAllTracks(ctx context.Context, mp mediaprovider.MediaProvider, opts mediaprovider.TrackFilterOptions) ([]*mediaprovider.Track, error) {
+	var tracks []*mediaprovider.Track
+	iter := mp.IterateTracks("", mediaprovider.NewTrackFilter(opts))
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, tr)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := mediaprovider.IteratorErr(iter); err != nil {
+		return nil, err
+	}
+	return tracks, nil
+}
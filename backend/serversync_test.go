@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// fakeSyncServer serves a library of tracks, failing with iterErr
+// after the first track if set, and playlists by ID.
+type fakeSyncServer struct {
+	mediaprovider.MediaProvider
+
+	tracks    []*mediaprovider.Track
+	iterErr   error
+	playlists map[string]*mediaprovider.PlaylistWithTracks
+}
+
+type fakeSyncTrackIter struct {
+	tracks []*mediaprovider.Track
+	err    error
+	pos    int
+}
+
+func (f *fakeSyncTrackIter) Next() *mediaprovider.Track {
+	if f.pos >= len(f.tracks) || (f.err != nil && f.pos > 0) {
+		return nil
+	}
+	f.pos++
+	return f.tracks[f.pos-1]
+}
+
+func (f *fakeSyncTrackIter) Err() error {
+	if f.pos < len(f.tracks) {
+		return f.err
+	}
+	return nil
+}
+
+func (f *fakeSyncServer) IterateTracks(_ string, _ mediaprovider.TrackFilter) mediaprovider.TrackIterator {
+	return &fakeSyncTrackIter{tracks: f.tracks, err: f.iterErr}
+}
+
+func (f *fakeSyncServer) GetPlaylists() ([]*mediaprovider.Playlist, error) {
+	var pls []*mediaprovider.Playlist
+	for _, pl := range f.playlists {
+		pls = append(pls, &pl.Playlist)
+	}
+	return pls, nil
+}
+
+func (f *fakeSyncServer) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
+	if pl, ok := f.playlists[playlistID]; ok {
+		return pl, nil
+	}
+	return nil, mediaprovider.ErrNotFound
+}
+
+func (f *fakeSyncServer) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
+	pl := f.playlists[playlistID]
+	pl.Tracks = nil
+	for _, id := range trackIDs {
+		pl.Tracks = append(pl.Tracks, &mediaprovider.Track{ID: id})
+	}
+	return nil
+}
+
+func newTestSyncEndpoints() (source, target *ServerSyncEndpoint) {
+	sourceServer := &fakeSyncServer{playlists: map[string]*mediaprovider.PlaylistWithTracks{
+		"p1": {Playlist: mediaprovider.Playlist{ID: "p1", Name: "Mix", Owner: "me"}, Tracks: []*mediaprovider.Track{
+			{ID: "s1", MusicBrainzID: "a"},
+			{ID: "s2", MusicBrainzID: "b", Title: "Only on source"},
+			{ID: "s3", MusicBrainzID: "c"},
+		}},
+	}}
+	targetServer := &fakeSyncServer{
+		tracks: []*mediaprovider.Track{
+			{ID: "ta", MusicBrainzID: "a"},
+			{ID: "tc", MusicBrainzID: "c"},
+			{ID: "tx", MusicBrainzID: "x"},
+		},
+		playlists: map[string]*mediaprovider.PlaylistWithTracks{
+			"q1": {Playlist: mediaprovider.Playlist{ID: "q1", Name: "Mix", Owner: "me"}, Tracks: []*mediaprovider.Track{
+				{ID: "tx"}, {ID: "tc"},
+			}},
+		},
+	}
+	source = &ServerSyncEndpoint{Server: &ServerConfig{ServerConnection: ServerConnection{Username: "me"}}, Provider: sourceServer}
+	target = &ServerSyncEndpoint{Server: &ServerConfig{ServerConnection: ServerConnection{Username: "me"}}, Provider: targetServer}
+	return source, target
+}
+
+func Test_ServerSyncKeepsTargetPlaylistTracks(t *testing.T) {
+	source, target := newTestSyncEndpoints()
+	s := NewServerSyncManager(nil, &ServerSyncConfig{})
+	plan, err := s.Plan(context.Background(), source, target, ServerSyncConfig{SyncPlaylists: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Playlists) != 1 {
+		t.Fatalf("got playlists %+v, want 1", plan.Playlists)
+	}
+	pl := plan.Playlists[0]
+	// the matched source tracks, then the track only on the target
+	if want := []string{"ta", "tc", "tx"}; !slices.Equal(pl.TrackIDs, want) {
+		t.Errorf("got tracks %v, want %v", pl.TrackIDs, want)
+	}
+	if pl.MatchedTrackCount != 2 || pl.SourceTrackCount != 3 {
+		t.Errorf("got %d / %d tracks matched, want 2 / 3", pl.MatchedTrackCount, pl.SourceTrackCount)
+	}
+	if len(plan.Unmatched) != 1 {
+		t.Errorf("got unmatched %v, want 1", plan.Unmatched)
+	}
+
+	if err := s.Apply(target, plan); err != nil {
+		t.Fatal(err)
+	}
+	got := target.Provider.(*fakeSyncServer).playlists["q1"]
+	if ids := sharedutil.TracksToIDs(got.Tracks); !slices.Equal(ids, pl.TrackIDs) {
+		t.Errorf("got target tracks %v, want %v", ids, pl.TrackIDs)
+	}
+
+	// a second sync finds nothing to change
+	plan, err = s.Plan(context.Background(), source, target, ServerSyncConfig{SyncPlaylists: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Playlists) != 0 {
+		t.Errorf("got playlists %+v after syncing, want none", plan.Playlists)
+	}
+}
+
+func Test_ServerSyncTargetReadError(t *testing.T) {
+	source, target := newTestSyncEndpoints()
+	connErr := errors.New("connection reset")
+	target.Provider.(*fakeSyncServer).iterErr = connErr
+	s := NewServerSyncManager(nil, &ServerSyncConfig{})
+	plan, err := s.Plan(context.Background(), source, target, ServerSyncConfig{SyncPlaylists: true}, nil)
+	if !errors.Is(err, connErr) {
+		t.Errorf("got error %v, want %v", err, connErr)
+	}
+	if plan != nil {
+		t.Errorf("got plan %+v from a partial read of the target library", plan)
+	}
+}
@@ -0,0 +1,349 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/google/uuid"
+)
+
+// Fields which smart playlist rules can match on
+const (
+	SmartPlaylistFieldGenre       = "Genre"
+	SmartPlaylistFieldYear        = "Year"
+	SmartPlaylistFieldRating      = "Rating"
+	SmartPlaylistFieldPlayCount   = "PlayCount"
+	SmartPlaylistFieldLastPlayed  = "LastPlayed"
+	SmartPlaylistFieldFavorite    = "Favorite"
+	SmartPlaylistFieldBitRate     = "BitRate"
+	SmartPlaylistFieldContentType = "ContentType"
+	SmartPlaylistFieldDuration    = "Duration"
+)
+
+// Comparison operators for smart playlist rules
+const (
+	SmartPlaylistOpIs          = "Is"
+	SmartPlaylistOpIsNot       = "IsNot"
+	SmartPlaylistOpContains    = "Contains"
+	SmartPlaylistOpGreaterThan = "GreaterThan"
+	SmartPlaylistOpLessThan    = "LessThan"
+	SmartPlaylistOpInLastDays  = "InLastDays"
+	SmartPlaylistOpNotInLast   = "NotInLastDays"
+)
+
+// Sort orders for smart playlists
+const (
+	SmartPlaylistSortNone       = ""
+	SmartPlaylistSortRandom     = "Random"
+	SmartPlaylistSortTitle      = "Title"
+	SmartPlaylistSortArtist     = "Artist"
+	SmartPlaylistSortAlbum      = "Album"
+	SmartPlaylistSortYear       = "Year"
+	SmartPlaylistSortRating     = "Rating"
+	SmartPlaylistSortPlayCount  = "PlayCount"
+	SmartPlaylistSortLastPlayed = "LastPlayed"
+	SmartPlaylistSortDuration   = "Duration"
+)
+
+// IDs of smart playlists are prefixed so they can be told apart
+// from server playlist IDs wherever both are shown together.
+const smartPlaylistIDPrefix = "smart-"
+
+var ErrSmartPlaylistNotFound = errors.New("smart playlist not found")
+
+var SmartPlaylistFields = []string{
+	SmartPlaylistFieldGenre,
+	SmartPlaylistFieldYear,
+	SmartPlaylistFieldRating,
+	SmartPlaylistFieldPlayCount,
+	SmartPlaylistFieldLastPlayed,
+	SmartPlaylistFieldFavorite,
+	SmartPlaylistFieldBitRate,
+	SmartPlaylistFieldContentType,
+	SmartPlaylistFieldDuration,
+}
+
+var SmartPlaylistSortOrders = []string{
+	SmartPlaylistSortNone,
+	SmartPlaylistSortRandom,
+	SmartPlaylistSortTitle,
+	SmartPlaylistSortArtist,
+	SmartPlaylistSortAlbum,
+	SmartPlaylistSortYear,
+	SmartPlaylistSortRating,
+	SmartPlaylistSortPlayCount,
+	SmartPlaylistSortLastPlayed,
+	SmartPlaylistSortDuration,
+}
+
+// Returns the operators that are valid for the given rule field.
+func SmartPlaylistOperatorsForField(field string) []string {
+	switch field {
+	case SmartPlaylistFieldGenre, SmartPlaylistFieldContentType:
+		return []string{SmartPlaylistOpIs, SmartPlaylistOpIsNot, SmartPlaylistOpContains}
+	case SmartPlaylistFieldFavorite:
+		return []string{SmartPlaylistOpIs}
+	case SmartPlaylistFieldLastPlayed:
+		return []string{SmartPlaylistOpInLastDays, SmartPlaylistOpNotInLast}
+	default:
+		return []string{SmartPlaylistOpIs, SmartPlaylistOpIsNot, SmartPlaylistOpGreaterThan, SmartPlaylistOpLessThan}
+	}
+}
+
+// Returns true if the given ID refers to a client-side smart playlist
+// rather than a playlist stored on the server.
+func IsSmartPlaylistID(id string) bool {
+	return strings.HasPrefix(id, smartPlaylistIDPrefix)
+}
+
+// SmartPlaylistManager stores the user's smart playlists in the config and
+// evaluates them client-side against the full library track list.
+type SmartPlaylistManager struct {
+	sm      *ServerManager
+	config  *Config
+	library *libraryTrackCache
+}
+
+func NewSmartPlaylistManager(sm *ServerManager, config *Config, library *libraryTrackCache) *SmartPlaylistManager {
+	return &SmartPlaylistManager{sm: sm, config: config, library: library}
+}
+
+// Returns all smart playlists configured by the user.
+func (s *SmartPlaylistManager) GetSmartPlaylists() []*SmartPlaylist {
+	return s.config.SmartPlaylists
+}
+
+func (s *SmartPlaylistManager) GetSmartPlaylist(id string) *SmartPlaylist {
+	for _, pl := range s.config.SmartPlaylists {
+		if pl.ID == id {
+			return pl
+		}
+	}
+	return nil
+}
+
+// Adds the smart playlist to the config if it is new, or updates the
+// existing one with the same ID.
+func (s *SmartPlaylistManager) SaveSmartPlaylist(pl *SmartPlaylist) {
+	if pl.ID == "" {
+		pl.ID = smartPlaylistIDPrefix + uuid.NewString()
+	}
+	for i, existing := range s.config.SmartPlaylists {
+		if existing.ID == pl.ID {
+			s.config.SmartPlaylists[i] = pl
+			return
+		}
+	}
+	s.config.SmartPlaylists = append(s.config.SmartPlaylists, pl)
+}
+
+func (s *SmartPlaylistManager) DeleteSmartPlaylist(id string) {
+	s.config.SmartPlaylists = sharedutil.FilterSlice(s.config.SmartPlaylists, func(pl *SmartPlaylist) bool {
+		return pl.ID != id
+	})
+}
+
+// Drops the cached library track list so that it is re-fetched
+// the next time a smart playlist is evaluated.
+func (s *SmartPlaylistManager) InvalidateLibraryCache() {
+	s.library.invalidate()
+}
+
+// Evaluates the smart playlist with the given ID against the library
+// of the currently connected server.
+func (s *SmartPlaylistManager) EvaluateSmartPlaylist(id string) ([]*mediaprovider.Track, error) {
+	pl := s.GetSmartPlaylist(id)
+	if pl == nil {
+		return nil, ErrSmartPlaylistNotFound
+	}
+	return s.Evaluate(pl)
+}
+
+// Evaluates the smart playlist against the library of the currently connected server.
+// The returned tracks are copies of the cached library tracks and may be freely modified.
+func (s *SmartPlaylistManager) Evaluate(pl *SmartPlaylist) ([]*mediaprovider.Track, error) {
+	tracks, err := s.library.getTracks()
+	if err != nil {
+		return nil, err
+	}
+	return pl.Apply(tracks), nil
+}
+
+// Evaluates the smart playlist and writes the resulting tracks to a server playlist
+// of the same name, creating it the first time.
+func (s *SmartPlaylistManager) SaveToServerPlaylist(id string) error {
+	pl := s.GetSmartPlaylist(id)
+	if pl == nil {
+		return ErrSmartPlaylistNotFound
+	}
+	tracks, err := s.Evaluate(pl)
+	if err != nil {
+		return err
+	}
+	trackIDs := sharedutil.TracksToIDs(tracks)
+	serverID := s.sm.ServerID.String()
+	if plID, ok := pl.ServerPlaylistIDs[serverID]; ok {
+		err := s.sm.Server.ReplacePlaylistTracks(plID, trackIDs)
+		if !errors.Is(err, mediaprovider.ErrNotFound) {
+			return err
+		}
+		// the server playlist was deleted; fall back to re-creating it
+	}
+
+	plID, err := createServerPlaylist(s.sm.Server, pl.Name, trackIDs)
+	if err != nil {
+		return err
+	}
+	if plID == "" {
+		// without the ID, the playlist can't be updated by the next save
+		return fmt.Errorf("created server playlist %q but could not find its ID", pl.Name)
+	}
+	if pl.ServerPlaylistIDs == nil {
+		pl.ServerPlaylistIDs = make(map[string]string)
+	}
+	pl.ServerPlaylistIDs[serverID] = plID
+	return nil
+}
+
+// Filters, sorts and limits the given tracks according to the smart playlist definition.
+// The input slice is not modified.
+func (pl *SmartPlaylist) Apply(tracks []*mediaprovider.Track) []*mediaprovider.Track {
+	now := time.Now()
+	result := sharedutil.FilterSlice(slices.Clone(tracks), func(tr *mediaprovider.Track) bool {
+		return pl.matches(tr, now)
+	})
+	pl.sortTracks(result)
+	if pl.Limit > 0 && len(result) > pl.Limit {
+		result = result[:pl.Limit]
+	}
+	return result
+}
+
+func (pl *SmartPlaylist) matches(tr *mediaprovider.Track, now time.Time) bool {
+	if len(pl.Rules) == 0 {
+		return true
+	}
+	for _, r := range pl.Rules {
+		m := r.matches(tr, now)
+		if pl.MatchAny && m {
+			return true
+		}
+		if !pl.MatchAny && !m {
+			return false
+		}
+	}
+	return !pl.MatchAny
+}
+
+func (pl *SmartPlaylist) sortTracks(tracks []*mediaprovider.Track) {
+	var less func(a, b *mediaprovider.Track) bool
+	switch pl.SortBy {
+	case SmartPlaylistSortRandom:
+		rand.Shuffle(len(tracks), func(i, j int) {
+			tracks[i], tracks[j] = tracks[j], tracks[i]
+		})
+		return
+	case SmartPlaylistSortTitle:
+		less = func(a, b *mediaprovider.Track) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	case SmartPlaylistSortArtist:
+		less = func(a, b *mediaprovider.Track) bool {
+			return strings.ToLower(strings.Join(a.ArtistNames, ", ")) < strings.ToLower(strings.Join(b.ArtistNames, ", "))
+		}
+	case SmartPlaylistSortAlbum:
+		less = func(a, b *mediaprovider.Track) bool { return strings.ToLower(a.Album) < strings.ToLower(b.Album) }
+	case SmartPlaylistSortYear:
+		less = func(a, b *mediaprovider.Track) bool { return a.Year < b.Year }
+	case SmartPlaylistSortRating:
+		less = func(a, b *mediaprovider.Track) bool { return a.Rating < b.Rating }
+	case SmartPlaylistSortPlayCount:
+		less = func(a, b *mediaprovider.Track) bool { return a.PlayCount < b.PlayCount }
+	case SmartPlaylistSortLastPlayed:
+		less = func(a, b *mediaprovider.Track) bool { return a.LastPlayed.Before(b.LastPlayed) }
+	case SmartPlaylistSortDuration:
+		less = func(a, b *mediaprovider.Track) bool { return a.Duration < b.Duration }
+	default:
+		return
+	}
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if pl.SortDescending {
+			return less(tracks[j], tracks[i])
+		}
+		return less(tracks[i], tracks[j])
+	})
+}
+
+func (r SmartPlaylistRule) matches(tr *mediaprovider.Track, now time.Time) bool {
+	switch r.Field {
+	case SmartPlaylistFieldGenre:
+		if r.Operator == SmartPlaylistOpIsNot {
+			return !slices.ContainsFunc(tr.Genres, func(g string) bool { return r.matchString(g, SmartPlaylistOpIs) })
+		}
+		return slices.ContainsFunc(tr.Genres, func(g string) bool { return r.matchString(g, r.Operator) })
+	case SmartPlaylistFieldContentType:
+		return r.matchString(tr.ContentType, r.Operator)
+	case SmartPlaylistFieldYear:
+		return r.matchInt(tr.Year)
+	case SmartPlaylistFieldRating:
+		return r.matchInt(tr.Rating)
+	case SmartPlaylistFieldPlayCount:
+		return r.matchInt(tr.PlayCount)
+	case SmartPlaylistFieldBitRate:
+		return r.matchInt(tr.BitRate)
+	case SmartPlaylistFieldDuration:
+		return r.matchInt(tr.Duration)
+	case SmartPlaylistFieldFavorite:
+		want, _ := strconv.ParseBool(r.Value)
+		return tr.Favorite == want
+	case SmartPlaylistFieldLastPlayed:
+		days, err := strconv.Atoi(strings.TrimSpace(r.Value))
+		if err != nil {
+			return false
+		}
+		inLast := !tr.LastPlayed.IsZero() && now.Sub(tr.LastPlayed) <= time.Duration(days)*24*time.Hour
+		if r.Operator == SmartPlaylistOpNotInLast {
+			return !inLast
+		}
+		return inLast
+	}
+	return false
+}
+
+func (r SmartPlaylistRule) matchString(s, op string) bool {
+	s = strings.ToLower(s)
+	v := strings.ToLower(strings.TrimSpace(r.Value))
+	switch op {
+	case SmartPlaylistOpIs:
+		return s == v
+	case SmartPlaylistOpIsNot:
+		return s != v
+	case SmartPlaylistOpContains:
+		return strings.Contains(s, v)
+	}
+	return false
+}
+
+func (r SmartPlaylistRule) matchInt(i int) bool {
+	v, err := strconv.Atoi(strings.TrimSpace(r.Value))
+	if err != nil {
+		return false
+	}
+	switch r.Operator {
+	case SmartPlaylistOpIs:
+		return i == v
+	case SmartPlaylistOpIsNot:
+		return i != v
+	case SmartPlaylistOpGreaterThan:
+		return i > v
+	case SmartPlaylistOpLessThan:
+		return i < v
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/google/uuid"
+)
+
+func Test_SmartPlaylistRuleMatches(t *testing.T) {
+	now := time.Now()
+	tr := &mediaprovider.Track{
+		Genres:      []string{"Rock", "Indie Pop"},
+		ContentType: "audio/flac",
+		Year:        1999,
+		Rating:      4,
+		PlayCount:   10,
+		BitRate:     900,
+		Duration:    240,
+		Favorite:    true,
+		LastPlayed:  now.Add(-3 * 24 * time.Hour),
+	}
+	for _, tc := range []struct {
+		rule SmartPlaylistRule
+		want bool
+	}{
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldGenre, Operator: SmartPlaylistOpIs, Value: " rock "}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldGenre, Operator: SmartPlaylistOpContains, Value: "pop"}, want: true},
+		// no genre of the track may be the value
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldGenre, Operator: SmartPlaylistOpIsNot, Value: "Rock"}, want: false},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldGenre, Operator: SmartPlaylistOpIsNot, Value: "Jazz"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldContentType, Operator: SmartPlaylistOpContains, Value: "FLAC"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldYear, Operator: SmartPlaylistOpLessThan, Value: "2000"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldRating, Operator: SmartPlaylistOpIs, Value: "4"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldPlayCount, Operator: SmartPlaylistOpGreaterThan, Value: "10"}, want: false},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldBitRate, Operator: SmartPlaylistOpIsNot, Value: "320"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldDuration, Operator: SmartPlaylistOpGreaterThan, Value: "not a number"}, want: false},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldFavorite, Operator: SmartPlaylistOpIs, Value: "true"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldLastPlayed, Operator: SmartPlaylistOpInLastDays, Value: "7"}, want: true},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldLastPlayed, Operator: SmartPlaylistOpInLastDays, Value: "2"}, want: false},
+		{rule: SmartPlaylistRule{Field: SmartPlaylistFieldLastPlayed, Operator: SmartPlaylistOpNotInLast, Value: "2"}, want: true},
+	} {
+		if got := tc.rule.matches(tr, now); got != tc.want {
+			t.Errorf("%+v: got %v, want %v", tc.rule, got, tc.want)
+		}
+	}
+
+	// a track never played was not played in the last days
+	never := SmartPlaylistRule{Field: SmartPlaylistFieldLastPlayed, Operator: SmartPlaylistOpNotInLast, Value: "30"}
+	if !never.matches(&mediaprovider.Track{}, now) {
+		t.Error("a track never played did not match not played in the last days")
+	}
+}
+
+func Test_SmartPlaylistApply(t *testing.T) {
+	tracks := []*mediaprovider.Track{
+		{ID: "1", Title: "b", Year: 1990, Rating: 5},
+		{ID: "2", Title: "C", Year: 2005, Rating: 3},
+		{ID: "3", Title: "a", Year: 2010, Rating: 1},
+		{ID: "4", Title: "d", Year: 1980, Rating: 4},
+	}
+	before := slices.Clone(tracks)
+	oldOrGood := []SmartPlaylistRule{
+		{Field: SmartPlaylistFieldYear, Operator: SmartPlaylistOpLessThan, Value: "2000"},
+		{Field: SmartPlaylistFieldRating, Operator: SmartPlaylistOpGreaterThan, Value: "2"},
+	}
+	for _, tc := range []struct {
+		name string
+		pl   SmartPlaylist
+		want []string
+	}{
+		{name: "no rules", pl: SmartPlaylist{}, want: []string{"1", "2", "3", "4"}},
+		{name: "all rules", pl: SmartPlaylist{Rules: oldOrGood}, want: []string{"1", "4"}},
+		{name: "any rule", pl: SmartPlaylist{Rules: oldOrGood, MatchAny: true}, want: []string{"1", "2", "4"}},
+		{name: "sorted by title", pl: SmartPlaylist{SortBy: SmartPlaylistSortTitle}, want: []string{"3", "1", "2", "4"}},
+		{name: "sorted by year descending, limited", pl: SmartPlaylist{SortBy: SmartPlaylistSortYear, SortDescending: true, Limit: 2}, want: []string{"3", "2"}},
+	} {
+		if got := sharedutil.TracksToIDs(tc.pl.Apply(tracks)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if !slices.Equal(tracks, before) {
+		t.Error("Apply modified the input tracks")
+	}
+
+	random := SmartPlaylist{SortBy: SmartPlaylistSortRandom, Limit: 3}
+	if got := random.Apply(tracks); len(got) != 3 {
+		t.Errorf("random: got %d tracks, want 3", len(got))
+	}
+}
+
+func Test_SaveSmartPlaylistToServer(t *testing.T) {
+	mp := &fakePlaylistIDServer{fakePlaylistServer{playlists: []*mediaprovider.Playlist{{ID: "1", Name: "Top Rated"}}}}
+	sm := &ServerManager{Server: mp, ServerID: uuid.New()}
+	library := newLibraryTrackCache(sm)
+	library.tracks = []*mediaprovider.Track{{ID: "a", Rating: 5}, {ID: "b", Rating: 2}}
+	library.cachedAt = time.Now()
+	s := NewSmartPlaylistManager(sm, &Config{}, library)
+	pl := &SmartPlaylist{Name: "Top Rated",
+		Rules: []SmartPlaylistRule{{Field: SmartPlaylistFieldRating, Operator: SmartPlaylistOpIs, Value: "5"}}}
+	s.SaveSmartPlaylist(pl)
+
+	if err := s.SaveToServerPlaylist(pl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id := pl.ServerPlaylistIDs[sm.ServerID.String()]; id != "2" {
+		t.Fatalf("got server playlist %q, want the new playlist 2", id)
+	}
+	// saving again replaces the tracks of the same playlist
+	library.tracks = append(library.tracks, &mediaprovider.Track{ID: "c", Rating: 5})
+	if err := s.SaveToServerPlaylist(pl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(mp.playlists) != 2 || mp.playlists[1].TrackCount != 2 {
+		t.Errorf("got server playlists %+v, want playlist 2 updated with 2 tracks", mp.playlists)
+	}
+
+	// a failure other than the playlist being deleted does not create another one
+	mp.replaceErr = errors.New("connection reset")
+	if err := s.SaveToServerPlaylist(pl.ID); !errors.Is(err, mp.replaceErr) {
+		t.Errorf("got error %v, want %v", err, mp.replaceErr)
+	}
+	if len(mp.playlists) != 2 || pl.ServerPlaylistIDs[sm.ServerID.String()] != "2" {
+		t.Errorf("got server playlists %+v after a failed save, want playlist 2 kept", mp.playlists)
+	}
+
+	// a deleted playlist is re-created
+	mp.replaceErr = nil
+	mp.playlists = mp.playlists[:1]
+	if err := s.SaveToServerPlaylist(pl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id := pl.ServerPlaylistIDs[sm.ServerID.String()]; id != "2" || len(mp.playlists) != 2 {
+		t.Errorf("got server playlist %q in %+v, want the re-created playlist", id, mp.playlists)
+	}
+}
+
+func Test_SmartPlaylistLibraryReadError(t *testing.T) {
+	iterErr := errors.New("connection reset")
+	mp := &fakeSyncServer{
+		tracks:  []*mediaprovider.Track{{ID: "a", Rating: 5}, {ID: "b", Rating: 5}},
+		iterErr: iterErr,
+		playlists: map[string]*mediaprovider.PlaylistWithTracks{
+			"p1": {Playlist: mediaprovider.Playlist{ID: "p1"}, Tracks: []*mediaprovider.Track{{ID: "a"}, {ID: "b"}}},
+		},
+	}
+	sm := &ServerManager{Server: mp, ServerID: uuid.New()}
+	library := newLibraryTrackCache(sm)
+	s := NewSmartPlaylistManager(sm, &Config{}, library)
+	pl := &SmartPlaylist{Name: "Top Rated", ServerPlaylistIDs: map[string]string{sm.ServerID.String(): "p1"}}
+	s.SaveSmartPlaylist(pl)
+
+	if err := s.SaveToServerPlaylist(pl.ID); !errors.Is(err, iterErr) {
+		t.Fatalf("got error %v, want %v", err, iterErr)
+	}
+	if library.tracks != nil {
+		t.Errorf("got cached library %v after a failed read, want none", library.tracks)
+	}
+	if tracks := mp.playlists["p1"].Tracks; len(tracks) != 2 {
+		t.Errorf("got server playlist tracks %v, want the playlist left as it was", tracks)
+	}
+
+	// the next evaluation reads the library again
+	mp.iterErr = nil
+	if tracks, err := s.Evaluate(pl); err != nil || len(tracks) != 2 {
+		t.Errorf("got %d tracks and error %v, want 2 tracks", len(tracks), err)
+	}
+}
+
+// fakeUnlistedPlaylistServer creates playlists which it doesn't list.
+type fakeUnlistedPlaylistServer struct {
+	fakePlaylistServer
+}
+
+func (f *fakeUnlistedPlaylistServer) CreatePlaylist(name string, trackIDs []string) error {
+	return nil
+}
+
+func Test_SaveSmartPlaylistToServerIDNotFound(t *testing.T) {
+	mp := &fakeUnlistedPlaylistServer{}
+	sm := &ServerManager{Server: mp, ServerID: uuid.New()}
+	library := newLibraryTrackCache(sm)
+	library.tracks = []*mediaprovider.Track{{ID: "a"}}
+	library.cachedAt = time.Now()
+	s := NewSmartPlaylistManager(sm, &Config{}, library)
+	pl := &SmartPlaylist{Name: "All"}
+	s.SaveSmartPlaylist(pl)
+
+	if err := s.SaveToServerPlaylist(pl.ID); err == nil {
+		t.Error("got no error when the created playlist was not found")
+	}
+	if len(pl.ServerPlaylistIDs) != 0 {
+		t.Errorf("got server playlists %v, want none recorded", pl.ServerPlaylistIDs)
+	}
+}
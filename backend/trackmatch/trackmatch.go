@@ -0,0 +1,177 @@
+// Package trackmatch finds the tracks in a library which correspond to
+// tracks known from elsewhere, such as a playlist file or another server,
+// by MusicBrainz ID, file path or metadata.
+package trackmatch
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/deluan/sanitize"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// MaxDurationDiff is the max difference in seconds between the durations of matching tracks
+const MaxDurationDiff = 3
+
+// number of trailing path components indexed for path matching
+const maxIndexedPathDepth = 4
+
+// trailing "(Remastered)", "[Live]" etc. qualifiers of track titles
+var titleQualifierRegex = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]\s*$`)
+
+// Normalize returns s lowercased and without accents or punctuation,
+// for comparing names that may be formatted differently.
+func Normalize(s string) string {
+	s = sanitize.Accents(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// TitleMatchScore returns 2 if the titles match exactly (after normalization),
+// 1 if they match after removing trailing qualifiers such as "(Live)", otherwise 0.
+func TitleMatchScore(a, b string) int {
+	if Normalize(a) == Normalize(b) {
+		return 2
+	}
+	if Normalize(titleQualifierRegex.ReplaceAllString(a, "")) == Normalize(titleQualifierRegex.ReplaceAllString(b, "")) {
+		return 1
+	}
+	return 0
+}
+
+// ArtistsMatch returns true if either artist name contains the other, after normalization.
+// Names are compared as a whole so "Artist feat. Other" matches "Artist".
+func ArtistsMatch(a, b string) bool {
+	a, b = Normalize(a), Normalize(b)
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// DurationScore returns how closely the durations in seconds match, from 0 to 1,
+// or -1 if they differ by more than MaxDurationDiff.
+// Unknown (zero) durations are considered a perfect match.
+func DurationScore(a, b int) float64 {
+	if a <= 0 || b <= 0 {
+		return 1
+	}
+	diff := math.Abs(float64(a - b))
+	if diff > MaxDurationDiff {
+		return -1
+	}
+	return 1 - diff/(MaxDurationDiff+1)
+}
+
+// SplitPath splits a path with either slash or backslash separators into its components.
+func SplitPath(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' })
+}
+
+// PathIndex finds library tracks by file path. Since paths known from elsewhere
+// may be relative to a different root than the library's paths,
+// tracks are matched by the longest unambiguous trailing part of their path.
+type PathIndex struct {
+	// nil value if several tracks share the same path suffix
+	tracks map[string]*mediaprovider.Track
+}
+
+func NewPathIndex(tracks []*mediaprovider.Track) *PathIndex {
+	idx := &PathIndex{tracks: make(map[string]*mediaprovider.Track)}
+	for _, tr := range tracks {
+		parts := SplitPath(strings.ToLower(tr.FilePath))
+		for n := 1; n <= min(len(parts), maxIndexedPathDepth); n++ {
+			key := strings.Join(parts[len(parts)-n:], "/")
+			if _, ok := idx.tracks[key]; ok {
+				idx.tracks[key] = nil
+			} else {
+				idx.tracks[key] = tr
+			}
+		}
+	}
+	return idx
+}
+
+// Lookup returns the track whose path matches the given file path, or nil.
+func (p *PathIndex) Lookup(filePath string) *mediaprovider.Track {
+	parts := SplitPath(strings.ToLower(filePath))
+	for n := min(len(parts), maxIndexedPathDepth); n >= 1; n-- {
+		if tr := p.tracks[strings.Join(parts[len(parts)-n:], "/")]; tr != nil {
+			return tr
+		}
+	}
+	return nil
+}
+
+// Index finds the library tracks matching tracks from another library.
+type Index struct {
+	byMBID  map[string]*mediaprovider.Track
+	byPath  *PathIndex
+	byTitle map[string][]*mediaprovider.Track // by normalized title without qualifiers
+}
+
+func NewIndex(tracks []*mediaprovider.Track) *Index {
+	idx := &Index{
+		byMBID:  make(map[string]*mediaprovider.Track),
+		byPath:  NewPathIndex(tracks),
+		byTitle: make(map[string][]*mediaprovider.Track),
+	}
+	for _, tr := range tracks {
+		if tr.MusicBrainzID != "" {
+			idx.byMBID[tr.MusicBrainzID] = tr
+		}
+		key := titleKey(tr.Title)
+		idx.byTitle[key] = append(idx.byTitle[key], tr)
+	}
+	return idx
+}
+
+// Match returns the library track corresponding to tr, matching first by
+// MusicBrainz ID, then by file path, and last by title, artist and duration.
+// Returns nil if there is no close enough match.
+func (idx *Index) Match(tr *mediaprovider.Track) *mediaprovider.Track {
+	if tr.MusicBrainzID != "" {
+		if m := idx.byMBID[tr.MusicBrainzID]; m != nil {
+			return m
+		}
+	}
+	if tr.FilePath != "" {
+		// a path match is only trusted if the metadata agrees
+		if m := idx.byPath.Lookup(tr.FilePath); m != nil && TitleMatchScore(m.Title, tr.Title) > 0 {
+			return m
+		}
+	}
+
+	key := titleKey(tr.Title)
+	if key == "" {
+		return nil
+	}
+	var best *mediaprovider.Track
+	bestScore := 0.0
+	for _, c := range idx.byTitle[key] {
+		score := float64(TitleMatchScore(c.Title, tr.Title))
+		if len(tr.ArtistNames) > 0 && len(c.ArtistNames) > 0 {
+			if !ArtistsMatch(strings.Join(c.ArtistNames, " "), strings.Join(tr.ArtistNames, " ")) {
+				continue
+			}
+			score += 2
+		}
+		d := DurationScore(c.Duration, tr.Duration)
+		if d < 0 {
+			continue
+		}
+		score += d
+		if tr.Album != "" && Normalize(c.Album) == Normalize(tr.Album) {
+			score += 1
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+func titleKey(title string) string {
+	return Normalize(titleQualifierRegex.ReplaceAllString(title, ""))
+}
@@ -0,0 +1,59 @@
+package trackmatch
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func Test_IndexMatch(t *testing.T) {
+	idx := NewIndex([]*mediaprovider.Track{
+		{ID: "mbid", Title: "Song", MusicBrainzID: "abc"},
+		{ID: "path", Title: "Song", ArtistNames: []string{"Artist"}, FilePath: "/music/Artist/Album/01 Song.flac", Duration: 180},
+		{ID: "meta", Title: "Another Song (Remastered)", ArtistNames: []string{"The Artist"}, Album: "Album", Duration: 200},
+		{ID: "meta2", Title: "Another Song", ArtistNames: []string{"The Artist"}, Album: "Best Of", Duration: 201},
+	})
+	tests := []struct {
+		track *mediaprovider.Track
+		want  string
+	}{
+		{&mediaprovider.Track{Title: "Different", MusicBrainzID: "abc"}, "mbid"},
+		{&mediaprovider.Track{Title: "song", FilePath: `D:\Music\Artist\Album\01 Song.flac`}, "path"},
+		{&mediaprovider.Track{Title: "Another Song", ArtistNames: []string{"Artist"}, Album: "Album", Duration: 199}, "meta"},
+		{&mediaprovider.Track{Title: "Another Song", ArtistNames: []string{"Other"}}, ""},
+		{&mediaprovider.Track{Title: "Another Song", Duration: 120}, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if m := idx.Match(tt.track); m != nil {
+			got = m.ID
+		}
+		if got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.track, got, tt.want)
+		}
+	}
+}
+
+func Test_PathIndex(t *testing.T) {
+	idx := NewPathIndex([]*mediaprovider.Track{
+		{ID: "1", FilePath: "Artist/Album/01 Intro.flac"},
+		{ID: "2", FilePath: "Other/Album2/01 Intro.flac"},
+		{ID: "3", FilePath: "Artist/Album/02 Song.flac"},
+	})
+	tests := map[string]string{
+		`C:\Users\me\Music\Artist\Album\01 Intro.flac`: "1",
+		"/home/me/Other/Album2/01 Intro.flac":          "2",
+		"../02 song.FLAC":                              "3",
+		"01 Intro.flac":                                "", // ambiguous
+		"Unknown/03 Song.flac":                         "",
+	}
+	for path, want := range tests {
+		var got string
+		if tr := idx.Lookup(path); tr != nil {
+			got = tr.ID
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"io"
 	"os"
+	"strings"
 )
 
 func CopyFile(srcPath, dstPath string) error {
@@ -21,3 +22,14 @@ func CopyFile(srcPath, dstPath string) error {
 	_, err = io.Copy(fout, fin)
 	return err
 }
+
+// SanitizeFileName replaces characters that are not allowed
+// in file names on common file systems with underscores.
+func SanitizeFileName(name string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name))
+}
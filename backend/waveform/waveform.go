@@ -0,0 +1,160 @@
+// Package waveform computes the loudness envelope of a track's audio,
+// as drawn by the waveform seek bar, and stores it on disk.
+package waveform
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Bins is the number of equal-length slices of a track in a Waveform
+	Bins = 1000
+
+	// SampleRate is the sample rate in Hz of the PCM audio analyzed
+	SampleRate = 8000
+
+	fileMagic = "SSWF"
+)
+
+var ErrInvalidFile = errors.New("invalid waveform file")
+
+// Waveform is the loudness of a track's audio over time.
+type Waveform struct {
+	// RMS level of each slice of the track, from 0 (silence)
+	// to 255 (the level of a full scale sine wave)
+	Levels []uint8
+}
+
+// Builder computes a Waveform from 16-bit mono PCM samples as they are decoded.
+type Builder struct {
+	sumSquares   []float64
+	counts       []int64
+	totalSamples int64
+	numSamples   int64
+}
+
+// NewBuilder returns a Builder for a track of the given duration in seconds.
+func NewBuilder(durationSecs int) *Builder {
+	return &Builder{
+		sumSquares:   make([]float64, Bins),
+		counts:       make([]int64, Bins),
+		totalSamples: max(1, int64(durationSecs)*SampleRate),
+	}
+}
+
+// AddSamples adds the next decoded samples to the waveform.
+func (b *Builder) AddSamples(samples []int16) {
+	for _, s := range samples {
+		bin := min(b.numSamples*Bins/b.totalSamples, Bins-1)
+		f := float64(s) / 32768
+		b.sumSquares[bin] += f * f
+		b.counts[bin]++
+		b.numSamples++
+	}
+}
+
+// Progress returns the fraction of the track analyzed so far.
+func (b *Builder) Progress() float64 {
+	return min(1, float64(b.numSamples)/float64(b.totalSamples))
+}
+
+// Waveform returns the waveform computed so far.
+func (b *Builder) Waveform() *Waveform {
+	w := &Waveform{Levels: make([]uint8, Bins)}
+	for i := range w.Levels {
+		w.Levels[i] = level(b.sumSquares[i], b.counts[i])
+	}
+	return w
+}
+
+// Finish returns the completed waveform. If the decoded audio was shorter
+// than the track duration, the waveform is stretched to fill all the bins.
+func (b *Builder) Finish() *Waveform {
+	used := (b.numSamples*Bins + b.totalSamples - 1) / b.totalSamples
+	if used == 0 || used >= Bins {
+		return b.Waveform()
+	}
+	w := &Waveform{Levels: make([]uint8, Bins)}
+	for i := range w.Levels {
+		first := int64(i) * used / Bins
+		last := max(first+1, int64(i+1)*used/Bins)
+		var sumSquares float64
+		var count int64
+		for j := first; j < last; j++ {
+			sumSquares += b.sumSquares[j]
+			count += b.counts[j]
+		}
+		w.Levels[i] = level(sumSquares, count)
+	}
+	return w
+}
+
+func level(sumSquares float64, count int64) uint8 {
+	if count == 0 {
+		return 0
+	}
+	// scaled so a full scale sine wave, with an RMS of 1/sqrt(2), is 255
+	return uint8(min(255, math.Round(math.Sqrt(2*sumSquares/float64(count))*255)))
+}
+
+// Write writes the waveform in the file format read by Read.
+func (w *Waveform) Write(wr io.Writer) error {
+	if _, err := io.WriteString(wr, fileMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(wr, binary.LittleEndian, uint16(len(w.Levels))); err != nil {
+		return err
+	}
+	_, err := wr.Write(w.Levels)
+	return err
+}
+
+// Read reads a waveform written by Write.
+func Read(r io.Reader) (*Waveform, error) {
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != fileMagic {
+		return nil, ErrInvalidFile
+	}
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, ErrInvalidFile
+	}
+	w := &Waveform{Levels: make([]uint8, n)}
+	if _, err := io.ReadFull(r, w.Levels); err != nil {
+		return nil, ErrInvalidFile
+	}
+	return w, nil
+}
+
+// Load reads the waveform stored in the file.
+func Load(path string) (*Waveform, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(bufio.NewReader(f))
+}
+
+// Save stores the waveform in the file, creating its directory if needed.
+func (w *Waveform) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,69 @@
+package waveform
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_Builder(t *testing.T) {
+	b := NewBuilder(2)
+	half := make([]int16, SampleRate)
+	// a full scale square wave in the first bin of 16 samples
+	for i := 0; i < 16; i++ {
+		half[i] = int16(32767 - i%2*65535)
+	}
+	b.AddSamples(half)
+	if p := b.Progress(); p != 0.5 {
+		t.Errorf("got progress %v, want 0.5", p)
+	}
+	w := b.Waveform()
+	if w.Levels[0] != 255 {
+		t.Errorf("got first level %d, want 255", w.Levels[0])
+	}
+	if slices.Max(w.Levels[1:]) != 0 {
+		t.Error("expected silence after the first bin")
+	}
+
+	loud := make([]int16, SampleRate)
+	for i := range loud {
+		loud[i] = 16384
+	}
+	b.AddSamples(loud)
+	w = b.Finish()
+	// RMS of 0.5 is 3 dB below a full scale sine wave
+	if w.Levels[Bins/2] != 180 || w.Levels[Bins-1] != 180 {
+		t.Errorf("got levels %d, %d; want 180", w.Levels[Bins/2], w.Levels[Bins-1])
+	}
+}
+
+func Test_FinishStretchesShortAudio(t *testing.T) {
+	b := NewBuilder(4)
+	samples := make([]int16, 2*SampleRate)
+	for i := range samples {
+		samples[i] = 16384
+	}
+	b.AddSamples(samples)
+	w := b.Finish()
+	if len(w.Levels) != Bins {
+		t.Fatalf("got %d bins, want %d", len(w.Levels), Bins)
+	}
+	if slices.Min(w.Levels) != 180 {
+		t.Errorf("expected the waveform to fill all bins, got min level %d", slices.Min(w.Levels))
+	}
+}
+
+func Test_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "waveforms", "1.waveform")
+	w := &Waveform{Levels: []uint8{0, 10, 255, 3}}
+	if err := w.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Levels, w.Levels) {
+		t.Errorf("got %v, want %v", got.Levels, w.Levels)
+	}
+}
@@ -0,0 +1,205 @@
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+	"github.com/dweymouth/supersonic/backend/waveform"
+	"github.com/google/uuid"
+)
+
+const (
+	waveformUpdateInterval        = 250 * time.Millisecond
+	waveformFileSuffix            = ".waveform"
+	defaultWaveformCacheSizeBytes = 20 * 1_048_576
+)
+
+// WaveformManager computes the waveforms of tracks shown by the waveform seek bar,
+// and caches them on disk in the server's cache directory, next to the cover cache.
+// Like the cover cache, the on-disk cache is kept under a maximum size by deleting
+// the least recently used waveforms.
+type WaveformManager struct {
+	s            *ServerManager
+	baseCacheDir string
+
+	maxOnDiskCacheSizeBytes int64
+}
+
+func NewWaveformManager(s *ServerManager, baseCacheDir string) *WaveformManager {
+	return &WaveformManager{
+		s:                       s,
+		baseCacheDir:            baseCacheDir,
+		maxOnDiskCacheSizeBytes: defaultWaveformCacheSizeBytes,
+	}
+}
+
+// GetWaveformAsync loads the waveform of the track from the disk cache, or computes it
+// from the track's audio. The callback is invoked with the waveform computed so far and
+// the fraction of the track it covers each time it progresses, until it reaches 1.
+// It returns a context.CancelFunc which stops the computation. The callback will not be
+// invoked after cancellation. The cancel func must be invoked to avoid resource leaks.
+func (w *WaveformManager) GetWaveformAsync(track *mediaprovider.Track, cb func(*waveform.Waveform, float64)) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		err := w.getWaveform(ctx, track, func(wf *waveform.Waveform, progress float64) {
+			if ctx.Err() == nil {
+				cb(wf, progress)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("error computing waveform: %s", err.Error())
+		}
+	}()
+	return cancel
+}
+
+func (w *WaveformManager) getWaveform(ctx context.Context, track *mediaprovider.Track, cb func(*waveform.Waveform, float64)) error {
+	serverID, mp := w.s.ServerID, w.s.Server
+	if serverID == uuid.Nil || mp == nil {
+		return errors.New("not logged in")
+	}
+	path := filepath.Join(w.baseCacheDir, serverID.String(), "waveforms", track.ID+waveformFileSuffix)
+	if wf, err := waveform.Load(path); err == nil {
+		// the modification time is used as the last access time when pruning the cache
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		cb(wf, 1)
+		return nil
+	}
+	if track.Duration <= 0 {
+		return errors.New("unknown track duration")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// decode straight from the stream, so the waveform is drawn
+	// progressively while the track is fetched from the server
+	url, err := mp.GetStreamURL(track.ID, true /*forceRaw*/)
+	if err != nil {
+		return err
+	}
+	pcmPath := path + ".pcm"
+	defer os.Remove(pcmPath)
+	decodeErr := make(chan error, 1)
+	go func() {
+		decodeErr <- mpv.DecodeToPCMFile(ctx, url, pcmPath, waveform.SampleRate)
+	}()
+
+	b := waveform.NewBuilder(track.Duration)
+	pcm := &pcmReader{path: pcmPath}
+	defer pcm.Close()
+	t := time.NewTicker(waveformUpdateInterval)
+	defer t.Stop()
+	for {
+		select {
+		case err := <-decodeErr:
+			if err != nil {
+				return err
+			}
+			// read the samples written after the last update
+			if err := pcm.ReadSamples(b); err != nil {
+				return err
+			}
+			wf := b.Finish()
+			cb(wf, 1)
+			if err := wf.Save(path); err != nil {
+				return err
+			}
+			w.pruneOnDiskCache()
+			return nil
+		case <-t.C:
+			if err := pcm.ReadSamples(b); err != nil {
+				return err
+			}
+			cb(b.Waveform(), b.Progress())
+		}
+	}
+}
+
+// deletes the least recently used waveforms (across servers)
+// until the cache is under the maximum size
+func (w *WaveformManager) pruneOnDiskCache() {
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []fileInfo
+	var totalSize int64
+	filepath.WalkDir(w.baseCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, waveformFileSuffix) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, fileInfo{path: path, size: info.Size(), modTime: info.ModTime().UnixMilli()})
+			totalSize += info.Size()
+		}
+		return nil
+	})
+
+	if totalSize > w.maxOnDiskCacheSizeBytes {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime < files[j].modTime
+		})
+		for i := 0; i < len(files) && totalSize > w.maxOnDiskCacheSizeBytes; i++ {
+			if err := os.Remove(files[i].path); err == nil {
+				totalSize -= files[i].size
+			}
+		}
+	}
+}
+
+// pcmReader reads the 16-bit samples appended to a PCM file while it is being written.
+type pcmReader struct {
+	path string
+	f    *os.File
+	buf  []byte
+	rem  []byte // incomplete sample left from the last read
+}
+
+func (p *pcmReader) ReadSamples(b *waveform.Builder) error {
+	if p.f == nil {
+		f, err := os.Open(p.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // decoder hasn't started writing yet
+		} else if err != nil {
+			return err
+		}
+		p.f = f
+		p.buf = make([]byte, 64*1024)
+	}
+	for {
+		n := copy(p.buf, p.rem)
+		m, err := p.f.Read(p.buf[n:])
+		n += m
+		samples := make([]int16, n/2)
+		for i := range samples {
+			samples[i] = int16(binary.LittleEndian.Uint16(p.buf[2*i:]))
+		}
+		b.AddSamples(samples)
+		p.rem = append(p.rem[:0], p.buf[len(samples)*2:n]...)
+		if err == io.EOF || m == 0 {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *pcmReader) Close() {
+	if p.f != nil {
+		p.f.Close()
+	}
+}
@@ -0,0 +1,38 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_PruneWaveformCache(t *testing.T) {
+	dir := t.TempDir()
+	w := &WaveformManager{baseCacheDir: dir, maxOnDiskCacheSizeBytes: 25}
+	now := time.Now()
+	var paths []string
+	// three 10-byte waveforms on two servers, from least to most recently used,
+	// and a cover which is not counted or pruned
+	for i, name := range []string{"a/waveforms/1.waveform", "b/waveforms/2.waveform", "a/waveforms/3.waveform", "a/covers/1.jpg"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, 10), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+
+	w.pruneOnDiskCache()
+	for i, wantExists := range []bool{false, true, true, true} {
+		if _, err := os.Stat(paths[i]); (err == nil) != wantExists {
+			t.Errorf("%s: got exists %v, want %v", paths[i], err == nil, wantExists)
+		}
+	}
+}
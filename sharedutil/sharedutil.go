@@ -1,6 +1,9 @@
 package sharedutil
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
 
@@ -103,6 +106,14 @@ func TracksToIDs(tracks []*mediaprovider.Track) []string {
 	})
 }
 
+// DescribeTrack returns "Artist1, Artist2 - Title", or the title if the track has no artists.
+func DescribeTrack(tr *mediaprovider.Track) string {
+	if len(tr.ArtistNames) == 0 {
+		return tr.Title
+	}
+	return strings.Join(tr.ArtistNames, ", ") + " - " + tr.Title
+}
+
 // Reorder items and return a new track slice.
 // idxToMove must contain only valid indexes into tracks, and no repeats
 func ReorderItems[T any](items []T, idxToMove []int, insertIdx int) []T {
@@ -133,3 +144,65 @@ func ReorderItems[T any](items []T, idxToMove []int, insertIdx int) []T {
 
 	return newItems
 }
+
+// MergeLists performs a three-way merge of two lists which were both changed
+// from base. Items removed in theirs are removed from ours, and items added in
+// theirs are inserted into ours after the item that precedes them in theirs,
+// or at the end if they were appended. Otherwise the order of ours is kept.
+func MergeLists[T comparable](base, ours, theirs []T) []T {
+	count := func(items []T) map[T]int {
+		c := make(map[T]int, len(items))
+		for _, it := range items {
+			c[it]++
+		}
+		return c
+	}
+	baseCount, theirsCount := count(base), count(theirs)
+
+	removed := make(map[T]int)
+	for it, n := range baseCount {
+		if d := n - theirsCount[it]; d > 0 {
+			removed[it] = d
+		}
+	}
+	merged := make([]T, 0, len(ours))
+	for _, it := range ours {
+		if removed[it] > 0 {
+			removed[it]--
+			continue
+		}
+		merged = append(merged, it)
+	}
+
+	// the last occurrences of items that appear more often in theirs than in base were added
+	added := make([]bool, len(theirs))
+	lastKept := -1
+	for i := len(theirs) - 1; i >= 0; i-- {
+		it := theirs[i]
+		if theirsCount[it] > baseCount[it] {
+			added[i] = true
+			theirsCount[it]--
+		} else if lastKept < 0 {
+			lastKept = i
+		}
+	}
+	for i, it := range theirs {
+		if !added[i] {
+			continue
+		}
+		pos := 0
+		if i > lastKept {
+			pos = len(merged) // appended items stay at the end
+		} else if i > 0 {
+			pos = len(merged)
+			for j := len(merged) - 1; j >= 0; j-- {
+				if merged[j] == theirs[i-1] {
+					pos = j + 1
+					break
+				}
+			}
+		}
+		merged = slices.Insert(merged, pos, it)
+	}
+	return merged
+}
@@ -49,6 +49,21 @@ func Test_ReorderItems(t *testing.T) {
 	}
 }
 
+func Test_MergeLists(t *testing.T) {
+	base := []string{"a", "b", "c", "d"}
+	ours := []string{"d", "a", "b"}             // moved d to top, removed c
+	theirs := []string{"a", "x", "c", "d", "y"} // removed b, added x and y
+	want := []string{"d", "a", "x", "y"}
+	if got := MergeLists(base, ours, theirs); !slices.Equal(got, want) {
+		t.Errorf("MergeLists: got %v, want %v", got, want)
+	}
+
+	// no changes on their side keeps ours
+	if got := MergeLists(base, ours, base); !slices.Equal(got, ours) {
+		t.Errorf("MergeLists: got %v, want %v", got, ours)
+	}
+}
+
 func tracklistsEqual(t *testing.T, a, b []*mediaprovider.Track) bool {
 	t.Helper()
 	return slices.EqualFunc(a, b, func(a, b *mediaprovider.Track) bool {
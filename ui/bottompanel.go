@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/waveform"
 	"github.com/dweymouth/supersonic/ui/controller"
 	"github.com/dweymouth/supersonic/ui/layouts"
 	"github.com/dweymouth/supersonic/ui/util"
@@ -18,6 +19,11 @@ type BottomPanel struct {
 
 	imageLoader util.ThumbnailLoader
 
+	pm             *backend.PlaybackManager
+	waveforms      *backend.WaveformManager
+	appConfig      *backend.AppConfig
+	cancelWaveform func()
+
 	NowPlaying  *widgets.NowPlayingCard
 	Controls    *widgets.PlayerControls
 	AuxControls *widgets.AuxControls
@@ -27,8 +33,8 @@ type BottomPanel struct {
 
 var _ fyne.Widget = (*BottomPanel)(nil)
 
-func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr *controller.Controller) *BottomPanel {
-	bp := &BottomPanel{}
+func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, wm *backend.WaveformManager, appConfig *backend.AppConfig, contr *controller.Controller) *BottomPanel {
+	bp := &BottomPanel{pm: pm, waveforms: wm, appConfig: appConfig}
 	bp.ExtendBaseWidget(bp)
 
 	pm.OnSongChange(bp.onSongChange)
@@ -101,6 +107,7 @@ func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr
 	bp.Controls.OnSeek(func(f float64) {
 		pm.SeekFraction(f)
 	})
+	pm.OnABLoopChange(bp.Controls.SetABLoop)
 
 	bp.AuxControls = widgets.NewAuxControls(pm.Volume())
 	pm.OnLoopModeChange(bp.AuxControls.SetLoopMode)
@@ -128,6 +135,28 @@ func (bp *BottomPanel) onSongChange(song mediaprovider.MediaItem, _ *mediaprovid
 		bp.NowPlaying.Update(song)
 		bp.imageLoader.Load(song.Metadata().CoverArtID)
 	}
+	bp.loadWaveform(song)
+}
+
+// ReloadWaveform shows or hides the waveform of the current track
+// after the waveform seek bar setting has changed.
+func (bp *BottomPanel) ReloadWaveform() {
+	bp.loadWaveform(bp.pm.NowPlaying())
+}
+
+func (bp *BottomPanel) loadWaveform(song mediaprovider.MediaItem) {
+	if bp.cancelWaveform != nil {
+		bp.cancelWaveform()
+		bp.cancelWaveform = nil
+	}
+	tr, ok := song.(*mediaprovider.Track)
+	if !ok || !bp.appConfig.ShowWaveformSeekBar {
+		bp.Controls.SetWaveform(nil, 0)
+		return
+	}
+	// draw a flat line until the waveform is computed
+	bp.Controls.SetWaveform(&waveform.Waveform{}, 0)
+	bp.cancelWaveform = bp.waveforms.GetWaveformAsync(tr, bp.Controls.SetWaveform)
 }
 
 func (bp *BottomPanel) CreateRenderer() fyne.WidgetRenderer {
@@ -253,6 +253,10 @@ func NewAlbumPageHeader(page *AlbumPage) *AlbumPageHeader {
 				a.page.contr.ShowDownloadDialog(a.page.tracks, a.titleLabel.String())
 			})
 			download.Icon = theme.DownloadIcon()
+			folderSync := fyne.NewMenuItem(lang.L("Add to Sync to Folder"), func() {
+				a.page.contr.AddAlbumToFolderSync(a.albumID, a.titleLabel.String())
+			})
+			folderSync.Icon = theme.FolderIcon()
 			info := fyne.NewMenuItem(lang.L("Show info")+"...", func() {
 				a.page.contr.ShowAlbumInfoDialog(a.albumID, a.titleLabel.String(), a.cover.Image())
 			})
@@ -261,7 +265,7 @@ func NewAlbumPageHeader(page *AlbumPage) *AlbumPageHeader {
 				a.page.contr.ShowShareDialog(a.albumID)
 			})
 			a.shareMenuItem.Icon = myTheme.ShareIcon
-			menu := fyne.NewMenu("", playNext, queue, playlist, download, info, a.shareMenuItem)
+			menu := fyne.NewMenu("", playNext, queue, playlist, download, folderSync, info, a.shareMenuItem)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
 		_, canShare := page.mp.(mediaprovider.SupportsSharing)
@@ -191,6 +191,12 @@ func (a *ArtistPage) showAlbumSortMenu() {
 	if !oneChecked {
 		m.Items[0].Checked = true
 	}
+	group := fyne.NewMenuItem(lang.L("Group by release type"), func() {
+		a.cfg.GroupDiscography = !a.cfg.GroupDiscography
+		a.showAlbumGrid(true /*reSort*/)
+	})
+	group.Checked = a.cfg.GroupDiscography
+	m.Items = append(m.Items, fyne.NewMenuItemSeparator(), group)
 	btnPos := fyne.CurrentApp().Driver().AbsolutePositionForObject(a.sortButton)
 	btnSize := a.sortButton.Size()
 	pop := widget.NewPopUpMenu(m, fyne.CurrentApp().Driver().CanvasForObject(a))
@@ -198,6 +204,48 @@ func (a *ArtistPage) showAlbumSortMenu() {
 	pop.ShowAtPosition(fyne.NewPos(btnPos.X+btnSize.Width-menuW, btnPos.Y+btnSize.Height))
 }
 
+// these strings should be keys in the translation dictionaries
+var discographySections = []string{"Albums", "EPs", "Singles", "Live", "Compilations"}
+
+// returns the index into discographySections of the section the album belongs in
+func discographySection(album *mediaprovider.Album) int {
+	switch rt := album.ReleaseTypes; {
+	case rt&mediaprovider.ReleaseTypeCompilation > 0:
+		return 4
+	case rt&mediaprovider.ReleaseTypeLive > 0:
+		return 3
+	case rt&mediaprovider.ReleaseTypeSingle > 0:
+		return 2
+	case rt&mediaprovider.ReleaseTypeEP > 0:
+		return 1
+	}
+	return 0
+}
+
+// Returns the sorted discography grouped into sections by release type,
+// or nil if grouping is disabled or all albums would be in the same section.
+func (a *ArtistPage) getGridViewAlbumSections() []widgets.GridViewSection {
+	if !a.cfg.GroupDiscography || a.artistInfo == nil {
+		return nil
+	}
+	model := a.getGridViewAlbumsModel() // sorts a.artistInfo.Albums
+	sections := make([]widgets.GridViewSection, len(discographySections))
+	for i, title := range discographySections {
+		sections[i].Title = lang.L(title)
+	}
+	for i, al := range a.artistInfo.Albums {
+		sec := discographySection(al)
+		sections[sec].Items = append(sections[sec].Items, model[i])
+	}
+	sections = sharedutil.FilterSlice(sections, func(s widgets.GridViewSection) bool {
+		return len(s.Items) > 0
+	})
+	if len(sections) < 2 {
+		return nil
+	}
+	return sections
+}
+
 func (a *ArtistPage) getGridViewAlbumsModel() []widgets.GridViewItemModel {
 	if a.artistInfo == nil {
 		return nil
@@ -260,24 +308,30 @@ func (a *ArtistPage) showAlbumGrid(reSort bool) {
 			a.activeView = 0 // if page still loading, will show discography view first
 			return
 		}
-		model := a.getGridViewAlbumsModel()
 		if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
 			a.albumGrid = g.(*widgets.GridView)
 			a.albumGrid.Placeholder = myTheme.AlbumIcon
-			a.albumGrid.ResetFixed(model)
 		} else {
-			a.albumGrid = widgets.NewFixedGridView(model, a.im, myTheme.AlbumIcon)
+			a.albumGrid = widgets.NewFixedGridView(nil, a.im, myTheme.AlbumIcon)
 		}
 		a.contr.ConnectAlbumGridActions(a.albumGrid)
+		a.resetAlbumGrid()
 	} else if reSort {
-		model := a.getGridViewAlbumsModel()
-		a.albumGrid.ResetFixed(model)
+		a.resetAlbumGrid()
 	}
 	a.sortButton.Show()
 	a.container.Objects[0].(*fyne.Container).Objects[0] = a.albumGrid
 	a.container.Objects[0].Refresh()
 }
 
+func (a *ArtistPage) resetAlbumGrid() {
+	if sections := a.getGridViewAlbumSections(); sections != nil {
+		a.albumGrid.ResetFixedSections(sections)
+	} else {
+		a.albumGrid.ResetFixed(a.getGridViewAlbumsModel())
+	}
+}
+
 func (a *ArtistPage) showTopTracks() {
 	if a.tracklistCtr == nil {
 		if a.artistInfo == nil {
@@ -42,6 +42,8 @@ type NowPlayingPage struct {
 	nowPlaying    mediaprovider.MediaItem
 	nowPlayingID  string
 	curLyricsID   string // id of track currently shown in lyrics
+	curLyricsSrc  string // source of the lyrics currently shown
+	curLyrics     *mediaprovider.Lyrics
 	curRelatedID  string // id of track currrently used to populate related list
 	totalTime     float64
 	lastPlayPos   float64
@@ -57,10 +59,13 @@ type NowPlayingPage struct {
 	queueList      *widgets.PlayQueueList
 	relatedList    *widgets.PlayQueueList
 	lyricsViewer   *widgets.LyricsViewer
+	lyricsEditor   *widgets.LyricsSyncEditor
+	lyricsPane     *fyne.Container
 	card           *widgets.LargeNowPlayingCard
 	statusLabel    *widget.Label
 	tabs           *container.AppTabs
 	lyricsLoading  *widgets.LoadingDots
+	lyricsSrcBtn   *widget.Button
 	relatedLoading *widgets.LoadingDots
 	container      *fyne.Container
 
@@ -80,7 +85,7 @@ type nowPlayingPageState struct {
 	mp       mediaprovider.MediaProvider
 	canRate  bool
 	canShare bool
-	lrcLib   bool
+	lm       *backend.LyricsManager
 }
 
 func NewNowPlayingPage(
@@ -93,10 +98,10 @@ func NewNowPlayingPage(
 	mp mediaprovider.MediaProvider,
 	canRate bool,
 	canShare bool,
-	lrcLibEnabled bool,
+	lm *backend.LyricsManager,
 ) *NowPlayingPage {
 	state := nowPlayingPageState{
-		conf: conf, contr: contr, pool: pool, sm: sm, im: im, pm: pm, mp: mp, canRate: canRate, canShare: canShare, lrcLib: lrcLibEnabled,
+		conf: conf, contr: contr, pool: pool, sm: sm, im: im, pm: pm, mp: mp, canRate: canRate, canShare: canShare, lm: lm,
 	}
 	if page, ok := pool.Obtain(util.WidgetTypeNowPlayingPage).(*NowPlayingPage); ok && page != nil {
 		page.nowPlayingPageState = state
@@ -170,6 +175,15 @@ func NewNowPlayingPage(
 		a.pm.LoadItems(items, backend.InsertNext, false)
 	}
 	a.lyricsViewer = widgets.NewLyricsViewer()
+	a.lyricsEditor = widgets.NewLyricsSyncEditor()
+	a.lyricsEditor.PlayPos = func() float64 {
+		return a.pm.CurrentPlayer().GetStatus().TimePos
+	}
+	a.lyricsEditor.OnSeek = func(secs float64) {
+		_ = a.pm.SeekSeconds(secs)
+	}
+	a.lyricsEditor.OnCancel = a.stopLyricsSync
+	a.lyricsEditor.Hide()
 	a.statusLabel = widget.NewLabel(lang.L("Stopped"))
 
 	a.Reload()
@@ -190,12 +204,19 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 			TopBottomObjectPercent: .8,
 		}
 		a.lyricsLoading = widgets.NewLoadingDots()
+		a.lyricsSrcBtn = widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), a.showLyricsSourceMenu)
+		a.lyricsSrcBtn.Importance = widget.LowImportance
+		a.lyricsSrcBtn.IconPlacement = widget.ButtonIconTrailingText
+		a.lyricsSrcBtn.Hide()
 		a.relatedLoading = widgets.NewLoadingDots()
+		a.lyricsPane = container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), a.lyricsSrcBtn), nil, nil,
+			a.lyricsViewer)
 		a.tabs = container.NewAppTabs(
 			container.NewTabItem(lang.L("Play Queue"),
 				container.NewBorder(layout.NewSpacer(), nil, nil, nil, a.queueList)),
 			container.NewTabItem(lang.L("Lyrics"), container.NewStack(
-				a.lyricsViewer,
+				a.lyricsPane,
+				a.lyricsEditor,
 				container.NewCenter(a.lyricsLoading))),
 			container.NewTabItem(lang.L("Related"), container.NewStack(
 				a.relatedList,
@@ -339,6 +360,7 @@ func (a *NowPlayingPage) updateLyrics() {
 	if a.nowPlaying == nil || a.nowPlaying.Metadata().Type == mediaprovider.MediaItemTypeRadioStation {
 		a.lyricsViewer.SetLyrics(nil)
 		a.curLyricsID = ""
+		a.lyricsSrcBtn.Hide()
 		return
 	}
 	a.curLyricsID = a.nowPlayingID
@@ -354,33 +376,129 @@ func (a *NowPlayingPage) updateLyrics() {
 }
 
 func (a *NowPlayingPage) fetchLyrics(ctx context.Context, song *mediaprovider.Track) {
-	var lyrics *mediaprovider.Lyrics
-	var err error
-	if lp, ok := a.sm.Server.(mediaprovider.LyricsProvider); ok {
-		if lyrics, err = lp.GetLyrics(song); err != nil {
-			log.Printf("Error fetching lyrics: %v", err)
-		}
-	}
-	if lyrics == nil {
-		lyrics, err = backend.FetchLrcLibLyrics(song.Title, song.ArtistNames[0], song.Album, song.Duration)
-		if err != nil {
-			log.Println(err.Error())
-		}
-	}
+	res := a.lm.GetLyrics(song)
 	select {
 	case <-ctx.Done():
 		return
 	default:
 		a.lyricLock.Lock()
 		a.lyricsLoading.Stop()
-		a.lyricsViewer.SetLyrics(lyrics)
-		if lyrics != nil {
-			a.lyricsViewer.OnSeeked(a.lastPlayPos)
+		if res == nil {
+			a.setLyrics(nil, "")
+		} else {
+			a.setLyrics(res.Lyrics, res.Source)
 		}
 		a.lyricLock.Unlock()
 	}
 }
 
+// must be called with lyricLock held
+func (a *NowPlayingPage) setLyrics(lyrics *mediaprovider.Lyrics, source string) {
+	a.curLyricsSrc = source
+	a.curLyrics = lyrics
+	a.lyricsViewer.SetLyrics(lyrics)
+	if lyrics != nil {
+		a.lyricsViewer.OnSeeked(a.lastPlayPos)
+		a.lyricsSrcBtn.SetText(fmt.Sprintf("%s: %s", lang.L("Lyrics source"), lang.L(source)))
+	} else {
+		a.lyricsSrcBtn.SetText(lang.L("Find lyrics"))
+	}
+	a.lyricsSrcBtn.Show()
+}
+
+// showLyricsSourceMenu queries all lyrics sources for the current
+// track and lets the user choose which lyrics to show
+func (a *NowPlayingPage) showLyricsSourceMenu() {
+	tr, ok := a.nowPlaying.(*mediaprovider.Track)
+	if !ok {
+		return
+	}
+	a.lyricsSrcBtn.Disable()
+	go func() {
+		results := a.lm.GetAllLyrics(tr)
+		a.lyricsSrcBtn.Enable()
+		if a.nowPlayingID != tr.ID {
+			return // track changed while fetching
+		}
+
+		var items []*fyne.MenuItem
+		for _, r := range results {
+			r := r
+			label := lang.L(r.Source)
+			if r.Lyrics.Synced {
+				label = fmt.Sprintf("%s (%s)", label, lang.L("synced"))
+			}
+			item := fyne.NewMenuItem(label, func() {
+				a.lm.SetChosenLyrics(tr.ID, r)
+				a.lyricLock.Lock()
+				defer a.lyricLock.Unlock()
+				if a.nowPlayingID == tr.ID {
+					a.setLyrics(r.Lyrics, r.Source)
+				}
+			})
+			item.Checked = r.Source == a.curLyricsSrc
+			items = append(items, item)
+		}
+		if len(results) == 0 {
+			noLyrics := fyne.NewMenuItem(lang.L("No lyrics found"), nil)
+			noLyrics.Disabled = true
+			items = append(items, noLyrics)
+		}
+		syncItem := fyne.NewMenuItem(lang.L("Sync lyrics")+"...", func() {
+			a.startLyricsSync(tr)
+		})
+		syncItem.Disabled = a.curLyrics == nil
+		items = append(items, fyne.NewMenuItemSeparator(), syncItem,
+			fyne.NewMenuItem(lang.L("Fetch again"), func() {
+				a.lm.ClearCachedLyrics(tr.ID)
+				a.curLyricsID = ""
+				a.updateLyrics()
+			}))
+
+		pop := widget.NewPopUpMenu(fyne.NewMenu("", items...),
+			fyne.CurrentApp().Driver().CanvasForObject(a.lyricsSrcBtn))
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(a.lyricsSrcBtn)
+		menuSize := pop.MinSize()
+		pop.ShowAtPosition(fyne.NewPos(
+			pos.X+a.lyricsSrcBtn.Size().Width-menuSize.Width, pos.Y-menuSize.Height))
+	}()
+}
+
+// startLyricsSync replaces the lyrics viewer with the sync editor
+// for the lyrics currently shown
+func (a *NowPlayingPage) startLyricsSync(tr *mediaprovider.Track) {
+	a.lyricLock.Lock()
+	lyrics := a.curLyrics
+	a.lyricLock.Unlock()
+	if lyrics == nil {
+		return
+	}
+	a.lyricsEditor.OnSave = func(edited *mediaprovider.Lyrics, publish bool) {
+		if err := a.lm.SaveUserLyrics(tr, edited); err != nil {
+			log.Printf("error saving synced lyrics: %s", err.Error())
+			return
+		}
+		a.stopLyricsSync()
+		a.lyricLock.Lock()
+		if a.nowPlayingID == tr.ID {
+			a.setLyrics(edited, backend.LyricsSourceFolder)
+		}
+		a.lyricLock.Unlock()
+		if publish {
+			a.contr.DoPublishLyricsWorkflow(tr, edited)
+		}
+	}
+	a.lyricsEditor.SetLyrics(lyrics)
+	a.lyricsPane.Hide()
+	a.lyricsEditor.Show()
+	fyne.CurrentApp().Driver().CanvasForObject(a).Focus(a.lyricsEditor)
+}
+
+func (a *NowPlayingPage) stopLyricsSync() {
+	a.lyricsEditor.Hide()
+	a.lyricsPane.Show()
+}
+
 func (a *NowPlayingPage) updateRelatedList() {
 	a.relatedLock.Lock()
 	defer a.relatedLock.Unlock()
@@ -450,7 +568,7 @@ func (a *NowPlayingPage) Reload() {
 }
 
 func (s *nowPlayingPageState) Restore() Page {
-	return NewNowPlayingPage(s.conf, s.contr, s.pool, s.sm, s.im, s.pm, s.mp, s.canRate, s.canShare, s.lrcLib)
+	return NewNowPlayingPage(s.conf, s.contr, s.pool, s.sm, s.im, s.pm, s.mp, s.canRate, s.canShare, s.lm)
 }
 
 var _ CanShowPlayTime = (*NowPlayingPage)(nil)
@@ -586,5 +704,15 @@ func (a *NowPlayingPage) formatMediaInfoStr(player player.BasePlayer) string {
 
 	// Note: bit depth intentionally omitted since MPV reports the decoded bit depth
 	// i.e. 24 bit files get reported as 32 bit. Also b/c bit depth isn't meaningful for lossy.
-	return fmt.Sprintf("%s %g kHz, %d kbps", codec, float64(audioInfo.Samplerate)/1000, audioInfo.Bitrate/1000)
+	info := fmt.Sprintf("%s %g kHz, %d kbps", codec, float64(audioInfo.Samplerate)/1000, audioInfo.Bitrate/1000)
+
+	// show the decoded format versus what is sent to the audio device,
+	// to make any resampling or sample format conversion visible
+	outInfo, err := mpv.GetOutputInfo()
+	if err != nil {
+		return info
+	}
+	return fmt.Sprintf("%s · %s %g kHz → %s %g kHz", info,
+		audioInfo.Format, float64(audioInfo.Samplerate)/1000,
+		outInfo.Format, float64(outInfo.Samplerate)/1000)
 }
@@ -1,8 +1,11 @@
 package browsing
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"slices"
 
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
@@ -31,6 +34,14 @@ type PlaylistPage struct {
 	tracks       []*mediaprovider.Track
 	nowPlayingID string
 	container    *fyne.Container
+	// the last edit queued to be written to the server
+	lastEdit *playlistEdit
+}
+
+type playlistEdit struct {
+	// closed once the edit has been written or has failed
+	done   chan struct{}
+	failed bool
 }
 
 type playlistPageState struct {
@@ -192,12 +203,10 @@ func (a *PlaylistPage) doSetNewTrackOrder(ids []string, newPos int) {
 	}
 	newTracks := sharedutil.ReorderItems(a.tracks, idxs, newPos)
 	// we can't block the UI waiting for the server so assume it will succeed
-	go func() {
-		ids = sharedutil.TracksToIDs(newTracks)
-		if err := a.sm.Server.ReplacePlaylistTracks(a.playlistID, ids); err != nil {
-			log.Printf("error updating playlist: %s", err.Error())
-		}
-	}()
+	base := sharedutil.TracksToIDs(a.tracks)
+	a.queueEdit(func() error {
+		return backend.SafeReplacePlaylistTracks(a.sm.Server, a.playlistID, base, sharedutil.TracksToIDs(newTracks))
+	})
 
 	renumberTracks(newTracks)
 	// force-switch back to unsorted view to show new track order
@@ -216,9 +225,114 @@ func (a *PlaylistPage) onRemoveSelectedFromPlaylist() {
 			idxs = append(idxs, i)
 		}
 	}
-	a.sm.Server.RemovePlaylistTracks(a.playlistID, idxs)
+	base := sharedutil.TracksToIDs(a.tracks)
 	a.tracklist.UnselectAll()
-	a.Reload()
+	a.queueEdit(func() error {
+		if err := backend.SafeRemovePlaylistTracks(a.sm.Server, a.playlistID, base, idxs); err != nil {
+			return err
+		}
+		a.load()
+		return nil
+	})
+}
+
+// shows a preview of removing the tracks at the given indexes from the playlist,
+// and the entries not listed by the server, which saving the tracks drops
+func (a *PlaylistPage) previewRemoval(title string, idxs []int, unlisted int) {
+	if len(idxs) == 0 && unlisted == 0 {
+		a.contr.ShowPlaylistEditPreview(title, lang.L("No tracks to remove were found."), nil, nil)
+		return
+	}
+	newTracks := make([]*mediaprovider.Track, 0, len(a.tracks)-len(idxs))
+	removed := make([]string, 0, len(idxs))
+	for i, tr := range a.tracks {
+		if slices.Contains(idxs, i) {
+			removed = append(removed, fmt.Sprintf("%d. %s", i+1, sharedutil.DescribeTrack(tr)))
+		} else {
+			newTracks = append(newTracks, tr)
+		}
+	}
+	if unlisted > 0 {
+		removed = append(removed, fmt.Sprintf("%s: %d", lang.L("Entries no longer listed by the server"), unlisted))
+	}
+	summary := fmt.Sprintf("%s: %d", lang.L("Tracks to remove"), len(idxs)+unlisted)
+	a.contr.ShowPlaylistEditPreview(title, summary, removed, func() { a.saveTracks(newTracks) })
+}
+
+// shows a preview of saving the playlist's tracks in a new order
+func (a *PlaylistPage) previewReorder(title string, newTracks []*mediaprovider.Track) {
+	items := make([]string, len(newTracks))
+	for i, tr := range newTracks {
+		items[i] = fmt.Sprintf("%d. %s", i+1, sharedutil.DescribeTrack(tr))
+	}
+	summary := lang.L("The playlist will be saved in this order.")
+	a.contr.ShowPlaylistEditPreview(title, summary, items, func() { a.saveTracks(newTracks) })
+}
+
+// replaces the playlist's tracks on the server and reloads the page
+func (a *PlaylistPage) saveTracks(newTracks []*mediaprovider.Track) {
+	base := sharedutil.TracksToIDs(a.tracks)
+	a.queueEdit(func() error {
+		err := backend.SafeReplacePlaylistTracks(a.sm.Server, a.playlistID, base, sharedutil.TracksToIDs(newTracks))
+		if err != nil {
+			return err
+		}
+		a.tracklist.SetSorting(widgets.TracklistSort{})
+		a.load()
+		return nil
+	})
+}
+
+// writes the edit to the server in the background, once the edits queued before it
+// have been written. The base of each edit is the page's track list, which a reorder
+// updates before it is written, so the edits must be written in order to not conflict.
+// Edits queued after one that failed are dropped, as their base was never written.
+func (a *PlaylistPage) queueEdit(edit func() error) {
+	prev := a.lastEdit
+	e := &playlistEdit{done: make(chan struct{})}
+	a.lastEdit = e
+	go func() {
+		defer close(e.done)
+		if prev != nil {
+			<-prev.done
+			if prev.failed {
+				e.failed = true
+				return
+			}
+		}
+		if err := edit(); err != nil {
+			e.failed = true
+			a.handleEditError(err)
+		}
+	}()
+}
+
+// handles the result of saving an edit to the playlist. If the playlist was changed
+// on the server in the meantime, asks the user how to resolve the conflict.
+func (a *PlaylistPage) handleEditError(err error) {
+	var conflict *backend.PlaylistConflictError
+	if !errors.As(err, &conflict) {
+		if err != nil {
+			log.Printf("error updating playlist: %s", err.Error())
+		}
+		return
+	}
+	a.contr.ShowPlaylistConflictDialog(conflict, func(merge bool) {
+		// the resolution starts from the server version, not from the failed edits
+		a.lastEdit = nil
+		if !merge {
+			a.Reload()
+			return
+		}
+		a.queueEdit(func() error {
+			serverIDs := sharedutil.TracksToIDs(conflict.Server.Tracks)
+			if err := backend.SafeReplacePlaylistTracks(a.sm.Server, a.playlistID, serverIDs, conflict.Merged); err != nil {
+				return err
+			}
+			a.load()
+			return nil
+		})
+	})
 }
 
 type PlaylistPageHeader struct {
@@ -227,6 +341,8 @@ type PlaylistPageHeader struct {
 	page         *PlaylistPage
 	playlistInfo *mediaprovider.PlaylistWithTracks
 	image        *widgets.ImagePlaceholder
+	// "Save current sort order" item of the playlist tools menu
+	saveSortItem *fyne.MenuItem
 
 	editButton       *widget.Button
 	titleLabel       *widget.RichText
@@ -268,6 +384,7 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 		a.page.pm.PlayFromBeginning()
 	})
 	var pop *widget.PopUpMenu
+	var tools *fyne.MenuItem
 	menuBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
 	menuBtn.OnTapped = func() {
 		if pop == nil {
@@ -288,9 +405,26 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 				a.page.contr.ShowDownloadDialog(a.page.tracks, a.titleLabel.String())
 			})
 			download.Icon = theme.DownloadIcon()
-			menu := fyne.NewMenu("", playNext, queue, playlist, download)
+			export := fyne.NewMenuItem(lang.L("Export")+"...", func() {
+				if a.playlistInfo != nil {
+					pl := *a.playlistInfo
+					pl.Tracks = a.page.tracks
+					a.page.contr.ShowExportPlaylistDialog(&pl)
+				}
+			})
+			export.Icon = theme.DocumentSaveIcon()
+			moveToFolder := fyne.NewMenuItem(lang.L("Move to folder")+"...", func() {
+				if a.playlistInfo != nil {
+					a.page.contr.ShowMoveToFolderDialog(&a.playlistInfo.Playlist)
+				}
+			})
+			moveToFolder.Icon = theme.FolderIcon()
+			tools = a.newToolsMenuItem()
+			menu := fyne.NewMenu("", playNext, queue, playlist, download, export, moveToFolder, tools)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
+		a.updateToolsMenuItem(tools)
+		pop.Refresh()
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
 		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
 	}
@@ -342,6 +476,47 @@ func (a *PlaylistPageHeader) Update(playlist *mediaprovider.PlaylistWithTracks)
 	a.Refresh()
 }
 
+// creates the submenu of tools for cleaning up and reordering the playlist
+func (a *PlaylistPageHeader) newToolsMenuItem() *fyne.MenuItem {
+	dedupeID := fyne.NewMenuItem(lang.L("Remove duplicate tracks"), func() {
+		a.page.previewRemoval(lang.L("Remove duplicate tracks"),
+			backend.FindDuplicatePlaylistTracks(a.page.tracks, false), 0)
+	})
+	dedupeArtistTitle := fyne.NewMenuItem(lang.L("Remove duplicates by artist and title"), func() {
+		a.page.previewRemoval(lang.L("Remove duplicates by artist and title"),
+			backend.FindDuplicatePlaylistTracks(a.page.tracks, true), 0)
+	})
+	broken := fyne.NewMenuItem(lang.L("Find broken entries")+"...", func() {
+		if a.playlistInfo == nil {
+			return
+		}
+		// the tracks in their current order, which may have been edited since loading
+		playlist := *a.playlistInfo
+		playlist.Tracks = a.page.tracks
+		a.page.contr.DoFindBrokenPlaylistTracksWorkflow(&playlist, func(b backend.BrokenPlaylistTracks) {
+			a.page.previewRemoval(lang.L("Remove broken entries"), b.Idxs, b.Unlisted)
+		})
+	})
+	a.saveSortItem = fyne.NewMenuItem(lang.L("Save current sort order"), func() {
+		a.page.previewReorder(lang.L("Save current sort order"), a.page.tracklist.GetTracks())
+	})
+	shuffle := fyne.NewMenuItem(lang.L("Save shuffled order"), func() {
+		tracks := slices.Clone(a.page.tracks)
+		rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+		a.page.previewReorder(lang.L("Save shuffled order"), tracks)
+	})
+	tools := fyne.NewMenuItem(lang.L("Playlist tools"), nil)
+	tools.Icon = theme.SettingsIcon()
+	tools.ChildMenu = fyne.NewMenu("", dedupeID, dedupeArtistTitle, broken, fyne.NewMenuItemSeparator(), a.saveSortItem, shuffle)
+	return tools
+}
+
+// enables the playlist tools if the user can edit the playlist
+func (a *PlaylistPageHeader) updateToolsMenuItem(tools *fyne.MenuItem) {
+	tools.Disabled = a.playlistInfo == nil || a.playlistInfo.Owner != a.page.sm.LoggedInUser
+	a.saveSortItem.Disabled = a.page.tracklist.Sorting().SortOrder == widgets.SortNone
+}
+
 func (a *PlaylistPageHeader) formatPlaylistOwnerStr(p *mediaprovider.PlaylistWithTracks) string {
 	pubPriv := lang.L("Public playlist by")
 	if !p.Public {
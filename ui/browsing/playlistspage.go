@@ -2,6 +2,7 @@ package browsing
 
 import (
 	"fmt"
+	"image/color"
 	"log"
 	"sort"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/playlistfolders"
 	"github.com/dweymouth/supersonic/res"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/controller"
@@ -18,6 +20,7 @@ import (
 	"github.com/dweymouth/supersonic/ui/widgets"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
@@ -32,27 +35,31 @@ type PlaylistsPage struct {
 	cfg               *backend.PlaylistsPageConfig
 	contr             *controller.Controller
 	mp                mediaprovider.MediaProvider
+	spm               *backend.SmartPlaylistManager
+	folders           *backend.PlaylistFolderManager
 	playlists         []*mediaprovider.Playlist
 	searchedPlaylists []*mediaprovider.Playlist
 
-	viewToggle *widgets.ToggleButtonGroup
-	searcher   *widgets.SearchEntry
-	titleDisp  *widget.RichText
-	container  *fyne.Container
-	listView   *PlaylistList
-	listSort   widgets.ListHeaderSort
-	gridView   *widgets.GridView
+	viewToggle  *widgets.ToggleButtonGroup
+	newSmartBtn *widget.Button
+	importBtn   *widget.Button
+	searcher    *widgets.SearchEntry
+	titleDisp   *widget.RichText
+	container   *fyne.Container
+	listView    *PlaylistList
+	listSort    widgets.ListHeaderSort
+	gridView    *widgets.GridView
 
 	initialListScrollPos float32
 	initialGridScrollPos float32
 }
 
-func NewPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *backend.PlaylistsPageConfig, mp mediaprovider.MediaProvider) *PlaylistsPage {
+func NewPlaylistsPage(contr *controller.Controller, pool *util.WidgetPool, cfg *backend.PlaylistsPageConfig, mp mediaprovider.MediaProvider, spm *backend.SmartPlaylistManager) *PlaylistsPage {
 	activeView := 0
 	if cfg.InitialView == "Grid" {
 		activeView = 1
 	}
-	return newPlaylistsPage(contr, pool, cfg, mp, "", activeView, widgets.ListHeaderSort{}, 0, 0)
+	return newPlaylistsPage(contr, pool, cfg, mp, spm, "", activeView, widgets.ListHeaderSort{}, 0, 0)
 }
 
 func newPlaylistsPage(
@@ -60,6 +67,7 @@ func newPlaylistsPage(
 	pool *util.WidgetPool,
 	cfg *backend.PlaylistsPageConfig,
 	mp mediaprovider.MediaProvider,
+	spm *backend.SmartPlaylistManager,
 	searchText string,
 	activeView int,
 	listSort widgets.ListHeaderSort,
@@ -70,6 +78,8 @@ func newPlaylistsPage(
 		pool:                 pool,
 		cfg:                  cfg,
 		mp:                   mp,
+		spm:                  spm,
+		folders:              contr.App.PlaylistFolders,
 		contr:                contr,
 		listSort:             listSort,
 		titleDisp:            widget.NewRichTextWithText(lang.L("Playlists")),
@@ -86,6 +96,10 @@ func newPlaylistsPage(
 		widget.NewButtonWithIcon("", theme.NewThemedResource(res.ResListSvg), a.showListView),
 		widget.NewButtonWithIcon("", theme.NewThemedResource(res.ResGridSvg), a.showGridView))
 	a.viewToggle.SetActivatedButton(activeView)
+	a.newSmartBtn = widget.NewButtonWithIcon(lang.L("New smart playlist"), theme.ContentAddIcon(), func() {
+		a.contr.DoEditSmartPlaylistWorkflow(nil)
+	})
+	a.importBtn = widget.NewButtonWithIcon(lang.L("Import"), theme.FolderOpenIcon(), a.contr.DoImportPlaylistWorkflow)
 	if activeView == 0 {
 		a.createListView()
 		a.buildContainer(a.listView)
@@ -113,54 +127,116 @@ func (a *PlaylistsPage) load(searchOnLoad bool) {
 	if err != nil {
 		log.Printf("error loading playlists: %v", err.Error())
 	}
-	a.playlists = playlists
+	a.playlists = append(a.smartPlaylists(), playlists...)
 	if searchOnLoad {
 		a.onSearched(a.searcher.Entry.Text)
 	} else {
-		a.refreshView(playlists)
+		a.refreshView(a.playlists)
+	}
+}
+
+// returns the user's smart playlists in the form of
+// playlist models to be shown alongside the server playlists
+func (a *PlaylistsPage) smartPlaylists() []*mediaprovider.Playlist {
+	return sharedutil.MapSlice(a.spm.GetSmartPlaylists(), func(pl *backend.SmartPlaylist) *mediaprovider.Playlist {
+		return &mediaprovider.Playlist{
+			ID:          pl.ID,
+			Name:        pl.Name,
+			Description: util.DescribeSmartPlaylist(pl),
+			Owner:       lang.L("Smart playlist"),
+		}
+	})
+}
+
+// loads the tracks of either a server playlist or a smart playlist
+func (a *PlaylistsPage) loadPlaylistTracks(id string) ([]*mediaprovider.Track, string, error) {
+	if backend.IsSmartPlaylistID(id) {
+		pl := a.spm.GetSmartPlaylist(id)
+		if pl == nil {
+			return nil, "", backend.ErrSmartPlaylistNotFound
+		}
+		tracks, err := a.spm.Evaluate(pl)
+		return tracks, pl.Name, err
+	}
+	pl, err := a.mp.GetPlaylist(id)
+	if err != nil {
+		return nil, "", err
 	}
+	return pl.Tracks, pl.Name, nil
 }
 
 func (a *PlaylistsPage) createListView() {
-	a.listView = NewPlaylistList(a.listSort)
+	a.listView = NewPlaylistList(a.listSort, a.folders)
 	a.listView.OnNavTo = a.showPlaylistPage
+	a.listView.OnFoldersChanged = func() { a.refreshView(a.shownPlaylists()) }
 }
 
 func (a *PlaylistsPage) createGridView(playlists []*mediaprovider.Playlist) {
-	model := createPlaylistGridViewModel(playlists)
 	if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
 		a.gridView = g.(*widgets.GridView)
 		a.gridView.Placeholder = myTheme.PlaylistIcon
-		a.gridView.ResetFixed(model)
 	} else {
-		a.gridView = widgets.NewFixedGridView(model, a.contr.App.ImageManager, myTheme.PlaylistIcon)
+		a.gridView = widgets.NewFixedGridView(nil, a.contr.App.ImageManager, myTheme.PlaylistIcon)
+	}
+	a.resetGridView(playlists)
+	a.gridView.OnSectionHeadingTapped = func(path string) {
+		a.folders.SetCollapsed(path, !a.folders.IsCollapsed(path))
+		offset := a.gridView.GetScrollOffset()
+		a.resetGridView(a.shownPlaylists())
+		a.gridView.ScrollToOffset(offset)
+		if a.listView != nil {
+			a.listView.SetPlaylists(a.shownPlaylists())
+		}
 	}
 	a.gridView.OnPlay = func(id string, shuffle bool) {
-		go a.contr.App.PlaybackManager.PlayPlaylist(id, 0, shuffle)
+		if !backend.IsSmartPlaylistID(id) {
+			go a.contr.App.PlaybackManager.PlayPlaylist(id, 0, shuffle)
+			return
+		}
+		go func() {
+			tracks, _, err := a.loadPlaylistTracks(id)
+			if err != nil {
+				log.Printf("error loading playlist: %s", err.Error())
+				return
+			}
+			a.contr.App.PlaybackManager.LoadTracks(tracks, backend.Replace, shuffle)
+			a.contr.App.PlaybackManager.PlayFromBeginning()
+		}()
 	}
 	a.gridView.OnAddToQueue = func(id string) {
-		go a.contr.App.PlaybackManager.LoadPlaylist(id, backend.Append, false)
+		if !backend.IsSmartPlaylistID(id) {
+			go a.contr.App.PlaybackManager.LoadPlaylist(id, backend.Append, false)
+			return
+		}
+		go func() {
+			tracks, _, err := a.loadPlaylistTracks(id)
+			if err != nil {
+				log.Printf("error loading playlist: %s", err.Error())
+				return
+			}
+			a.contr.App.PlaybackManager.LoadTracks(tracks, backend.Append, false)
+		}()
 	}
 	a.gridView.OnShowItemPage = a.showPlaylistPage
 	a.gridView.OnShowSecondaryPage = nil
 	a.gridView.OnAddToPlaylist = func(id string) {
 		go func() {
-			pl, err := a.contr.App.ServerManager.Server.GetPlaylist(id)
+			tracks, _, err := a.loadPlaylistTracks(id)
 			if err != nil {
 				log.Printf("error loading playlist: %s", err.Error())
 				return
 			}
-			a.contr.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(pl.Tracks))
+			a.contr.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(tracks))
 		}()
 	}
 	a.gridView.OnDownload = func(id string) {
 		go func() {
-			pl, err := a.contr.App.ServerManager.Server.GetPlaylist(id)
+			tracks, name, err := a.loadPlaylistTracks(id)
 			if err != nil {
 				log.Printf("error loading playlist: %s", err.Error())
 				return
 			}
-			a.contr.ShowDownloadDialog(pl.Tracks, pl.Name)
+			a.contr.ShowDownloadDialog(tracks, name)
 		}()
 	}
 }
@@ -169,11 +245,8 @@ func (a *PlaylistsPage) showListView() {
 	a.cfg.InitialView = "List" // save setting
 	if a.listView == nil {
 		a.createListView()
-		if a.searcher.Entry.Text != "" {
-			a.listView.SetPlaylists(a.searchedPlaylists)
-		} else {
-			a.listView.SetPlaylists(a.playlists)
-		}
+		a.listView.ExpandAllFolders = a.searcher.Entry.Text != ""
+		a.listView.SetPlaylists(a.shownPlaylists())
 	}
 	a.container.Objects[0].(*fyne.Container).Objects[0] = a.listView
 	a.container.Objects[0].Refresh()
@@ -182,18 +255,69 @@ func (a *PlaylistsPage) showListView() {
 func (a *PlaylistsPage) showGridView() {
 	a.cfg.InitialView = "Grid" // save setting
 	if a.gridView == nil {
-		playlists := a.playlists
-		if a.searcher.Entry.Text != "" {
-			playlists = a.searchedPlaylists
-		}
-		a.createGridView(playlists)
+		a.createGridView(a.shownPlaylists())
 	}
 	a.container.Objects[0].(*fyne.Container).Objects[0] = a.gridView
 	a.container.Objects[0].Refresh()
 }
 
-func createPlaylistGridViewModel(playlists []*mediaprovider.Playlist) []widgets.GridViewItemModel {
+// returns the playlists matching the current search, or all playlists if not searching
+func (a *PlaylistsPage) shownPlaylists() []*mediaprovider.Playlist {
+	if a.searcher.Entry.Text != "" {
+		return a.searchedPlaylists
+	}
+	return a.playlists
+}
+
+// shows the playlists in the grid view, grouped under a heading
+// for each folder if any playlists are in folders
+func (a *PlaylistsPage) resetGridView(playlists []*mediaprovider.Playlist) {
+	tree := a.folders.Tree(playlists)
+	if len(tree.Folders) == 0 {
+		a.gridView.ResetFixed(createPlaylistGridViewModel(playlists, nil))
+		return
+	}
+	searching := a.searcher.Entry.Text != ""
+	sections := []widgets.GridViewSection{{Items: createPlaylistGridViewModel(tree.Playlists, a.folders.DisplayName)}}
+	var addFolders func(*playlistfolders.Folder)
+	addFolders = func(parent *playlistfolders.Folder) {
+		for _, f := range parent.Folders {
+			collapsed := !searching && a.folders.IsCollapsed(f.Path)
+			section := widgets.GridViewSection{
+				ID:          f.Path,
+				Title:       fmt.Sprintf("%s (%d)", f.Name, f.Count()),
+				Depth:       f.Depth,
+				Collapsible: true,
+				Collapsed:   collapsed,
+			}
+			if !collapsed {
+				section.Items = createPlaylistGridViewModel(f.Playlists, a.folders.DisplayName)
+			}
+			sections = append(sections, section)
+			if !collapsed {
+				addFolders(f)
+			}
+		}
+	}
+	addFolders(tree)
+	a.gridView.ResetFixedSections(sections)
+}
+
+// creates the grid view items for the playlists, with names
+// given by nameOf, or the full playlist names if nil
+func createPlaylistGridViewModel(playlists []*mediaprovider.Playlist, nameOf func(*mediaprovider.Playlist) string) []widgets.GridViewItemModel {
 	return sharedutil.MapSlice(playlists, func(pl *mediaprovider.Playlist) widgets.GridViewItemModel {
+		name := pl.Name
+		if nameOf != nil {
+			name = nameOf(pl)
+		}
+		if backend.IsSmartPlaylistID(pl.ID) {
+			return widgets.GridViewItemModel{
+				Name:      name,
+				ID:        pl.ID,
+				Secondary: []string{pl.Owner},
+			}
+		}
 		var tracks string
 		if pl.TrackCount == 1 {
 			tracks = lang.L("track")
@@ -201,7 +325,7 @@ func createPlaylistGridViewModel(playlists []*mediaprovider.Playlist) []widgets.
 			tracks = lang.L("tracks")
 		}
 		return widgets.GridViewItemModel{
-			Name:       pl.Name,
+			Name:       name,
 			ID:         pl.ID,
 			CoverArtID: pl.CoverArtID,
 			Secondary:  []string{fmt.Sprintf("%d %s", pl.TrackCount, tracks)},
@@ -210,6 +334,10 @@ func createPlaylistGridViewModel(playlists []*mediaprovider.Playlist) []widgets.
 }
 
 func (a *PlaylistsPage) showPlaylistPage(id string) {
+	if backend.IsSmartPlaylistID(id) {
+		a.contr.NavigateTo(controller.SmartPlaylistRoute(id))
+		return
+	}
 	a.contr.NavigateTo(controller.PlaylistRoute(id))
 }
 
@@ -236,10 +364,11 @@ func (a *PlaylistsPage) onSearched(query string) {
 // refresh the active view
 func (a *PlaylistsPage) refreshView(playlists []*mediaprovider.Playlist) {
 	if a.listView != nil {
+		a.listView.ExpandAllFolders = a.searcher.Entry.Text != ""
 		a.listView.SetPlaylists(playlists)
 	}
 	if a.gridView != nil {
-		a.gridView.ResetFixed(createPlaylistGridViewModel(playlists))
+		a.resetGridView(playlists)
 	}
 	if a.viewToggle.ActivatedButtonIndex() == 0 {
 		a.listView.Refresh() // ensures content size updates for ScrollToOffset too
@@ -277,12 +406,14 @@ func (a *PlaylistsPage) Save() SavedPage {
 		pool:       a.pool,
 		cfg:        a.cfg,
 		mp:         a.mp,
+		spm:        a.spm,
 		searchText: a.searcher.Entry.Text,
 		activeView: a.viewToggle.ActivatedButtonIndex(),
 	}
 	if a.gridView != nil {
 		s.gridScrollPos = a.gridView.GetScrollOffset()
 		a.gridView.Clear()
+		a.gridView.OnSectionHeadingTapped = nil
 		a.pool.Release(util.WidgetTypeGridView, a.gridView)
 	}
 	if a.listView != nil {
@@ -297,6 +428,7 @@ type savedPlaylistsPage struct {
 	pool          *util.WidgetPool
 	cfg           *backend.PlaylistsPageConfig
 	mp            mediaprovider.MediaProvider
+	spm           *backend.SmartPlaylistManager
 	searchText    string
 	activeView    int
 	listSort      widgets.ListHeaderSort
@@ -305,14 +437,14 @@ type savedPlaylistsPage struct {
 }
 
 func (s *savedPlaylistsPage) Restore() Page {
-	return newPlaylistsPage(s.contr, s.pool, s.cfg, s.mp, s.searchText, s.activeView, s.listSort, s.listScrollPos, s.gridScrollPos)
+	return newPlaylistsPage(s.contr, s.pool, s.cfg, s.mp, s.spm, s.searchText, s.activeView, s.listSort, s.listScrollPos, s.gridScrollPos)
 }
 
 func (a *PlaylistsPage) buildContainer(initialView fyne.CanvasObject) {
 	searchVbox := container.NewVBox(layout.NewSpacer(), a.searcher, layout.NewSpacer())
 	a.container = container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 5, BottomPadding: 15},
 		container.NewBorder(
-			container.NewHBox(a.titleDisp, container.NewCenter(a.viewToggle), layout.NewSpacer(), searchVbox),
+			container.NewHBox(a.titleDisp, container.NewCenter(a.viewToggle), layout.NewSpacer(), container.NewCenter(a.importBtn), container.NewCenter(a.newSmartBtn), searchVbox),
 			nil, nil, nil, initialView))
 }
 
@@ -324,9 +456,16 @@ type PlaylistList struct {
 	widget.BaseWidget
 
 	OnNavTo func(string)
+	// Invoked when a playlist is moved to another folder, or a folder is collapsed or expanded
+	OnFoldersChanged func()
+
+	// If true, the contents of collapsed folders are shown, e.g. for search results
+	ExpandAllFolders bool
 
+	folders            *backend.PlaylistFolderManager
 	playlistsOrigOrder []*mediaprovider.Playlist
 	playlists          []*mediaprovider.Playlist
+	items              []playlistfolders.Item
 	sorting            widgets.ListHeaderSort
 
 	columnsLayout *layouts.ColumnsLayout
@@ -335,18 +474,19 @@ type PlaylistList struct {
 	container     *fyne.Container
 }
 
-func NewPlaylistList(initialSort widgets.ListHeaderSort) *PlaylistList {
+func NewPlaylistList(initialSort widgets.ListHeaderSort, folders *backend.PlaylistFolderManager) *PlaylistList {
 	a := &PlaylistList{
 		sorting: initialSort,
+		folders: folders,
 	}
 	a.buildHeaderAndLayout()
 	a.list = widgets.NewFocusList(
 		func() int {
-			return len(a.playlists)
+			return len(a.items)
 		},
 		func() fyne.CanvasObject {
 			r := NewPlaylistListRow(a.columnsLayout)
-			r.OnTapped = func() { a.onRowTapped(r.PlaylistID) }
+			r.OnTapped = func() { a.onRowTapped(r) }
 			r.OnFocusNeighbor = func(up bool) {
 				a.list.FocusNeighbor(r.ItemID(), up)
 			}
@@ -354,18 +494,34 @@ func NewPlaylistList(initialSort widgets.ListHeaderSort) *PlaylistList {
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
 			row := item.(*PlaylistListRow)
-			if row.PlaylistID != a.playlists[id].ID {
+			it := a.items[id]
+			if it.Folder != nil {
+				collapsed := a.isCollapsed(it.Folder.Path)
+				key := fmt.Sprintf("folder:%s:%t:%d", it.Folder.Path, collapsed, it.Folder.Count())
+				if row.key != key {
+					row.EnsureUnfocused()
+					row.ListItemID = id
+					row.key = key
+					row.PlaylistID = ""
+					row.FolderPath = it.Folder.Path
+					row.updateFolder(it.Folder, it.Depth, collapsed)
+				}
+				return
+			}
+			pl := it.Playlist
+			name := a.folders.DisplayName(pl)
+			key := fmt.Sprintf("playlist:%s:%d:%s", pl.ID, it.Depth, name)
+			if row.key != key {
 				row.EnsureUnfocused()
 				row.ListItemID = id
-				row.PlaylistID = a.playlists[id].ID
-				row.nameLabel.Text = a.playlists[id].Name
-				row.descrptionLabel.Text = a.playlists[id].Description
-				row.ownerLabel.Text = a.playlists[id].Owner
-				row.trackCountLabel.Text = strconv.Itoa(a.playlists[id].TrackCount)
-				row.Refresh()
+				row.key = key
+				row.PlaylistID = pl.ID
+				row.FolderPath = ""
+				row.updatePlaylist(pl, name, it.Depth)
 			}
 		},
 	)
+	a.list.OnDragEnd = a.onDragEnd
 	a.container = container.NewBorder(a.header, nil, nil, nil, a.list)
 	a.ExtendBaseWidget(a)
 	return a
@@ -389,21 +545,75 @@ func (p *PlaylistList) buildHeaderAndLayout() {
 func (p *PlaylistList) SetPlaylists(playlists []*mediaprovider.Playlist) {
 	p.playlistsOrigOrder = playlists
 	p.doSortPlaylists()
+	p.buildItems()
 }
 
 func (p *PlaylistList) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(p.container)
 }
 
-func (p *PlaylistList) onRowTapped(id string) {
+func (p *PlaylistList) onRowTapped(row *PlaylistListRow) {
+	if row.FolderPath != "" {
+		p.folders.SetCollapsed(row.FolderPath, !p.folders.IsCollapsed(row.FolderPath))
+		p.foldersChanged()
+		return
+	}
 	if p.OnNavTo != nil {
-		p.OnNavTo(id)
+		p.OnNavTo(row.PlaylistID)
+	}
+}
+
+// Moves the dragged playlist into the folder of the item above the drop
+// position, or into the folder itself if dropped just below its heading.
+// Dropping at the very top or bottom moves the playlist to the top level.
+func (p *PlaylistList) onDragEnd(dragged, insertPos int) {
+	if dragged >= len(p.items) || p.items[dragged].Playlist == nil ||
+		insertPos == dragged || insertPos == dragged+1 {
+		return
+	}
+	folder := ""
+	if insertPos > 0 && insertPos < len(p.items) {
+		if above := p.items[insertPos-1]; above.Folder != nil {
+			folder = above.Folder.Path
+		} else {
+			folder = p.folders.FolderOf(above.Playlist)
+		}
+	}
+	pl := p.items[dragged].Playlist
+	if folder == p.folders.FolderOf(pl) {
+		return
+	}
+	p.folders.SetFolder(pl.ID, folder)
+	if folder != "" {
+		p.folders.SetCollapsed(folder, false)
+	}
+	p.foldersChanged()
+}
+
+func (p *PlaylistList) foldersChanged() {
+	if p.OnFoldersChanged != nil {
+		p.OnFoldersChanged()
+		return
 	}
+	p.buildItems()
+	p.Refresh()
+}
+
+func (p *PlaylistList) isCollapsed(path string) bool {
+	return !p.ExpandAllFolders && p.folders.IsCollapsed(path)
+}
+
+func (p *PlaylistList) buildItems() {
+	tree := p.folders.Tree(p.playlists)
+	p.items = tree.Flatten(p.isCollapsed)
+	// moving playlists between folders only makes sense if there are any
+	p.list.EnableDragging = len(tree.Folders) > 0
 }
 
 func (p *PlaylistList) onSorted(sort widgets.ListHeaderSort) {
 	p.sorting = sort
 	p.doSortPlaylists()
+	p.buildItems()
 	p.Refresh()
 }
 
@@ -453,7 +663,15 @@ type PlaylistListRow struct {
 	widgets.FocusListRowBase
 
 	PlaylistID string
+	// Path of the folder, if the row is a folder heading
+	FolderPath string
+
+	// identifies the shown content, to skip unnecessary updates
+	key string
 
+	indent          *canvas.Rectangle
+	folderIcon      *widget.Icon
+	nameLeading     *fyne.Container
 	nameLabel       *widget.Label
 	descrptionLabel *widget.Label
 	ownerLabel      *widget.Label
@@ -462,16 +680,63 @@ type PlaylistListRow struct {
 
 func NewPlaylistListRow(layout *layouts.ColumnsLayout) *PlaylistListRow {
 	a := &PlaylistListRow{
+		indent:          canvas.NewRectangle(color.Transparent),
+		folderIcon:      widget.NewIcon(nil),
 		nameLabel:       util.NewTruncatingLabel(),
 		descrptionLabel: util.NewTruncatingLabel(),
 		ownerLabel:      util.NewTruncatingLabel(),
 		trackCountLabel: util.NewTrailingAlignLabel(),
 	}
-	a.Content = container.New(layout, a.nameLabel, a.descrptionLabel, a.ownerLabel, a.trackCountLabel)
+	a.nameLeading = container.NewHBox(a.indent, a.folderIcon)
+	a.nameLeading.Hide()
+	a.Content = container.New(layout,
+		container.NewBorder(nil, nil, a.nameLeading, nil, a.nameLabel),
+		a.descrptionLabel, a.ownerLabel, a.trackCountLabel)
 	a.ExtendBaseWidget(a)
 	return a
 }
 
+func (a *PlaylistListRow) updatePlaylist(pl *mediaprovider.Playlist, name string, depth int) {
+	a.setIndent(depth, false)
+	a.nameLabel.Text = name
+	a.nameLabel.TextStyle.Bold = false
+	a.descrptionLabel.Text = pl.Description
+	a.ownerLabel.Text = pl.Owner
+	if backend.IsSmartPlaylistID(pl.ID) {
+		a.trackCountLabel.Text = ""
+	} else {
+		a.trackCountLabel.Text = strconv.Itoa(pl.TrackCount)
+	}
+	a.Refresh()
+}
+
+func (a *PlaylistListRow) updateFolder(f *playlistfolders.Folder, depth int, collapsed bool) {
+	a.setIndent(depth, true)
+	if collapsed {
+		a.folderIcon.SetResource(theme.MenuExpandIcon())
+	} else {
+		a.folderIcon.SetResource(theme.MenuDropDownIcon())
+	}
+	a.nameLabel.Text = f.Name
+	a.nameLabel.TextStyle.Bold = true
+	n := f.Count()
+	if n == 1 {
+		a.descrptionLabel.Text = fmt.Sprintf("%d %s", n, lang.L("playlist"))
+	} else {
+		a.descrptionLabel.Text = fmt.Sprintf("%d %s", n, lang.L("playlists"))
+	}
+	a.ownerLabel.Text = ""
+	a.trackCountLabel.Text = ""
+	a.Refresh()
+}
+
+func (a *PlaylistListRow) setIndent(depth int, isFolder bool) {
+	a.folderIcon.Hidden = !isFolder
+	// playlists in a folder line up with the name of the folder
+	a.indent.SetMinSize(fyne.NewSize(float32(depth)*(theme.IconInlineSize()+theme.Padding()), 1))
+	a.nameLeading.Hidden = depth == 0 && !isFolder
+}
+
 func (a *PlaylistListRow) Tapped(*fyne.PointEvent) {
 	if a.OnTapped != nil {
 		a.OnTapped()
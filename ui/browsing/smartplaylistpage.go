@@ -0,0 +1,283 @@
+package browsing
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SmartPlaylistPage shows the tracks currently matched by a
+// client-side smart playlist.
+type SmartPlaylistPage struct {
+	widget.BaseWidget
+
+	smartPlaylistPageState
+
+	disposed     bool
+	header       *SmartPlaylistPageHeader
+	tracklist    *widgets.Tracklist
+	tracks       []*mediaprovider.Track
+	nowPlayingID string
+	container    *fyne.Container
+}
+
+type smartPlaylistPageState struct {
+	playlistID string
+	conf       *backend.PlaylistPageConfig
+	contr      *controller.Controller
+	widgetPool *util.WidgetPool
+	spm        *backend.SmartPlaylistManager
+	pm         *backend.PlaybackManager
+	im         *backend.ImageManager
+	trackSort  widgets.TracklistSort
+}
+
+func NewSmartPlaylistPage(
+	playlistID string,
+	conf *backend.PlaylistPageConfig,
+	pool *util.WidgetPool,
+	contr *controller.Controller,
+	spm *backend.SmartPlaylistManager,
+	pm *backend.PlaybackManager,
+	im *backend.ImageManager,
+) *SmartPlaylistPage {
+	return newSmartPlaylistPage(playlistID, conf, contr, pool, spm, pm, im, widgets.TracklistSort{})
+}
+
+func newSmartPlaylistPage(
+	playlistID string,
+	conf *backend.PlaylistPageConfig,
+	contr *controller.Controller,
+	pool *util.WidgetPool,
+	spm *backend.SmartPlaylistManager,
+	pm *backend.PlaybackManager,
+	im *backend.ImageManager,
+	trackSort widgets.TracklistSort,
+) *SmartPlaylistPage {
+	a := &SmartPlaylistPage{smartPlaylistPageState: smartPlaylistPageState{
+		playlistID: playlistID, conf: conf, contr: contr, widgetPool: pool, spm: spm, pm: pm, im: im}}
+	a.ExtendBaseWidget(a)
+	a.header = NewSmartPlaylistPageHeader(a)
+	if tl := a.widgetPool.Obtain(util.WidgetTypeTracklist); tl != nil {
+		a.tracklist = tl.(*widgets.Tracklist)
+		a.tracklist.Reset()
+	} else {
+		a.tracklist = widgets.NewTracklist(nil, a.im, false)
+	}
+	a.tracklist.SetVisibleColumns(conf.TracklistColumns)
+	a.tracklist.SetSorting(trackSort)
+	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
+		conf.TracklistColumns = cols
+	}
+	_, canRate := a.contr.App.ServerManager.Server.(mediaprovider.SupportsRating)
+	_, canShare := a.contr.App.ServerManager.Server.(mediaprovider.SupportsSharing)
+	a.tracklist.Options = widgets.TracklistOptions{
+		DisableRating:  !canRate,
+		DisableSharing: !canShare,
+	}
+	// connect tracklist actions
+	a.contr.ConnectTracklistActions(a.tracklist)
+
+	a.container = container.NewBorder(
+		container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 15, BottomPadding: 10}, a.header),
+		nil, nil, nil, container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, BottomPadding: 15}, a.tracklist))
+	go a.load()
+	return a
+}
+
+func (a *SmartPlaylistPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+func (a *SmartPlaylistPage) Save() SavedPage {
+	a.disposed = true
+	p := a.smartPlaylistPageState
+	p.trackSort = a.tracklist.Sorting()
+	a.tracklist.Clear()
+	p.widgetPool.Release(util.WidgetTypeTracklist, a.tracklist)
+	return &p
+}
+
+func (a *SmartPlaylistPage) Route() controller.Route {
+	return controller.SmartPlaylistRoute(a.playlistID)
+}
+
+var _ CanShowNowPlaying = (*SmartPlaylistPage)(nil)
+
+func (a *SmartPlaylistPage) OnSongChange(item mediaprovider.MediaItem, lastScrobbledIfAny *mediaprovider.Track) {
+	a.nowPlayingID = sharedutil.MediaItemIDOrEmptyStr(item)
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.tracklist.IncrementPlayCount(sharedutil.MediaItemIDOrEmptyStr(lastScrobbledIfAny))
+}
+
+func (a *SmartPlaylistPage) Reload() {
+	// the user is explicitly asking for fresh results
+	a.spm.InvalidateLibraryCache()
+	go a.load()
+}
+
+func (a *SmartPlaylistPage) Tapped(*fyne.PointEvent) {
+	a.tracklist.UnselectAll()
+}
+
+func (a *SmartPlaylistPage) SelectAll() {
+	a.tracklist.SelectAll()
+}
+
+var _ Scrollable = (*SmartPlaylistPage)(nil)
+
+func (a *SmartPlaylistPage) Scroll(scrollAmt float32) {
+	a.tracklist.Scroll(scrollAmt)
+}
+
+// should be called asynchronously
+func (a *SmartPlaylistPage) load() {
+	playlist := a.spm.GetSmartPlaylist(a.playlistID)
+	if playlist == nil {
+		log.Printf("Failed to get smart playlist: %s", backend.ErrSmartPlaylistNotFound.Error())
+		return
+	}
+	a.header.Update(playlist, nil)
+	tracks, err := a.spm.Evaluate(playlist)
+	if err != nil {
+		log.Printf("Failed to evaluate smart playlist: %s", err.Error())
+		return
+	}
+	if a.disposed {
+		return
+	}
+	renumberTracks(tracks)
+	a.tracks = tracks
+	a.tracklist.SetTracks(tracks)
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.header.Update(playlist, tracks)
+}
+
+type SmartPlaylistPageHeader struct {
+	widget.BaseWidget
+
+	page     *SmartPlaylistPage
+	playlist *backend.SmartPlaylist
+
+	titleLabel       *widget.RichText
+	descriptionLabel *widget.Label
+	trackTimeLabel   *widget.Label
+
+	container *fyne.Container
+}
+
+func NewSmartPlaylistPageHeader(page *SmartPlaylistPage) *SmartPlaylistPageHeader {
+	a := &SmartPlaylistPageHeader{page: page}
+	a.ExtendBaseWidget(a)
+
+	image := widgets.NewImagePlaceholder(myTheme.PlaylistIcon, 225)
+	a.titleLabel = util.NewTruncatingRichText()
+	a.titleLabel.Segments[0].(*widget.TextSegment).Style = widget.RichTextStyle{
+		SizeName: theme.SizeNameHeadingText,
+	}
+	a.descriptionLabel = util.NewTruncatingLabel()
+	a.trackTimeLabel = widget.NewLabel("")
+	editButton := widget.NewButtonWithIcon(lang.L("Edit"), theme.DocumentCreateIcon(), func() {
+		if a.playlist != nil {
+			a.page.contr.DoEditSmartPlaylistWorkflow(a.playlist)
+		}
+	})
+	playButton := widget.NewButtonWithIcon(lang.L("Play"), theme.MediaPlayIcon(), func() {
+		a.page.pm.LoadTracks(a.page.tracks, backend.Replace, false)
+		a.page.pm.PlayFromBeginning()
+	})
+	shuffleBtn := widget.NewButtonWithIcon(lang.L("Shuffle"), myTheme.ShuffleIcon, func() {
+		a.page.pm.LoadTracks(a.page.tracks, backend.Replace, true)
+		a.page.pm.PlayFromBeginning()
+	})
+	var pop *widget.PopUpMenu
+	menuBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
+	menuBtn.OnTapped = func() {
+		if pop == nil {
+			playNext := fyne.NewMenuItem(lang.L("Play next"), func() {
+				a.page.pm.LoadTracks(a.page.tracks, backend.InsertNext, false)
+			})
+			playNext.Icon = myTheme.PlayNextIcon
+			queue := fyne.NewMenuItem(lang.L("Add to queue"), func() {
+				a.page.pm.LoadTracks(a.page.tracks, backend.Append, false)
+			})
+			queue.Icon = theme.ContentAddIcon()
+			playlist := fyne.NewMenuItem(lang.L("Add to playlist")+"...", func() {
+				a.page.contr.DoAddTracksToPlaylistWorkflow(
+					sharedutil.TracksToIDs(a.page.tracks))
+			})
+			playlist.Icon = myTheme.PlaylistIcon
+			saveToServer := fyne.NewMenuItem(lang.L("Save to server playlist"), func() {
+				a.page.contr.DoSaveSmartPlaylistToServerWorkflow(a.page.playlistID)
+			})
+			saveToServer.Icon = theme.UploadIcon()
+			download := fyne.NewMenuItem(lang.L("Download")+"...", func() {
+				a.page.contr.ShowDownloadDialog(a.page.tracks, a.titleLabel.String())
+			})
+			download.Icon = theme.DownloadIcon()
+			menu := fyne.NewMenu("", playNext, queue, playlist, saveToServer, download)
+			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
+		}
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
+		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
+	}
+
+	a.container = util.AddHeaderBackground(
+		container.NewBorder(nil, nil, image, nil,
+			container.NewVBox(a.titleLabel, container.New(layout.NewCustomPaddedVBoxLayout(theme.Padding()-10),
+				a.descriptionLabel,
+				a.trackTimeLabel),
+				container.NewHBox(editButton, playButton, shuffleBtn, menuBtn),
+			)))
+	return a
+}
+
+func (a *SmartPlaylistPageHeader) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+// Updates the header for the given playlist. If tracks is nil,
+// the playlist is still being evaluated.
+func (a *SmartPlaylistPageHeader) Update(playlist *backend.SmartPlaylist, tracks []*mediaprovider.Track) {
+	a.playlist = playlist
+	a.titleLabel.Segments[0].(*widget.TextSegment).Text = playlist.Name
+	a.descriptionLabel.SetText(util.DescribeSmartPlaylist(playlist))
+	if tracks == nil {
+		a.trackTimeLabel.SetText(lang.L("Loading") + "...")
+	} else {
+		a.trackTimeLabel.SetText(a.formatTrackTimeStr(tracks))
+	}
+	a.Refresh()
+}
+
+func (a *SmartPlaylistPageHeader) formatTrackTimeStr(tracks []*mediaprovider.Track) string {
+	var tracksStr string
+	if len(tracks) == 1 {
+		tracksStr = lang.L("track")
+	} else {
+		tracksStr = lang.L("tracks")
+	}
+	var dur int
+	for _, tr := range tracks {
+		dur += tr.Duration
+	}
+	return fmt.Sprintf("%d %s, %s", len(tracks), tracksStr, util.SecondsToTimeString(float64(dur)))
+}
+
+func (s *smartPlaylistPageState) Restore() Page {
+	return newSmartPlaylistPage(s.playlistID, s.conf, s.contr, s.widgetPool, s.spm, s.pm, s.im, s.trackSort)
+}
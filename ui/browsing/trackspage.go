@@ -25,6 +25,7 @@ type TracksPage struct {
 
 	title           *widget.RichText
 	searcher        *widgets.SearchEntry
+	filterBtn       *widgets.TrackFilterButton
 	tracklist       *widgets.Tracklist
 	loader          *widgets.TracklistLoader
 	searchTracklist *widgets.Tracklist
@@ -35,6 +36,7 @@ type TracksPage struct {
 
 type tracksPageState struct {
 	searchText string
+	filter     mediaprovider.TrackFilter
 	widgetPool *util.WidgetPool
 	contr      *controller.Controller
 	conf       *backend.TracksPageConfig
@@ -45,7 +47,11 @@ type tracksPageState struct {
 }
 
 func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig, pool *util.WidgetPool, mp mediaprovider.MediaProvider, im *backend.ImageManager) *TracksPage {
-	t := &TracksPage{tracksPageState: tracksPageState{contr: contr, conf: conf, widgetPool: pool, mp: mp, im: im}}
+	return newTracksPage(contr, conf, pool, mp, im, mediaprovider.NewTrackFilter(mediaprovider.TrackFilterOptions{}))
+}
+
+func newTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig, pool *util.WidgetPool, mp mediaprovider.MediaProvider, im *backend.ImageManager, filter mediaprovider.TrackFilter) *TracksPage {
+	t := &TracksPage{tracksPageState: tracksPageState{contr: contr, conf: conf, widgetPool: pool, mp: mp, im: im, filter: filter}}
 	t.ExtendBaseWidget(t)
 
 	t.tracklist = t.obtainTracklist()
@@ -72,6 +78,10 @@ func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig,
 	t.searcher = widgets.NewSearchEntry()
 	t.searcher.PlaceHolder = lang.L("Search page")
 	t.searcher.OnSearched = t.OnSearched
+	t.filterBtn = widgets.NewTrackFilterButton(filter, mp.GetGenres)
+	t.filterBtn.RatingDisabled = !t.canRate
+	t.filterBtn.OnChanged = t.onFilterChanged
+	t.filterBtn.Refresh()
 	t.createContainer()
 	t.Reload()
 	return t
@@ -80,7 +90,8 @@ func NewTracksPage(contr *controller.Controller, conf *backend.TracksPageConfig,
 func (t *TracksPage) createContainer() {
 	playRandomVbox := container.NewVBox(layout.NewSpacer(), t.playRandom, layout.NewSpacer())
 	searchVbox := container.NewVBox(layout.NewSpacer(), t.searcher, layout.NewSpacer())
-	topRow := container.NewHBox(t.title, playRandomVbox, layout.NewSpacer(), searchVbox)
+	filterBtnVbox := container.NewVBox(layout.NewSpacer(), t.filterBtn, layout.NewSpacer())
+	topRow := container.NewHBox(t.title, playRandomVbox, layout.NewSpacer(), filterBtnVbox, searchVbox)
 	t.container = container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 5, BottomPadding: 15},
 		container.NewBorder(topRow, nil, nil, nil, t.tracklist))
 }
@@ -91,7 +102,7 @@ func (t *TracksPage) Route() controller.Route {
 
 func (t *TracksPage) Reload() {
 	t.tracklist.Clear()
-	iter := t.mp.IterateTracks("")
+	iter := t.mp.IterateTracks("", t.filter)
 	// loads asynchronously
 	t.loader = widgets.NewTracklistLoader(t.tracklist, iter)
 }
@@ -153,14 +164,24 @@ func (t *TracksPage) doSearch(query string) {
 		}
 		t.contr.ConnectTracklistActions(t.searchTracklist)
 	} else {
+		t.searchLoader.Dispose()
 		t.searchTracklist.Clear()
 	}
-	iter := t.mp.IterateTracks(query)
+	iter := t.mp.IterateTracks(query, t.filter)
 	t.searchLoader = widgets.NewTracklistLoader(t.searchTracklist, iter)
 	t.container.Objects[0].(*fyne.Container).Objects[0] = t.searchTracklist
 	t.Refresh()
 }
 
+func (t *TracksPage) onFilterChanged() {
+	if t.searchText != "" {
+		t.doSearch(t.searchText)
+		return
+	}
+	t.loader.Dispose()
+	t.Reload()
+}
+
 func (t *TracksPage) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(t.container)
 }
@@ -179,7 +200,7 @@ func (t *TracksPage) Save() SavedPage {
 }
 
 func (s *tracksPageState) Restore() Page {
-	t := NewTracksPage(s.contr, s.conf, s.widgetPool, s.mp, s.im)
+	t := newTracksPage(s.contr, s.conf, s.widgetPool, s.mp, s.im, s.filter)
 	t.searchText = s.searchText
 	if t.searchText != "" {
 		t.searcher.Entry.Text = t.searchText
@@ -1,16 +1,12 @@
 package controller
 
 import (
-	"archive/zip"
 	"context"
 	"fmt"
 	"image"
 	"image/color"
-	"io"
 	"log"
 	"net/url"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
@@ -49,6 +45,7 @@ type Controller struct {
 	ReloadFunc        func()
 	RefreshPageFunc   func()
 	SelectAllPageFunc func()
+	ReloadWaveform    func()
 
 	popUpQueueMutex    sync.Mutex
 	popUpQueue         *widget.PopUp
@@ -57,6 +54,13 @@ type Controller struct {
 	escapablePopUp     *widget.PopUp
 	haveModal          bool
 	runOnModalClosed   func()
+
+	onEscapablePopUpClosed func()
+
+	// the last shown settings dialog, updated when audio devices change while it is visible
+	settingsDialogMutex sync.Mutex
+	settingsDialog      *dialogs.SettingsDialog
+	settingsPopUp       *widget.PopUp
 }
 
 func New(app *backend.App, appVersion string, mainWindow fyne.Window) *Controller {
@@ -66,6 +70,21 @@ func New(app *backend.App, appVersion string, mainWindow fyne.Window) *Controlle
 		App:        app,
 	}
 	c.initVisualizations()
+	c.App.Downloads.OnJobFinished(c.onDownloadJobFinished)
+	c.App.OnAudioDeviceSwitched(func(dev mpv.AudioDevice, connected bool) {
+		if connected {
+			c.sendNotification(lang.L("Audio device reconnected"), dev.Description)
+		} else {
+			c.sendNotification(lang.L("Audio device disconnected"), dev.Description)
+		}
+	})
+	c.App.OnAudioDevicesChanged(func(devs []mpv.AudioDevice) {
+		c.settingsDialogMutex.Lock()
+		defer c.settingsDialogMutex.Unlock()
+		if c.settingsPopUp != nil && c.settingsPopUp.Visible() {
+			c.settingsDialog.SetAudioDevices(devs)
+		}
+	})
 	c.App.PlaybackManager.OnQueueChange(func() {
 		c.popUpQueueMutex.Lock()
 		defer c.popUpQueueMutex.Unlock()
@@ -106,7 +125,15 @@ func (m *Controller) NavigateTo(route Route) {
 }
 
 func (m *Controller) ClosePopUpOnEscape(pop *widget.PopUp) {
+	m.ClosePopUpOnEscapeThen(pop, nil)
+}
+
+// Same as ClosePopUpOnEscape, but runs onClosed, if non-nil, when the
+// pop up is closed with Escape, for the clean up done by the dialog's
+// own close handler which is skipped in that case.
+func (m *Controller) ClosePopUpOnEscapeThen(pop *widget.PopUp, onClosed func()) {
 	m.escapablePopUp = pop
+	m.onEscapablePopUpClosed = onClosed
 }
 
 func (m *Controller) CloseEscapablePopUp() {
@@ -114,6 +141,10 @@ func (m *Controller) CloseEscapablePopUp() {
 		m.escapablePopUp.Hide()
 		m.escapablePopUp = nil
 		m.doModalClosed()
+		if m.onEscapablePopUpClosed != nil {
+			m.onEscapablePopUpClosed()
+			m.onEscapablePopUpClosed = nil
+		}
 	}
 }
 
@@ -275,7 +306,7 @@ func (m *Controller) PromptForFirstServer() {
 // Depending on the results of that dialog, potentially create a new playlist
 // Add tracks to the user-specified playlist
 func (m *Controller) DoAddTracksToPlaylistWorkflow(trackIDs []string) {
-	sp := dialogs.NewSelectPlaylistDialog(m.App.ServerManager.Server, m.App.ImageManager,
+	sp := dialogs.NewSelectPlaylistDialog(m.App.ServerManager.Server, m.App.ImageManager, m.App.PlaylistFolders,
 		m.App.ServerManager.LoggedInUser, m.App.Config.Application.AddToPlaylistSkipDuplicates)
 	pop := widget.NewModalPopUp(sp.SearchDialog, m.MainWindow.Canvas())
 	sp.SetOnDismiss(func() {
@@ -534,8 +565,8 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 	_, isEqualizerPlayer := curPlayer.(*mpv.Player)
 	_, canSavePlayQueue := c.App.ServerManager.Server.(mediaprovider.CanSavePlayQueue)
 	isLocalPlayer := isEqualizerPlayer
-	bands := c.App.LocalPlayer.Equalizer().BandFrequencies()
-	dlg := dialogs.NewSettingsDialog(c.App.Config,
+	bands := (&mpv.ISO15BandEqualizer{}).BandFrequencies()
+	dlg := dialogs.NewSettingsDialog(c.App.Config, c.App.BitPerfectConflicts,
 		devs, themeFiles, bands,
 		c.App.ServerManager.Server.ClientDecidesScrobble(),
 		isLocalPlayer, isReplayGainPlayer, isEqualizerPlayer, canSavePlayQueue,
@@ -547,18 +578,14 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 		c.App.LocalPlayer.SetAudioExclusive(c.App.Config.LocalPlayback.AudioExclusive)
 	}
 	dlg.OnAudioDeviceSettingChanged = func() {
-		c.App.LocalPlayer.SetAudioDevice(c.App.Config.LocalPlayback.AudioDeviceName)
+		c.App.SetAudioDevice(c.App.Config.LocalPlayback.AudioDeviceName)
 	}
+	dlg.OnAudioOutputSettingsChanged = c.App.UpdateAudioOutput
+	dlg.OnVolumeFadeSettingChanged = c.App.UpdateVolumeFades
 	dlg.OnThemeSettingChanged = themeUpdateCallbk
-	dlg.OnEqualizerSettingsChanged = func() {
-		// currently we only have one equalizer type
-		eq := c.App.LocalPlayer.Equalizer().(*mpv.ISO15BandEqualizer)
-		eq.Disabled = !c.App.Config.LocalPlayback.EqualizerEnabled
-		eq.EQPreamp = c.App.Config.LocalPlayback.EqualizerPreamp
-		copy(eq.BandGains[:], c.App.Config.LocalPlayback.GraphicEqualizerBands)
-		c.App.LocalPlayer.SetEqualizer(eq)
-	}
+	dlg.OnEqualizerSettingsChanged = c.App.UpdateEqualizer
 	dlg.OnPageNeedsRefresh = c.RefreshPageFunc
+	dlg.OnWaveformSettingChanged = c.ReloadWaveform
 	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
 	fynetooltip.AddPopUpToolTipLayer(pop)
 	dlg.OnDismiss = func() {
@@ -567,6 +594,9 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 		c.doModalClosed()
 		c.App.SaveConfigFile()
 	}
+	c.settingsDialogMutex.Lock()
+	c.settingsDialog, c.settingsPopUp = dlg, pop
+	c.settingsDialogMutex.Unlock()
 	c.ClosePopUpOnEscape(pop)
 	c.haveModal = true
 	pop.Show()
@@ -736,99 +766,6 @@ func (c *Controller) createShareURL(id string) (*url.URL, error) {
 	return shareUrl, nil
 }
 
-func (c *Controller) ShowDownloadDialog(tracks []*mediaprovider.Track, downloadName string) {
-	numTracks := len(tracks)
-	var fileName string
-	if numTracks == 1 {
-		fileName = filepath.Base(tracks[0].FilePath)
-	} else {
-		fileName = "downloaded_tracks.zip"
-	}
-
-	dg := dialog.NewFileSave(
-		func(file fyne.URIWriteCloser, err error) {
-			if err != nil {
-				log.Println(err)
-				return
-			}
-
-			if file == nil {
-				return
-			}
-			if numTracks == 1 {
-				go c.downloadTrack(tracks[0], file.URI().Path())
-			} else {
-				go c.downloadTracks(tracks, file.URI().Path(), downloadName)
-			}
-
-		},
-		c.MainWindow)
-	dg.SetFileName(fileName)
-	dg.Show()
-}
-
-func (c *Controller) downloadTrack(track *mediaprovider.Track, filePath string) {
-	reader, err := c.App.ServerManager.Server.DownloadTrack(track.ID)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-
-	file, err := os.Create(filePath)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-	defer file.Close()
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-
-	log.Printf("Saved song %s to: %s\n", track.Title, filePath)
-	c.sendNotification(fmt.Sprintf(lang.L("Download completed")+": %s", track.Title), fmt.Sprintf(lang.L("Saved at")+": %s", filePath))
-}
-
-func (c *Controller) downloadTracks(tracks []*mediaprovider.Track, filePath, downloadName string) {
-	zipFile, err := os.Create(filePath)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-	defer zipFile.Close()
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	for _, track := range tracks {
-		reader, err := c.App.ServerManager.Server.DownloadTrack(track.ID)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-
-		fileName := filepath.Base(track.FilePath)
-
-		fileWriter, err := zipWriter.Create(fileName)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-
-		_, err = io.Copy(fileWriter, reader)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-
-		log.Printf("Saved song %s to: %s\n", track.Title, filePath)
-	}
-
-	c.sendNotification(fmt.Sprintf(lang.L("Download completed")+": %s", downloadName), fmt.Sprintf("Saved at: %s", filePath))
-}
-
 func (c *Controller) sendNotification(title, content string) {
 	fyne.CurrentApp().SendNotification(&fyne.Notification{
 		Title:   title,
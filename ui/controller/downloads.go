@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// how often the downloads dialog refreshes the progress of active downloads
+const downloadsRefreshInterval = 500 * time.Millisecond
+
+// ShowDownloadDialog shows the dialog to choose the destination and options
+// for downloading the tracks, and queues the download.
+func (c *Controller) ShowDownloadDialog(tracks []*mediaprovider.Track, downloadName string) {
+	_, canTranscode := c.App.ServerManager.Server.(mediaprovider.SupportsTranscodedDownload)
+	d := dialogs.NewDownloadDialog(downloadName, tracks, c.App.Config.Downloads, canTranscode)
+	pop := widget.NewModalPopUp(d, c.MainWindow.Canvas())
+	d.OnDismiss = func() {
+		pop.Hide()
+		c.doModalClosed()
+	}
+	d.OnBrowse = func() {
+		dlg := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if dir != nil {
+				d.SetDestination(dir.Path())
+			}
+		}, c.MainWindow)
+		dlg.Show()
+	}
+	d.OnDownload = func() {
+		opts := d.Options()
+		dest := d.Destination()
+		if _, err := c.App.Downloads.Enqueue(downloadName, dest, tracks, opts); err != nil {
+			log.Printf("error queuing download: %s", err.Error())
+			c.showError(lang.L("Could not start the download") + ": " + err.Error())
+			return
+		}
+		conf := &c.App.Config.Downloads
+		conf.FolderTemplate = opts.FolderTemplate
+		conf.SkipExisting = opts.SkipExisting
+		conf.TranscodeFormat = opts.TranscodeFormat
+		conf.TranscodeBitRate = opts.TranscodeBitRate
+		conf.LastDestination = dest
+		pop.Hide()
+		c.doModalClosed()
+		c.ShowDownloadsDialog()
+	}
+	c.ClosePopUpOnEscape(pop)
+	c.haveModal = true
+	pop.Resize(d.MinSize())
+	pop.Show()
+}
+
+// ShowDownloadsDialog shows the progress of queued and finished downloads.
+func (c *Controller) ShowDownloadsDialog() {
+	dm := c.App.Downloads
+	d := dialogs.NewDownloadsDialog()
+	d.Update(dm.Jobs())
+	pop := widget.NewModalPopUp(d, c.MainWindow.Canvas())
+	done := make(chan struct{})
+	// called both by the Close button and when closed with Escape
+	stopRefresh := sync.OnceFunc(func() { close(done) })
+	d.OnDismiss = func() {
+		stopRefresh()
+		pop.Hide()
+		c.doModalClosed()
+	}
+	d.OnCancel = func(jobID int) {
+		dm.Cancel(jobID)
+		d.Update(dm.Jobs())
+	}
+	d.OnRetry = func(jobID int) {
+		dm.RetryFailed(jobID)
+		d.Update(dm.Jobs())
+	}
+	d.OnClearFinished = func() {
+		dm.ClearFinished()
+		d.Update(dm.Jobs())
+	}
+	go func() {
+		t := time.NewTicker(downloadsRefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				d.Update(dm.Jobs())
+			}
+		}
+	}()
+	c.ClosePopUpOnEscapeThen(pop, stopRefresh)
+	c.haveModal = true
+	pop.Resize(d.MinSize())
+	pop.Show()
+}
+
+func (c *Controller) onDownloadJobFinished(job backend.DownloadJob) {
+	done := job.Count(backend.DownloadDone) + job.Count(backend.DownloadSkipped)
+	if done == 0 {
+		return // canceled or failed entirely
+	}
+	content := fmt.Sprintf(lang.L("Saved at")+": %s", job.Dir)
+	if failed := job.Count(backend.DownloadFailed); failed > 0 {
+		content += fmt.Sprintf(" (%d %s)", failed, lang.L("failed"))
+	}
+	c.sendNotification(fmt.Sprintf(lang.L("Download completed")+": %s", job.Name), content)
+}
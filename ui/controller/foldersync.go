@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/foldersync"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// max number of files listed in the folder sync report
+const maxReportedSyncFiles = 50
+
+// ShowFolderSyncDialog shows the dialog for syncing playlists, albums
+// and favorites to a folder, such as that of a portable player.
+func (m *Controller) ShowFolderSyncDialog() {
+	go func() {
+		playlists, err := m.App.ServerManager.Server.GetPlaylists()
+		if err != nil {
+			log.Printf("error loading playlists: %s", err.Error())
+			m.showError(lang.L("An error occurred loading playlists"))
+			return
+		}
+		_, canTranscode := m.App.ServerManager.Server.(mediaprovider.SupportsTranscodedDownload)
+		d := dialogs.NewFolderSyncDialog(m.App.Config.FolderSync, m.App.ServerManager.ServerID.String(), playlists, canTranscode)
+		pop := widget.NewModalPopUp(d, m.MainWindow.Canvas())
+		ctx, cancel := context.WithCancel(context.Background())
+		// also called when the dialog is closed with Escape, which hides it
+		onClosed := func() {
+			cancel()
+			m.App.Config.FolderSync = d.Config()
+			m.App.SaveConfigFile()
+		}
+		closeDialog := func() {
+			pop.Hide()
+			m.doModalClosed()
+			onClosed()
+		}
+		d.OnDismiss = closeDialog
+		d.OnBrowse = func() {
+			dlg := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+				if err != nil {
+					log.Println(err)
+					return
+				}
+				if dir != nil {
+					d.SetDestination(dir.Path())
+				}
+			}, m.MainWindow)
+			dlg.Show()
+		}
+		d.OnPreview = func() { m.runFolderSync(ctx, d, nil) }
+		d.OnSync = func() {
+			m.runFolderSync(ctx, d, func() {
+				closeDialog()
+				m.ShowDownloadsDialog()
+			})
+		}
+		m.ClosePopUpOnEscapeThen(pop, onClosed)
+		m.haveModal = true
+		pop.Resize(d.MinSize())
+		pop.Show()
+	}()
+}
+
+// AddAlbumToFolderSync adds the album to the albums synced to the sync folder.
+func (m *Controller) AddAlbumToFolderSync(albumID, albumName string) {
+	m.App.FolderSync.AddAlbum(albumID, albumName)
+	m.sendNotification(lang.L("Added to Sync to Folder"), albumName)
+}
+
+// plans the sync and shows the changes in the dialog. If onApplied is non-nil,
+// the sync is started and onApplied is called once the downloads are queued.
+func (m *Controller) runFolderSync(ctx context.Context, d *dialogs.FolderSyncDialog, onApplied func()) {
+	conf := d.Config()
+	m.App.Config.FolderSync = conf
+	fs := m.App.FolderSync
+	if onApplied != nil && fs.IsRunning() {
+		d.SetReport(lang.L("A sync to the folder is already in progress"))
+		return
+	}
+	d.SetBusy(true)
+	go func() {
+		defer d.SetBusy(false)
+		plan, err := fs.Plan(ctx, func(step string) {
+			d.SetReport(lang.L(step) + "...")
+		})
+		if ctx.Err() != nil {
+			return // dialog closed
+		}
+		if err != nil {
+			log.Printf("folder sync: error planning sync: %s", err.Error())
+			if errors.Is(err, backend.ErrFolderSyncOtherServer) {
+				d.SetReport(lang.L("The folder was synced from another server"))
+			} else {
+				d.SetReport(lang.L("An error occurred") + ": " + err.Error())
+			}
+			return
+		}
+		report := formatFolderSyncPlan(plan)
+		if onApplied == nil {
+			d.SetReport(report)
+			return
+		}
+		name := lang.L("Sync to Folder") + ": " + filepath.Base(plan.Dir)
+		err = fs.Apply(plan, name, func(err error) {
+			if err != nil {
+				log.Printf("folder sync: %s", err.Error())
+				m.sendNotification(lang.L("Sync to folder finished with errors"), plan.Dir)
+			} else {
+				m.sendNotification(lang.L("Sync to folder complete"), plan.Dir)
+			}
+		})
+		if err != nil {
+			log.Printf("folder sync: error starting sync: %s", err.Error())
+			d.SetReport(lang.L("Could not start the sync") + ": " + err.Error())
+			return
+		}
+		onApplied()
+	}()
+}
+
+func formatFolderSyncPlan(plan *backend.FolderSyncPlan) string {
+	var sb strings.Builder
+	c := plan.Changes
+	if plan.IsEmpty() {
+		sb.WriteString(lang.L("The folder is already in sync") + "\n")
+	}
+	fmt.Fprintf(&sb, "%s: %d\n", lang.L("Tracks to copy"), len(c.Download))
+	fmt.Fprintf(&sb, "%s: %d\n", lang.L("Tracks to delete"), len(c.Delete))
+	fmt.Fprintf(&sb, "%s: %d\n", lang.L("Tracks up to date"), c.Unchanged)
+	if len(plan.Playlists) > 0 {
+		fmt.Fprintf(&sb, "%s: %s\n", lang.L("Playlist files"), strings.Join(plan.Playlists, ", "))
+	}
+	if n := len(c.Conflicts) + len(plan.PlaylistConflicts); n > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", lang.L("Files not created by a sync, left unchanged"), n)
+	}
+	if n := len(plan.Missing); n > 0 {
+		fmt.Fprintf(&sb, "%s: %s\n", lang.L("Not found on the server"), strings.Join(plan.Missing, ", "))
+	}
+	listFiles := func(heading string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		sb.WriteString("\n" + heading + ":\n")
+		for _, e := range entries[:min(len(entries), maxReportedSyncFiles)] {
+			sb.WriteString("  " + e + "\n")
+		}
+		if len(entries) > maxReportedSyncFiles {
+			sb.WriteString("  ...\n")
+		}
+	}
+	entryPath := func(e foldersync.Entry) string { return e.Path }
+	listFiles(lang.L("Copy"), sharedutil.MapSlice(c.Download, entryPath))
+	listFiles(lang.L("Delete"), sharedutil.MapSlice(c.Delete, entryPath))
+	listFiles(lang.L("Files not created by a sync, left unchanged"),
+		append(sharedutil.MapSlice(c.Conflicts, entryPath), plan.PlaylistConflicts...))
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+)
+
+// DoPublishLyricsWorkflow asks the user to confirm and then
+// uploads the synced lyrics for the track to LRCLIB.
+func (m *Controller) DoPublishLyricsWorkflow(track *mediaprovider.Track, lyrics *mediaprovider.Lyrics) {
+	dialog.ShowConfirm(lang.L("Publish to LRCLIB"),
+		lang.L("The synced lyrics will be publicly available on LRCLIB. Continue?"),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			go func() {
+				// solving the publish challenge can take a while
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				defer cancel()
+				if err := m.App.LyricsManager.PublishToLrcLib(ctx, track, lyrics); err != nil {
+					log.Printf("error publishing lyrics to LRCLIB: %s", err.Error())
+					m.showError(lang.L("An error occurred publishing the lyrics"))
+					return
+				}
+				m.sendNotification(lang.L("Lyrics published"), track.Title)
+			}()
+		}, m.MainWindow)
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowPlaylistConflictDialog tells the user that the playlist was changed on
+// the server while they were editing it, and asks whether to merge their changes
+// into the server version or to reload the playlist, discarding their changes.
+func (m *Controller) ShowPlaylistConflictDialog(conflict *backend.PlaylistConflictError, onResolve func(merge bool)) {
+	msg := widget.NewLabel(fmt.Sprintf(lang.L("The playlist was changed on the server since it was loaded")+": %s", conflict.Server.Name))
+	msg.Wrapping = fyne.TextWrapWord
+	changes := widget.NewLabel(fmt.Sprintf("%s: %d, %s: %d",
+		lang.L("Tracks added"), conflict.ServerAdded, lang.L("Tracks removed"), conflict.ServerRemoved))
+	changes.Importance = widget.LowImportance
+	hint := widget.NewLabel(lang.L("Merge your changes into the server version, or reload the playlist and discard them?"))
+	hint.Wrapping = fyne.TextWrapWord
+
+	dlg := dialog.NewCustomConfirm(lang.L("Playlist changed"), lang.L("Merge changes"), lang.L("Reload"),
+		container.NewVBox(msg, changes, hint), func(merge bool) {
+			m.doModalClosed()
+			onResolve(merge)
+		}, m.MainWindow)
+	dlg.Resize(fyne.NewSize(450, dlg.MinSize().Height))
+	m.haveModal = true
+	dlg.Show()
+}
+
+// ShowPlaylistEditPreview shows a summary of an edit to a playlist's tracks,
+// with a list of the affected tracks, and calls onApply if the user confirms.
+// If no tracks are affected, only the summary is shown.
+func (m *Controller) ShowPlaylistEditPreview(title, summary string, items []string, onApply func()) {
+	if len(items) == 0 {
+		dialog.ShowInformation(title, summary, m.MainWindow)
+		return
+	}
+	summaryLabel := widget.NewLabel(summary)
+	summaryLabel.Wrapping = fyne.TextWrapWord
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			co.(*widget.Label).SetText(items[id])
+		})
+	listHeight := fyne.Min(float32(len(items))*list.MinSize().Height, 300)
+	content := container.NewBorder(summaryLabel, nil, nil, nil,
+		container.NewGridWrap(fyne.NewSize(450, listHeight), list))
+
+	dlg := dialog.NewCustomConfirm(title, lang.L("Apply"), lang.L("Cancel"), content, func(ok bool) {
+		m.doModalClosed()
+		if ok {
+			onApply()
+		}
+	}, m.MainWindow)
+	m.haveModal = true
+	dlg.Show()
+}
+
+// DoFindBrokenPlaylistTracksWorkflow checks which of the playlist's entries the
+// server can no longer resolve, showing a cancelable progress dialog,
+// and calls onFound with the result.
+func (m *Controller) DoFindBrokenPlaylistTracksWorkflow(playlist *mediaprovider.PlaylistWithTracks, onFound func(backend.BrokenPlaylistTracks)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := widget.NewProgressBar()
+	progressDlg := dialog.NewCustom(lang.L("Checking playlist entries"), lang.L("Cancel"), progress, m.MainWindow)
+	progressDlg.SetOnClosed(cancel)
+	progressDlg.Resize(fyne.NewSize(350, progressDlg.MinSize().Height))
+	progressDlg.Show()
+
+	go func() {
+		broken, err := backend.FindBrokenPlaylistTracks(ctx, m.App.ServerManager.Server, playlist, func(done, total int) {
+			progress.SetValue(float64(done) / float64(total))
+		})
+		if ctx.Err() != nil {
+			return // canceled by user
+		}
+		progressDlg.Hide()
+		cancel()
+		if err != nil {
+			log.Printf("error checking playlist tracks: %s", err.Error())
+			m.showError(lang.L("An error occurred checking the playlist tracks"))
+			return
+		}
+		onFound(broken)
+	}()
+}
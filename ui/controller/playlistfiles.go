@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/playlistio"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowExportPlaylistDialog asks the user for the playlist file format and
+// whether to write file paths or stream URLs, then saves the playlist to a file.
+func (m *Controller) ShowExportPlaylistDialog(playlist *mediaprovider.PlaylistWithTracks) {
+	formats := make([]string, len(playlistio.Formats))
+	for i, f := range playlistio.Formats {
+		formats[i] = string(f)
+	}
+	formatSelect := widget.NewSelect(formats, nil)
+	formatSelect.SetSelectedIndex(0)
+	pathsOpt, urlsOpt := lang.L("Relative file paths"), lang.L("Stream URLs")
+	locationRadio := widget.NewRadioGroup([]string{pathsOpt, urlsOpt}, nil)
+	locationRadio.Required = true
+	locationRadio.SetSelected(pathsOpt)
+
+	content := container.New(layout.NewFormLayout(),
+		widget.NewLabel(lang.L("Format")), formatSelect,
+		widget.NewLabel(lang.L("Track locations")), locationRadio)
+	dialog.ShowCustomConfirm(lang.L("Export Playlist"), lang.L("Export"), lang.L("Cancel"), content,
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			format := playlistio.Formats[formatSelect.SelectedIndex()]
+			useStreamURLs := locationRadio.Selected == urlsOpt
+			dlg := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+				if err != nil {
+					log.Println(err)
+					return
+				}
+				if file == nil {
+					return
+				}
+				// the playlist is written by path below
+				file.Close()
+				filePath := file.URI().Path()
+				go func() {
+					if err := m.App.PlaylistFiles.ExportPlaylist(playlist, format, useStreamURLs, filePath); err != nil {
+						log.Printf("error exporting playlist: %s", err.Error())
+						m.showError(lang.L("An error occurred exporting the playlist"))
+						return
+					}
+					m.sendNotification(fmt.Sprintf(lang.L("Playlist exported")+": %s", playlist.Name),
+						fmt.Sprintf(lang.L("Saved at")+": %s", filePath))
+				}()
+			}, m.MainWindow)
+			dlg.SetFileName(playlist.Name + format.FileExtension())
+			dlg.Show()
+		}, m.MainWindow)
+}
+
+// DoImportPlaylistWorkflow asks the user for a playlist file, matches its
+// entries to library tracks, and lets the user review the result
+// before creating the playlist on the server.
+func (m *Controller) DoImportPlaylistWorkflow() {
+	dlg := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if file == nil {
+			return
+		}
+		defer file.Close()
+		filePath := file.URI().Path()
+		format, err := playlistio.FormatForFileName(filePath)
+		if err != nil {
+			m.showError(lang.L("Unsupported playlist file format"))
+			return
+		}
+		pl, err := playlistio.Read(file, format)
+		if err != nil {
+			log.Printf("error reading playlist file: %s", err.Error())
+			m.showError(lang.L("An error occurred reading the playlist file"))
+			return
+		}
+		if len(pl.Entries) == 0 {
+			m.showError(lang.L("The playlist file contains no tracks"))
+			return
+		}
+		if pl.Name == "" {
+			pl.Name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		}
+		m.matchImportedPlaylist(pl)
+	}, m.MainWindow)
+	dlg.SetFilter(storage.NewExtensionFileFilter(playlistio.FileExtensions()))
+	dlg.Show()
+}
+
+func (m *Controller) matchImportedPlaylist(pl *playlistio.Playlist) {
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := widget.NewProgressBar()
+	progressDlg := dialog.NewCustom(lang.L("Matching tracks"), lang.L("Cancel"),
+		container.NewVBox(widget.NewLabel(pl.Name), progress), m.MainWindow)
+	progressDlg.SetOnClosed(cancel)
+	progressDlg.Resize(fyne.NewSize(350, progressDlg.MinSize().Height))
+	progressDlg.Show()
+
+	go func() {
+		matches, err := m.App.PlaylistFiles.MatchEntries(ctx, pl.Entries, func(done, total int) {
+			progress.SetValue(float64(done) / float64(total))
+		})
+		if ctx.Err() != nil {
+			return // canceled by user
+		}
+		progressDlg.Hide()
+		cancel()
+		if err != nil {
+			log.Printf("error matching playlist tracks: %s", err.Error())
+			m.showError(lang.L("An error occurred matching the playlist tracks"))
+			return
+		}
+		m.showPlaylistImportReview(pl.Name, matches)
+	}()
+}
+
+func (m *Controller) showPlaylistImportReview(name string, matches []backend.PlaylistImportMatch) {
+	dlg := dialogs.NewPlaylistImportDialog(name, matches)
+	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
+	m.ClosePopUpOnEscape(pop)
+	dlg.OnCanceled = func() {
+		pop.Hide()
+		m.doModalClosed()
+	}
+	dlg.OnFindMatch = func(idx int) {
+		pop.Hide()
+		m.showFindTrackDialog(dlg.Entry(idx), func(track *mediaprovider.Track) {
+			if track != nil {
+				dlg.SetMatch(idx, track)
+			}
+			pop.Show()
+		})
+	}
+	dlg.OnCreate = func(name string, matches []backend.PlaylistImportMatch) {
+		pop.Hide()
+		m.doModalClosed()
+		if name == "" {
+			name = lang.L("Imported playlist")
+		}
+		go func() {
+			id, err := m.App.PlaylistFiles.CreatePlaylist(name, matches)
+			if err != nil {
+				log.Printf("error creating imported playlist: %s", err.Error())
+				m.showError(lang.L("An error occurred creating the playlist"))
+				return
+			}
+			m.sendNotification(lang.L("Playlist imported"), name)
+			if id != "" {
+				m.NavigateTo(PlaylistRoute(id))
+			} else if m.CurPageFunc().Page == Playlists {
+				m.ReloadFunc()
+			}
+		}()
+	}
+	m.haveModal = true
+	pop.Resize(dlg.MinSize())
+	pop.Show()
+}
+
+// showFindTrackDialog lets the user search the library for the track
+// matching the playlist file entry. onDone is called with nil if canceled.
+func (m *Controller) showFindTrackDialog(entry playlistio.Entry, onDone func(*mediaprovider.Track)) {
+	sd := dialogs.NewSearchDialog(m.App.ImageManager, lang.L("Find track"), lang.L("Cancel"),
+		func(query string) []*mediaprovider.SearchResult {
+			if query == "" {
+				return nil
+			}
+			res, err := m.App.ServerManager.Server.SearchAll(query, 20)
+			if err != nil {
+				log.Printf("Error searching: %s", err.Error())
+				return nil
+			}
+			var tracks []*mediaprovider.SearchResult
+			for _, r := range res {
+				if r.Type == mediaprovider.ContentTypeTrack {
+					tracks = append(tracks, r)
+				}
+			}
+			return tracks
+		})
+	artist, title := entry.SearchTerms()
+	sd.SetSearchQuery(strings.TrimSpace(artist + " " + title))
+	pop := widget.NewModalPopUp(sd, m.MainWindow.Canvas())
+	sd.OnDismiss = func() {
+		pop.Hide()
+		onDone(nil)
+	}
+	sd.OnNavigateTo = func(_ mediaprovider.ContentType, id string) {
+		pop.Hide()
+		go func() {
+			tr, err := m.App.ServerManager.Server.GetTrack(id)
+			if err != nil {
+				log.Printf("error fetching track: %s", err.Error())
+				tr = nil
+			}
+			onDone(tr)
+		}()
+	}
+	sd.Show()
+	pop.Resize(fyne.NewSize(sd.MinSize().Width, sd.MinSize().Height*1.5))
+	pop.Show()
+	m.MainWindow.Canvas().Focus(sd.GetSearchEntry())
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"log"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowMoveToFolderDialog lets the user choose an existing playlist folder,
+// or type the path of a new one, to move the playlist to.
+func (m *Controller) ShowMoveToFolderDialog(playlist *mediaprovider.Playlist) {
+	go func() {
+		playlists, err := m.App.ServerManager.Server.GetPlaylists()
+		if err != nil {
+			log.Printf("error loading playlists: %s", err.Error())
+		}
+		folders := m.App.PlaylistFolders
+		folderEntry := widget.NewSelectEntry(folders.Tree(playlists).Paths())
+		folderEntry.SetText(folders.FolderOf(playlist))
+		folderEntry.PlaceHolder = lang.L("None")
+		hint := widget.NewLabel(lang.L("Separate nested folders with a spaced slash, e.g. Work / Focus"))
+		hint.Importance = widget.LowImportance
+		dlg := dialog.NewForm(lang.L("Move to folder"), lang.L("OK"), lang.L("Cancel"),
+			[]*widget.FormItem{
+				widget.NewFormItem(lang.L("Folder"), folderEntry),
+				widget.NewFormItem("", hint),
+			},
+			func(ok bool) {
+				m.doModalClosed()
+				if !ok {
+					return
+				}
+				folders.SetFolder(playlist.ID, folderEntry.Text)
+				if m.CurPageFunc().Page == Playlists {
+					m.ReloadFunc()
+				}
+			}, m.MainWindow)
+		m.haveModal = true
+		dlg.Show()
+	}()
+}
@@ -16,6 +16,7 @@ const (
 	Playlists
 	Tracks
 	Radios
+	SmartPlaylist
 )
 
 func (p PageName) String() string {
@@ -44,6 +45,8 @@ func (p PageName) String() string {
 		return "All Tracks"
 	case Radios:
 		return "Internet Radio Stations"
+	case SmartPlaylist:
+		return "Smart Playlist"
 	default:
 		return ""
 	}
@@ -81,6 +84,10 @@ func GenresRoute() Route {
 func PlaylistRoute(id string) Route {
 	return Route{Page: Playlist, Arg: id}
 }
+func SmartPlaylistRoute(id string) Route {
+	return Route{Page: SmartPlaylist, Arg: id}
+}
+
 func PlaylistsRoute() Route {
 	return Route{Page: Playlists}
 }
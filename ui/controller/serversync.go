@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// max number of unmatched items listed in the sync report
+const maxReportedUnmatched = 50
+
+// ShowServerSyncDialog shows the dialog for copying playlists, favorites
+// and ratings from one configured server to another.
+func (m *Controller) ShowServerSyncDialog() {
+	if len(m.App.Config.Servers) < 2 {
+		m.showError(lang.L("Add another server to sync with"))
+		return
+	}
+	d := dialogs.NewServerSyncDialog(m.App.Config.Servers, m.App.ServerSync.Config(), m.App.ServerManager.GetServerPassword)
+	pop := widget.NewModalPopUp(d, m.MainWindow.Canvas())
+	ctx, cancel := context.WithCancel(context.Background())
+	d.OnDismiss = func() {
+		cancel()
+		pop.Hide()
+		m.doModalClosed()
+		m.App.ServerSync.SetConfig(d.Config())
+		m.App.SaveConfigFile()
+	}
+	d.OnPreview = func() { m.runServerSync(ctx, d, false) }
+	d.OnSync = func() { m.runServerSync(ctx, d, true) }
+	m.ClosePopUpOnEscape(pop)
+	m.haveModal = true
+	pop.Resize(d.MinSize())
+	pop.Show()
+}
+
+func (m *Controller) runServerSync(ctx context.Context, d *dialogs.ServerSyncDialog, apply bool) {
+	conf := d.Config()
+	sourcePass, targetPass := d.Passwords()
+	source, target := d.Source(), d.Target()
+	d.SetBusy(true)
+	d.SetReport(lang.L("Connecting") + "...")
+	go func() {
+		defer d.SetBusy(false)
+		ss := m.App.ServerSync
+		src, err := ss.Connect(source.ID, sourcePass)
+		if err != nil {
+			log.Printf("server sync: error connecting to %s: %s", source.Nickname, err.Error())
+			d.SetReport(fmt.Sprintf(lang.L("Could not connect to")+" %s", source.Nickname))
+			return
+		}
+		tgt, err := ss.Connect(target.ID, targetPass)
+		if err != nil {
+			log.Printf("server sync: error connecting to %s: %s", target.Nickname, err.Error())
+			d.SetReport(fmt.Sprintf(lang.L("Could not connect to")+" %s", target.Nickname))
+			return
+		}
+
+		plan, err := ss.Plan(ctx, src, tgt, conf, func(step string) {
+			d.SetReport(lang.L(step) + "...")
+		})
+		if ctx.Err() != nil {
+			return // dialog closed
+		}
+		if err != nil {
+			log.Printf("server sync: error planning sync: %s", err.Error())
+			d.SetReport(lang.L("An error occurred reading from the servers"))
+			return
+		}
+		report := formatServerSyncPlan(plan)
+		if !apply || plan.IsEmpty() {
+			d.SetReport(report)
+			return
+		}
+		d.SetReport(lang.L("Syncing") + "...")
+		if err := ss.Apply(tgt, plan); err != nil {
+			log.Printf("server sync: error applying changes: %s", err.Error())
+			d.SetReport(lang.L("Some changes could not be made on the target server") + "\n\n" + report)
+			return
+		}
+		d.SetReport(lang.L("Sync complete") + "\n\n" + report)
+		if target.ID == m.App.ServerManager.ServerID {
+			m.ReloadFunc()
+		}
+	}()
+}
+
+func formatServerSyncPlan(plan *backend.ServerSyncPlan) string {
+	if plan.IsEmpty() && len(plan.Unmatched) == 0 {
+		return lang.L("The target server is already in sync")
+	}
+	var sb strings.Builder
+	for _, pl := range plan.Playlists {
+		action := lang.L("Update playlist")
+		if pl.TargetID == "" {
+			action = lang.L("Create playlist")
+		}
+		fmt.Fprintf(&sb, "%s: %s (%d / %d %s)\n", action, pl.Name,
+			pl.MatchedTrackCount, pl.SourceTrackCount, lang.L("tracks"))
+	}
+	if n := len(plan.FavoriteTrackIDs); n > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", lang.L("Favorite tracks"), n)
+	}
+	if n := len(plan.FavoriteAlbumIDs); n > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", lang.L("Favorite albums"), n)
+	}
+	if n := len(plan.FavoriteArtistIDs); n > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", lang.L("Favorite artists"), n)
+	}
+	if n := len(plan.Ratings); n > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", lang.L("Track ratings"), n)
+	}
+	if plan.RatingsUnsupported {
+		sb.WriteString(lang.L("The target server does not support ratings") + "\n")
+	}
+	if n := len(plan.Unmatched); n > 0 {
+		fmt.Fprintf(&sb, "\n%s: %d\n", lang.L("Not found on the target server"), n)
+		for _, u := range plan.Unmatched[:min(n, maxReportedUnmatched)] {
+			sb.WriteString("  " + u + "\n")
+		}
+		if n > maxReportedUnmatched {
+			sb.WriteString("  ...\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DoEditSmartPlaylistWorkflow shows the smart playlist editor for the given playlist,
+// or for a new smart playlist if playlist is nil.
+func (m *Controller) DoEditSmartPlaylistWorkflow(playlist *backend.SmartPlaylist) {
+	dlg := dialogs.NewSmartPlaylistDialog(playlist)
+	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
+	m.ClosePopUpOnEscape(pop)
+	dlg.OnCanceled = func() {
+		pop.Hide()
+		m.doModalClosed()
+	}
+	dlg.OnDelete = func() {
+		pop.Hide()
+		dialog.ShowCustomConfirm(lang.L("Confirm Delete Playlist"), lang.L("OK"), lang.L("Cancel"), layout.NewSpacer(), /*custom content*/
+			func(ok bool) {
+				if !ok {
+					pop.Show()
+					return
+				}
+				m.doModalClosed()
+				m.App.SmartPlaylists.DeleteSmartPlaylist(playlist.ID)
+				m.App.SaveConfigFile()
+				if rte := m.CurPageFunc(); rte.Page == SmartPlaylist && rte.Arg == playlist.ID {
+					// navigate to playlists page if user is still on the page of the deleted playlist
+					m.NavigateTo(PlaylistsRoute())
+				} else if rte.Page == Playlists {
+					m.ReloadFunc()
+				}
+			}, m.MainWindow)
+	}
+	dlg.OnSave = func() {
+		pop.Hide()
+		m.doModalClosed()
+		if dlg.Playlist.Name == "" {
+			dlg.Playlist.Name = lang.L("Smart Playlist")
+		}
+		m.App.SmartPlaylists.SaveSmartPlaylist(dlg.Playlist)
+		m.App.SaveConfigFile()
+		if playlist == nil {
+			m.NavigateTo(SmartPlaylistRoute(dlg.Playlist.ID))
+		} else if rte := m.CurPageFunc(); rte.Page == Playlists ||
+			(rte.Page == SmartPlaylist && rte.Arg == dlg.Playlist.ID) {
+			m.ReloadFunc()
+		}
+	}
+	m.haveModal = true
+	pop.Show()
+}
+
+// DoSaveSmartPlaylistToServerWorkflow evaluates the smart playlist and
+// writes the result to a regular playlist on the server.
+func (m *Controller) DoSaveSmartPlaylistToServerWorkflow(id string) {
+	go func() {
+		if err := m.App.SmartPlaylists.SaveToServerPlaylist(id); err != nil {
+			log.Printf("error saving smart playlist to server: %s", err.Error())
+			m.showError(lang.L("An error occurred saving the playlist to the server"))
+			return
+		}
+		m.App.SaveConfigFile()
+		if pl := m.App.SmartPlaylists.GetSmartPlaylist(id); pl != nil {
+			m.sendNotification(lang.L("Saved to server"), pl.Name)
+		}
+	}()
+}
@@ -5,8 +5,11 @@ import (
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
 	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/ui/shortcuts"
 	"github.com/dweymouth/supersonic/ui/util"
 	"github.com/dweymouth/supersonic/ui/visualizations"
@@ -17,6 +20,10 @@ type visualizationData struct {
 	peakMeter    *visualizations.PeakMeter
 	peakMeterWin fyne.Window
 
+	spectrum       *visualizations.SpectrumAnalyzer
+	spectrumWin    fyne.Window
+	spectrumLevels []float64
+
 	visualizationAnim *fyne.Animation
 }
 
@@ -24,7 +31,7 @@ func (c *Controller) initVisualizations() {
 	c.App.LocalPlayer.OnStopped(c.stopVisualizationAnim)
 	c.App.LocalPlayer.OnPaused(c.stopVisualizationAnim)
 	c.App.LocalPlayer.OnPlaying(func() {
-		if c.peakMeter != nil {
+		if c.peakMeter != nil || c.spectrum != nil {
 			c.startVisualizationAnim()
 		}
 	})
@@ -38,8 +45,8 @@ func (c *Controller) ShowPeakMeter() {
 	c.peakMeterWin = fyne.CurrentApp().NewWindow(lang.L("Peak Meter"))
 
 	onClose := func() {
-		c.stopVisualizationAnim()
 		c.peakMeter = nil
+		c.updateVisualizationAnim()
 		util.SaveWindowSize(c.peakMeterWin,
 			&c.App.Config.PeakMeter.WindowWidth,
 			&c.App.Config.PeakMeter.WindowHeight)
@@ -67,17 +74,93 @@ func (c *Controller) ShowPeakMeter() {
 	c.peakMeterWin.Show()
 }
 
+func (c *Controller) ShowSpectrumAnalyzer() {
+	if c.spectrumWin != nil {
+		c.spectrumWin.Show()
+		return
+	}
+	conf := &c.App.Config.SpectrumAnalyzer
+	c.spectrumWin = fyne.CurrentApp().NewWindow(lang.L("Spectrum Analyzer"))
+
+	onClose := func() {
+		c.spectrum = nil
+		c.updateVisualizationAnim()
+		util.SaveWindowSize(c.spectrumWin, &conf.WindowWidth, &conf.WindowHeight)
+		c.spectrumWin.Close()
+		c.spectrumWin = nil
+	}
+
+	c.spectrumWin.SetCloseIntercept(onClose)
+	c.spectrumWin.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
+		onClose()
+	})
+	if conf.WindowHeight > 0 {
+		c.spectrumWin.Resize(fyne.NewSize(float32(conf.WindowWidth), float32(conf.WindowHeight)))
+	}
+	c.spectrum = visualizations.NewSpectrumAnalyzer(mpv.SpectrumBandFrequencies)
+	c.spectrumLevels = make([]float64, len(mpv.SpectrumBandFrequencies))
+	c.spectrum.PeakHold = conf.PeakHold
+	if conf.Mode == "Line" {
+		c.spectrum.Mode = visualizations.SpectrumModeLine
+	}
+
+	modes := []string{lang.L("Bars"), lang.L("Line")}
+	mode := widget.NewRadioGroup(modes, func(s string) {
+		if s == modes[1] {
+			conf.Mode = "Line"
+			c.spectrum.Mode = visualizations.SpectrumModeLine
+		} else {
+			conf.Mode = "Bars"
+			c.spectrum.Mode = visualizations.SpectrumModeBars
+		}
+		c.spectrum.Refresh()
+	})
+	mode.Horizontal = true
+	mode.Required = true
+	mode.Selected = modes[c.spectrum.Mode]
+	peakHold := widget.NewCheck(lang.L("Peak hold"), func(b bool) {
+		conf.PeakHold = b
+		c.spectrum.PeakHold = b
+		c.spectrum.Refresh()
+	})
+	peakHold.Checked = conf.PeakHold
+
+	c.spectrumWin.SetContent(container.NewBorder(nil,
+		container.NewHBox(mode, peakHold), nil, nil, c.spectrum))
+	if c.App.LocalPlayer.GetStatus().State == player.Playing {
+		c.startVisualizationAnim()
+	} else {
+		c.spectrum.Refresh()
+	}
+	c.spectrumWin.Show()
+}
+
+// stops the animation and analysis if no visualization window is open,
+// otherwise disables the analysis no longer needed
+func (c *Controller) updateVisualizationAnim() {
+	if c.peakMeter == nil && c.spectrum == nil {
+		c.stopVisualizationAnim()
+		return
+	}
+	if c.visualizationAnim != nil {
+		c.App.LocalPlayer.SetPeaksEnabled(c.peakMeter != nil)
+		c.App.LocalPlayer.SetSpectrumEnabled(c.spectrum != nil)
+	}
+}
+
 func (c *Controller) stopVisualizationAnim() {
 	if c.visualizationAnim != nil {
 		c.visualizationAnim.Stop()
 		c.visualizationAnim = nil
 		c.App.LocalPlayer.SetPeaksEnabled(false)
+		c.App.LocalPlayer.SetSpectrumEnabled(false)
 	}
 }
 
 func (c *Controller) startVisualizationAnim() {
+	c.App.LocalPlayer.SetPeaksEnabled(c.peakMeter != nil)
+	c.App.LocalPlayer.SetSpectrumEnabled(c.spectrum != nil)
 	if c.visualizationAnim == nil {
-		c.App.LocalPlayer.SetPeaksEnabled(true)
 		c.visualizationAnim = fyne.NewAnimation(
 			time.Duration(math.MaxInt64), /*until stopped*/
 			c.tickVisualizations)
@@ -86,8 +169,12 @@ func (c *Controller) startVisualizationAnim() {
 }
 
 func (c *Controller) tickVisualizations(_ float32) {
-	lP, rP, lRMS, rRMS := c.App.LocalPlayer.GetPeaks()
 	if c.visualizationData.peakMeter != nil {
+		lP, rP, lRMS, rRMS := c.App.LocalPlayer.GetPeaks()
 		c.visualizationData.peakMeter.UpdatePeaks(lP, rP, lRMS, rRMS)
 	}
+	if c.visualizationData.spectrum != nil {
+		c.App.LocalPlayer.GetSpectrum(c.spectrumLevels)
+		c.visualizationData.spectrum.UpdateLevels(c.spectrumLevels)
+	}
 }
@@ -0,0 +1,191 @@
+package dialogs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
+	"github.com/dweymouth/supersonic/sharedutil"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var (
+	downloadTranscodeFormats = []string{"", "mp3", "opus", "ogg", "aac", "flac"}
+	downloadBitRates         = []int{0, 128, 192, 256, 320}
+)
+
+// DownloadDialog lets the user choose where and how to download tracks.
+type DownloadDialog struct {
+	widget.BaseWidget
+
+	OnBrowse   func()
+	OnDownload func()
+	OnDismiss  func()
+
+	sample *mediaprovider.Track
+
+	destEntry     *widget.Entry
+	templateEntry *widget.Entry
+	example       *widget.Label
+	formatSelect  *widget.Select
+	bitRateSelect *widget.Select
+	skipCheck     *widget.Check
+	downloadBtn   *widget.Button
+	container     *fyne.Container
+}
+
+// NewDownloadDialog creates a dialog for downloading the tracks. If canTranscode
+// is false, the server does not support transcoded downloads.
+func NewDownloadDialog(name string, tracks []*mediaprovider.Track, conf backend.DownloadConfig, canTranscode bool) *DownloadDialog {
+	d := &DownloadDialog{}
+	d.ExtendBaseWidget(d)
+	if len(tracks) > 0 {
+		d.sample = tracks[0]
+	}
+
+	d.destEntry = widget.NewEntry()
+	d.destEntry.SetText(conf.LastDestination)
+	d.destEntry.OnChanged = func(_ string) { d.update() }
+	browseBtn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		if d.OnBrowse != nil {
+			d.OnBrowse()
+		}
+	})
+
+	d.templateEntry = widget.NewEntry()
+	d.templateEntry.SetText(conf.FolderTemplate)
+	d.templateEntry.OnChanged = func(_ string) { d.update() }
+	d.example = widget.NewLabel("")
+	d.example.Importance = widget.LowImportance
+	d.example.Truncation = fyne.TextTruncateEllipsis
+	fields := strings.Join(sharedutil.MapSlice(pathtemplate.Fields, func(f string) string { return "{" + f + "}" }), " ")
+	fieldsHint := widget.NewLabel(lang.L("Fields") + ": " + fields)
+	fieldsHint.Importance = widget.LowImportance
+	fieldsHint.Wrapping = fyne.TextWrapWord
+
+	formats := []string{lang.L("Original")}
+	formats = append(formats, downloadTranscodeFormats[1:]...)
+	d.formatSelect = widget.NewSelect(formats, func(_ string) { d.update() })
+	d.formatSelect.SetSelectedIndex(0)
+	bitRates := []string{lang.L("Server default")}
+	for _, b := range downloadBitRates[1:] {
+		bitRates = append(bitRates, strconv.Itoa(b)+" kbps")
+	}
+	d.bitRateSelect = widget.NewSelect(bitRates, nil)
+	d.bitRateSelect.SetSelectedIndex(0)
+	if canTranscode {
+		for i, f := range downloadTranscodeFormats {
+			if f == conf.TranscodeFormat {
+				d.formatSelect.SetSelectedIndex(i)
+			}
+		}
+		for i, b := range downloadBitRates {
+			if b == conf.TranscodeBitRate {
+				d.bitRateSelect.SetSelectedIndex(i)
+			}
+		}
+	} else {
+		d.formatSelect.Disable()
+	}
+
+	d.skipCheck = widget.NewCheck(lang.L("Skip files that already exist"), nil)
+	d.skipCheck.Checked = conf.SkipExisting
+
+	d.downloadBtn = widget.NewButtonWithIcon(lang.L("Download"), theme.DownloadIcon(), func() {
+		if d.OnDownload != nil {
+			d.OnDownload()
+		}
+	})
+	d.downloadBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton(lang.L("Cancel"), func() {
+		if d.OnDismiss != nil {
+			d.OnDismiss()
+		}
+	})
+
+	title := widget.NewLabel(fmt.Sprintf("%s (%d %s)", name, len(tracks), lang.L("tracks")))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	title.Truncation = fyne.TextTruncateEllipsis
+	d.container = container.NewVBox(
+		title,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Destination")), container.NewBorder(nil, nil, nil, browseBtn, d.destEntry),
+			widget.NewLabel(lang.L("File names")), d.templateEntry,
+			layout.NewSpacer(), d.example,
+			layout.NewSpacer(), fieldsHint,
+			widget.NewLabel(lang.L("Format")), d.formatSelect,
+			widget.NewLabel(lang.L("Max bit rate")), d.bitRateSelect,
+			layout.NewSpacer(), d.skipCheck,
+		),
+		widget.NewSeparator(),
+		container.NewHBox(layout.NewSpacer(), cancelBtn, d.downloadBtn),
+	)
+	d.update()
+	return d
+}
+
+// Destination returns the folder to download into.
+func (d *DownloadDialog) Destination() string {
+	return strings.TrimSpace(d.destEntry.Text)
+}
+
+func (d *DownloadDialog) SetDestination(dir string) {
+	d.destEntry.SetText(dir)
+}
+
+// Options returns the download options chosen in the dialog.
+func (d *DownloadDialog) Options() backend.DownloadOptions {
+	opts := backend.DownloadOptions{
+		FolderTemplate: strings.TrimSpace(d.templateEntry.Text),
+		SkipExisting:   d.skipCheck.Checked,
+	}
+	if i := d.formatSelect.SelectedIndex(); i > 0 {
+		opts.TranscodeFormat = downloadTranscodeFormats[i]
+		opts.TranscodeBitRate = downloadBitRates[max(d.bitRateSelect.SelectedIndex(), 0)]
+	}
+	return opts
+}
+
+func (d *DownloadDialog) update() {
+	if d.downloadBtn == nil {
+		return // not yet created
+	}
+	opts := d.Options()
+	if opts.TranscodeFormat == "" {
+		d.bitRateSelect.Disable()
+	} else {
+		d.bitRateSelect.Enable()
+	}
+	err := pathtemplate.Validate(opts.FolderTemplate)
+	switch {
+	case err != nil:
+		d.example.SetText(err.Error())
+	case d.sample != nil:
+		ext := pathtemplate.Extension(d.sample, opts.TranscodeFormat)
+		d.example.SetText(filepath.FromSlash(pathtemplate.Render(opts.FolderTemplate, d.sample, ext)))
+	}
+	if err != nil || d.Destination() == "" {
+		d.downloadBtn.Disable()
+	} else {
+		d.downloadBtn.Enable()
+	}
+}
+
+func (d *DownloadDialog) MinSize() fyne.Size {
+	return fyne.NewSize(550, d.BaseWidget.MinSize().Height)
+}
+
+func (d *DownloadDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.container)
+}
@@ -0,0 +1,178 @@
+package dialogs
+
+import (
+	"fmt"
+
+	"github.com/dweymouth/supersonic/backend"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DownloadsDialog shows the progress of the download jobs.
+type DownloadsDialog struct {
+	widget.BaseWidget
+
+	OnCancel        func(jobID int)
+	OnRetry         func(jobID int)
+	OnClearFinished func()
+	OnDismiss       func()
+
+	jobs      []backend.DownloadJob
+	list      *widget.List
+	empty     *widget.Label
+	container *fyne.Container
+}
+
+func NewDownloadsDialog() *DownloadsDialog {
+	d := &DownloadsDialog{}
+	d.ExtendBaseWidget(d)
+
+	d.list = widget.NewList(
+		func() int { return len(d.jobs) },
+		func() fyne.CanvasObject { return newDownloadJobRow(d) },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(d.jobs) {
+				obj.(*downloadJobRow).Update(&d.jobs[id])
+			}
+		},
+	)
+	d.empty = widget.NewLabel(lang.L("No downloads"))
+	d.empty.Alignment = fyne.TextAlignCenter
+	d.empty.Importance = widget.LowImportance
+
+	title := widget.NewLabel(lang.L("Downloads"))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	clearBtn := widget.NewButton(lang.L("Clear finished"), func() {
+		if d.OnClearFinished != nil {
+			d.OnClearFinished()
+		}
+	})
+	closeBtn := widget.NewButton(lang.L("Close"), func() {
+		if d.OnDismiss != nil {
+			d.OnDismiss()
+		}
+	})
+	d.container = container.NewBorder(
+		container.NewVBox(title, widget.NewSeparator()),
+		container.NewVBox(widget.NewSeparator(),
+			container.NewHBox(clearBtn, layout.NewSpacer(), closeBtn)),
+		nil, nil, container.NewStack(d.list, d.empty))
+	return d
+}
+
+// Update sets the jobs shown in the dialog.
+func (d *DownloadsDialog) Update(jobs []backend.DownloadJob) {
+	d.jobs = jobs
+	d.empty.Hidden = len(jobs) > 0
+	d.list.Refresh()
+	d.empty.Refresh()
+}
+
+func (d *DownloadsDialog) MinSize() fyne.Size {
+	return fyne.NewSize(550, 400)
+}
+
+func (d *DownloadsDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.container)
+}
+
+type downloadJobRow struct {
+	widget.BaseWidget
+
+	jobID     int
+	name      *widget.Label
+	status    *widget.Label
+	progress  *widget.ProgressBar
+	cancelBtn *widget.Button
+	retryBtn  *widget.Button
+	container *fyne.Container
+}
+
+func newDownloadJobRow(d *DownloadsDialog) *downloadJobRow {
+	r := &downloadJobRow{
+		name:     widget.NewLabel(""),
+		status:   widget.NewLabel(""),
+		progress: widget.NewProgressBar(),
+	}
+	r.ExtendBaseWidget(r)
+	r.name.TextStyle.Bold = true
+	r.name.Truncation = fyne.TextTruncateEllipsis
+	r.status.Importance = widget.LowImportance
+	r.status.Truncation = fyne.TextTruncateEllipsis
+	r.progress.TextFormatter = func() string { return "" }
+	r.cancelBtn = widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		if d.OnCancel != nil {
+			d.OnCancel(r.jobID)
+		}
+	})
+	r.retryBtn = widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		if d.OnRetry != nil {
+			d.OnRetry(r.jobID)
+		}
+	})
+	r.container = container.NewBorder(nil, nil, nil,
+		container.NewHBox(r.retryBtn, r.cancelBtn),
+		container.NewVBox(r.name, r.progress, r.status))
+	return r
+}
+
+func (r *downloadJobRow) Update(job *backend.DownloadJob) {
+	r.jobID = job.ID
+	r.name.SetText(job.Name)
+
+	total := len(job.Items)
+	done := job.Count(backend.DownloadDone)
+	skipped := job.Count(backend.DownloadSkipped)
+	failed := job.Count(backend.DownloadFailed)
+	canceled := job.Count(backend.DownloadCanceled)
+	status := fmt.Sprintf("%d / %d %s", done+skipped, total, lang.L("tracks"))
+	if skipped > 0 {
+		status += fmt.Sprintf(", %d %s", skipped, lang.L("skipped"))
+	}
+	if failed > 0 {
+		status += fmt.Sprintf(", %d %s", failed, lang.L("failed"))
+	}
+	if canceled > 0 {
+		status += ", " + lang.L("canceled")
+	}
+	for _, it := range job.Items {
+		if it.Status == backend.DownloadFailed && it.Err != nil {
+			status += " (" + it.Err.Error() + ")"
+			break
+		}
+	}
+	r.status.SetText(status + " — " + job.Dir)
+
+	// count the bytes of active downloads toward progress if the size is known
+	progress := float64(done + skipped + failed + canceled)
+	for _, it := range job.Items {
+		if it.Status == backend.DownloadActive && it.Track.Size > 0 {
+			progress += min(float64(it.BytesWritten)/float64(it.Track.Size), 1)
+		}
+	}
+	if total > 0 {
+		r.progress.SetValue(progress / float64(total))
+	}
+
+	if job.IsFinished() {
+		r.cancelBtn.Disable()
+		if failed+canceled > 0 && job.CanRetry() {
+			r.retryBtn.Enable()
+		} else {
+			r.retryBtn.Disable()
+		}
+	} else {
+		r.cancelBtn.Enable()
+		r.retryBtn.Disable()
+	}
+}
+
+func (r *downloadJobRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(r.container)
+}
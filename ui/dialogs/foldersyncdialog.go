@@ -0,0 +1,260 @@
+package dialogs
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/pathtemplate"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// FolderSyncDialog lets the user choose the playlists, albums and favorites
+// to sync to a folder, such as that of a portable player, and run the sync.
+type FolderSyncDialog struct {
+	widget.BaseWidget
+
+	OnBrowse  func()
+	OnPreview func()
+	OnSync    func()
+	OnDismiss func()
+
+	conf      backend.FolderSyncConfig
+	serverID  string
+	playlists []*mediaprovider.Playlist
+	// album ID -> name of the albums of the current server to sync
+	albums map[string]string
+
+	destEntry      *widget.Entry
+	templateEntry  *widget.Entry
+	formatSelect   *widget.Select
+	bitRateSelect  *widget.Select
+	favTracksCheck *widget.Check
+	favAlbumsCheck *widget.Check
+	playlistChecks []*widget.Check
+	albumsBox      *fyne.Container
+	report         *widget.Label
+	previewBtn     *widget.Button
+	syncBtn        *widget.Button
+
+	container *fyne.Container
+}
+
+// NewFolderSyncDialog creates the dialog for the playlists of the current server, serverID.
+// If canTranscode is false, the server does not support transcoded downloads.
+func NewFolderSyncDialog(conf backend.FolderSyncConfig, serverID string, playlists []*mediaprovider.Playlist, canTranscode bool) *FolderSyncDialog {
+	d := &FolderSyncDialog{conf: conf, serverID: serverID, playlists: playlists}
+	d.ExtendBaseWidget(d)
+	d.albums = maps.Clone(conf.Albums[serverID])
+	if d.albums == nil {
+		d.albums = make(map[string]string)
+	}
+
+	d.destEntry = widget.NewEntry()
+	d.destEntry.SetText(conf.Destination)
+	d.destEntry.OnChanged = func(_ string) { d.updateButtons() }
+	browseBtn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		if d.OnBrowse != nil {
+			d.OnBrowse()
+		}
+	})
+	d.templateEntry = widget.NewEntry()
+	d.templateEntry.SetText(conf.FolderTemplate)
+	d.templateEntry.OnChanged = func(_ string) { d.updateButtons() }
+
+	formats := append([]string{lang.L("Original")}, downloadTranscodeFormats[1:]...)
+	d.formatSelect = widget.NewSelect(formats, func(_ string) { d.updateButtons() })
+	d.formatSelect.SetSelectedIndex(0)
+	bitRates := []string{lang.L("Server default")}
+	for _, b := range downloadBitRates[1:] {
+		bitRates = append(bitRates, strconv.Itoa(b)+" kbps")
+	}
+	d.bitRateSelect = widget.NewSelect(bitRates, nil)
+	d.bitRateSelect.SetSelectedIndex(0)
+	if canTranscode {
+		if i := slices.Index(downloadTranscodeFormats, conf.TranscodeFormat); i >= 0 {
+			d.formatSelect.SetSelectedIndex(i)
+		}
+		if i := slices.Index(downloadBitRates, conf.TranscodeBitRate); i >= 0 {
+			d.bitRateSelect.SetSelectedIndex(i)
+		}
+	} else {
+		d.formatSelect.Disable()
+	}
+
+	d.favTracksCheck = widget.NewCheck(lang.L("Favorite songs"), func(_ bool) { d.updateButtons() })
+	d.favTracksCheck.Checked = conf.FavoriteTracks
+	d.favAlbumsCheck = widget.NewCheck(lang.L("Favorite albums"), func(_ bool) { d.updateButtons() })
+	d.favAlbumsCheck.Checked = conf.FavoriteAlbums
+
+	playlistsBox := container.NewVBox()
+	for _, pl := range playlists {
+		c := widget.NewCheck(pl.Name, func(_ bool) { d.updateButtons() })
+		c.Checked = slices.Contains(conf.PlaylistIDs[serverID], pl.ID)
+		d.playlistChecks = append(d.playlistChecks, c)
+		playlistsBox.Add(c)
+	}
+	d.albumsBox = container.NewVBox()
+	d.rebuildAlbums()
+
+	d.report = widget.NewLabel(lang.L("Preview the sync to see which files will be copied and deleted."))
+	d.report.Wrapping = fyne.TextWrapWord
+
+	d.previewBtn = widget.NewButtonWithIcon(lang.L("Preview"), theme.SearchIcon(), func() {
+		if d.OnPreview != nil {
+			d.OnPreview()
+		}
+	})
+	d.syncBtn = widget.NewButtonWithIcon(lang.L("Sync now"), theme.ViewRefreshIcon(), func() {
+		if d.OnSync != nil {
+			d.OnSync()
+		}
+	})
+	d.syncBtn.Importance = widget.HighImportance
+	closeBtn := widget.NewButton(lang.L("Close"), func() {
+		if d.OnDismiss != nil {
+			d.OnDismiss()
+		}
+	})
+
+	title := widget.NewLabel(lang.L("Sync to Folder"))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	albumsHint := widget.NewLabel(lang.L("Add albums from the album page menu."))
+	albumsHint.Importance = widget.LowImportance
+	selection := container.NewVBox(
+		widget.NewRichTextFromMarkdown("**"+lang.L("Favorites")+"**"),
+		container.NewHBox(d.favTracksCheck, d.favAlbumsCheck),
+		widget.NewRichTextFromMarkdown("**"+lang.L("Playlists")+"**"),
+		playlistsBox,
+		widget.NewRichTextFromMarkdown("**"+lang.L("Albums")+"**"),
+		d.albumsBox,
+		albumsHint,
+	)
+	d.container = container.NewBorder(
+		container.NewVBox(
+			title,
+			container.New(layout.NewFormLayout(),
+				widget.NewLabel(lang.L("Destination")), container.NewBorder(nil, nil, nil, browseBtn, d.destEntry),
+				widget.NewLabel(lang.L("File names")), d.templateEntry,
+				widget.NewLabel(lang.L("Format")), container.NewGridWithColumns(2, d.formatSelect, d.bitRateSelect),
+			),
+			widget.NewSeparator(),
+		),
+		container.NewVBox(widget.NewSeparator(),
+			container.NewHBox(layout.NewSpacer(), closeBtn, d.previewBtn, d.syncBtn)),
+		nil, nil,
+		container.NewGridWithRows(2,
+			container.NewVScroll(selection),
+			container.NewVScroll(d.report)),
+	)
+	d.updateButtons()
+	return d
+}
+
+// Config returns the sync settings chosen in the dialog.
+func (d *FolderSyncDialog) Config() backend.FolderSyncConfig {
+	conf := d.conf
+	conf.Destination = strings.TrimSpace(d.destEntry.Text)
+	conf.FolderTemplate = strings.TrimSpace(d.templateEntry.Text)
+	conf.TranscodeFormat, conf.TranscodeBitRate = "", 0
+	if i := d.formatSelect.SelectedIndex(); i > 0 {
+		conf.TranscodeFormat = downloadTranscodeFormats[i]
+		conf.TranscodeBitRate = downloadBitRates[max(d.bitRateSelect.SelectedIndex(), 0)]
+	}
+	conf.FavoriteTracks = d.favTracksCheck.Checked
+	conf.FavoriteAlbums = d.favAlbumsCheck.Checked
+	var playlistIDs []string
+	for i, c := range d.playlistChecks {
+		if c.Checked {
+			playlistIDs = append(playlistIDs, d.playlists[i].ID)
+		}
+	}
+	// the selections of the other servers are kept
+	conf.PlaylistIDs = maps.Clone(conf.PlaylistIDs)
+	if conf.PlaylistIDs == nil {
+		conf.PlaylistIDs = make(map[string][]string)
+	}
+	conf.PlaylistIDs[d.serverID] = playlistIDs
+	conf.Albums = maps.Clone(conf.Albums)
+	if conf.Albums == nil {
+		conf.Albums = make(map[string]map[string]string)
+	}
+	conf.Albums[d.serverID] = maps.Clone(d.albums)
+	return conf
+}
+
+func (d *FolderSyncDialog) SetDestination(dir string) {
+	d.destEntry.SetText(dir)
+}
+
+// SetReport sets the text shown below the selection, such as the result of a preview.
+func (d *FolderSyncDialog) SetReport(text string) {
+	d.report.SetText(text)
+}
+
+// SetBusy disables the preview and sync buttons while an operation is running.
+func (d *FolderSyncDialog) SetBusy(busy bool) {
+	if busy {
+		d.previewBtn.Disable()
+		d.syncBtn.Disable()
+	} else {
+		d.updateButtons()
+	}
+}
+
+func (d *FolderSyncDialog) rebuildAlbums() {
+	ids := make([]string, 0, len(d.albums))
+	for id := range d.albums {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return d.albums[ids[i]] < d.albums[ids[j]] })
+	d.albumsBox.RemoveAll()
+	for _, id := range ids {
+		id := id
+		removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+			delete(d.albums, id)
+			d.rebuildAlbums()
+			d.updateButtons()
+		})
+		removeBtn.Importance = widget.LowImportance
+		d.albumsBox.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(d.albums[id])))
+	}
+}
+
+func (d *FolderSyncDialog) updateButtons() {
+	if d.previewBtn == nil {
+		return // not yet created
+	}
+	conf := d.Config()
+	if conf.TranscodeFormat == "" {
+		d.bitRateSelect.Disable()
+	} else {
+		d.bitRateSelect.Enable()
+	}
+	if conf.Destination != "" && pathtemplate.Validate(conf.FolderTemplate) == nil {
+		d.previewBtn.Enable()
+		d.syncBtn.Enable()
+	} else {
+		d.previewBtn.Disable()
+		d.syncBtn.Disable()
+	}
+}
+
+func (d *FolderSyncDialog) MinSize() fyne.Size {
+	return fyne.NewSize(550, max(d.BaseWidget.MinSize().Height, 600))
+}
+
+func (d *FolderSyncDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.container)
+}
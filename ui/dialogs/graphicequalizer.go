@@ -20,8 +20,9 @@ type GraphicEqualizer struct {
 	OnChanged       func(band int, gain float64)
 	OnPreampChanged func(gain float64)
 
-	bandSliders []*eqSlider
-	container   *fyne.Container
+	preampSlider *eqSlider
+	bandSliders  []*eqSlider
+	container    *fyne.Container
 }
 
 func NewGraphicEqualizer(preamp float64, bandFreqs []string, bandGains []float64) *GraphicEqualizer {
@@ -45,6 +46,7 @@ func (g *GraphicEqualizer) buildSliders(preamp float64, bands []string, bandGain
 	pre := newCaptionTextSizeLabel("Pre", fyne.TextAlignCenter)
 	preampSlider := newEQSlider()
 	preampSlider.SetValue(preamp)
+	g.preampSlider = preampSlider
 	preampSlider.OnChanged = func(f float64) {
 		if g.OnPreampChanged != nil {
 			g.OnPreampChanged(f)
@@ -86,6 +88,24 @@ func (g *GraphicEqualizer) buildSliders(preamp float64, bands []string, bandGain
 	)
 }
 
+// SetGains sets the preamp and band gains shown by the sliders
+// without invoking the change callbacks.
+func (g *GraphicEqualizer) SetGains(preamp float64, bandGains []float64) {
+	onChanged, onPreampChanged := g.OnChanged, g.OnPreampChanged
+	g.OnChanged, g.OnPreampChanged = nil, nil
+	defer func() { g.OnChanged, g.OnPreampChanged = onChanged, onPreampChanged }()
+	g.preampSlider.SetValue(preamp)
+	g.preampSlider.UpdateToolTip()
+	for i, s := range g.bandSliders {
+		var gain float64
+		if i < len(bandGains) {
+			gain = bandGains[i]
+		}
+		s.SetValue(gain)
+		s.UpdateToolTip()
+	}
+}
+
 func newCaptionTextSizeLabel(text string, alignment fyne.TextAlign) *widget.RichText {
 	l := widget.NewRichTextWithText(text)
 	ts := l.Segments[0].(*widget.TextSegment)
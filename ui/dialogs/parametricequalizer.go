@@ -0,0 +1,332 @@
+package dialogs
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/dweymouth/supersonic/backend/parametriceq"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	responseMinFreq    = 20
+	responseMaxFreq    = 20000
+	responseRangeDB    = 15 // the curve is drawn from -15 to +15 dB
+	responsePoints     = 120
+	defaultBandFreq    = 1000
+	defaultBandQ       = 1.0
+	maxParametricBands = 20
+)
+
+var (
+	responseGridFreqs   = []int{50, 100, 200, 500, 1000, 2000, 5000, 10000}
+	responseFrequencies = func() []float64 {
+		f := make([]float64, responsePoints)
+		for i := range f {
+			f[i] = responseMinFreq * math.Pow(responseMaxFreq/responseMinFreq, float64(i)/(responsePoints-1))
+		}
+		return f
+	}()
+)
+
+// ParametricEqualizerEditor edits the bands of a parametric equalizer
+// and shows the resulting frequency response.
+type ParametricEqualizerEditor struct {
+	widget.BaseWidget
+
+	OnChanged func(preamp float64, bands []parametriceq.Band)
+	OnImport  func()
+	OnExport  func()
+
+	preamp float64
+	bands  []parametriceq.Band
+
+	curve       *eqResponseCurve
+	preampEntry *widget.Entry
+	bandsBox    *fyne.Container
+	addBtn      *widget.Button
+	container   *fyne.Container
+}
+
+func NewParametricEqualizerEditor(preamp float64, bands []parametriceq.Band) *ParametricEqualizerEditor {
+	e := &ParametricEqualizerEditor{}
+	e.ExtendBaseWidget(e)
+
+	e.curve = newEQResponseCurve()
+	e.preampEntry = widget.NewEntry()
+	e.bandsBox = container.NewVBox()
+	e.addBtn = widget.NewButtonWithIcon(lang.L("Add band"), theme.ContentAddIcon(), func() {
+		e.bands = append(e.bands, parametriceq.Band{
+			Type:      parametriceq.FilterTypePeaking,
+			Frequency: defaultBandFreq,
+			Q:         defaultBandQ,
+		})
+		e.rebuildBands()
+		e.onChanged()
+	})
+	importBtn := widget.NewButtonWithIcon(lang.L("Import")+"...", theme.FolderOpenIcon(), func() {
+		if e.OnImport != nil {
+			e.OnImport()
+		}
+	})
+	exportBtn := widget.NewButtonWithIcon(lang.L("Export")+"...", theme.DocumentSaveIcon(), func() {
+		if e.OnExport != nil {
+			e.OnExport()
+		}
+	})
+
+	header := container.NewGridWithColumns(5,
+		widget.NewLabel(lang.L("Filter")),
+		widget.NewLabel(lang.L("Frequency")+" (Hz)"),
+		widget.NewLabel(lang.L("Gain")+" (dB)"),
+		widget.NewLabel("Q"),
+		layout.NewSpacer(),
+	)
+	e.container = container.NewBorder(
+		container.NewVBox(
+			e.curve,
+			container.NewHBox(
+				widget.NewLabel(lang.L("Preamp")), container.NewGridWrap(fyne.NewSize(80, e.preampEntry.MinSize().Height), e.preampEntry), widget.NewLabel("dB"),
+				layout.NewSpacer(), importBtn, exportBtn),
+			header,
+		),
+		container.NewHBox(e.addBtn),
+		nil, nil,
+		container.NewVScroll(e.bandsBox),
+	)
+	e.SetEqualizer(preamp, bands)
+	return e
+}
+
+// SetEqualizer replaces the preamp and bands shown in the editor.
+func (e *ParametricEqualizerEditor) SetEqualizer(preamp float64, bands []parametriceq.Band) {
+	e.preamp = preamp
+	e.bands = slices.Clone(bands)
+	e.preampEntry.OnChanged = nil
+	e.preampEntry.SetText(strconv.FormatFloat(preamp, 'f', 1, 64))
+	e.preampEntry.OnChanged = func(s string) {
+		if g, err := strconv.ParseFloat(s, 64); err == nil {
+			e.preamp = g
+			e.onChanged()
+		}
+	}
+	e.rebuildBands()
+	e.curve.SetCurve(e.preamp, e.bands)
+}
+
+func (e *ParametricEqualizerEditor) Equalizer() (preamp float64, bands []parametriceq.Band) {
+	return e.preamp, slices.Clone(e.bands)
+}
+
+func (e *ParametricEqualizerEditor) onChanged() {
+	e.curve.SetCurve(e.preamp, e.bands)
+	if e.OnChanged != nil {
+		e.OnChanged(e.Equalizer())
+	}
+}
+
+func (e *ParametricEqualizerEditor) rebuildBands() {
+	e.bandsBox.RemoveAll()
+	for i := range e.bands {
+		e.bandsBox.Add(e.newBandRow(i))
+	}
+	if len(e.bands) >= maxParametricBands {
+		e.addBtn.Disable()
+	} else {
+		e.addBtn.Enable()
+	}
+}
+
+func (e *ParametricEqualizerEditor) newBandRow(i int) fyne.CanvasObject {
+	band := &e.bands[i]
+	typeNames := []string{lang.L("Peaking"), lang.L("Low shelf"), lang.L("High shelf"), lang.L("Low pass"), lang.L("High pass")}
+	gainEntry := newNumberEntry(band.Gain, func(v float64) {
+		band.Gain = v
+		e.onChanged()
+	})
+	typeSelect := widget.NewSelect(typeNames, nil)
+	typeIdx := slices.Index(parametriceq.FilterTypes, band.Type)
+	typeSelect.SetSelectedIndex(max(typeIdx, 0))
+	updateGain := func() {
+		if band.Type == parametriceq.FilterTypeLowPass || band.Type == parametriceq.FilterTypeHighPass {
+			gainEntry.Disable()
+		} else {
+			gainEntry.Enable()
+		}
+	}
+	updateGain()
+	typeSelect.OnChanged = func(_ string) {
+		band.Type = parametriceq.FilterTypes[typeSelect.SelectedIndex()]
+		updateGain()
+		e.onChanged()
+	}
+	freqEntry := newNumberEntry(float64(band.Frequency), func(v float64) {
+		if v >= parametriceq.MinFrequency && v <= parametriceq.MaxFrequency {
+			band.Frequency = int(math.Round(v))
+			e.onChanged()
+		}
+	})
+	qEntry := newNumberEntry(band.Q, func(v float64) {
+		if v > 0 {
+			band.Q = v
+			e.onChanged()
+		}
+	})
+	removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.bands = slices.Delete(e.bands, i, i+1)
+		e.rebuildBands()
+		e.onChanged()
+	})
+	removeBtn.Importance = widget.LowImportance
+	return container.NewGridWithColumns(5, typeSelect, freqEntry, gainEntry, qEntry,
+		container.NewHBox(removeBtn))
+}
+
+func newNumberEntry(value float64, onChanged func(float64)) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.FormatFloat(value, 'f', -1, 64))
+	entry.OnChanged = func(s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			onChanged(v)
+		}
+	}
+	return entry
+}
+
+func (e *ParametricEqualizerEditor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(e.container)
+}
+
+// eqResponseCurve draws the frequency response of an equalizer curve
+// on a logarithmic frequency scale.
+type eqResponseCurve struct {
+	widget.BaseWidget
+
+	response []float64
+}
+
+func newEQResponseCurve() *eqResponseCurve {
+	c := &eqResponseCurve{response: make([]float64, responsePoints)}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *eqResponseCurve) SetCurve(preamp float64, bands []parametriceq.Band) {
+	c.response = parametriceq.Response(bands, responseFrequencies)
+	for i := range c.response {
+		c.response[i] += preamp
+	}
+	c.Refresh()
+}
+
+func (c *eqResponseCurve) CreateRenderer() fyne.WidgetRenderer {
+	r := &eqResponseCurveRenderer{c: c}
+	r.background = canvas.NewRectangle(color.Transparent)
+	r.zeroLine = canvas.NewLine(color.Transparent)
+	for _, f := range responseGridFreqs {
+		r.gridLines = append(r.gridLines, canvas.NewLine(color.Transparent))
+		label := canvas.NewText(parametriceq.FormatFrequency(f), color.Transparent)
+		label.TextSize = theme.CaptionTextSize()
+		r.gridLabels = append(r.gridLabels, label)
+	}
+	for _, db := range []int{responseRangeDB, -responseRangeDB} {
+		label := canvas.NewText(fmt.Sprintf("%+d dB", db), color.Transparent)
+		label.TextSize = theme.CaptionTextSize()
+		r.dbLabels = append(r.dbLabels, label)
+	}
+	r.segments = make([]*canvas.Line, responsePoints-1)
+	for i := range r.segments {
+		r.segments[i] = canvas.NewLine(color.Transparent)
+		r.segments[i].StrokeWidth = 2
+	}
+	r.objects = []fyne.CanvasObject{r.background, r.zeroLine}
+	for i := range r.gridLines {
+		r.objects = append(r.objects, r.gridLines[i], r.gridLabels[i])
+	}
+	for _, l := range r.dbLabels {
+		r.objects = append(r.objects, l)
+	}
+	for _, s := range r.segments {
+		r.objects = append(r.objects, s)
+	}
+	r.Refresh()
+	return r
+}
+
+type eqResponseCurveRenderer struct {
+	c *eqResponseCurve
+
+	background *canvas.Rectangle
+	zeroLine   *canvas.Line
+	gridLines  []*canvas.Line
+	gridLabels []*canvas.Text
+	dbLabels   []*canvas.Text
+	segments   []*canvas.Line
+	objects    []fyne.CanvasObject
+}
+
+func (r *eqResponseCurveRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 130)
+}
+
+func (r *eqResponseCurveRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	xFor := func(f float64) float32 {
+		return float32(math.Log(f/responseMinFreq)/math.Log(responseMaxFreq/responseMinFreq)) * size.Width
+	}
+	yFor := func(db float64) float32 {
+		db = math.Max(-responseRangeDB, math.Min(responseRangeDB, db))
+		return float32((responseRangeDB-db)/(2*responseRangeDB)) * size.Height
+	}
+	r.zeroLine.Position1 = fyne.NewPos(0, yFor(0))
+	r.zeroLine.Position2 = fyne.NewPos(size.Width, yFor(0))
+	for i, f := range responseGridFreqs {
+		x := xFor(float64(f))
+		r.gridLines[i].Position1 = fyne.NewPos(x, 0)
+		r.gridLines[i].Position2 = fyne.NewPos(x, size.Height)
+		r.gridLabels[i].Move(fyne.NewPos(x+2, size.Height-r.gridLabels[i].MinSize().Height))
+	}
+	r.dbLabels[0].Move(fyne.NewPos(2, 0))
+	r.dbLabels[1].Move(fyne.NewPos(2, size.Height-2*r.dbLabels[1].MinSize().Height))
+	resp := r.c.response
+	for i, s := range r.segments {
+		s.Position1 = fyne.NewPos(xFor(responseFrequencies[i]), yFor(resp[i]))
+		s.Position2 = fyne.NewPos(xFor(responseFrequencies[i+1]), yFor(resp[i+1]))
+	}
+}
+
+func (r *eqResponseCurveRenderer) Refresh() {
+	fg, bg := theme.ForegroundColor(), theme.InputBackgroundColor()
+	grid := myTheme.BlendColors(fg, bg, 0.2)
+	r.background.FillColor = bg
+	r.zeroLine.StrokeColor = myTheme.BlendColors(fg, bg, 0.4)
+	for i := range r.gridLines {
+		r.gridLines[i].StrokeColor = grid
+		r.gridLabels[i].Color = myTheme.BlendColors(fg, bg, 0.6)
+	}
+	for _, l := range r.dbLabels {
+		l.Color = myTheme.BlendColors(fg, bg, 0.6)
+	}
+	for _, s := range r.segments {
+		s.StrokeColor = theme.PrimaryColor()
+	}
+	r.Layout(r.c.Size())
+	canvas.Refresh(r.c)
+}
+
+func (r *eqResponseCurveRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *eqResponseCurveRenderer) Destroy() {}
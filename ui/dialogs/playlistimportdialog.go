@@ -0,0 +1,161 @@
+package dialogs
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/playlistio"
+)
+
+// PlaylistImportDialog shows the results of matching an imported playlist file
+// to library tracks, so the user can review and fix unmatched entries
+// before the playlist is created.
+type PlaylistImportDialog struct {
+	widget.BaseWidget
+
+	OnCanceled func()
+	OnCreate   func(name string, matches []backend.PlaylistImportMatch)
+	// Invoked when the user wants to find a library track for the entry at index
+	OnFindMatch func(idx int)
+
+	matches   []backend.PlaylistImportMatch
+	unmatched []int // indexes into matches of the initially unmatched entries
+
+	nameEntry    *widget.Entry
+	summaryLabel *widget.Label
+	list         *widget.List
+	container    *fyne.Container
+}
+
+func NewPlaylistImportDialog(name string, matches []backend.PlaylistImportMatch) *PlaylistImportDialog {
+	d := &PlaylistImportDialog{matches: matches}
+	d.ExtendBaseWidget(d)
+	for i, m := range matches {
+		if m.Track == nil {
+			d.unmatched = append(d.unmatched, i)
+		}
+	}
+
+	d.nameEntry = widget.NewEntry()
+	d.nameEntry.SetText(name)
+	d.summaryLabel = widget.NewLabel("")
+	d.list = widget.NewList(
+		func() int { return len(d.unmatched) },
+		func() fyne.CanvasObject {
+			icon := widget.NewIcon(theme.WarningIcon())
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			btn := widget.NewButtonWithIcon(lang.L("Find"), theme.SearchIcon(), nil)
+			return container.NewBorder(nil, nil, icon, btn, label)
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			c := co.(*fyne.Container)
+			label := c.Objects[0].(*widget.Label)
+			icon := c.Objects[1].(*widget.Icon)
+			btn := c.Objects[2].(*widget.Button)
+			idx := d.unmatched[id]
+			m := d.matches[idx]
+			text := describeEntry(m.Entry)
+			if m.Track != nil {
+				icon.SetResource(theme.ConfirmIcon())
+				text = fmt.Sprintf("%s → %s - %s", text, strings.Join(m.Track.ArtistNames, ", "), m.Track.Title)
+			} else {
+				icon.SetResource(theme.WarningIcon())
+			}
+			label.SetText(text)
+			btn.OnTapped = func() {
+				if d.OnFindMatch != nil {
+					d.OnFindMatch(idx)
+				}
+			}
+		},
+	)
+
+	createBtn := widget.NewButtonWithIcon(lang.L("Create playlist"), theme.ConfirmIcon(), func() {
+		if d.OnCreate != nil {
+			d.OnCreate(d.nameEntry.Text, d.matches)
+		}
+	})
+	createBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButtonWithIcon(lang.L("Cancel"), theme.CancelIcon(), func() {
+		if d.OnCanceled != nil {
+			d.OnCanceled()
+		}
+	})
+
+	title := widget.NewLabel(lang.L("Import Playlist"))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	unmatchedHint := widget.NewLabel(lang.L("Tracks that could not be found in the library will be skipped."))
+	unmatchedHint.Importance = widget.LowImportance
+	unmatchedHint.Wrapping = fyne.TextWrapWord
+	top := container.NewVBox(
+		title,
+		container.New(layout.NewFormLayout(), widget.NewLabel(lang.L("Name")), d.nameEntry),
+		d.summaryLabel,
+	)
+	if len(d.unmatched) > 0 {
+		top.Add(unmatchedHint)
+	} else {
+		d.list.Hide()
+	}
+	d.container = container.NewBorder(
+		top,
+		container.NewVBox(widget.NewSeparator(),
+			container.NewHBox(layout.NewSpacer(), cancelBtn, createBtn)),
+		nil, nil, d.list)
+	d.updateSummary()
+	return d
+}
+
+// SetMatch sets the library track matched to the entry at index idx.
+func (d *PlaylistImportDialog) SetMatch(idx int, track *mediaprovider.Track) {
+	d.matches[idx].Track = track
+	d.updateSummary()
+	d.list.Refresh()
+}
+
+// Entry returns the playlist file entry at index idx.
+func (d *PlaylistImportDialog) Entry(idx int) playlistio.Entry {
+	return d.matches[idx].Entry
+}
+
+func (d *PlaylistImportDialog) updateSummary() {
+	matched := 0
+	for _, m := range d.matches {
+		if m.Track != nil {
+			matched++
+		}
+	}
+	d.summaryLabel.SetText(fmt.Sprintf("%s: %d / %d", lang.L("Tracks matched"), matched, len(d.matches)))
+}
+
+func (d *PlaylistImportDialog) MinSize() fyne.Size {
+	h := d.BaseWidget.MinSize().Height
+	if !d.list.Hidden {
+		h = max(h, 400)
+	}
+	return fyne.NewSize(500, h)
+}
+
+func (d *PlaylistImportDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.container)
+}
+
+func describeEntry(e playlistio.Entry) string {
+	if e.Title == "" {
+		return e.Location
+	}
+	if e.Artist == "" {
+		return e.Title
+	}
+	return e.Artist + " - " + e.Title
+}
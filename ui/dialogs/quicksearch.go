@@ -17,6 +17,7 @@ type QuickSearch struct {
 func NewQuickSearch(mp mediaprovider.MediaProvider, im util.ImageFetcher) *QuickSearch {
 	q := &QuickSearch{mp: mp}
 	q.SearchDialog = NewSearchDialog(im, lang.L("Search Everywhere"), lang.L("Close"), q.onSearched)
+	q.SearchDialog.PlaceholderText = lang.L("Search (e.g. artist:name year:1990..1999 is:favorite)")
 	return q
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image"
 	"log"
+	"strings"
 	"sync"
 
 	"fyne.io/fyne/v2"
@@ -13,6 +14,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 
 	"fyne.io/fyne/v2/widget"
+	"github.com/deluan/sanitize"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 
 	myTheme "github.com/dweymouth/supersonic/ui/theme"
@@ -41,6 +43,7 @@ type SearchDialog struct {
 
 	searchEntry *searchEntry
 	loadingDots *widgets.LoadingDots
+	statusLabel *widget.Label
 	list        *widget.List
 	dialogTitle string
 	dismissText string
@@ -51,6 +54,7 @@ func NewSearchDialog(im util.ImageFetcher, title, dismissBtn string, onSearched
 	sd := &SearchDialog{
 		imgSource:   im,
 		loadingDots: widgets.NewLoadingDots(),
+		statusLabel: widget.NewLabel(""),
 		OnSearched:  onSearched,
 		dialogTitle: title,
 		dismissText: dismissBtn,
@@ -86,6 +90,8 @@ func NewSearchDialog(im util.ImageFetcher, title, dismissBtn string, onSearched
 		},
 	)
 	sd.list.HideSeparators = true
+	sd.statusLabel.Importance = widget.LowImportance
+	sd.statusLabel.Hide()
 	return sd
 }
 
@@ -99,9 +105,14 @@ func (sd *SearchDialog) SearchQuery() string {
 	return sd.searchEntry.Text
 }
 
+// SetSearchQuery sets the query to search for when the dialog is shown
+func (sd *SearchDialog) SetSearchQuery(query string) {
+	sd.searchEntry.Entry.Text = query
+}
+
 func (sd *SearchDialog) Show() {
 	sd.BaseWidget.Show()
-	go sd.onSearched("")
+	go sd.onSearched(sd.searchEntry.Text)
 }
 
 func (sd *SearchDialog) Refresh() {
@@ -171,6 +182,49 @@ func (sd *SearchDialog) onSearched(query string) {
 	}
 	sd.loadingDots.Stop()
 	sd.setResults(results)
+	sd.updateStatusLabel(query, results)
+}
+
+// updateStatusLabel tells the user when there are no results for the query,
+// or when the results are only close matches suggested in case of a typo
+func (sd *SearchDialog) updateStatusLabel(query string, results []*mediaprovider.SearchResult) {
+	var status string
+	if query != "" {
+		if len(results) == 0 {
+			status = lang.L("No results found")
+		} else if onlyCloseMatches(query, results) {
+			status = lang.L("No exact matches. Did you mean:")
+		}
+	}
+	sd.statusLabel.SetText(status)
+	if status == "" {
+		sd.statusLabel.Hide()
+	} else {
+		sd.statusLabel.Show()
+	}
+}
+
+// onlyCloseMatches returns true if none of the results (excluding action
+// items with no ID) contain any of the query terms in their name
+func onlyCloseMatches(query string, results []*mediaprovider.SearchResult) bool {
+	if !mediaprovider.ParseSearchQuery(query).IsPlain() {
+		return false
+	}
+	terms := strings.Fields(strings.ToLower(sanitize.Accents(query)))
+	haveResult := false
+	for _, r := range results {
+		if r.ID == "" {
+			continue
+		}
+		haveResult = true
+		name := strings.ToLower(sanitize.Accents(r.Name + " " + r.ArtistName))
+		for _, t := range terms {
+			if strings.Contains(name, t) {
+				return false
+			}
+		}
+	}
+	return haveResult
 }
 
 func (sd *SearchDialog) CreateRenderer() fyne.WidgetRenderer {
@@ -187,7 +241,8 @@ func (sd *SearchDialog) CreateRenderer() fyne.WidgetRenderer {
 		container.NewBorder(
 			container.NewVBox(title,
 				container.New(layout.NewCustomPaddedLayout(0, 0, 2, 2),
-					sd.searchEntry)),
+					sd.searchEntry),
+				sd.statusLabel),
 			container.NewVBox(widget.NewSeparator(), bottomRow),
 			nil, nil,
 			container.New(layout.NewCustomPaddedLayout(0, 0, 4, 4), sd.list)),
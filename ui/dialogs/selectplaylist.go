@@ -7,11 +7,15 @@ import (
 	"strings"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
 	"github.com/deluan/sanitize"
+	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/backend/playlistfolders"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/util"
 )
@@ -19,14 +23,18 @@ import (
 type SelectPlaylist struct {
 	SearchDialog      *SearchDialog
 	mp                mediaprovider.MediaProvider
+	folders           *backend.PlaylistFolderManager
 	loggedInUser      string
 	allPlaylistResuts []*mediaprovider.SearchResult
+	playlistFolders   map[string]string // playlist ID -> folder path
+	folderSelect      *widget.Select
 	SkipDuplicates    bool
 }
 
-func NewSelectPlaylistDialog(mp mediaprovider.MediaProvider, im util.ImageFetcher, loggedInUser string, skipDups bool) *SelectPlaylist {
+func NewSelectPlaylistDialog(mp mediaprovider.MediaProvider, im util.ImageFetcher, folders *backend.PlaylistFolderManager, loggedInUser string, skipDups bool) *SelectPlaylist {
 	sp := &SelectPlaylist{
 		mp:             mp,
+		folders:        folders,
 		loggedInUser:   loggedInUser,
 		SkipDuplicates: skipDups,
 	}
@@ -36,7 +44,13 @@ func NewSelectPlaylistDialog(mp mediaprovider.MediaProvider, im util.ImageFetche
 		lang.L("Cancel"),
 		sp.onSearched,
 	)
-	sd.ActionItem = widget.NewCheckWithData(lang.L("Skip duplicate tracks"), binding.BindBool(&sp.SkipDuplicates))
+	sp.folderSelect = widget.NewSelect(nil, func(_ string) {
+		go sd.onSearched(sd.SearchQuery())
+	})
+	sp.folderSelect.Hide() // shown once playlists are fetched, if there are folders
+	sd.ActionItem = container.NewHBox(
+		widget.NewCheckWithData(lang.L("Skip duplicate tracks"), binding.BindBool(&sp.SkipDuplicates)),
+		sp.folderSelect)
 	sd.PlaceholderText = lang.L("Search playlists or new playlist name")
 	sp.SearchDialog = sd
 	return sp
@@ -52,6 +66,27 @@ func (sp *SelectPlaylist) fetchUserOwnedPlaylists() {
 		return playlist.Owner == sp.loggedInUser
 	})
 	sp.allPlaylistResuts = sharedutil.MapSlice(userPlaylists, sp.playlistToSearchResult)
+
+	sp.playlistFolders = make(map[string]string, len(userPlaylists))
+	for _, pl := range userPlaylists {
+		sp.playlistFolders[pl.ID] = sp.folders.FolderOf(pl)
+	}
+	if paths := sp.folders.Tree(userPlaylists).Paths(); len(paths) > 0 {
+		sp.folderSelect.Options = append([]string{lang.L("All folders")}, paths...)
+		sp.folderSelect.Selected = sp.folderSelect.Options[0]
+		sp.folderSelect.Show()
+	}
+}
+
+// filters the playlist results to those in the selected folder or its subfolders
+func (sp *SelectPlaylist) filterByFolder(results []*mediaprovider.SearchResult) []*mediaprovider.SearchResult {
+	if sp.folderSelect.SelectedIndex() <= 0 {
+		return results
+	}
+	folder := sp.folderSelect.Selected
+	return sharedutil.FilterSlice(results, func(r *mediaprovider.SearchResult) bool {
+		return playlistfolders.IsWithin(sp.playlistFolders[r.ID], folder)
+	})
 }
 
 func (sp *SelectPlaylist) playlistToSearchResult(playlist *mediaprovider.Playlist) *mediaprovider.SearchResult {
@@ -73,15 +108,20 @@ func (sp *SelectPlaylist) onSearched(query string) []*mediaprovider.SearchResult
 		sp.fetchUserOwnedPlaylists()
 	}
 	var results []*mediaprovider.SearchResult
+	playlists := sp.filterByFolder(sp.allPlaylistResuts)
 	if query == "" {
-		results = sp.allPlaylistResuts
+		results = playlists
 	} else {
-		results = sharedutil.FilterSlice(sp.allPlaylistResuts, func(playlist *mediaprovider.SearchResult) bool {
+		results = sharedutil.FilterSlice(playlists, func(playlist *mediaprovider.SearchResult) bool {
 			return strings.Contains(
 				sanitize.Accents(strings.ToLower(playlist.Name)),
 				sanitize.Accents(strings.ToLower(query)),
 			)
 		})
+		if len(results) == 0 {
+			// the query may contain a typo - suggest close matches instead
+			results = helpers.FuzzyMatchResults(playlists, query)
+		}
 		results = append(results, &mediaprovider.SearchResult{
 			Name: fmt.Sprintf("%s: %s", lang.L("Create new playlist"), query),
 			Type: mediaprovider.ContentTypePlaylist,
@@ -0,0 +1,212 @@
+package dialogs
+
+import (
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/sharedutil"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var autoSyncIntervalHours = []int{0, 24, 24 * 7}
+
+// ServerSyncDialog lets the user choose two configured servers and what to
+// copy from one to the other, preview the changes and run the sync.
+type ServerSyncDialog struct {
+	widget.BaseWidget
+
+	OnPreview func()
+	OnSync    func()
+	OnDismiss func()
+
+	servers []*backend.ServerConfig
+	conf    backend.ServerSyncConfig
+
+	sourceSelect   *widget.Select
+	targetSelect   *widget.Select
+	sourcePass     *widget.Entry
+	targetPass     *widget.Entry
+	playlistsCheck *widget.Check
+	favoritesCheck *widget.Check
+	ratingsCheck   *widget.Check
+	autoSyncSelect *widget.Select
+	report         *widget.Label
+	previewBtn     *widget.Button
+	syncBtn        *widget.Button
+	closeBtn       *widget.Button
+
+	container *fyne.Container
+}
+
+func NewServerSyncDialog(servers []*backend.ServerConfig, conf backend.ServerSyncConfig, pwFetch PasswordFetchFunc) *ServerSyncDialog {
+	d := &ServerSyncDialog{servers: servers, conf: conf}
+	d.ExtendBaseWidget(d)
+
+	d.sourcePass = widget.NewPasswordEntry()
+	d.targetPass = widget.NewPasswordEntry()
+	serverNames := sharedutil.MapSlice(servers, func(s *backend.ServerConfig) string { return s.Nickname })
+	newServerSelect := func(passField *widget.Entry) *widget.Select {
+		var s *widget.Select
+		s = widget.NewSelect(serverNames, func(_ string) {
+			passField.SetText("")
+			if pwFetch != nil {
+				if pw, err := pwFetch(servers[s.SelectedIndex()].ID); err == nil {
+					passField.SetText(pw)
+				}
+			}
+			d.updateButtons()
+		})
+		return s
+	}
+	d.sourceSelect = newServerSelect(d.sourcePass)
+	d.targetSelect = newServerSelect(d.targetPass)
+
+	d.playlistsCheck = widget.NewCheck(lang.L("Playlists"), func(_ bool) { d.updateButtons() })
+	d.playlistsCheck.Checked = conf.SyncPlaylists
+	d.favoritesCheck = widget.NewCheck(lang.L("Favorites"), func(_ bool) { d.updateButtons() })
+	d.favoritesCheck.Checked = conf.SyncFavorites
+	d.ratingsCheck = widget.NewCheck(lang.L("Ratings"), func(_ bool) { d.updateButtons() })
+	d.ratingsCheck.Checked = conf.SyncRatings
+
+	d.autoSyncSelect = widget.NewSelect([]string{lang.L("Off"), lang.L("Daily"), lang.L("Weekly")}, nil)
+	d.autoSyncSelect.SetSelectedIndex(0)
+	for i, h := range autoSyncIntervalHours {
+		if h == conf.AutoSyncIntervalHours {
+			d.autoSyncSelect.SetSelectedIndex(i)
+		}
+	}
+
+	d.report = widget.NewLabel(lang.L("Preview the sync to see which changes will be made on the target server."))
+	d.report.Wrapping = fyne.TextWrapWord
+
+	d.previewBtn = widget.NewButtonWithIcon(lang.L("Preview"), theme.SearchIcon(), func() {
+		if d.OnPreview != nil {
+			d.OnPreview()
+		}
+	})
+	d.syncBtn = widget.NewButtonWithIcon(lang.L("Sync now"), theme.ViewRefreshIcon(), func() {
+		if d.OnSync != nil {
+			d.OnSync()
+		}
+	})
+	d.syncBtn.Importance = widget.HighImportance
+	d.closeBtn = widget.NewButton(lang.L("Close"), func() {
+		if d.OnDismiss != nil {
+			d.OnDismiss()
+		}
+	})
+
+	for i, s := range servers {
+		if s.ID.String() == conf.SourceServerID {
+			d.sourceSelect.SetSelectedIndex(i)
+		}
+		if s.ID.String() == conf.TargetServerID {
+			d.targetSelect.SetSelectedIndex(i)
+		}
+	}
+
+	title := widget.NewLabel(lang.L("Sync Between Servers"))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	autoSyncHint := widget.NewLabel(lang.L("Recurring syncs use the saved server passwords."))
+	autoSyncHint.Importance = widget.LowImportance
+	d.container = container.NewBorder(
+		container.NewVBox(
+			title,
+			container.New(layout.NewFormLayout(),
+				widget.NewLabel(lang.L("Copy from")), d.sourceSelect,
+				widget.NewLabel(lang.L("Password")), d.sourcePass,
+				widget.NewLabel(lang.L("Copy to")), d.targetSelect,
+				widget.NewLabel(lang.L("Password")), d.targetPass,
+				layout.NewSpacer(), container.NewHBox(d.playlistsCheck, d.favoritesCheck, d.ratingsCheck),
+				widget.NewLabel(lang.L("Automatic sync")), d.autoSyncSelect,
+			),
+			autoSyncHint,
+			widget.NewSeparator(),
+		),
+		container.NewVBox(widget.NewSeparator(),
+			container.NewHBox(layout.NewSpacer(), d.closeBtn, d.previewBtn, d.syncBtn)),
+		nil, nil, container.NewVScroll(d.report))
+	d.updateButtons()
+	return d
+}
+
+// Config returns the sync settings chosen in the dialog.
+func (d *ServerSyncDialog) Config() backend.ServerSyncConfig {
+	conf := d.conf
+	conf.SourceServerID, conf.TargetServerID = "", ""
+	if s := d.Source(); s != nil {
+		conf.SourceServerID = s.ID.String()
+	}
+	if t := d.Target(); t != nil {
+		conf.TargetServerID = t.ID.String()
+	}
+	conf.SyncPlaylists = d.playlistsCheck.Checked
+	conf.SyncFavorites = d.favoritesCheck.Checked
+	conf.SyncRatings = d.ratingsCheck.Checked
+	conf.AutoSyncIntervalHours = autoSyncIntervalHours[d.autoSyncSelect.SelectedIndex()]
+	return conf
+}
+
+// Source returns the server to copy from, or nil if none is selected.
+func (d *ServerSyncDialog) Source() *backend.ServerConfig {
+	return d.selectedServer(d.sourceSelect)
+}
+
+// Target returns the server to copy to, or nil if none is selected.
+func (d *ServerSyncDialog) Target() *backend.ServerConfig {
+	return d.selectedServer(d.targetSelect)
+}
+
+func (d *ServerSyncDialog) Passwords() (source, target string) {
+	return d.sourcePass.Text, d.targetPass.Text
+}
+
+// SetReport sets the text shown below the settings, such as the result of a preview.
+func (d *ServerSyncDialog) SetReport(text string) {
+	d.report.SetText(text)
+}
+
+// SetBusy disables the preview and sync buttons while an operation is running.
+func (d *ServerSyncDialog) SetBusy(busy bool) {
+	if busy {
+		d.previewBtn.Disable()
+		d.syncBtn.Disable()
+	} else {
+		d.updateButtons()
+	}
+}
+
+func (d *ServerSyncDialog) updateButtons() {
+	if d.previewBtn == nil {
+		return // not yet created
+	}
+	s, t := d.Source(), d.Target()
+	if s != nil && t != nil && s.ID != t.ID &&
+		(d.playlistsCheck.Checked || d.favoritesCheck.Checked || d.ratingsCheck.Checked) {
+		d.previewBtn.Enable()
+		d.syncBtn.Enable()
+	} else {
+		d.previewBtn.Disable()
+		d.syncBtn.Disable()
+	}
+}
+
+func (d *ServerSyncDialog) selectedServer(s *widget.Select) *backend.ServerConfig {
+	if i := s.SelectedIndex(); i >= 0 && i < len(d.servers) {
+		return d.servers[i]
+	}
+	return nil
+}
+
+func (d *ServerSyncDialog) MinSize() fyne.Size {
+	return fyne.NewSize(500, max(d.BaseWidget.MinSize().Height, 500))
+}
+
+func (d *ServerSyncDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.container)
+}
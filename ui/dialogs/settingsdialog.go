@@ -2,6 +2,7 @@ package dialogs
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"os"
 	"slices"
@@ -11,7 +12,9 @@ import (
 	"unicode"
 
 	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/parametriceq"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
+	"github.com/dweymouth/supersonic/sharedutil"
 	myTheme "github.com/dweymouth/supersonic/ui/theme"
 	"github.com/dweymouth/supersonic/ui/util"
 	"github.com/dweymouth/supersonic/ui/widgets"
@@ -33,10 +36,13 @@ type SettingsDialog struct {
 	OnReplayGainSettingsChanged    func()
 	OnAudioExclusiveSettingChanged func()
 	OnAudioDeviceSettingChanged    func()
+	OnAudioOutputSettingsChanged   func()
+	OnVolumeFadeSettingChanged     func()
 	OnThemeSettingChanged          func()
 	OnDismiss                      func()
 	OnEqualizerSettingsChanged     func()
 	OnPageNeedsRefresh             func()
+	OnWaveformSettingChanged       func()
 
 	config       *backend.Config
 	audioDevices []mpv.AudioDevice
@@ -45,12 +51,22 @@ type SettingsDialog struct {
 
 	clientDecidesScrobble bool
 
+	// updates the equalizer tab from the config, set if it was created
+	refreshEqualizer func()
+
+	deviceSelect *widget.Select
+
+	// returns the features preventing bit-perfect playback
+	bitPerfectConflicts func() []string
+	bitPerfectWarning   *widget.Label
+
 	content fyne.CanvasObject
 }
 
 // TODO: having this depend on the mpv package for the AudioDevice type is kinda gross. Refactor.
 func NewSettingsDialog(
 	config *backend.Config,
+	bitPerfectConflicts func() []string,
 	audioDeviceList []mpv.AudioDevice,
 	themeFileList map[string]string,
 	equalizerBands []string,
@@ -61,7 +77,7 @@ func NewSettingsDialog(
 	canSavePlayQueue bool,
 	window fyne.Window,
 ) *SettingsDialog {
-	s := &SettingsDialog{config: config, audioDevices: audioDeviceList, themeFiles: themeFileList, clientDecidesScrobble: clientDecidesScrobble}
+	s := &SettingsDialog{config: config, bitPerfectConflicts: bitPerfectConflicts, audioDevices: audioDeviceList, themeFiles: themeFileList, clientDecidesScrobble: clientDecidesScrobble}
 	s.ExtendBaseWidget(s)
 
 	// TODO: Once Fyne supports disableable sliders, it's probably a nicer UX
@@ -69,14 +85,14 @@ func NewSettingsDialog(
 	var tabs *container.AppTabs
 	if isEqualizerPlayer {
 		tabs = container.NewAppTabs(
-			s.createGeneralTab(canSavePlayQueue),
+			s.createGeneralTab(canSavePlayQueue, window),
 			s.createPlaybackTab(isLocalPlayer, isReplayGainPlayer),
-			s.createEqualizerTab(equalizerBands),
+			s.createEqualizerTab(equalizerBands, window),
 			s.createExperimentalTab(window),
 		)
 	} else {
 		tabs = container.NewAppTabs(
-			s.createGeneralTab(canSavePlayQueue),
+			s.createGeneralTab(canSavePlayQueue, window),
 			s.createPlaybackTab(isLocalPlayer, isReplayGainPlayer),
 			s.createExperimentalTab(window),
 		)
@@ -85,6 +101,7 @@ func NewSettingsDialog(
 	tabs.SelectIndex(s.getActiveTabNumFromConfig())
 	tabs.OnSelected = func(ti *container.TabItem) {
 		s.saveSelectedTab(tabs.SelectedIndex())
+		s.updateBitPerfectWarning()
 	}
 	s.promptText = widget.NewRichTextWithText("")
 	s.content = container.NewVBox(tabs, widget.NewSeparator(),
@@ -97,7 +114,7 @@ func NewSettingsDialog(
 	return s
 }
 
-func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.TabItem {
+func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool, window fyne.Window) *container.TabItem {
 	themeNames := []string{"Default"}
 	themeFileNames := []string{""}
 	i, selIndex := 1, 0
@@ -204,6 +221,29 @@ func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.
 		}
 	})
 	albumGridYears.Checked = s.config.AlbumsPage.ShowYears
+	waveformSeekBar := widget.NewCheck(lang.L("Show waveform in seek bar"), func(b bool) {
+		s.config.Application.ShowWaveformSeekBar = b
+		if s.OnWaveformSettingChanged != nil {
+			s.OnWaveformSettingChanged()
+		}
+	})
+	waveformSeekBar.Checked = s.config.Application.ShowWaveformSeekBar
+
+	// Lyrics settings
+
+	lyricsFolder := widget.NewEntry()
+	lyricsFolder.SetPlaceHolder(lang.L("Default"))
+	lyricsFolder.Text = s.config.Lyrics.LyricsFolder
+	lyricsFolder.OnChanged = func(path string) {
+		s.config.Lyrics.LyricsFolder = path
+	}
+	lyricsFolderBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(lu fyne.ListableURI, err error) {
+			if err == nil && lu != nil {
+				lyricsFolder.SetText(lu.Path())
+			}
+		}, window)
+	})
 
 	// Scrobble settings
 
@@ -296,6 +336,14 @@ func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.
 		saveQueueHBox,
 		trackNotif,
 		albumGridYears,
+		waveformSeekBar,
+		s.newSectionSeparator(),
+
+		widget.NewRichText(&widget.TextSegment{Text: lang.L("Lyrics"), Style: util.BoldRichTextStyle}),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Lyrics sources")), s.newLyricsSourcesRow(),
+			widget.NewLabel(lang.L("Lyrics folder")), container.NewBorder(nil, nil, nil, lyricsFolderBrowse, lyricsFolder),
+		),
 		s.newSectionSeparator(),
 
 		widget.NewRichText(&widget.TextSegment{Text: "Scrobbling", Style: util.BoldRichTextStyle}),
@@ -315,22 +363,19 @@ func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.
 
 func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer bool) *container.TabItem {
 	disableTranscode := widget.NewCheckWithData(lang.L("Disable server transcoding"), binding.BindBool(&s.config.Transcoding.ForceRawFile))
-	deviceList := make([]string, len(s.audioDevices))
-	var selIndex int
-	for i, dev := range s.audioDevices {
-		deviceList[i] = dev.Description
-		if dev.Name == s.config.LocalPlayback.AudioDeviceName {
-			selIndex = i
-		}
-	}
-	deviceSelect := widget.NewSelect(deviceList, nil)
-	deviceSelect.SetSelectedIndex(selIndex)
+	deviceSelect := widget.NewSelect(nil, nil)
+	s.deviceSelect = deviceSelect
+	s.updateDeviceSelect()
 	deviceSelect.OnChanged = func(_ string) {
 		dev := s.audioDevices[deviceSelect.SelectedIndex()]
 		s.config.LocalPlayback.AudioDeviceName = dev.Name
 		if s.OnAudioDeviceSettingChanged != nil {
 			s.OnAudioDeviceSettingChanged()
 		}
+		// a preset may have been applied for the new device
+		if s.refreshEqualizer != nil {
+			s.refreshEqualizer()
+		}
 	}
 
 	rGainOpts := []string{lang.L("None"), lang.L("Album"), lang.L("Track"), lang.L("Auto")}
@@ -361,11 +406,12 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 		replayGainSelect.SetSelectedIndex(0)
 	}
 
-	preampGain := widgets.NewTextRestrictedEntry(func(curText, _ string, r rune) bool {
+	gainEntryRestriction := func(curText, _ string, r rune) bool {
 		return (curText == "" && r == '-') ||
 			(curText == "" && unicode.IsDigit(r)) ||
 			((curText == "-" || curText == "0") && unicode.IsDigit(r))
-	})
+	}
+	preampGain := widgets.NewTextRestrictedEntry(gainEntryRestriction)
 	preampGain.SetMinCharWidth(2)
 	preampGain.OnChanged = func(text string) {
 		if f, err := strconv.ParseFloat(text, 64); err == nil {
@@ -381,26 +427,68 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 	}
 	preampGain.Text = strconv.Itoa(int(initVal))
 
+	// applied to tracks with neither ReplayGain tags nor ReplayGain info from the server
+	fallbackGain := widgets.NewTextRestrictedEntry(gainEntryRestriction)
+	fallbackGain.SetMinCharWidth(2)
+	fallbackGain.OnChanged = func(text string) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			s.config.ReplayGain.FallbackGainDB = f
+			s.onReplayGainSettingsChanged()
+		}
+	}
+	fallbackGain.Text = strconv.Itoa(int(max(-9, min(9, math.Round(s.config.ReplayGain.FallbackGainDB)))))
+
 	preventClipping := widget.NewCheck("", func(checked bool) {
 		s.config.ReplayGain.PreventClipping = checked
 		s.onReplayGainSettingsChanged()
 	})
 	preventClipping.Checked = s.config.ReplayGain.PreventClipping
 
+	analyzeLoudness := widget.NewCheck(lang.L("Analyze loudness of tracks without ReplayGain"), func(checked bool) {
+		s.config.ReplayGain.AnalyzeLoudness = checked
+		s.onReplayGainSettingsChanged()
+	})
+	analyzeLoudness.Checked = s.config.ReplayGain.AnalyzeLoudness
+
 	audioExclusive := widget.NewCheck(lang.L("Exclusive mode"), func(checked bool) {
 		s.config.LocalPlayback.AudioExclusive = checked
 		s.onAudioExclusiveSettingsChanged()
 	})
 	audioExclusive.Checked = s.config.LocalPlayback.AudioExclusive
 
+	disconnectActions := []string{backend.AudioDeviceDisconnectPause, backend.AudioDeviceDisconnectFallback}
+	disconnectSelect := widget.NewSelect([]string{lang.L("Pause playback"), lang.L("Switch to default device")}, nil)
+	disconnectSelect.SetSelectedIndex(max(slices.Index(disconnectActions, s.config.LocalPlayback.AudioDeviceDisconnectAction), 0))
+	disconnectSelect.OnChanged = func(_ string) {
+		s.config.LocalPlayback.AudioDeviceDisconnectAction = disconnectActions[disconnectSelect.SelectedIndex()]
+	}
+
+	fadeDurations := []int{0, 150, 250, 500}
+	fadeNames := sharedutil.MapSlice(fadeDurations[1:], func(ms int) string { return fmt.Sprintf("%d ms", ms) })
+	fadeSelect := widget.NewSelect(append([]string{lang.L("Off")}, fadeNames...), nil)
+	fadeSelect.SetSelectedIndex(max(slices.Index(fadeDurations, s.config.LocalPlayback.FadeDurationMS), 0))
+	fadeSelect.OnChanged = func(_ string) {
+		s.config.LocalPlayback.FadeDurationMS = fadeDurations[fadeSelect.SelectedIndex()]
+		if s.OnVolumeFadeSettingChanged != nil {
+			s.OnVolumeFadeSettingChanged()
+		}
+		s.updateBitPerfectWarning()
+	}
+
+	output := s.createAudioOutputSettings()
 	if !isLocalPlayer {
 		deviceSelect.Disable()
+		disconnectSelect.Disable()
+		fadeSelect.Disable()
 		audioExclusive.Disable()
+		output.Hide()
 	}
 	if !isReplayGainPlayer {
 		replayGainSelect.Disable()
 		preventClipping.Disable()
 		preampGain.Disable()
+		fallbackGain.Disable()
+		analyzeLoudness.Disable()
 	}
 
 	return container.NewTabItem(lang.L("Playback"), container.NewVBox(
@@ -409,19 +497,24 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 			container.New(layout.NewFormLayout(),
 				widget.NewLabel(lang.L("Audio device")), container.NewBorder(nil, nil, nil, util.NewHSpace(70), deviceSelect),
 				layout.NewSpacer(), audioExclusive,
+				widget.NewLabel(lang.L("When disconnected")), container.NewGridWithColumns(2, disconnectSelect),
+				widget.NewLabel(lang.L("Volume fades")), container.NewGridWithColumns(2, fadeSelect),
 			)),
+		output,
 		s.newSectionSeparator(),
 
 		widget.NewRichText(&widget.TextSegment{Text: "ReplayGain", Style: util.BoldRichTextStyle}),
 		container.New(layout.NewFormLayout(),
 			widget.NewLabel(lang.L("ReplayGain mode")), container.NewGridWithColumns(2, replayGainSelect),
 			widget.NewLabel(lang.L("ReplayGain preamp")), container.NewHBox(preampGain, widget.NewLabel("dB")),
+			widget.NewLabel(lang.L("Fallback gain")), container.NewHBox(fallbackGain, widget.NewLabel("dB")),
 			widget.NewLabel(lang.L("Prevent clipping")), preventClipping,
 		),
+		analyzeLoudness,
 	))
 }
 
-func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem {
+func (s *SettingsDialog) createEqualizerTab(eqBands []string, window fyne.Window) *container.TabItem {
 	enabled := widget.NewCheck(lang.L("Enabled"), func(b bool) {
 		s.config.LocalPlayback.EqualizerEnabled = b
 		if s.OnEqualizerSettingsChanged != nil {
@@ -429,6 +522,14 @@ func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem
 		}
 	})
 	enabled.Checked = s.config.LocalPlayback.EqualizerEnabled
+	var updatePresets func()
+	// a manual edit of the curve means it no longer matches the applied preset
+	clearPreset := func() {
+		if s.config.LocalPlayback.EqualizerPreset != "" {
+			s.config.LocalPlayback.EqualizerPreset = ""
+			updatePresets()
+		}
+	}
 	geq := NewGraphicEqualizer(s.config.LocalPlayback.EqualizerPreamp,
 		eqBands,
 		s.config.LocalPlayback.GraphicEqualizerBands)
@@ -439,16 +540,170 @@ func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem
 	})
 	geq.OnChanged = func(b int, g float64) {
 		s.config.LocalPlayback.GraphicEqualizerBands[b] = g
+		clearPreset()
 		debouncer()
 	}
 	geq.OnPreampChanged = func(g float64) {
 		s.config.LocalPlayback.EqualizerPreamp = g
+		clearPreset()
+		debouncer()
+	}
+
+	parametricEQ := s.config.LocalPlayback.ParametricEqualizer()
+	peq := NewParametricEqualizerEditor(parametricEQ.Preamp, parametricEQ.Bands)
+	peq.OnChanged = func(preamp float64, bands []parametriceq.Band) {
+		s.config.LocalPlayback.SetParametricEqualizer(&parametriceq.Equalizer{Preamp: preamp, Bands: bands})
+		clearPreset()
 		debouncer()
 	}
-	cont := container.NewBorder(enabled, nil, nil, nil, geq)
+	peq.OnImport = func() { s.doImportParametricEQ(window, peq, clearPreset) }
+	peq.OnExport = func() { s.doExportParametricEQ(window) }
+
+	eqTypes := []string{(*mpv.ISO15BandEqualizer)(nil).Type(), (*mpv.ParametricEqualizer)(nil).Type()}
+	typeSelect := widget.NewSelect([]string{lang.L("Graphic (15 bands)"), lang.L("Parametric")}, nil)
+	updateEQType := func() {
+		parametric := s.config.LocalPlayback.EqualizerType == eqTypes[1]
+		geq.Hidden = parametric
+		peq.Hidden = !parametric
+		geq.Refresh()
+		peq.Refresh()
+	}
+	typeSelect.SetSelectedIndex(max(slices.Index(eqTypes, s.config.LocalPlayback.EqualizerType), 0))
+	typeSelect.OnChanged = func(_ string) {
+		s.config.LocalPlayback.EqualizerType = eqTypes[typeSelect.SelectedIndex()]
+		updateEQType()
+		clearPreset()
+		if s.OnEqualizerSettingsChanged != nil {
+			s.OnEqualizerSettingsChanged()
+		}
+	}
+	updateEQType()
+
+	conf := &s.config.LocalPlayback
+	presetSelect := widget.NewSelect(nil, nil)
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+	deviceCheck := widget.NewCheck(lang.L("Use for current audio device"), nil)
+	updatePresets = func() {
+		presetSelect.Options = sharedutil.MapSlice(conf.AllEqualizerPresets(), func(p backend.EqualizerPreset) string {
+			return p.Name
+		})
+		presetSelect.Selected = conf.EqualizerPreset
+		presetSelect.Refresh()
+		if conf.EqualizerPreset == "" || backend.IsBuiltinEqualizerPreset(conf.EqualizerPreset) {
+			deleteBtn.Disable()
+		} else {
+			deleteBtn.Enable()
+		}
+		if conf.EqualizerPreset == "" {
+			deviceCheck.Disable()
+		} else {
+			deviceCheck.Enable()
+		}
+		deviceCheck.Checked = conf.EqualizerPreset != "" &&
+			conf.DeviceEqualizerPresets[conf.AudioDeviceName] == conf.EqualizerPreset
+		deviceCheck.Refresh()
+	}
+	s.refreshEqualizer = func() {
+		enabled.Checked = conf.EqualizerEnabled
+		enabled.Refresh()
+		geq.SetGains(conf.EqualizerPreamp, conf.GraphicEqualizerBands)
+		parametricEQ := conf.ParametricEqualizer()
+		peq.SetEqualizer(parametricEQ.Preamp, parametricEQ.Bands)
+		typeSelect.Selected = typeSelect.Options[max(slices.Index(eqTypes, conf.EqualizerType), 0)]
+		typeSelect.Refresh()
+		updateEQType()
+		updatePresets()
+	}
+	presetSelect.OnChanged = func(name string) {
+		p := conf.FindEqualizerPreset(name)
+		if p == nil {
+			return
+		}
+		conf.ApplyEqualizerPreset(p)
+		s.refreshEqualizer()
+		if s.OnEqualizerSettingsChanged != nil {
+			s.OnEqualizerSettingsChanged()
+		}
+	}
+	saveBtn := widget.NewButtonWithIcon(lang.L("Save preset"), theme.DocumentSaveIcon(), func() {
+		nameEntry := widget.NewEntry()
+		if !backend.IsBuiltinEqualizerPreset(conf.EqualizerPreset) {
+			nameEntry.SetText(conf.EqualizerPreset)
+		}
+		items := []*widget.FormItem{widget.NewFormItem(lang.L("Name"), nameEntry)}
+		dialog.ShowForm(lang.L("Save preset"), lang.L("Save"), lang.L("Cancel"), items, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := conf.SaveEqualizerPreset(nameEntry.Text); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			updatePresets()
+		}, window)
+	})
+	deleteBtn.OnTapped = func() {
+		conf.DeleteEqualizerPreset(conf.EqualizerPreset)
+		updatePresets()
+	}
+	deviceCheck.OnChanged = func(b bool) {
+		if b {
+			conf.SetDeviceEqualizerPreset(conf.AudioDeviceName, conf.EqualizerPreset)
+		} else {
+			conf.SetDeviceEqualizerPreset(conf.AudioDeviceName, "")
+		}
+	}
+	updatePresets()
+
+	top := container.NewVBox(
+		container.NewHBox(enabled, layout.NewSpacer(), typeSelect),
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Preset")),
+			container.NewHBox(saveBtn, deleteBtn), presetSelect),
+		deviceCheck,
+	)
+	cont := container.NewBorder(top, nil, nil, nil, container.NewStack(geq, peq))
 	return container.NewTabItem(lang.L("Equalizer"), cont)
 }
 
+func (s *SettingsDialog) doImportParametricEQ(window fyne.Window, peq *ParametricEqualizerEditor, onImported func()) {
+	dlg := dialog.NewFileOpen(func(urirc fyne.URIReadCloser, err error) {
+		if err != nil || urirc == nil {
+			return
+		}
+		defer urirc.Close()
+		eq, err := parametriceq.Parse(urirc)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		s.config.LocalPlayback.SetParametricEqualizer(eq)
+		peq.SetEqualizer(eq.Preamp, eq.Bands)
+		onImported()
+		if s.OnEqualizerSettingsChanged != nil {
+			s.OnEqualizerSettingsChanged()
+		}
+	}, window)
+	dlg.SetFilter(&storage.ExtensionFileFilter{Extensions: []string{".txt"}})
+	dlg.Show()
+}
+
+func (s *SettingsDialog) doExportParametricEQ(window fyne.Window) {
+	dlg := dialog.NewFileSave(func(uriwc fyne.URIWriteCloser, err error) {
+		if err != nil || uriwc == nil {
+			return
+		}
+		err = parametriceq.Write(uriwc, s.config.LocalPlayback.ParametricEqualizer())
+		if cerr := uriwc.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+	dlg.SetFileName("ParametricEQ.txt")
+	dlg.Show()
+}
+
 func (s *SettingsDialog) createExperimentalTab(window fyne.Window) *container.TabItem {
 	warningLabel := widget.NewLabel("WARNING: these settings are experimental and may " +
 		"make the application buggy or increase system resource use. " +
@@ -508,6 +763,57 @@ func (s *SettingsDialog) createExperimentalTab(window fyne.Window) *container.Ta
 	))
 }
 
+// newLyricsSourcesRow creates checks to enable each lyrics source,
+// with buttons between them to swap the priority of adjacent sources
+func (s *SettingsDialog) newLyricsSourcesRow() fyne.CanvasObject {
+	enabled := make(map[string]bool)
+	var order []string
+	for _, src := range s.config.Lyrics.Sources {
+		if slices.Contains(backend.SupportedLyricsSources, src) && !enabled[src] {
+			enabled[src] = src != backend.LyricsSourceLrcLib || s.config.Application.EnableLrcLib
+			order = append(order, src)
+		}
+	}
+	for _, src := range backend.SupportedLyricsSources {
+		if !slices.Contains(order, src) {
+			order = append(order, src)
+		}
+	}
+	save := func() {
+		s.config.Lyrics.Sources = sharedutil.FilterSlice(order, func(src string) bool {
+			return enabled[src]
+		})
+		s.config.Application.EnableLrcLib = enabled[backend.LyricsSourceLrcLib]
+	}
+
+	row := container.NewHBox()
+	var rebuild func()
+	rebuild = func() {
+		row.Objects = nil
+		for i, src := range order {
+			i, src := i, src
+			if i > 0 {
+				swap := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+					order[i-1], order[i] = order[i], order[i-1]
+					save()
+					rebuild()
+				})
+				swap.Importance = widget.LowImportance
+				row.Add(swap)
+			}
+			check := widget.NewCheck(lang.L(src), func(b bool) {
+				enabled[src] = b
+				save()
+			})
+			check.Checked = enabled[src]
+			row.Add(check)
+		}
+		row.Refresh()
+	}
+	rebuild()
+	return row
+}
+
 func (s *SettingsDialog) doChooseTTFFile(window fyne.Window, entry *widget.Entry) {
 	callback := func(urirc fyne.URIReadCloser, err error) {
 		if err == nil && urirc != nil {
@@ -548,6 +854,127 @@ func (s *SettingsDialog) onReplayGainSettingsChanged() {
 	if s.OnReplayGainSettingsChanged != nil {
 		s.OnReplayGainSettingsChanged()
 	}
+	s.updateBitPerfectWarning()
+}
+
+var (
+	outputSampleRates   = []int{0, 44100, 48000, 88200, 96000, 176400, 192000}
+	outputSampleFormats = []string{"", "s16", "s32", "float"}
+)
+
+func (s *SettingsDialog) createAudioOutputSettings() fyne.CanvasObject {
+	conf := &s.config.LocalPlayback
+	onChanged := func() {
+		if s.OnAudioOutputSettingsChanged != nil {
+			s.OnAudioOutputSettingsChanged()
+		}
+	}
+
+	rates := []string{lang.L("Match source")}
+	for _, r := range outputSampleRates[1:] {
+		rates = append(rates, fmt.Sprintf("%g kHz", float64(r)/1000))
+	}
+	rateSelect := widget.NewSelect(rates, nil)
+	rateSelect.SetSelectedIndex(max(slices.Index(outputSampleRates, conf.OutputSampleRate), 0))
+	rateSelect.OnChanged = func(_ string) {
+		conf.OutputSampleRate = outputSampleRates[rateSelect.SelectedIndex()]
+		onChanged()
+	}
+
+	formats := append([]string{lang.L("Automatic")}, outputSampleFormats[1:]...)
+	formatSelect := widget.NewSelect(formats, nil)
+	formatSelect.SetSelectedIndex(max(slices.Index(outputSampleFormats, conf.OutputSampleFormat), 0))
+	formatSelect.OnChanged = func(_ string) {
+		conf.OutputSampleFormat = outputSampleFormats[formatSelect.SelectedIndex()]
+		onChanged()
+	}
+
+	qualities := sharedutil.MapSlice(mpv.ResamplerQualities, func(q mpv.ResamplerQuality) string {
+		return lang.L(string(q))
+	})
+	qualitySelect := widget.NewSelect(qualities, nil)
+	qualitySelect.SetSelectedIndex(max(slices.Index(mpv.ResamplerQualities, mpv.ResamplerQuality(conf.ResamplerQuality)), 0))
+	qualitySelect.OnChanged = func(_ string) {
+		conf.ResamplerQuality = string(mpv.ResamplerQualities[qualitySelect.SelectedIndex()])
+		onChanged()
+	}
+
+	softVolume := widget.NewCheck(lang.L("Disable software volume"), func(b bool) {
+		conf.DisableSoftwareVolume = b
+		onChanged()
+	})
+	softVolume.Checked = conf.DisableSoftwareVolume
+
+	updateEnabled := func() {
+		for _, w := range []fyne.Disableable{rateSelect, formatSelect, qualitySelect, softVolume} {
+			if conf.BitPerfect {
+				w.Disable()
+			} else {
+				w.Enable()
+			}
+		}
+	}
+	bitPerfect := widget.NewCheck(lang.L("Bit-perfect mode"), func(b bool) {
+		conf.BitPerfect = b
+		updateEnabled()
+		s.updateBitPerfectWarning()
+		onChanged()
+	})
+	bitPerfect.Checked = conf.BitPerfect
+	updateEnabled()
+
+	s.bitPerfectWarning = widget.NewLabel("")
+	s.bitPerfectWarning.Importance = widget.WarningImportance
+	s.bitPerfectWarning.Wrapping = fyne.TextWrapWord
+	s.updateBitPerfectWarning()
+
+	return container.NewVBox(
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Sample rate")), container.NewGridWithColumns(2, rateSelect),
+			widget.NewLabel(lang.L("Sample format")), container.NewGridWithColumns(2, formatSelect),
+			widget.NewLabel(lang.L("Resampler quality")), container.NewGridWithColumns(2, qualitySelect),
+			layout.NewSpacer(), container.NewHBox(softVolume, bitPerfect),
+		),
+		s.bitPerfectWarning,
+	)
+}
+
+// SetAudioDevices updates the list of audio devices, after one is connected or disconnected.
+func (s *SettingsDialog) SetAudioDevices(devs []mpv.AudioDevice) {
+	s.audioDevices = devs
+	// the selection changes without the user choosing another device
+	onChanged := s.deviceSelect.OnChanged
+	s.deviceSelect.OnChanged = nil
+	s.updateDeviceSelect()
+	s.deviceSelect.OnChanged = onChanged
+}
+
+func (s *SettingsDialog) updateDeviceSelect() {
+	deviceList := make([]string, len(s.audioDevices))
+	var selIndex int
+	for i, dev := range s.audioDevices {
+		deviceList[i] = dev.Description
+		if dev.Name == s.config.LocalPlayback.AudioDeviceName {
+			selIndex = i
+		}
+	}
+	s.deviceSelect.SetOptions(deviceList)
+	s.deviceSelect.SetSelectedIndex(selIndex)
+}
+
+// shows which enabled features alter the samples in bit-perfect mode
+func (s *SettingsDialog) updateBitPerfectWarning() {
+	if s.bitPerfectWarning == nil {
+		return
+	}
+	conflicts := s.bitPerfectConflicts()
+	if len(conflicts) == 0 {
+		s.bitPerfectWarning.Hide()
+		return
+	}
+	names := sharedutil.MapSlice(conflicts, func(c string) string { return lang.L(c) })
+	s.bitPerfectWarning.SetText(lang.L("Playback is not bit-perfect while these are enabled") + ": " + strings.Join(names, ", "))
+	s.bitPerfectWarning.Show()
 }
 
 func (s *SettingsDialog) onAudioExclusiveSettingsChanged() {
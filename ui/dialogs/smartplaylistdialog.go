@@ -0,0 +1,213 @@
+package dialogs
+
+import (
+	"slices"
+	"strconv"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+)
+
+// SmartPlaylistDialog edits the name, rules, sort order and limit of a smart playlist.
+// The edits are made on a copy, which is available as Playlist when OnSave is invoked.
+type SmartPlaylistDialog struct {
+	widget.BaseWidget
+
+	OnCanceled func()
+	OnDelete   func()
+	OnSave     func()
+
+	Playlist *backend.SmartPlaylist
+
+	rulesContainer *fyne.Container
+	container      *fyne.Container
+}
+
+func NewSmartPlaylistDialog(playlist *backend.SmartPlaylist) *SmartPlaylistDialog {
+	isNew := playlist == nil
+	pl := &backend.SmartPlaylist{}
+	if !isNew {
+		*pl = *playlist
+		pl.Rules = slices.Clone(playlist.Rules)
+	}
+	s := &SmartPlaylistDialog{Playlist: pl}
+	s.ExtendBaseWidget(s)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(pl.Name)
+	nameEntry.OnChanged = func(name string) { s.Playlist.Name = name }
+
+	matchAll, matchAny := lang.L("all"), lang.L("any")
+	matchSelect := widget.NewSelect([]string{matchAll, matchAny}, func(sel string) {
+		s.Playlist.MatchAny = sel == matchAny
+	})
+	if pl.MatchAny {
+		matchSelect.SetSelected(matchAny)
+	} else {
+		matchSelect.SetSelected(matchAll)
+	}
+
+	s.rulesContainer = container.NewVBox()
+	for i := range pl.Rules {
+		s.rulesContainer.Add(s.newRuleRow(i))
+	}
+	addRuleBtn := widget.NewButtonWithIcon(lang.L("Add rule"), theme.ContentAddIcon(), func() {
+		s.Playlist.Rules = append(s.Playlist.Rules, backend.SmartPlaylistRule{
+			Field:    backend.SmartPlaylistFieldGenre,
+			Operator: backend.SmartPlaylistOpIs,
+		})
+		s.rulesContainer.Add(s.newRuleRow(len(s.Playlist.Rules) - 1))
+	})
+
+	sortNames := sharedutil.MapSlice(backend.SmartPlaylistSortOrders, util.SmartPlaylistSortDisplayName)
+	sortSelect := widget.NewSelect(sortNames, func(_ string) {})
+	sortSelect.OnChanged = func(_ string) {
+		s.Playlist.SortBy = backend.SmartPlaylistSortOrders[sortSelect.SelectedIndex()]
+	}
+	sortSelect.SetSelectedIndex(max(0, slices.Index(backend.SmartPlaylistSortOrders, pl.SortBy)))
+	descendingCheck := widget.NewCheck(lang.L("Descending"), func(b bool) {
+		s.Playlist.SortDescending = b
+	})
+	descendingCheck.Checked = pl.SortDescending
+
+	limitEntry := widgets.NewTextRestrictedEntry(func(_, _ string, r rune) bool {
+		return unicode.IsDigit(r)
+	})
+	limitEntry.SetMinCharWidth(5)
+	limitEntry.PlaceHolder = lang.L("none")
+	if pl.Limit > 0 {
+		limitEntry.Text = strconv.Itoa(pl.Limit)
+	}
+	limitEntry.OnChanged = func(text string) {
+		s.Playlist.Limit, _ = strconv.Atoi(text)
+	}
+
+	deleteBtn := widget.NewButtonWithIcon(lang.L("Delete Playlist"), theme.DeleteIcon(), func() {
+		if s.OnDelete != nil {
+			s.OnDelete()
+		}
+	})
+	deleteBtn.Hidden = isNew
+	submitBtn := widget.NewButtonWithIcon(lang.L("OK"), theme.ConfirmIcon(), func() {
+		if s.OnSave != nil {
+			s.OnSave()
+		}
+	})
+	submitBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButtonWithIcon(lang.L("Cancel"), theme.CancelIcon(), func() {
+		if s.OnCanceled != nil {
+			s.OnCanceled()
+		}
+	})
+
+	titleText := lang.L("Edit Smart Playlist")
+	if isNew {
+		titleText = lang.L("New Smart Playlist")
+	}
+	title := widget.NewLabel(titleText)
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	s.container = container.NewVBox(
+		title,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Name")),
+			nameEntry,
+		),
+		container.NewHBox(widget.NewLabel(lang.L("Match")), matchSelect, widget.NewLabel(lang.L("of the following rules"))),
+		s.rulesContainer,
+		container.NewHBox(addRuleBtn),
+		container.NewHBox(
+			widget.NewLabel(lang.L("Sort by")), sortSelect, descendingCheck,
+			layout.NewSpacer(),
+			widget.NewLabel(lang.L("Limit")), limitEntry),
+		container.NewHBox(layout.NewSpacer(), deleteBtn),
+		widget.NewSeparator(),
+		container.NewHBox(
+			layout.NewSpacer(),
+			cancelBtn, submitBtn),
+	)
+
+	return s
+}
+
+func (s *SmartPlaylistDialog) newRuleRow(idx int) fyne.CanvasObject {
+	rule := &s.Playlist.Rules[idx]
+
+	valueEntry := widget.NewEntry()
+	valueEntry.SetText(rule.Value)
+	valueEntry.OnChanged = func(v string) { s.Playlist.Rules[idx].Value = v }
+	favoriteSelect := widget.NewSelect([]string{lang.L("Yes"), lang.L("No")}, nil)
+	favoriteSelect.OnChanged = func(_ string) {
+		s.Playlist.Rules[idx].Value = strconv.FormatBool(favoriteSelect.SelectedIndex() == 0)
+	}
+
+	opSelect := widget.NewSelect(nil, nil)
+	updateOperators := func(field string) {
+		ops := backend.SmartPlaylistOperatorsForField(field)
+		opSelect.Options = sharedutil.MapSlice(ops, util.SmartPlaylistOperatorDisplayName)
+		opSelect.OnChanged = func(_ string) {
+			s.Playlist.Rules[idx].Operator = ops[opSelect.SelectedIndex()]
+		}
+		opSelect.SetSelectedIndex(max(0, slices.Index(ops, s.Playlist.Rules[idx].Operator)))
+
+		isFav := field == backend.SmartPlaylistFieldFavorite
+		valueEntry.Hidden = isFav
+		favoriteSelect.Hidden = !isFav
+		if isFav {
+			if fav, _ := strconv.ParseBool(s.Playlist.Rules[idx].Value); fav {
+				favoriteSelect.SetSelectedIndex(0)
+			} else {
+				favoriteSelect.SetSelectedIndex(1)
+			}
+		}
+	}
+
+	fieldNames := sharedutil.MapSlice(backend.SmartPlaylistFields, util.SmartPlaylistFieldDisplayName)
+	fieldSelect := widget.NewSelect(fieldNames, nil)
+	fieldSelect.SetSelectedIndex(max(0, slices.Index(backend.SmartPlaylistFields, rule.Field)))
+	fieldSelect.OnChanged = func(_ string) {
+		field := backend.SmartPlaylistFields[fieldSelect.SelectedIndex()]
+		s.Playlist.Rules[idx].Field = field
+		updateOperators(field)
+	}
+	updateOperators(rule.Field)
+
+	var row *fyne.Container
+	removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		s.removeRule(row)
+	})
+	row = container.NewBorder(nil, nil, container.NewHBox(fieldSelect, opSelect), removeBtn,
+		container.NewStack(valueEntry, favoriteSelect))
+	return row
+}
+
+func (s *SmartPlaylistDialog) removeRule(row fyne.CanvasObject) {
+	idx := slices.Index(s.rulesContainer.Objects, row)
+	if idx < 0 {
+		return
+	}
+	s.Playlist.Rules = slices.Delete(s.Playlist.Rules, idx, idx+1)
+	// rebuild the rows, since each row's callbacks capture its rule index
+	s.rulesContainer.RemoveAll()
+	for i := range s.Playlist.Rules {
+		s.rulesContainer.Add(s.newRuleRow(i))
+	}
+	s.Refresh()
+}
+
+func (s *SmartPlaylistDialog) MinSize() fyne.Size {
+	return fyne.NewSize(550, s.BaseWidget.MinSize().Height)
+}
+
+func (s *SmartPlaylistDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
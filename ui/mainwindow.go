@@ -81,7 +81,8 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 		))
 	}
 
-	m.BottomPanel = NewBottomPanel(app.PlaybackManager, app.ImageManager, m.Controller)
+	m.BottomPanel = NewBottomPanel(app.PlaybackManager, app.ImageManager, app.Waveforms, &app.Config.Application, m.Controller)
+	m.Controller.ReloadWaveform = m.BottomPanel.ReloadWaveform
 	app.PlaybackManager.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
 		if item == nil {
 			m.Window.SetTitle(displayAppName)
@@ -113,10 +114,14 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.BrowsingPane.AddSettingsMenuItem(lang.L("Log Out"), func() { app.ServerManager.Logout(true) })
 	m.BrowsingPane.AddSettingsMenuItem(lang.L("Switch Servers"), func() { app.ServerManager.Logout(false) })
 	m.BrowsingPane.AddSettingsMenuItem(lang.L("Rescan Library"), func() { app.ServerManager.Server.RescanLibrary() })
+	m.BrowsingPane.AddSettingsMenuItem(lang.L("Sync Between Servers")+"...", m.Controller.ShowServerSyncDialog)
+	m.BrowsingPane.AddSettingsMenuItem(lang.L("Sync to Folder")+"...", m.Controller.ShowFolderSyncDialog)
+	m.BrowsingPane.AddSettingsMenuItem(lang.L("Downloads")+"...", m.Controller.ShowDownloadsDialog)
 	m.BrowsingPane.AddSettingsMenuSeparator()
 	m.BrowsingPane.AddSettingsSubmenu(lang.L("Visualizations"),
 		fyne.NewMenu("", []*fyne.MenuItem{
 			fyne.NewMenuItem(lang.L("Peak Meter"), m.Controller.ShowPeakMeter),
+			fyne.NewMenuItem(lang.L("Spectrum Analyzer"), m.Controller.ShowSpectrumAnalyzer),
 		}...))
 	m.BrowsingPane.AddSettingsMenuSeparator()
 	m.BrowsingPane.AddSettingsMenuItem(lang.L("Check for Updates"), func() {
@@ -369,6 +374,22 @@ func (m *MainWindow) addShortcuts() {
 		}
 	})
 
+	m.Canvas().AddShortcut(&shortcuts.ShortcutSetABLoopA, func(_ fyne.Shortcut) {
+		if err := m.App.PlaybackManager.SetABLoopA(); err != nil {
+			log.Printf("failed to set A-B loop start: %s", err.Error())
+		}
+	})
+	m.Canvas().AddShortcut(&shortcuts.ShortcutSetABLoopB, func(_ fyne.Shortcut) {
+		if err := m.App.PlaybackManager.SetABLoopB(); err != nil {
+			log.Printf("failed to set A-B loop end: %s", err.Error())
+		}
+	})
+	m.Canvas().AddShortcut(&shortcuts.ShortcutClearABLoop, func(_ fyne.Shortcut) {
+		if err := m.App.PlaybackManager.ClearABLoop(); err != nil {
+			log.Printf("failed to clear A-B loop: %s", err.Error())
+		}
+	})
+
 	for i, ns := range shortcuts.NavShortcuts {
 		m.Canvas().AddShortcut(&ns, func(i int) func(fyne.Shortcut) {
 			return func(fyne.Shortcut) {
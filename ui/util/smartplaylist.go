@@ -0,0 +1,86 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+func SmartPlaylistFieldDisplayName(field string) string {
+	switch field {
+	case backend.SmartPlaylistFieldGenre:
+		return lang.L("Genre")
+	case backend.SmartPlaylistFieldYear:
+		return lang.L("Year")
+	case backend.SmartPlaylistFieldRating:
+		return lang.L("Rating")
+	case backend.SmartPlaylistFieldPlayCount:
+		return lang.L("Play count")
+	case backend.SmartPlaylistFieldLastPlayed:
+		return lang.L("Last played")
+	case backend.SmartPlaylistFieldFavorite:
+		return lang.L("Favorite")
+	case backend.SmartPlaylistFieldBitRate:
+		return lang.L("Bit rate")
+	case backend.SmartPlaylistFieldContentType:
+		return lang.L("Content type")
+	case backend.SmartPlaylistFieldDuration:
+		return lang.L("Duration")
+	}
+	return field
+}
+
+func SmartPlaylistOperatorDisplayName(op string) string {
+	switch op {
+	case backend.SmartPlaylistOpIs:
+		return lang.L("is")
+	case backend.SmartPlaylistOpIsNot:
+		return lang.L("is not")
+	case backend.SmartPlaylistOpContains:
+		return lang.L("contains")
+	case backend.SmartPlaylistOpGreaterThan:
+		return lang.L("greater than")
+	case backend.SmartPlaylistOpLessThan:
+		return lang.L("less than")
+	case backend.SmartPlaylistOpInLastDays:
+		return lang.L("in the last (days)")
+	case backend.SmartPlaylistOpNotInLast:
+		return lang.L("not in the last (days)")
+	}
+	return op
+}
+
+func SmartPlaylistSortDisplayName(sort string) string {
+	switch sort {
+	case backend.SmartPlaylistSortNone:
+		return lang.L("None")
+	case backend.SmartPlaylistSortRandom:
+		return lang.L("Random")
+	case backend.SmartPlaylistSortTitle:
+		return lang.L("Title")
+	case backend.SmartPlaylistSortArtist:
+		return lang.L("Artist")
+	case backend.SmartPlaylistSortAlbum:
+		return lang.L("Album")
+	}
+	// remaining sort orders share their names with rule fields
+	return SmartPlaylistFieldDisplayName(sort)
+}
+
+// Returns a short, localized summary of the smart playlist's rules.
+func DescribeSmartPlaylist(pl *backend.SmartPlaylist) string {
+	rules := sharedutil.MapSlice(pl.Rules, func(r backend.SmartPlaylistRule) string {
+		return fmt.Sprintf("%s %s %s",
+			SmartPlaylistFieldDisplayName(r.Field),
+			SmartPlaylistOperatorDisplayName(r.Operator),
+			r.Value)
+	})
+	joiner := fmt.Sprintf(" %s ", lang.L("and"))
+	if pl.MatchAny {
+		joiner = fmt.Sprintf(" %s ", lang.L("or"))
+	}
+	return strings.Join(rules, joiner)
+}
@@ -0,0 +1,266 @@
+package visualizations
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+)
+
+const (
+	spectrumRangeDB        = 72
+	spectrumRuleStepDB     = 12
+	spectrumFallDB         = 1.5 // per frame
+	spectrumPeakFallDB     = 0.5 // per frame, after the hold time
+	spectrumPeakHoldFrames = 60
+
+	// half the width of a 1/3 octave band on the log frequency scale
+	bandHalfWidth = 1.0 / 6
+)
+
+// frequencies labeled on the frequency axis
+var spectrumFreqLabels = []float64{50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+type SpectrumMode int
+
+const (
+	SpectrumModeBars SpectrumMode = iota
+	SpectrumModeLine
+)
+
+// SpectrumAnalyzer shows the level of each band of the audio spectrum
+// on a log frequency scale, as bars or a line, with optional peak hold.
+type SpectrumAnalyzer struct {
+	widget.BaseWidget
+
+	Mode     SpectrumMode
+	PeakHold bool
+
+	freqs        []float64
+	levels       []float64
+	peaks        []float64
+	peakFrames   []uint64
+	frameCounter uint64
+}
+
+// NewSpectrumAnalyzer returns a spectrum analyzer for
+// bands with the given center frequencies in Hz.
+func NewSpectrumAnalyzer(freqs []float64) *SpectrumAnalyzer {
+	s := &SpectrumAnalyzer{
+		freqs:      freqs,
+		levels:     make([]float64, len(freqs)),
+		peaks:      make([]float64, len(freqs)),
+		peakFrames: make([]uint64, len(freqs)),
+	}
+	for i := range freqs {
+		s.levels[i] = noiseFloorDB
+		s.peaks[i] = noiseFloorDB
+	}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// UpdateLevels updates the band levels in dB that are displayed.
+// This function is expected to be called from a fyne.Animation callback,
+// running at 60 Hz
+func (s *SpectrumAnalyzer) UpdateLevels(levels []float64) {
+	for i := range s.levels {
+		l := float64(noiseFloorDB)
+		if i < len(levels) && !math.IsNaN(levels[i]) {
+			l = math.Max(noiseFloorDB, levels[i])
+		}
+		// rise instantly, fall gradually
+		s.levels[i] = math.Max(l, s.levels[i]-spectrumFallDB)
+
+		if l >= s.peaks[i] {
+			s.peaks[i] = l
+			s.peakFrames[i] = s.frameCounter
+		} else if s.frameCounter-s.peakFrames[i] > spectrumPeakHoldFrames {
+			s.peaks[i] = math.Max(s.levels[i], s.peaks[i]-spectrumPeakFallDB)
+		}
+	}
+	s.frameCounter++
+	s.Refresh()
+}
+
+func (s *SpectrumAnalyzer) CreateRenderer() fyne.WidgetRenderer {
+	return newSpectrumRenderer(s)
+}
+
+type spectrumRenderer struct {
+	s *SpectrumAnalyzer
+
+	bars      []canvas.Rectangle
+	lines     []canvas.Line
+	peakMarks []canvas.Rectangle
+
+	rulerLines  []canvas.Rectangle
+	rulerLabels []canvas.Text
+	freqLabels  []canvas.Text
+
+	objects []fyne.CanvasObject
+
+	fgColor   color.Color
+	bgColor   color.Color
+	ruleColor color.Color
+}
+
+func newSpectrumRenderer(s *SpectrumAnalyzer) *spectrumRenderer {
+	r := &spectrumRenderer{s: s}
+	n := len(s.freqs)
+	r.bars = make([]canvas.Rectangle, n)
+	r.peakMarks = make([]canvas.Rectangle, n)
+	if n > 1 {
+		r.lines = make([]canvas.Line, n-1)
+	}
+	numRules := spectrumRangeDB/spectrumRuleStepDB + 1
+	r.rulerLines = make([]canvas.Rectangle, numRules)
+	r.rulerLabels = make([]canvas.Text, numRules)
+	for i := range r.rulerLabels {
+		r.rulerLabels[i].Text = fmt.Sprintf("%d dB", -i*spectrumRuleStepDB)
+		r.rulerLabels[i].TextSize = 11
+		r.rulerLabels[i].Alignment = fyne.TextAlignTrailing
+	}
+	r.freqLabels = make([]canvas.Text, len(spectrumFreqLabels))
+	for i, f := range spectrumFreqLabels {
+		text := fmt.Sprintf("%g", f)
+		if f >= 1000 {
+			text = fmt.Sprintf("%gk", f/1000)
+		}
+		r.freqLabels[i].Text = text
+		r.freqLabels[i].TextSize = 11
+		r.freqLabels[i].Alignment = fyne.TextAlignCenter
+	}
+	r.Layout(s.Size())
+	return r
+}
+
+func (r *spectrumRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 120)
+}
+
+func (r *spectrumRenderer) Layout(size fyne.Size) {
+	const (
+		leftSpace   = float32(42)
+		rightSpace  = float32(8)
+		topSpace    = float32(6)
+		bottomSpace = float32(16)
+	)
+	width := size.Width - leftSpace - rightSpace
+	height := size.Height - topSpace - bottomSpace
+	n := len(r.s.freqs)
+	if n == 0 || width <= 0 || height <= 0 {
+		return
+	}
+
+	// log2 frequency range, from the lower edge of the first band to the upper edge of the last
+	minOct := math.Log2(r.s.freqs[0]) - bandHalfWidth
+	maxOct := math.Log2(r.s.freqs[n-1]) + bandHalfWidth
+	xPos := func(oct float64) float32 {
+		return leftSpace + float32((oct-minOct)/(maxOct-minOct))*width
+	}
+	yPos := func(db float64) float32 {
+		frac := math.Min(1, math.Max(0, (spectrumRangeDB+db)/spectrumRangeDB))
+		return topSpace + height*float32(1-frac)
+	}
+
+	for i := range r.rulerLines {
+		y := yPos(float64(-i * spectrumRuleStepDB))
+		r.rulerLines[i].Move(fyne.NewPos(leftSpace, y))
+		r.rulerLines[i].Resize(fyne.NewSize(width, 1))
+		labelSize := r.rulerLabels[i].MinSize()
+		r.rulerLabels[i].Move(fyne.NewPos(leftSpace-labelSize.Width-4, y-labelSize.Height/2))
+		r.rulerLabels[i].Resize(labelSize)
+	}
+	for i, f := range spectrumFreqLabels {
+		labelSize := r.freqLabels[i].MinSize()
+		r.freqLabels[i].Move(fyne.NewPos(xPos(math.Log2(f))-labelSize.Width/2, topSpace+height+2))
+		r.freqLabels[i].Resize(labelSize)
+	}
+
+	bars := r.s.Mode == SpectrumModeBars
+	peakHeight := theme.SeparatorThicknessSize() * 2
+	for i, f := range r.s.freqs {
+		oct := math.Log2(f)
+		left, right := xPos(oct-bandHalfWidth)+1, xPos(oct+bandHalfWidth)-1
+		y := yPos(r.s.levels[i])
+		r.bars[i].Hidden = !bars
+		r.bars[i].Move(fyne.NewPos(left, y))
+		r.bars[i].Resize(fyne.NewSize(right-left, topSpace+height-y))
+
+		r.peakMarks[i].Hidden = !r.s.PeakHold || r.s.peaks[i] <= -spectrumRangeDB
+		r.peakMarks[i].Move(fyne.NewPos(left, yPos(r.s.peaks[i])-peakHeight/2))
+		r.peakMarks[i].Resize(fyne.NewSize(right-left, peakHeight))
+
+		if i > 0 {
+			line := &r.lines[i-1]
+			line.Hidden = bars
+			line.StrokeWidth = 2
+			line.Position1 = fyne.NewPos(xPos(math.Log2(r.s.freqs[i-1])), yPos(r.s.levels[i-1]))
+			line.Position2 = fyne.NewPos(xPos(oct), y)
+		}
+	}
+}
+
+func (r *spectrumRenderer) Refresh() {
+	foreground := theme.ForegroundColor()
+	background := theme.BackgroundColor()
+	c := theme.PrimaryColor().(color.NRGBA)
+	lineColor := c
+	c.A = 160
+
+	if foreground != r.fgColor || background != r.bgColor {
+		r.ruleColor = myTheme.BlendColors(foreground, background, 0.25)
+		r.fgColor = foreground
+		r.bgColor = background
+	}
+	for i := range r.rulerLines {
+		r.rulerLines[i].FillColor = r.ruleColor
+		r.rulerLabels[i].Color = foreground
+	}
+	for i := range r.freqLabels {
+		r.freqLabels[i].Color = foreground
+	}
+	for i := range r.bars {
+		r.bars[i].FillColor = c
+		r.peakMarks[i].FillColor = foreground
+	}
+	for i := range r.lines {
+		r.lines[i].StrokeColor = lineColor
+	}
+
+	r.Layout(r.s.Size())
+	canvas.Refresh(r.s)
+}
+
+func (r *spectrumRenderer) Objects() []fyne.CanvasObject {
+	if r.objects == nil {
+		r.objects = make([]fyne.CanvasObject, 0,
+			2*len(r.rulerLines)+len(r.freqLabels)+2*len(r.bars)+len(r.lines))
+		for i := range r.rulerLines {
+			r.objects = append(r.objects, &r.rulerLines[i], &r.rulerLabels[i])
+		}
+		for i := range r.freqLabels {
+			r.objects = append(r.objects, &r.freqLabels[i])
+		}
+		for i := range r.bars {
+			r.objects = append(r.objects, &r.bars[i])
+		}
+		for i := range r.lines {
+			r.objects = append(r.objects, &r.lines[i])
+		}
+		for i := range r.peakMarks {
+			r.objects = append(r.objects, &r.peakMarks[i])
+		}
+	}
+	return r.objects
+}
+
+func (r *spectrumRenderer) Destroy() {
+}
@@ -82,6 +82,7 @@ func (a *AlbumFilterButton) filterEmpty() bool {
 	filterOptions := a.filter.Options()
 	return filterOptions.MinYear == 0 && filterOptions.MaxYear == 0 &&
 		(a.FavoriteDisabled || !filterOptions.ExcludeFavorited && !filterOptions.ExcludeUnfavorited) &&
+		filterOptions.ReleaseTypes == 0 && filterOptions.ExcludeReleaseTypes == 0 &&
 		(a.GenreDisabled || len(filterOptions.Genres) == 0)
 }
 
@@ -102,6 +103,21 @@ func (a *AlbumFilterButton) showFilterDialog() {
 	a.dialog.ShowAtPosition(fyne.NewPos(pos.X+a.Size().Width/2-a.dialog.MinSize().Width/2, pos.Y+a.Size().Height))
 }
 
+// release types that can be included or excluded in the filter popup
+var (
+	filterReleaseTypes = []mediaprovider.ReleaseType{
+		mediaprovider.ReleaseTypeAlbum,
+		mediaprovider.ReleaseTypeEP,
+		mediaprovider.ReleaseTypeSingle,
+		mediaprovider.ReleaseTypeLive,
+		mediaprovider.ReleaseTypeCompilation,
+		mediaprovider.ReleaseTypeSoundtrack,
+		mediaprovider.ReleaseTypeRemix,
+	}
+	// these strings should be keys in the translation dictionaries
+	filterReleaseTypeNames = []string{"Album", "EP", "Single", "Live", "Compilation", "Soundtrack", "Remix"}
+)
+
 type AlbumFilterPopup struct {
 	widget.BaseWidget
 
@@ -179,6 +195,39 @@ func NewAlbumFilterPopup(filter *AlbumFilterButton) *AlbumFilterPopup {
 	})
 	a.isNotFavorite.Hidden = a.filterBtn.FavoriteDisabled
 
+	// setup release type filters
+	releaseTypes := container.NewGridWithColumns(4)
+	releaseTypeOpts := []string{lang.L("Any"), lang.L("Include"), lang.L("Exclude")}
+	for i, rt := range filterReleaseTypes {
+		rt := rt
+		sel := widget.NewSelect(releaseTypeOpts, nil)
+		switch {
+		case filterOptions.ReleaseTypes&rt > 0:
+			sel.SetSelectedIndex(1)
+		case filterOptions.ExcludeReleaseTypes&rt > 0:
+			sel.SetSelectedIndex(2)
+		default:
+			sel.SetSelectedIndex(0)
+		}
+		sel.OnChanged = func(_ string) {
+			filterOptions := a.filterBtn.filter.Options()
+			filterOptions.ReleaseTypes &^= rt
+			filterOptions.ExcludeReleaseTypes &^= rt
+			switch sel.SelectedIndex() {
+			case 1:
+				filterOptions.ReleaseTypes |= rt
+			case 2:
+				filterOptions.ExcludeReleaseTypes |= rt
+			}
+			a.filterBtn.filter.SetOptions(filterOptions)
+			debounceOnChanged()
+		}
+		releaseTypes.Add(widget.NewLabel(lang.L(filterReleaseTypeNames[i])))
+		releaseTypes.Add(sel)
+	}
+	releaseTypesTitle := widget.NewLabel(lang.L("Release types"))
+	releaseTypesTitle.TextStyle.Bold = true
+
 	// create genre filter subsection
 	a.genreFilter = NewGenreFilterSubsection(func(selectedGenres []string) {
 		filterOptions := a.filterBtn.filter.Options()
@@ -195,6 +244,8 @@ func NewAlbumFilterPopup(filter *AlbumFilterButton) *AlbumFilterPopup {
 		container.NewHBox(layout.NewSpacer(), title, layout.NewSpacer()),
 		container.NewHBox(widget.NewLabel(lang.L("Year from")), minYear, widget.NewLabel(lang.L("to")), maxYear),
 		container.NewHBox(a.isFavorite, a.isNotFavorite),
+		releaseTypesTitle,
+		releaseTypes,
 		a.genreFilter,
 	)
 
@@ -14,6 +14,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
@@ -112,6 +113,29 @@ type GridView struct {
 	itemWidth          float32
 	numColsCached      int
 	shareMenuItem      *fyne.MenuItem
+
+	// Invoked when the heading of a collapsible section is tapped
+	OnSectionHeadingTapped func(sectionID string)
+
+	// set when showing a fixed set of items grouped under headings
+	sections      []GridViewSection
+	sectionCards  []*GridViewItem
+	sectionBox    *fyne.Container
+	sectionScroll *container.Scroll
+}
+
+// GridViewSection is a titled group of items shown by ResetFixedSections.
+type GridViewSection struct {
+	// ID identifies the section in OnSectionHeadingTapped
+	ID    string
+	Title string
+	Items []GridViewItemModel
+	// Indentation level of the heading, for nested sections
+	Depth int
+	// Collapsible sections are shown with an expand/collapse indicator,
+	// even if they have no items
+	Collapsible bool
+	Collapsed   bool
 }
 
 type GridViewState struct {
@@ -143,7 +167,10 @@ func newGridView() *GridView {
 		loadingDots:  NewLoadingDots(),
 		itemWidth:    NewGridViewItem(nil).MinSize().Width,
 		itemForIndex: make(map[int]*GridViewItem),
+		sectionBox:   container.NewVBox(),
 	}
+	g.sectionScroll = container.NewVScroll(g.sectionBox)
+	g.sectionScroll.Hide()
 	return g
 }
 
@@ -180,7 +207,7 @@ func (g *GridView) SaveToState() *GridViewState {
 	g.stateMutex.RLock()
 	s := g.GridViewState
 	g.stateMutex.RUnlock()
-	s.scrollPos = g.grid.GetScrollOffset()
+	s.scrollPos = g.GetScrollOffset()
 	return &s
 }
 
@@ -199,6 +226,7 @@ func (g *GridView) Clear() {
 	defer g.stateMutex.Unlock()
 	g.cancelFetch()
 	g.items = nil
+	g.sections = nil
 	g.done = true
 }
 
@@ -210,6 +238,7 @@ func (g *GridView) Reset(iter GridViewIterator) {
 	g.done = false
 	g.highestShown = 0
 	g.iter = iter
+	g.sections = nil
 	g.stateMutex.Unlock()
 	g.checkFetchMoreItems(36)
 	g.loadingDots.Start()
@@ -221,8 +250,9 @@ func (g *GridView) ResetFromState(state *GridViewState) {
 	g.cancelFetch()
 	g.GridViewState = *state
 	g.itemForIndex = make(map[int]*GridViewItem)
+	g.sections = nil
 	g.stateMutex.Unlock()
-	g.grid.Refresh()
+	g.Refresh()
 	g.grid.ScrollToOffset(state.scrollPos)
 }
 
@@ -234,18 +264,175 @@ func (g *GridView) ResetFixed(items []GridViewItemModel) {
 	g.done = true
 	g.highestShown = 0
 	g.iter = nil
+	g.sections = nil
 	g.stateMutex.Unlock()
 	g.Refresh()
 }
 
+// ResetFixedSections resets the grid to show a fixed set of items,
+// grouped under a heading for each section.
+func (g *GridView) ResetFixedSections(sections []GridViewSection) {
+	var items []GridViewItemModel
+	for _, s := range sections {
+		items = append(items, s.Items...)
+	}
+	g.stateMutex.Lock()
+	g.cancelFetch()
+	g.items = items
+	g.itemForIndex = make(map[int]*GridViewItem)
+	g.done = true
+	g.highestShown = 0
+	g.iter = nil
+	g.sections = sections
+	g.stateMutex.Unlock()
+	g.buildSections()
+	g.Refresh()
+}
+
+// (re)builds the sectioned layout, reusing the item cards of the previous build.
+// Sections are not virtualized, so this is intended for small sets of items.
+func (g *GridView) buildSections() {
+	g.sectionBox.RemoveAll()
+	itemIdx := 0
+	for _, section := range g.sections {
+		if len(section.Items) == 0 && !section.Collapsible {
+			continue
+		}
+		cards := make([]fyne.CanvasObject, 0, len(section.Items))
+		for range section.Items {
+			if itemIdx == len(g.sectionCards) {
+				g.sectionCards = append(g.sectionCards, g.createNewItemCard().(*GridViewItem))
+			}
+			card := g.sectionCards[itemIdx]
+			g.doUpdateItemCard(itemIdx, card)
+			cards = append(cards, card)
+			itemIdx++
+		}
+		if heading := g.newSectionHeading(section); heading != nil {
+			g.sectionBox.Add(heading)
+		}
+		if len(cards) > 0 {
+			g.sectionBox.Add(container.NewGridWrap(g.sectionCards[0].MinSize(), cards...))
+		}
+	}
+	g.sectionScroll.ScrollToTop()
+}
+
+func (g *GridView) newSectionHeading(section GridViewSection) fyne.CanvasObject {
+	padding := &layout.CustomPaddedLayout{LeftPadding: 10 + float32(section.Depth)*theme.IconInlineSize()}
+	if !section.Collapsible {
+		if section.Title == "" {
+			return nil
+		}
+		title := widget.NewRichText(&widget.TextSegment{
+			Text:  section.Title,
+			Style: widget.RichTextStyle{SizeName: theme.SizeNameSubHeadingText, TextStyle: fyne.TextStyle{Bold: true}},
+		})
+		return container.New(padding, title)
+	}
+	icon := theme.MenuDropDownIcon()
+	if section.Collapsed {
+		icon = theme.MenuExpandIcon()
+	}
+	id := section.ID
+	btn := widget.NewButtonWithIcon(section.Title, icon, func() {
+		if g.OnSectionHeadingTapped != nil {
+			g.OnSectionHeadingTapped(id)
+		}
+	})
+	btn.Importance = widget.LowImportance
+	btn.Alignment = widget.ButtonAlignLeading
+	return container.New(padding, container.NewHBox(btn))
+}
+
 func (g *GridView) GetScrollOffset() float32 {
+	if g.sections != nil {
+		return g.sectionScroll.Offset.Y
+	}
 	return g.grid.GetScrollOffset()
 }
 
 func (g *GridView) ScrollToOffset(offs float32) {
+	if g.sections != nil {
+		g.sectionScroll.Offset.Y = offs
+		g.sectionScroll.Refresh()
+		return
+	}
 	g.grid.ScrollToOffset(offs)
 }
 
+func (g *GridView) Refresh() {
+	g.grid.Hidden = g.sections != nil
+	if g.sections != nil {
+		g.sectionScroll.Show()
+	} else {
+		g.sectionScroll.Hide()
+	}
+	g.BaseWidget.Refresh()
+}
+
+func (g *GridView) columnCount() int {
+	if g.sections == nil {
+		return g.grid.ColumnCount()
+	}
+	pad := theme.Padding()
+	return max(1, int((g.sectionScroll.Size().Width+pad)/(g.itemWidth+pad)))
+}
+
+// returns the index of the item above or below the given one, or -1 if there is none
+func (g *GridView) verticalNeighbor(itemIdx int, down bool) int {
+	cols := g.columnCount()
+	if g.sections == nil {
+		if down {
+			return itemIdx + cols
+		}
+		return itemIdx - cols
+	}
+	lens := make([]int, len(g.sections))
+	for i, s := range g.sections {
+		lens[i] = len(s.Items)
+	}
+	return sectionVerticalNeighbor(lens, cols, itemIdx, down)
+}
+
+// returns the index of the item above or below the given one when the items of each
+// section are laid out in their own rows of up to cols items, or -1 if there is none.
+// Moving past the first or last row of a section moves to the same column of the
+// nearest row of the previous or next non-empty section, or its last item if shorter.
+func sectionVerticalNeighbor(sectionLens []int, cols, itemIdx int, down bool) int {
+	start, sec := 0, 0
+	for sec < len(sectionLens) && itemIdx >= start+sectionLens[sec] {
+		start += sectionLens[sec]
+		sec++
+	}
+	if sec == len(sectionLens) {
+		return -1
+	}
+	n := sectionLens[sec]
+	row, col := (itemIdx-start)/cols, (itemIdx-start)%cols
+	if down {
+		if next := (row + 1) * cols; next < n {
+			return start + min(next+col, n-1)
+		}
+		for start, sec = start+n, sec+1; sec < len(sectionLens); start, sec = start+sectionLens[sec], sec+1 {
+			if sectionLens[sec] > 0 {
+				return start + min(col, sectionLens[sec]-1)
+			}
+		}
+		return -1
+	}
+	if row > 0 {
+		return start + (row-1)*cols + col
+	}
+	for sec--; sec >= 0; sec-- {
+		if n = sectionLens[sec]; n > 0 {
+			start -= n
+			return start + min((n-1)/cols*cols+col, n-1)
+		}
+	}
+	return -1
+}
+
 func (g *GridView) Resize(size fyne.Size) {
 	g.numColsCached = -1
 	g.BaseWidget.Resize(size)
@@ -296,12 +483,14 @@ func (g *GridView) createNewItemCard() fyne.CanvasObject {
 		case 1: // right
 			focusIndex = card.ItemIndex + 1
 		case 2: // up
-			focusIndex = card.ItemIndex - g.grid.ColumnCount()
+			focusIndex = g.verticalNeighbor(card.ItemIndex, false)
 		case 3: // down
-			focusIndex = card.ItemIndex + g.grid.ColumnCount()
+			focusIndex = g.verticalNeighbor(card.ItemIndex, true)
 		}
 		if focusIndex >= 0 && focusIndex < g.lenItems() {
-			g.grid.ScrollTo(focusIndex)
+			if g.sections == nil {
+				g.grid.ScrollTo(focusIndex)
+			}
 			g.stateMutex.RLock()
 			if item, ok := g.itemForIndex[focusIndex]; ok {
 				fyne.CurrentApp().Driver().CanvasForObject(g).Focus(item)
@@ -457,7 +646,7 @@ func (g *GridView) onPlay(itemID string, shuffle bool) {
 
 func (g *GridView) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(container.NewStack(
-		g.grid, container.NewCenter(g.loadingDots),
+		g.grid, g.sectionScroll, container.NewCenter(g.loadingDots),
 	))
 }
 
@@ -0,0 +1,251 @@
+package widgets
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/lrc"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+// amount a single nudge shifts timestamps by, in seconds
+const lyricsNudgeAmount = 0.1
+
+// LyricsSyncEditor lets the user create synced lyrics by stamping
+// each line with the current playback position as it is sung.
+// While focused, Enter stamps the selected line and advances to the next,
+// Up/Down change the selection, Left/Right nudge the selected line's timestamp,
+// and Backspace clears the selected line's timestamp.
+type LyricsSyncEditor struct {
+	widget.BaseWidget
+
+	// PlayPos returns the current playback position in seconds
+	PlayPos  func() float64
+	OnSeek   func(secs float64)
+	OnSave   func(lyrics *mediaprovider.Lyrics, publish bool)
+	OnCancel func()
+
+	title    string
+	artist   string
+	lines    []mediaprovider.LyricLine
+	stamped  []bool
+	selected int
+
+	list      *widget.List
+	publish   *widget.Check
+	saveBtn   *widget.Button
+	container *fyne.Container
+}
+
+var _ fyne.Focusable = (*LyricsSyncEditor)(nil)
+
+func NewLyricsSyncEditor() *LyricsSyncEditor {
+	e := &LyricsSyncEditor{}
+	e.ExtendBaseWidget(e)
+
+	e.list = widget.NewList(
+		func() int { return len(e.lines) },
+		func() fyne.CanvasObject {
+			ts := widget.NewLabel("00:00.00")
+			ts.TextStyle.Monospace = true
+			text := widget.NewLabel("")
+			text.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, ts, nil, text)
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			c := co.(*fyne.Container)
+			text := c.Objects[0].(*widget.Label)
+			ts := c.Objects[1].(*widget.Label)
+			text.SetText(e.lines[id].Text)
+			if e.stamped[id] {
+				ts.SetText(lrc.FormatTimestamp(e.lines[id].Start))
+				ts.Importance = widget.MediumImportance
+			} else {
+				ts.SetText("--:--.--")
+				ts.Importance = widget.LowImportance
+			}
+			ts.Refresh()
+		},
+	)
+	e.list.OnSelected = func(id widget.ListItemID) {
+		e.selected = id
+		e.requestFocus()
+	}
+
+	stampBtn := widget.NewButtonWithIcon(lang.L("Stamp line"), theme.MediaRecordIcon(), e.stampSelected)
+	stampBtn.Importance = widget.HighImportance
+	seekBtn := widget.NewButtonWithIcon("", theme.MediaPlayIcon(), e.seekToSelected)
+	nudgeBack := widget.NewButton("-0.1s", func() { e.nudgeSelected(-lyricsNudgeAmount) })
+	nudgeFwd := widget.NewButton("+0.1s", func() { e.nudgeSelected(lyricsNudgeAmount) })
+	allBack := widget.NewButton(lang.L("All")+" -0.1s", func() { e.nudgeAll(-lyricsNudgeAmount) })
+	allFwd := widget.NewButton(lang.L("All")+" +0.1s", func() { e.nudgeAll(lyricsNudgeAmount) })
+
+	e.publish = widget.NewCheck(lang.L("Publish to LRCLIB"), nil)
+	e.saveBtn = widget.NewButtonWithIcon(lang.L("Save"), theme.DocumentSaveIcon(), func() {
+		if e.OnSave != nil {
+			e.OnSave(e.Lyrics(), e.publish.Checked)
+		}
+	})
+	cancelBtn := widget.NewButton(lang.L("Cancel"), func() {
+		if e.OnCancel != nil {
+			e.OnCancel()
+		}
+	})
+	hint := widget.NewLabel(lang.L("Press Enter as each line is sung to stamp it"))
+	hint.Importance = widget.LowImportance
+	hint.Wrapping = fyne.TextWrapWord
+
+	e.container = container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(stampBtn, seekBtn, util.NewHSpace(5), nudgeBack, nudgeFwd, util.NewHSpace(5), allBack, allFwd),
+			hint,
+		),
+		container.NewHBox(e.publish, layout.NewSpacer(), cancelBtn, e.saveBtn),
+		nil, nil, e.list)
+	return e
+}
+
+// SetLyrics sets the lyrics to edit. Lines of synced lyrics start out stamped.
+func (e *LyricsSyncEditor) SetLyrics(lyrics *mediaprovider.Lyrics) {
+	e.title, e.artist = lyrics.Title, lyrics.Artist
+	e.lines = make([]mediaprovider.LyricLine, 0, len(lyrics.Lines))
+	e.stamped = make([]bool, 0, len(lyrics.Lines))
+	for _, line := range lyrics.Lines {
+		line.Words = slices.Clone(line.Words)
+		e.lines = append(e.lines, line)
+		e.stamped = append(e.stamped, lyrics.Synced)
+	}
+	e.selected = 0
+	e.publish.SetChecked(false)
+	e.list.Refresh()
+	e.list.ScrollToTop()
+	e.list.Select(0)
+	e.updateSaveEnabled()
+}
+
+// Lyrics returns the edited synced lyrics
+func (e *LyricsSyncEditor) Lyrics() *mediaprovider.Lyrics {
+	lines := make([]mediaprovider.LyricLine, len(e.lines))
+	for i, line := range e.lines {
+		line.Words = slices.Clone(line.Words)
+		lines[i] = line
+	}
+	// lines may have been stamped out of order
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Start < lines[j].Start
+	})
+	return &mediaprovider.Lyrics{Title: e.title, Artist: e.artist, Synced: true, Lines: lines}
+}
+
+func (e *LyricsSyncEditor) stampSelected() {
+	if e.selected >= len(e.lines) || e.PlayPos == nil {
+		return
+	}
+	e.lines[e.selected].Start = e.PlayPos()
+	// word timings would no longer match the new line timing
+	e.lines[e.selected].Words = nil
+	e.stamped[e.selected] = true
+	e.list.RefreshItem(e.selected)
+	e.updateSaveEnabled()
+	e.moveSelection(1)
+}
+
+func (e *LyricsSyncEditor) seekToSelected() {
+	if e.selected < len(e.lines) && e.stamped[e.selected] && e.OnSeek != nil {
+		e.OnSeek(e.lines[e.selected].Start)
+	}
+}
+
+func (e *LyricsSyncEditor) nudgeSelected(secs float64) {
+	if e.selected < len(e.lines) && e.stamped[e.selected] {
+		nudgeLyricLine(&e.lines[e.selected], secs)
+		e.list.RefreshItem(e.selected)
+	}
+}
+
+func (e *LyricsSyncEditor) nudgeAll(secs float64) {
+	for i := range e.lines {
+		if e.stamped[i] {
+			nudgeLyricLine(&e.lines[i], secs)
+		}
+	}
+	e.list.Refresh()
+}
+
+func (e *LyricsSyncEditor) clearSelected() {
+	if e.selected < len(e.lines) {
+		e.stamped[e.selected] = false
+		e.list.RefreshItem(e.selected)
+		e.updateSaveEnabled()
+		e.moveSelection(-1)
+	}
+}
+
+func (e *LyricsSyncEditor) moveSelection(delta int) {
+	if i := e.selected + delta; i >= 0 && i < len(e.lines) {
+		e.list.Select(i)
+		e.list.ScrollTo(i)
+	}
+}
+
+func (e *LyricsSyncEditor) updateSaveEnabled() {
+	for _, s := range e.stamped {
+		if !s {
+			e.saveBtn.Disable()
+			return
+		}
+	}
+	e.saveBtn.Enable()
+}
+
+func (e *LyricsSyncEditor) requestFocus() {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(e); c != nil {
+		c.Focus(e)
+	}
+}
+
+func (e *LyricsSyncEditor) Tapped(*fyne.PointEvent) {
+	e.requestFocus()
+}
+
+func (e *LyricsSyncEditor) FocusGained() {}
+
+func (e *LyricsSyncEditor) FocusLost() {}
+
+func (e *LyricsSyncEditor) TypedRune(rune) {}
+
+func (e *LyricsSyncEditor) TypedKey(k *fyne.KeyEvent) {
+	switch k.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		e.stampSelected()
+	case fyne.KeyUp:
+		e.moveSelection(-1)
+	case fyne.KeyDown:
+		e.moveSelection(1)
+	case fyne.KeyLeft:
+		e.nudgeSelected(-lyricsNudgeAmount)
+	case fyne.KeyRight:
+		e.nudgeSelected(lyricsNudgeAmount)
+	case fyne.KeyBackspace:
+		e.clearSelected()
+	}
+}
+
+func (e *LyricsSyncEditor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(e.container)
+}
+
+func nudgeLyricLine(line *mediaprovider.LyricLine, secs float64) {
+	line.Start = math.Max(0, line.Start+secs)
+	for i := range line.Words {
+		line.Words[i].Start = math.Max(0, line.Words[i].Start+secs)
+	}
+}
@@ -1,13 +1,16 @@
 package widgets
 
 import (
+	"slices"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	fynelyrics "github.com/dweymouth/fyne-lyrics"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
-	"github.com/dweymouth/supersonic/ui/theme"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
 )
 
 type LyricsViewer struct {
@@ -19,6 +22,13 @@ type LyricsViewer struct {
 	nextLyricLine int
 	lastPlayPos   float64
 
+	// shows the current line highlighted word by word,
+	// for lyrics with word-level timings
+	wordLine      *widget.RichText
+	hasWordTiming bool
+	curWordLine   int // index of the line shown in wordLine
+	curWordCount  int // number of words highlighted in wordLine
+
 	// keeps track if UpdatePlayPos has been called yet
 	// for the current lyrics
 	firstUpdate bool
@@ -31,10 +41,13 @@ func NewLyricsViewer() *LyricsViewer {
 	l := &LyricsViewer{
 		noLyricsMsg: container.NewCenter(NewInfoMessage(
 			lang.L("Lyrics not available"), "")),
-		isEmpty: true,
+		wordLine: widget.NewRichText(),
+		isEmpty:  true,
 	}
 	l.ExtendBaseWidget(l)
-	l.container = container.NewStack(l.noLyricsMsg)
+	l.wordLine.Wrapping = fyne.TextWrapWord
+	l.wordLine.Hide()
+	l.container = container.NewBorder(nil, l.wordLine, nil, nil, l.noLyricsMsg)
 	return l
 }
 
@@ -42,6 +55,7 @@ func (l *LyricsViewer) SetLyrics(lyrics *mediaprovider.Lyrics) {
 	l.lyrics = lyrics
 	l.nextLyricLine = 0
 	l.firstUpdate = true
+	l.resetWordLine(lyrics)
 	if lyrics == nil || len(lyrics.Lines) == 0 {
 		if !l.isEmpty {
 			l.container.Objects[0] = l.noLyricsMsg
@@ -54,7 +68,7 @@ func (l *LyricsViewer) SetLyrics(lyrics *mediaprovider.Lyrics) {
 	if l.viewer == nil {
 		l.viewer = fynelyrics.NewLyricsViewer()
 		l.viewer.ActiveLyricPosition = fynelyrics.ActiveLyricPositionUpperMiddle
-		l.viewer.InactiveLyricColorName = theme.ColorNameInactiveLyric
+		l.viewer.InactiveLyricColorName = myTheme.ColorNameInactiveLyric
 	}
 	lines := make([]string, len(lyrics.Lines))
 	for i, line := range lyrics.Lines {
@@ -78,6 +92,7 @@ func (l *LyricsViewer) UpdatePlayPos(timeSecs float64) {
 		return
 	}
 	l.lastPlayPos = timeSecs
+	l.updateWordLine(timeSecs)
 	// advance if needed
 	if l.lyrics.Lines[l.nextLyricLine].Start <= timeSecs {
 		l.viewer.NextLine()
@@ -92,6 +107,7 @@ func (l *LyricsViewer) OnSeeked(timeSecs float64) {
 	if l.lyrics == nil || !l.lyrics.Synced {
 		return
 	}
+	l.updateWordLine(timeSecs)
 
 	// find first line that starts after timeSecs
 	nextLine := -1
@@ -112,6 +128,70 @@ func (l *LyricsViewer) OnSeeked(timeSecs float64) {
 	l.viewer.SetCurrentLine(nextLine /*one-indexed*/)
 }
 
+func (l *LyricsViewer) resetWordLine(lyrics *mediaprovider.Lyrics) {
+	l.hasWordTiming = lyrics != nil && lyrics.Synced &&
+		slices.ContainsFunc(lyrics.Lines, func(line mediaprovider.LyricLine) bool {
+			return len(line.Words) > 0
+		})
+	l.curWordLine, l.curWordCount = -1, 0
+	l.wordLine.Segments = nil
+	if l.hasWordTiming {
+		l.wordLine.Show()
+	} else {
+		l.wordLine.Hide()
+	}
+	l.wordLine.Refresh()
+}
+
+// updateWordLine highlights the words of the current line that have been sung
+func (l *LyricsViewer) updateWordLine(timeSecs float64) {
+	if !l.hasWordTiming {
+		return
+	}
+	line := -1
+	for i, ln := range l.lyrics.Lines {
+		if ln.Start > timeSecs {
+			break
+		}
+		line = i
+	}
+	words := 0
+	if line >= 0 {
+		for _, w := range l.lyrics.Lines[line].Words {
+			if w.Start > timeSecs {
+				break
+			}
+			words++
+		}
+	}
+	if line == l.curWordLine && words == l.curWordCount {
+		return // nothing changed
+	}
+	l.curWordLine, l.curWordCount = line, words
+
+	l.wordLine.Segments = nil
+	if line >= 0 {
+		ln := l.lyrics.Lines[line]
+		newSegment := func(text string, active bool) *widget.TextSegment {
+			style := widget.RichTextStyleSubHeading
+			style.Alignment = fyne.TextAlignCenter
+			style.Inline = true
+			style.ColorName = myTheme.ColorNameInactiveLyric
+			if active {
+				style.ColorName = theme.ColorNameForeground
+			}
+			return &widget.TextSegment{Text: text, Style: style}
+		}
+		if len(ln.Words) == 0 {
+			l.wordLine.Segments = append(l.wordLine.Segments, newSegment(ln.Text, true))
+		}
+		for i, w := range ln.Words {
+			l.wordLine.Segments = append(l.wordLine.Segments, newSegment(w.Text, i < words))
+		}
+	}
+	l.wordLine.Refresh()
+}
+
 func (l *LyricsViewer) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(l.container)
 }
@@ -1,9 +1,13 @@
 package widgets
 
 import (
+	"image/color"
+
+	"github.com/dweymouth/supersonic/backend/waveform"
 	"github.com/dweymouth/supersonic/ui/util"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
@@ -82,6 +86,8 @@ type PlayerControls struct {
 	widget.BaseWidget
 
 	slider         *TrackPosSlider
+	waveform       *WaveformSeekBar
+	abLoop         *abLoopRange
 	curTimeLabel   *labelMinSize
 	totalTimeLabel *labelMinSize
 	prev           *IconButton
@@ -127,6 +133,12 @@ func NewPlayerControls() *PlayerControls {
 			pc.curTimeLabel.SetText(util.SecondsToMMSS(time))
 		}
 	}
+	pc.waveform = NewWaveformSeekBar()
+	pc.waveform.Disable()
+	pc.waveform.Hide()
+	pc.waveform.OnChanged = func(f float64) {
+		pc.curTimeLabel.SetText(util.SecondsToMMSS(f * pc.totalTime))
+	}
 
 	pc.prev = NewIconButton(theme.MediaSkipPreviousIcon(), func() {})
 	pc.prev.SetToolTip(lang.L("Previous"))
@@ -138,7 +150,11 @@ func NewPlayerControls() *PlayerControls {
 
 	buttons := container.NewHBox(layout.NewSpacer(), pc.prev, pc.playpause, pc.next, layout.NewSpacer())
 
-	c := container.NewBorder(nil, nil, pc.curTimeLabel, pc.totalTimeLabel, pc.slider)
+	pc.abLoop = newABLoopRange()
+	pc.abLoop.inset = sliderEndOffset()
+
+	c := container.NewBorder(nil, nil, pc.curTimeLabel, pc.totalTimeLabel,
+		container.NewStack(pc.slider, pc.waveform, pc.abLoop))
 	pc.container = container.New(layout.NewCustomPaddedVBoxLayout(0), c, buttons)
 
 	return pc
@@ -152,6 +168,39 @@ func (pc *PlayerControls) OnSeek(f func(float64)) {
 			f(pos)
 		}
 	}
+	pc.waveform.OnChangeEnded = f
+}
+
+// SetWaveform shows the waveform seek bar in place of the slider, drawing the
+// waveform up to progress, the fraction of the track it covers.
+// If wf is nil, the slider is shown instead.
+func (pc *PlayerControls) SetWaveform(wf *waveform.Waveform, progress float64) {
+	pc.waveform.SetWaveform(wf, progress)
+	if wf == nil {
+		pc.waveform.Hide()
+		pc.slider.Show()
+		pc.abLoop.inset = sliderEndOffset()
+		pc.abLoop.Refresh()
+	} else if !pc.waveform.Visible() {
+		pc.waveform.SetValue(pc.slider.Value)
+		pc.slider.Hide()
+		pc.waveform.Show()
+		pc.abLoop.inset = 0
+		pc.abLoop.Refresh()
+	}
+}
+
+// SetABLoop shows the A-B loop points, in seconds, on the seek bar.
+// Negative values are not shown.
+func (pc *PlayerControls) SetABLoop(a, b float64) {
+	fraction := func(secs float64) float64 {
+		if secs < 0 || pc.totalTime <= 0 {
+			return -1
+		}
+		return secs / pc.totalTime
+	}
+	pc.abLoop.a, pc.abLoop.b = fraction(a), fraction(b)
+	pc.abLoop.Refresh()
 }
 
 func (pc *PlayerControls) OnSeekPrevious(f func()) {
@@ -191,10 +240,12 @@ func (pc *PlayerControls) UpdatePlayTime(curTime, totalTime float64) {
 	}
 	if totalTime > 0 {
 		pc.slider.Enable()
+		pc.waveform.Enable()
 	} else {
 		pc.slider.Disable()
+		pc.waveform.Disable()
 	}
-	if !pc.slider.IsDragging() {
+	if !pc.slider.IsDragging() && !pc.waveform.IsDragging() {
 		ct := util.SecondsToMMSS(curTime)
 		if ct != pc.curTimeLabel.Text {
 			pc.curTimeLabel.SetText(ct)
@@ -203,6 +254,7 @@ func (pc *PlayerControls) UpdatePlayTime(curTime, totalTime float64) {
 		if updated {
 			// Only update slider once a second when time label changes
 			pc.slider.SetValue(v)
+			pc.waveform.SetValue(v)
 		}
 	}
 }
@@ -210,3 +262,80 @@ func (pc *PlayerControls) UpdatePlayTime(curTime, totalTime float64) {
 func (p *PlayerControls) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(p.container)
 }
+
+// abLoopRange draws the A-B loop points, and the range between them, over the seek bar.
+type abLoopRange struct {
+	widget.BaseWidget
+
+	// loop points as fractions of the track duration, or -1 if unset
+	a, b float64
+	// horizontal distance from the edges to the ends of the seek bar
+	inset float32
+}
+
+func newABLoopRange() *abLoopRange {
+	r := &abLoopRange{a: -1, b: -1}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+// the distance from the edges of a widget.Slider to the ends of its track
+func sliderEndOffset() float32 {
+	return (theme.IconInlineSize()-4)/2 + theme.InnerPadding() - 1.5
+}
+
+func (r *abLoopRange) CreateRenderer() fyne.WidgetRenderer {
+	return &abLoopRangeRenderer{
+		r:     r,
+		rng:   canvas.NewRectangle(color.Transparent),
+		markA: canvas.NewRectangle(color.Transparent),
+		markB: canvas.NewRectangle(color.Transparent),
+	}
+}
+
+type abLoopRangeRenderer struct {
+	r                 *abLoopRange
+	rng, markA, markB *canvas.Rectangle
+}
+
+func (r *abLoopRangeRenderer) Layout(size fyne.Size) {
+	width := size.Width - 2*r.r.inset
+	xPos := func(f float64) float32 {
+		return r.r.inset + float32(f)*width
+	}
+	const markWidth = 2
+	for _, m := range []struct {
+		rect *canvas.Rectangle
+		pos  float64
+	}{{r.markA, r.r.a}, {r.markB, r.r.b}} {
+		m.rect.Hidden = m.pos < 0
+		m.rect.Move(fyne.NewPos(xPos(m.pos)-markWidth/2, 0))
+		m.rect.Resize(fyne.NewSize(markWidth, size.Height))
+	}
+	r.rng.Hidden = r.r.a < 0 || r.r.b < 0
+	r.rng.Move(fyne.NewPos(xPos(r.r.a), 0))
+	r.rng.Resize(fyne.NewSize(xPos(r.r.b)-xPos(r.r.a), size.Height))
+}
+
+func (r *abLoopRangeRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func (r *abLoopRangeRenderer) Refresh() {
+	c := theme.PrimaryColor()
+	r.markA.FillColor = c
+	r.markB.FillColor = c
+	rc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	rc.A = 60
+	r.rng.FillColor = rc
+	r.Layout(r.r.Size())
+	for _, o := range r.Objects() {
+		o.Refresh()
+	}
+}
+
+func (r *abLoopRangeRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.rng, r.markA, r.markB}
+}
+
+func (r *abLoopRangeRenderer) Destroy() {}
@@ -0,0 +1,226 @@
+package widgets
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	ttwidget "github.com/dweymouth/fyne-tooltip/widget"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+type TrackFilterButton struct {
+	ttwidget.Button
+
+	OnChanged      func()
+	RatingDisabled bool
+
+	genreListChan chan []string
+
+	filter mediaprovider.TrackFilter
+	dialog *widget.PopUp
+}
+
+var _ FilterButton[mediaprovider.Track, mediaprovider.TrackFilterOptions] = (*TrackFilterButton)(nil)
+
+func NewTrackFilterButton(filter mediaprovider.TrackFilter, fetchGenresFunc func() ([]*mediaprovider.Genre, error)) *TrackFilterButton {
+	t := &TrackFilterButton{
+		filter: filter,
+		Button: ttwidget.Button{
+			Button: widget.Button{
+				Icon: theme.NewThemedResource(myTheme.FilterIcon),
+			},
+		},
+	}
+	t.SetToolTip(lang.L("Filter tracks"))
+	t.OnTapped = t.showFilterDialog
+	t.ExtendBaseWidget(t)
+	t.genreListChan = make(chan []string)
+	go func() {
+		if genres, err := fetchGenresFunc(); err == nil {
+			genreNames := sharedutil.MapSlice(genres, func(g *mediaprovider.Genre) string {
+				return g.Name
+			})
+			slices.Sort(genreNames)
+			t.genreListChan <- genreNames
+		}
+	}()
+	return t
+}
+
+func (t *TrackFilterButton) Refresh() {
+	themedIcon := t.Icon.(*theme.ThemedResource)
+	if t.filter.IsNil() {
+		themedIcon.ColorName = theme.ColorNameForeground
+	} else {
+		themedIcon.ColorName = theme.ColorNamePrimary
+	}
+	t.Button.Refresh()
+}
+
+func (t *TrackFilterButton) Filter() mediaprovider.TrackFilter {
+	return t.filter
+}
+
+func (t *TrackFilterButton) SetOnChanged(fn func()) {
+	t.OnChanged = fn
+}
+
+func (t *TrackFilterButton) onFilterChanged() {
+	t.Refresh()
+	if t.OnChanged != nil {
+		t.OnChanged()
+	}
+}
+
+func (t *TrackFilterButton) showFilterDialog() {
+	if t.dialog == nil {
+		filterDlg := NewTrackFilterPopup(t)
+		filterDlg.OnChanged = t.onFilterChanged
+		t.dialog = widget.NewPopUp(filterDlg, fyne.CurrentApp().Driver().CanvasForObject(t))
+	}
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(t)
+	t.dialog.ShowAtPosition(fyne.NewPos(pos.X+t.Size().Width/2-t.dialog.MinSize().Width/2, pos.Y+t.Size().Height))
+}
+
+type TrackFilterPopup struct {
+	widget.BaseWidget
+
+	OnChanged func()
+
+	isFavorite    *widget.Check
+	isNotFavorite *widget.Check
+	minRating     *widget.Select
+	genreFilter   *GenreFilterSubsection
+	filterBtn     *TrackFilterButton
+	container     *fyne.Container
+}
+
+func NewTrackFilterPopup(filter *TrackFilterButton) *TrackFilterPopup {
+	t := &TrackFilterPopup{filterBtn: filter}
+	t.ExtendBaseWidget(t)
+
+	debounceOnChanged := util.NewDebouncer(350*time.Millisecond, t.emitOnChanged)
+	updateOptions := func(update func(*mediaprovider.TrackFilterOptions)) {
+		filterOptions := t.filterBtn.filter.Options()
+		update(&filterOptions)
+		t.filterBtn.filter.SetOptions(filterOptions)
+		debounceOnChanged()
+	}
+	filterOptions := t.filterBtn.filter.Options()
+
+	// creates an entry for a non-negative integer filter option
+	numberEntry := func(maxDigits int, initial int, onChanged func(*mediaprovider.TrackFilterOptions, int)) *TextRestrictedEntry {
+		e := NewTextRestrictedEntry(func(curText, selText string, r rune) bool {
+			l := len(curText) - len(selText)
+			return unicode.IsDigit(r) && l < maxDigits
+		})
+		e.SetMinCharWidth(maxDigits)
+		if initial > 0 {
+			e.Text = strconv.Itoa(initial)
+		}
+		e.OnChanged = func(s string) {
+			i, _ := strconv.Atoi(s)
+			updateOptions(func(o *mediaprovider.TrackFilterOptions) { onChanged(o, i) })
+		}
+		return e
+	}
+
+	minYear := numberEntry(4, filterOptions.MinYear, func(o *mediaprovider.TrackFilterOptions, i int) { o.MinYear = i })
+	maxYear := numberEntry(4, filterOptions.MaxYear, func(o *mediaprovider.TrackFilterOptions, i int) { o.MaxYear = i })
+	minPlays := numberEntry(4, filterOptions.MinPlayCount, func(o *mediaprovider.TrackFilterOptions, i int) { o.MinPlayCount = i })
+	maxPlays := numberEntry(4, filterOptions.MaxPlayCount, func(o *mediaprovider.TrackFilterOptions, i int) { o.MaxPlayCount = i })
+	minBitRate := numberEntry(4, filterOptions.MinBitRate, func(o *mediaprovider.TrackFilterOptions, i int) { o.MinBitRate = i })
+
+	neverPlayed := widget.NewCheck(lang.L("Never played"), func(b bool) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ExcludePlayed = b })
+	})
+	neverPlayed.Checked = filterOptions.ExcludePlayed
+
+	contentTypes := widget.NewEntry()
+	contentTypes.SetPlaceHolder("flac, mp3")
+	contentTypes.Text = strings.Join(filterOptions.ContentTypes, ", ")
+	contentTypes.OnChanged = func(s string) {
+		types := sharedutil.FilterMapSlice(strings.Split(s, ","), func(t string) (string, bool) {
+			t = strings.TrimSpace(t)
+			return t, t != ""
+		})
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ContentTypes = types })
+	}
+
+	ratings := []string{lang.L("Any"), "1+", "2+", "3+", "4+", "5"}
+	t.minRating = widget.NewSelect(ratings, nil)
+	t.minRating.SetSelectedIndex(filterOptions.MinRating)
+	t.minRating.OnChanged = func(_ string) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.MinRating = t.minRating.SelectedIndex() })
+	}
+	t.minRating.Hidden = t.filterBtn.RatingDisabled
+
+	// setup is favorite/not favorite filters
+	t.isFavorite = widget.NewCheck(lang.L("Is favorite"), func(fav bool) {
+		if fav {
+			t.isNotFavorite.SetChecked(false)
+		}
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ExcludeUnfavorited = fav })
+	})
+	t.isFavorite.Checked = filterOptions.ExcludeUnfavorited
+	t.isNotFavorite = widget.NewCheck(lang.L("Is not favorite"), func(fav bool) {
+		if fav {
+			t.isFavorite.SetChecked(false)
+		}
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.ExcludeFavorited = fav })
+	})
+	t.isNotFavorite.Checked = filterOptions.ExcludeFavorited
+
+	// create genre filter subsection
+	t.genreFilter = NewGenreFilterSubsection(func(selectedGenres []string) {
+		updateOptions(func(o *mediaprovider.TrackFilterOptions) { o.Genres = selectedGenres })
+	}, filterOptions.Genres)
+
+	// setup container
+	title := widget.NewLabel(lang.L("Track filters"))
+	title.TextStyle.Bold = true
+	ratingLabel := widget.NewLabel(lang.L("Rating"))
+	ratingLabel.Hidden = t.filterBtn.RatingDisabled
+	t.container = container.NewVBox(
+		container.NewHBox(layout.NewSpacer(), title, layout.NewSpacer()),
+		container.NewHBox(widget.NewLabel(lang.L("Year from")), minYear, widget.NewLabel(lang.L("to")), maxYear),
+		container.NewHBox(widget.NewLabel(lang.L("Plays")), minPlays, widget.NewLabel(lang.L("to")), maxPlays, neverPlayed),
+		container.NewHBox(ratingLabel, t.minRating, widget.NewLabel(lang.L("Min. bit rate")), minBitRate),
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Content type")), nil, contentTypes),
+		container.NewHBox(t.isFavorite, t.isNotFavorite),
+		t.genreFilter,
+	)
+
+	go func() {
+		t.genreFilter.SetGenreList(<-t.filterBtn.genreListChan)
+	}()
+
+	return t
+}
+
+func (t *TrackFilterPopup) Tapped(_ *fyne.PointEvent) {
+	// swallow the Tapped event so that the popup is
+	// only dismissed by clicking outside of it
+}
+
+func (t *TrackFilterPopup) emitOnChanged() {
+	if t.OnChanged != nil {
+		t.OnChanged()
+	}
+}
+
+func (t *TrackFilterPopup) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.container)
+}
@@ -0,0 +1,185 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/dweymouth/supersonic/backend/waveform"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+)
+
+// WaveformSeekBar is a seek bar that draws the waveform of the track,
+// colored up to the playback position. The part of the waveform that
+// has not been computed yet is drawn as a flat line.
+type WaveformSeekBar struct {
+	widget.DisableableWidget
+
+	// playback position, from 0 to 1
+	Value float64
+
+	// invoked while dragging
+	OnChanged func(float64)
+	// invoked when the user seeks by tapping or at the end of a drag
+	OnChangeEnded func(float64)
+
+	waveform   *waveform.Waveform
+	progress   float64
+	isDragging bool
+}
+
+var _ desktop.Cursorable = (*WaveformSeekBar)(nil)
+
+func NewWaveformSeekBar() *WaveformSeekBar {
+	w := &WaveformSeekBar{}
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// SetWaveform sets the waveform drawn and the fraction of the track it covers.
+func (w *WaveformSeekBar) SetWaveform(wf *waveform.Waveform, progress float64) {
+	w.waveform = wf
+	w.progress = progress
+	w.Refresh()
+}
+
+func (w *WaveformSeekBar) SetValue(value float64) {
+	value = max(0, min(1, value))
+	if value == w.Value {
+		return
+	}
+	w.Value = value
+	w.Refresh()
+}
+
+func (w *WaveformSeekBar) IsDragging() bool {
+	return w.isDragging
+}
+
+func (w *WaveformSeekBar) Tapped(e *fyne.PointEvent) {
+	if w.Disabled() {
+		return
+	}
+	w.isDragging = false
+	w.SetValue(w.valueAt(e.Position))
+	if w.OnChangeEnded != nil {
+		w.OnChangeEnded(w.Value)
+	}
+}
+
+func (w *WaveformSeekBar) Dragged(e *fyne.DragEvent) {
+	if w.Disabled() {
+		return
+	}
+	w.isDragging = true
+	w.SetValue(w.valueAt(e.Position))
+	if w.OnChanged != nil {
+		w.OnChanged(w.Value)
+	}
+}
+
+func (w *WaveformSeekBar) DragEnd() {
+	if !w.isDragging {
+		return
+	}
+	w.isDragging = false
+	if w.OnChangeEnded != nil {
+		w.OnChangeEnded(w.Value)
+	}
+}
+
+func (w *WaveformSeekBar) Cursor() desktop.Cursor {
+	if w.Disabled() {
+		return desktop.DefaultCursor
+	}
+	return desktop.PointerCursor
+}
+
+func (w *WaveformSeekBar) valueAt(pos fyne.Position) float64 {
+	width := w.Size().Width
+	if width <= 0 {
+		return 0
+	}
+	return max(0, min(1, float64(pos.X/width)))
+}
+
+func (w *WaveformSeekBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &waveformSeekBarRenderer{w: w}
+	r.raster = canvas.NewRaster(r.draw)
+	return r
+}
+
+type waveformSeekBarRenderer struct {
+	w      *WaveformSeekBar
+	raster *canvas.Raster
+}
+
+func (r *waveformSeekBarRenderer) Layout(size fyne.Size) {
+	r.raster.Resize(size)
+}
+
+func (r *waveformSeekBarRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(theme.IconInlineSize()*2, theme.IconInlineSize()+theme.InnerPadding())
+}
+
+func (r *waveformSeekBarRenderer) Refresh() {
+	r.raster.Refresh()
+}
+
+func (r *waveformSeekBarRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.raster}
+}
+
+func (r *waveformSeekBarRenderer) Destroy() {}
+
+// draws the waveform as bars 2 device-independent pixels wide
+// with a gap of 1, mirrored around the vertical center.
+func (r *waveformSeekBarRenderer) draw(pw, ph int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, pw, ph))
+	width := r.w.Size().Width
+	if width <= 0 || pw <= 0 {
+		return img
+	}
+	scale := float32(pw) / width
+	barWidth := max(1, int(2*scale))
+	step := barWidth + max(1, int(scale))
+	minBarHeight := max(1, int(scale))
+
+	played := theme.PrimaryColor()
+	unplayed := myTheme.BlendColors(theme.ForegroundColor(), theme.BackgroundColor(), 0.4)
+	if r.w.Disabled() {
+		played, unplayed = theme.DisabledColor(), theme.DisabledColor()
+	}
+	playedX := int(r.w.Value * float64(pw))
+	computedX := 0
+	var levels []uint8
+	if r.w.waveform != nil {
+		levels = r.w.waveform.Levels
+		computedX = int(r.w.progress * float64(pw))
+	}
+
+	for x := 0; x < pw; x += step {
+		var level uint8
+		if x < computedX && len(levels) > 0 {
+			first := x * len(levels) / pw
+			last := min(len(levels), max(first+1, (x+step)*len(levels)/pw))
+			for _, l := range levels[first:last] {
+				level = max(level, l)
+			}
+		}
+		barHeight := max(minBarHeight, int(level)*ph/255)
+		var c color.Color = unplayed
+		if x < playedX {
+			c = played
+		}
+		top := (ph - barHeight) / 2
+		draw.Draw(img, image.Rect(x, top, x+barWidth, top+barHeight), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return img
+}